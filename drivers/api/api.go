@@ -104,3 +104,46 @@ func PerformTask(userTask func() error, opts *TriggerOptions) error {
 		time.Now(), true)
 	return err
 }
+
+// Capability is an optional operation that a driver may or may not support
+type Capability string
+
+// Capabilities is the set of capabilities advertised by a driver
+type Capabilities map[Capability]bool
+
+// NewCapabilities returns a capability set with the given capabilities
+func NewCapabilities(caps ...Capability) Capabilities {
+	c := make(Capabilities)
+	for _, capability := range caps {
+		c[capability] = true
+	}
+	return c
+}
+
+// Has returns true if all the given capabilities are in the set
+func (c Capabilities) Has(caps ...Capability) bool {
+	return len(c.Missing(caps...)) == 0
+}
+
+// Missing returns the given capabilities which are not in the set
+func (c Capabilities) Missing(caps ...Capability) []Capability {
+	var missing []Capability
+	for _, capability := range caps {
+		if !c[capability] {
+			missing = append(missing, capability)
+		}
+	}
+	return missing
+}
+
+// With returns a copy of the set extended with the given capabilities
+func (c Capabilities) With(caps ...Capability) Capabilities {
+	extended := make(Capabilities)
+	for capability := range c {
+		extended[capability] = true
+	}
+	for _, capability := range caps {
+		extended[capability] = true
+	}
+	return extended
+}
@@ -5,8 +5,10 @@ import (
 	"os"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-02-01/containerservice"
 	"github.com/libopenstorage/cloudops"
 	"github.com/libopenstorage/cloudops/azure"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/node/ssh"
 )
@@ -20,12 +22,19 @@ type aks struct {
 	ssh.SSH
 	ops           cloudops.Ops
 	instanceGroup string
+	agentPools    *containerservice.AgentPoolsClient
+	resourceGroup string
+	clusterName   string
 }
 
 func (a *aks) String() string {
 	return DriverName
 }
 
+func (a *aks) Capabilities() driver_api.Capabilities {
+	return a.SSH.Capabilities().With(node.CapabilityASGResize, node.CapabilityNodePools)
+}
+
 func (a *aks) Init(nodeOpts node.InitOptions) error {
 	a.SSH.Init(nodeOpts)
 
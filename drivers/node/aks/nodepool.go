@@ -0,0 +1,273 @@
+package aks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-02-01/containerservice"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// nodePoolLabel is the label AKS sets to the name of the agent pool on its nodes
+	nodePoolLabel = "agentpool"
+	// clusterLabel is the label AKS sets to the node resource group of the cluster on its nodes
+	clusterLabel = "kubernetes.azure.com/cluster"
+)
+
+// getAgentPoolsClient returns the agent pools client of the cluster. The subscription, resource group and cluster
+// are taken from the environment like the cloudops client does, or from a node of the cluster.
+func (a *aks) getAgentPoolsClient() (*containerservice.AgentPoolsClient, error) {
+	if a.agentPools != nil {
+		return a.agentPools, nil
+	}
+
+	authorizer, err := auth.NewAuthorizerFromEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	baseURI := azure.PublicCloud.ResourceManagerEndpoint
+	if name := os.Getenv(auth.EnvironmentName); len(name) != 0 {
+		env, err := azure.EnvironmentFromName(name)
+		if err != nil {
+			return nil, err
+		}
+		baseURI = env.ResourceManagerEndpoint
+	}
+
+	subscription := os.Getenv(auth.SubscriptionID)
+	a.resourceGroup = os.Getenv("AZURE_RESOURCE_GROUP_NAME")
+	a.clusterName = os.Getenv("AZURE_MANAGED_CLUSTER_NAME")
+	if len(subscription) == 0 || len(a.resourceGroup) == 0 || len(a.clusterName) == 0 {
+		nodes, err := core.Instance().GetNodes()
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes.Items) == 0 {
+			return nil, fmt.Errorf("no nodes to get the cluster of")
+		}
+		n := nodes.Items[0]
+		// provider ID is azure:///subscriptions/<subscription>/resourceGroups/...
+		fields := strings.Split(n.Spec.ProviderID, "/")
+		if len(fields) < 5 || fields[3] != "subscriptions" {
+			return nil, fmt.Errorf("unexpected provider ID %s of node %s", n.Spec.ProviderID, n.Name)
+		}
+		subscription = fields[4]
+		// resource group and cluster names may contain underscores, so they are not parsed from the node resource
+		// group but looked up by it
+		clusters := containerservice.NewManagedClustersClientWithBaseURI(baseURI, subscription)
+		clusters.Authorizer = authorizer
+		ctx := context.Background()
+		it, err := clusters.ListComplete(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list managed clusters. Err: %v", err)
+		}
+		var managedClusters []containerservice.ManagedCluster
+		for ; it.NotDone(); err = it.NextWithContext(ctx) {
+			if err != nil {
+				return nil, err
+			}
+			managedClusters = append(managedClusters, it.Value())
+		}
+		a.resourceGroup, a.clusterName, err = findManagedCluster(managedClusters, n.Labels[clusterLabel])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client := containerservice.NewAgentPoolsClientWithBaseURI(baseURI, subscription)
+	client.Authorizer = authorizer
+	a.agentPools = &client
+	return a.agentPools, nil
+}
+
+// findManagedCluster returns the resource group and name of the managed cluster with the node resource group
+func findManagedCluster(clusters []containerservice.ManagedCluster, nodeResourceGroup string) (string, string, error) {
+	for _, mc := range clusters {
+		if mc.ManagedClusterProperties == nil || mc.NodeResourceGroup == nil || mc.ID == nil || mc.Name == nil {
+			continue
+		}
+		if !strings.EqualFold(*mc.NodeResourceGroup, nodeResourceGroup) {
+			continue
+		}
+		// ID is /subscriptions/<subscription>/resourceGroups/<resource group>/providers/...
+		fields := strings.Split(*mc.ID, "/")
+		if len(fields) < 5 || !strings.EqualFold(fields[3], "resourceGroups") {
+			return "", "", fmt.Errorf("unexpected ID %s of managed cluster %s", *mc.ID, *mc.Name)
+		}
+		return fields[4], *mc.Name, nil
+	}
+	return "", "", fmt.Errorf("no managed cluster with node resource group %s", nodeResourceGroup)
+}
+
+// ListNodePools returns the agent pools of the cluster
+func (a *aks) ListNodePools() ([]node.NodePool, error) {
+	client, err := a.getAgentPoolsClient()
+	if err != nil {
+		return nil, err
+	}
+	members, err := node.GetNodePoolNodes(nodePoolLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	it, err := client.ListComplete(ctx, a.resourceGroup, a.clusterName)
+	if err != nil {
+		log.Errorf("failed to list node pools of cluster %s. Error: %v", a.clusterName, err)
+		return nil, err
+	}
+	var pools []node.NodePool
+	for ; it.NotDone(); err = it.NextWithContext(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		p := it.Value()
+		pool := node.NodePool{
+			Name:  *p.Name,
+			Zones: agentPoolZones(p),
+			Nodes: members[*p.Name],
+		}
+		if p.ManagedClusterAgentPoolProfileProperties != nil {
+			pool.InstanceType = string(p.VMSize)
+			if p.Count != nil {
+				pool.Size = int64(*p.Count)
+			}
+			if p.OrchestratorVersion != nil {
+				pool.Version = *p.OrchestratorVersion
+			}
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// SetNodePoolSize sets the node count of the agent pool
+func (a *aks) SetNodePoolSize(name string, size int64, timeout time.Duration) error {
+	return a.updateAgentPool(name, timeout, func(p *containerservice.AgentPool) {
+		// Azure SDK requires total pool size
+		count := int32(size)
+		log.Infof("Setting size of node pool %s to %d", name, count)
+		p.Count = &count
+	})
+}
+
+// AddNodePool adds a scale set agent pool with the VM size of the spec, in the zones and subnet of the first pool
+func (a *aks) AddNodePool(spec node.NodePoolSpec, timeout time.Duration) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	client, err := a.getAgentPoolsClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	template, err := a.getTemplateAgentPool(ctx)
+	if err != nil {
+		return err
+	}
+	count := int32(spec.Size(len(agentPoolZones(template))))
+	properties := &containerservice.ManagedClusterAgentPoolProfileProperties{
+		Count:        &count,
+		VMSize:       containerservice.VMSizeTypes(spec.InstanceType),
+		OsType:       containerservice.Linux,
+		Type:         containerservice.VirtualMachineScaleSets,
+		VnetSubnetID: template.VnetSubnetID,
+	}
+	if template.AvailabilityZones != nil {
+		properties.AvailabilityZones = template.AvailabilityZones
+	}
+
+	log.Infof("Adding node pool %s of %d %s nodes", spec.Name, count, spec.InstanceType)
+	future, err := client.CreateOrUpdate(ctx, a.resourceGroup, a.clusterName, spec.Name,
+		containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: properties})
+	if err != nil {
+		log.Errorf("failed to add node pool %s. Error: %v", spec.Name, err)
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, client.Client)
+}
+
+// DeleteNodePool deletes the agent pool from the cluster
+func (a *aks) DeleteNodePool(name string, timeout time.Duration) error {
+	client, err := a.getAgentPoolsClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	log.Infof("Deleting node pool %s", name)
+	future, err := client.Delete(ctx, a.resourceGroup, a.clusterName, name)
+	if err != nil {
+		log.Errorf("failed to delete node pool %s. Error: %v", name, err)
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, client.Client)
+}
+
+// UpgradeNodePool upgrades the nodes of the agent pool to the kubernetes version
+func (a *aks) UpgradeNodePool(name string, version string, timeout time.Duration) error {
+	return a.updateAgentPool(name, timeout, func(p *containerservice.AgentPool) {
+		log.Infof("Upgrading node pool %s to version %s", name, version)
+		p.OrchestratorVersion = &version
+	})
+}
+
+// updateAgentPool gets the agent pool, applies the update to it and puts it back
+func (a *aks) updateAgentPool(name string, timeout time.Duration, update func(*containerservice.AgentPool)) error {
+	client, err := a.getAgentPoolsClient()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	pool, err := client.Get(ctx, a.resourceGroup, a.clusterName, name)
+	if err != nil {
+		log.Errorf("failed to get node pool %s. Error: %v", name, err)
+		return err
+	}
+	if pool.ManagedClusterAgentPoolProfileProperties == nil {
+		return fmt.Errorf("node pool %s has no properties", name)
+	}
+	update(&pool)
+	pool.ProvisioningState = nil
+
+	future, err := client.CreateOrUpdate(ctx, a.resourceGroup, a.clusterName, name,
+		containerservice.AgentPool{ManagedClusterAgentPoolProfileProperties: pool.ManagedClusterAgentPoolProfileProperties})
+	if err != nil {
+		log.Errorf("failed to update node pool %s. Error: %v", name, err)
+		return err
+	}
+	return future.WaitForCompletionRef(ctx, client.Client)
+}
+
+// getTemplateAgentPool returns the first agent pool of the cluster, whose network and zones new pools get
+func (a *aks) getTemplateAgentPool(ctx context.Context) (containerservice.AgentPool, error) {
+	it, err := a.agentPools.ListComplete(ctx, a.resourceGroup, a.clusterName)
+	if err != nil {
+		return containerservice.AgentPool{}, err
+	}
+	if !it.NotDone() || it.Value().ManagedClusterAgentPoolProfileProperties == nil {
+		return containerservice.AgentPool{}, fmt.Errorf("cluster %s has no node pool to take the network from", a.clusterName)
+	}
+	return it.Value(), nil
+}
+
+// agentPoolZones returns the availability zones of the agent pool, none for a pool without zones
+func agentPoolZones(p containerservice.AgentPool) []string {
+	if p.ManagedClusterAgentPoolProfileProperties == nil || p.AvailabilityZones == nil {
+		return nil
+	}
+	return *p.AvailabilityZones
+}
@@ -0,0 +1,32 @@
+package aks
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/services/containerservice/mgmt/2019-02-01/containerservice"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindManagedCluster(t *testing.T) {
+	managedCluster := func(id, name, nodeResourceGroup string) containerservice.ManagedCluster {
+		return containerservice.ManagedCluster{
+			ID:                       &id,
+			Name:                     &name,
+			ManagedClusterProperties: &containerservice.ManagedClusterProperties{NodeResourceGroup: &nodeResourceGroup},
+		}
+	}
+	clusters := []containerservice.ManagedCluster{
+		managedCluster("/subscriptions/sub/resourcegroups/other/providers/Microsoft.ContainerService/managedClusters/other",
+			"other", "MC_other_other_eastus"),
+		managedCluster("/subscriptions/sub/resourceGroups/px_rg/providers/Microsoft.ContainerService/managedClusters/px_aks_1",
+			"px_aks_1", "MC_px_rg_px_aks_1_eastus"),
+	}
+
+	rg, name, err := findManagedCluster(clusters, "mc_px_rg_px_aks_1_eastus")
+	require.NoError(t, err)
+	require.Equal(t, "px_rg", rg)
+	require.Equal(t, "px_aks_1", name)
+
+	_, _, err = findManagedCluster(clusters, "MC_missing_missing_eastus")
+	require.Error(t, err)
+}
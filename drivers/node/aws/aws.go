@@ -6,8 +6,10 @@ import (
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/portworx/sched-ops/task"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/pkg/log"
 	"os"
@@ -29,12 +31,24 @@ type aws struct {
 	svc         *ec2.EC2
 	svcSsm      *ssm.SSM
 	instances   []*ec2.Instance
+	eks         *eks.EKS
+	clusterName string
 }
 
 func (a *aws) String() string {
 	return DriverName
 }
 
+func (a *aws) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities(
+		node.CapabilityReboot,
+		node.CapabilityShutdown,
+		node.CapabilityDeleteNode,
+		node.CapabilitySystemctl,
+		node.CapabilityNodePools,
+	)
+}
+
 func (a *aws) Init(nodeOpts node.InitOptions) error {
 	var err error
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
@@ -0,0 +1,287 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	aws_pkg "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// nodePoolLabel is the label EKS sets to the name of the managed node group on its nodes
+	nodePoolLabel = "eks.amazonaws.com/nodegroup"
+	// clusterNameTag is the tag EKS sets to the name of the cluster on the instances of its node groups
+	clusterNameTag = "eks:cluster-name"
+	// updateRetryInterval is how often a node group update is checked for completion
+	updateRetryInterval = 30 * time.Second
+)
+
+// getEKS returns the EKS client and the name of the cluster, taken from the EKS_CLUSTER_NAME env var or from the
+// tags of the instances
+func (a *aws) getEKS() (*eks.EKS, string, error) {
+	if a.eks != nil {
+		return a.eks, a.clusterName, nil
+	}
+	clusterName := os.Getenv("EKS_CLUSTER_NAME")
+	if len(clusterName) == 0 {
+		for _, i := range a.instances {
+			for _, tag := range i.Tags {
+				if aws_pkg.StringValue(tag.Key) == clusterNameTag {
+					clusterName = aws_pkg.StringValue(tag.Value)
+				}
+			}
+		}
+	}
+	if len(clusterName) == 0 {
+		return nil, "", fmt.Errorf("EKS cluster name not provided as env var EKS_CLUSTER_NAME nor found in the %s tag of the instances", clusterNameTag)
+	}
+	a.eks = eks.New(a.session, a.config)
+	a.clusterName = clusterName
+	return a.eks, a.clusterName, nil
+}
+
+// ListNodePools returns the managed node groups of the cluster
+func (a *aws) ListNodePools() ([]node.NodePool, error) {
+	svc, clusterName, err := a.getEKS()
+	if err != nil {
+		return nil, err
+	}
+	members, err := node.GetNodePoolNodes(nodePoolLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []*string
+	err = svc.ListNodegroupsPages(&eks.ListNodegroupsInput{ClusterName: aws_pkg.String(clusterName)},
+		func(page *eks.ListNodegroupsOutput, lastPage bool) bool {
+			names = append(names, page.Nodegroups...)
+			return true
+		})
+	if err != nil {
+		log.Errorf("failed to list node groups of cluster %s. Error: %v", clusterName, err)
+		return nil, err
+	}
+
+	var pools []node.NodePool
+	for _, name := range names {
+		ng, err := a.describeNodegroup(aws_pkg.StringValue(name))
+		if err != nil {
+			return nil, err
+		}
+		zones, err := a.getSubnetZones(ng.Subnets)
+		if err != nil {
+			return nil, err
+		}
+		pool := node.NodePool{
+			Name:    aws_pkg.StringValue(ng.NodegroupName),
+			Zones:   zones,
+			Version: aws_pkg.StringValue(ng.Version),
+			Nodes:   members[aws_pkg.StringValue(ng.NodegroupName)],
+		}
+		if len(ng.InstanceTypes) > 0 {
+			pool.InstanceType = aws_pkg.StringValue(ng.InstanceTypes[0])
+		}
+		if ng.ScalingConfig != nil {
+			pool.Size = aws_pkg.Int64Value(ng.ScalingConfig.DesiredSize)
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// SetNodePoolSize sets the desired size of the node group, widening its minimum and maximum size if needed
+func (a *aws) SetNodePoolSize(name string, size int64, timeout time.Duration) error {
+	svc, clusterName, err := a.getEKS()
+	if err != nil {
+		return err
+	}
+	ng, err := a.describeNodegroup(name)
+	if err != nil {
+		return err
+	}
+	scaling := &eks.NodegroupScalingConfig{
+		DesiredSize: aws_pkg.Int64(size),
+		MinSize:     ng.ScalingConfig.MinSize,
+		MaxSize:     ng.ScalingConfig.MaxSize,
+	}
+	if aws_pkg.Int64Value(scaling.MinSize) > size {
+		scaling.MinSize = aws_pkg.Int64(size)
+	}
+	if aws_pkg.Int64Value(scaling.MaxSize) < size {
+		scaling.MaxSize = aws_pkg.Int64(size)
+	}
+
+	log.Infof("Setting size of node group %s to %d", name, size)
+	out, err := svc.UpdateNodegroupConfig(&eks.UpdateNodegroupConfigInput{
+		ClusterName:   aws_pkg.String(clusterName),
+		NodegroupName: aws_pkg.String(name),
+		ScalingConfig: scaling,
+	})
+	if err != nil {
+		log.Errorf("failed to set size of node group %s. Error: %v", name, err)
+		return err
+	}
+	return a.waitForUpdate(name, out.Update, timeout)
+}
+
+// AddNodePool adds a managed node group with the instance type of the spec, in the subnets and with the role of
+// the first node group
+func (a *aws) AddNodePool(spec node.NodePoolSpec, timeout time.Duration) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	svc, clusterName, err := a.getEKS()
+	if err != nil {
+		return err
+	}
+	out, err := svc.ListNodegroups(&eks.ListNodegroupsInput{ClusterName: aws_pkg.String(clusterName)})
+	if err != nil {
+		return err
+	}
+	if len(out.Nodegroups) == 0 {
+		return fmt.Errorf("cluster %s has no node group to take the subnets and role from", clusterName)
+	}
+	template, err := a.describeNodegroup(aws_pkg.StringValue(out.Nodegroups[0]))
+	if err != nil {
+		return err
+	}
+	zones, err := a.getSubnetZones(template.Subnets)
+	if err != nil {
+		return err
+	}
+	size := spec.Size(len(zones))
+
+	log.Infof("Adding node group %s of %d %s nodes", spec.Name, size, spec.InstanceType)
+	_, err = svc.CreateNodegroup(&eks.CreateNodegroupInput{
+		ClusterName:   aws_pkg.String(clusterName),
+		NodegroupName: aws_pkg.String(spec.Name),
+		NodeRole:      template.NodeRole,
+		Subnets:       template.Subnets,
+		InstanceTypes: []*string{aws_pkg.String(spec.InstanceType)},
+		ScalingConfig: &eks.NodegroupScalingConfig{
+			DesiredSize: aws_pkg.Int64(size),
+			MinSize:     aws_pkg.Int64(size),
+			MaxSize:     aws_pkg.Int64(size),
+		},
+	})
+	if err != nil {
+		log.Errorf("failed to add node group %s. Error: %v", spec.Name, err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return svc.WaitUntilNodegroupActiveWithContext(ctx, &eks.DescribeNodegroupInput{
+		ClusterName:   aws_pkg.String(clusterName),
+		NodegroupName: aws_pkg.String(spec.Name),
+	})
+}
+
+// DeleteNodePool deletes the node group from the cluster
+func (a *aws) DeleteNodePool(name string, timeout time.Duration) error {
+	svc, clusterName, err := a.getEKS()
+	if err != nil {
+		return err
+	}
+	log.Infof("Deleting node group %s", name)
+	if _, err := svc.DeleteNodegroup(&eks.DeleteNodegroupInput{
+		ClusterName:   aws_pkg.String(clusterName),
+		NodegroupName: aws_pkg.String(name),
+	}); err != nil {
+		log.Errorf("failed to delete node group %s. Error: %v", name, err)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return svc.WaitUntilNodegroupDeletedWithContext(ctx, &eks.DescribeNodegroupInput{
+		ClusterName:   aws_pkg.String(clusterName),
+		NodegroupName: aws_pkg.String(name),
+	})
+}
+
+// UpgradeNodePool upgrades the nodes of the node group to the kubernetes version
+func (a *aws) UpgradeNodePool(name string, version string, timeout time.Duration) error {
+	svc, clusterName, err := a.getEKS()
+	if err != nil {
+		return err
+	}
+	log.Infof("Upgrading node group %s to version %s", name, version)
+	out, err := svc.UpdateNodegroupVersion(&eks.UpdateNodegroupVersionInput{
+		ClusterName:   aws_pkg.String(clusterName),
+		NodegroupName: aws_pkg.String(name),
+		Version:       aws_pkg.String(version),
+	})
+	if err != nil {
+		log.Errorf("failed to set version for node group %s. Error: %v", name, err)
+		return err
+	}
+	return a.waitForUpdate(name, out.Update, timeout)
+}
+
+func (a *aws) describeNodegroup(name string) (*eks.Nodegroup, error) {
+	out, err := a.eks.DescribeNodegroup(&eks.DescribeNodegroupInput{
+		ClusterName:   aws_pkg.String(a.clusterName),
+		NodegroupName: aws_pkg.String(name),
+	})
+	if err != nil {
+		log.Errorf("failed to describe node group %s. Error: %v", name, err)
+		return nil, err
+	}
+	return out.Nodegroup, nil
+}
+
+// getSubnetZones returns the sorted availability zones of the subnets
+func (a *aws) getSubnetZones(subnets []*string) ([]string, error) {
+	out, err := a.svc.DescribeSubnets(&ec2.DescribeSubnetsInput{SubnetIds: subnets})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe subnets %v. Error: %v", aws_pkg.StringValueSlice(subnets), err)
+	}
+	seen := make(map[string]bool)
+	var zones []string
+	for _, subnet := range out.Subnets {
+		zone := aws_pkg.StringValue(subnet.AvailabilityZone)
+		if !seen[zone] {
+			seen[zone] = true
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+// waitForUpdate waits for the node group update to be successful
+func (a *aws) waitForUpdate(name string, update *eks.Update, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		out, err := a.eks.DescribeUpdate(&eks.DescribeUpdateInput{
+			Name:          aws_pkg.String(a.clusterName),
+			NodegroupName: aws_pkg.String(name),
+			UpdateId:      update.Id,
+		})
+		if err != nil {
+			return nil, true, err
+		}
+		switch status := aws_pkg.StringValue(out.Update.Status); status {
+		case eks.UpdateStatusSuccessful:
+			return nil, false, nil
+		case eks.UpdateStatusFailed, eks.UpdateStatusCancelled:
+			var errs []string
+			for _, e := range out.Update.Errors {
+				errs = append(errs, aws_pkg.StringValue(e.ErrorMessage))
+			}
+			return nil, false, fmt.Errorf("update %s of node group %s is %s: %s",
+				aws_pkg.StringValue(update.Id), name, status, strings.Join(errs, "; "))
+		default:
+			return nil, true, fmt.Errorf("update %s of node group %s is %s", aws_pkg.StringValue(update.Id), name, status)
+		}
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, updateRetryInterval)
+	return err
+}
@@ -0,0 +1,26 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClockSkewHandle is a clock skew injected on a node
+type ClockSkewHandle struct {
+	// ID identifies the skew. The script and the transient systemd unit restoring the clock are named after it.
+	ID string
+	// Node is the node whose clock is skewed
+	Node Node
+	// Offset is how far the clock of the node was moved, negative if back in time
+	Offset time.Duration
+	// NTPServices are the time sync services stopped for the skew and started again on restore
+	NTPServices []string
+	// NTPEnabled is whether time sync was enabled in timedatectl before the skew
+	NTPEnabled bool
+	// Until is when the clock restores itself
+	Until time.Time
+}
+
+func (h *ClockSkewHandle) String() string {
+	return fmt.Sprintf("%s (%v on %s until %s)", h.ID, h.Offset, h.Node.Name, h.Until.Format(time.RFC3339))
+}
@@ -0,0 +1,105 @@
+package node
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCommandConcurrency is the number of nodes a command runs on at a time when no concurrency is given
+const DefaultCommandConcurrency = 10
+
+// ParallelCommandOpts are the options to run a command on many nodes at once
+type ParallelCommandOpts struct {
+	ConnectionOpts
+	// Concurrency is the maximum number of nodes the command runs on at a time
+	Concurrency int
+	// OnOutput, if set, is called with each line of output of the command as the node writes it. It is called from
+	// the goroutine of each node, so it must be safe for concurrent use.
+	OnOutput func(n Node, line string)
+}
+
+// CommandResult is the result of running a command on one node
+type CommandResult struct {
+	Node     Node
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// RunOnNodes runs the function for each node with at most the given number of nodes at a time and returns the
+// results in the order of the nodes. It returns an ErrFailedToRunCommandOnNodes if the function failed on any node.
+func RunOnNodes(nodes []Node, concurrency int, run func(n Node) (string, error)) ([]CommandResult, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultCommandConcurrency
+	}
+	results := make([]CommandResult, len(nodes))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n Node) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			start := time.Now()
+			output, err := run(n)
+			results[i] = CommandResult{Node: n, Output: output, Err: err, Duration: time.Since(start)}
+		}(i, n)
+	}
+	wg.Wait()
+
+	var failed []CommandResult
+	for _, r := range results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	if len(failed) > 0 {
+		return results, &ErrFailedToRunCommandOnNodes{Failed: failed, Total: len(nodes)}
+	}
+	return results, nil
+}
+
+// LineWriter is an io.Writer which calls the function with each complete line written to it and keeps all of the
+// output. Flush calls the function with the last line if it has no newline.
+type LineWriter struct {
+	onLine  func(line string)
+	output  strings.Builder
+	partial string
+	mu      sync.Mutex
+}
+
+// NewLineWriter returns a LineWriter which calls onLine with each line
+func NewLineWriter(onLine func(line string)) *LineWriter {
+	return &LineWriter{onLine: onLine}
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.output.Write(p)
+	lines := strings.Split(w.partial+string(p), "\n")
+	w.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		w.onLine(line)
+	}
+	return len(p), nil
+}
+
+// Flush calls the function with the last line if it has no newline
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.partial) > 0 {
+		w.onLine(w.partial)
+		w.partial = ""
+	}
+}
+
+// String returns all of the output written so far
+func (w *LineWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.output.String()
+}
@@ -0,0 +1,54 @@
+package node
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOnNodes(t *testing.T) {
+	nodes := []Node{{Name: "node-1"}, {Name: "node-2"}, {Name: "node-3"}, {Name: "node-4"}}
+	var running, maxRunning int32
+	results, err := RunOnNodes(nodes, 2, func(n Node) (string, error) {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if n.Name == "node-3" {
+			return "", fmt.Errorf("unreachable")
+		}
+		return "up " + n.Name, nil
+	})
+
+	require.LessOrEqual(t, maxRunning, int32(2))
+	require.Len(t, results, 4)
+	require.Equal(t, "up node-1", results[0].Output)
+	require.Equal(t, "up node-4", results[3].Output)
+	require.Error(t, results[2].Err)
+
+	failedErr, ok := err.(*ErrFailedToRunCommandOnNodes)
+	require.True(t, ok)
+	require.Len(t, failedErr.Failed, 1)
+	require.Equal(t, "node-3", failedErr.Failed[0].Node.Name)
+}
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := NewLineWriter(func(line string) {
+		lines = append(lines, line)
+	})
+	w.Write([]byte("first\nsec"))
+	w.Write([]byte("ond\nthi"))
+	require.Equal(t, []string{"first", "second"}, lines)
+	w.Flush()
+	require.Equal(t, []string{"first", "second", "thi"}, lines)
+	require.Equal(t, "first\nsecond\nthi", w.String())
+}
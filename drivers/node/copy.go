@@ -0,0 +1,32 @@
+package node
+
+// DefaultMaxCopySize is the size of the largest file copied to or from a node when no maximum size is given
+const DefaultMaxCopySize = 1 << 30
+
+// CopyOpts are the options to copy a file to or from a node
+type CopyOpts struct {
+	// ConnectionOpts with Sudo set copy the file as root, for files only root may access
+	ConnectionOpts
+	// MaxSize is the size in bytes of the largest file to copy. Larger files are not copied.
+	MaxSize int64
+}
+
+// CopiedFile is a file copied to or from a node
+type CopiedFile struct {
+	// Path is the path of the file on the node
+	Path string
+	// LocalPath is the path of the file on the torpedo side
+	LocalPath string
+	// Size is the size of the file in bytes
+	Size int64
+	// SHA256 is the hex encoded sha256 checksum of the file, which is the same on the node and the torpedo side
+	SHA256 string
+}
+
+// GetMaxSize returns the maximum size of the options or the default one if there is none
+func (o CopyOpts) GetMaxSize() int64 {
+	if o.MaxSize <= 0 {
+		return DefaultMaxCopySize
+	}
+	return o.MaxSize
+}
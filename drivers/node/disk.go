@@ -0,0 +1,61 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+// DiskFaultType identifies the kind of a disk fault
+type DiskFaultType string
+
+const (
+	// DiskFaultDelay delays all I/O to the disk by Delay
+	DiskFaultDelay DiskFaultType = "delay"
+	// DiskFaultFlakey lets the disk work for UpInterval and then fails all its I/O for DownInterval, repeatedly
+	DiskFaultFlakey DiskFaultType = "flakey"
+	// DiskFaultReadOnly fails all writes to the disk, reads still succeed
+	DiskFaultReadOnly DiskFaultType = "read-only"
+	// DiskFaultError fails all I/O to the disk
+	DiskFaultError DiskFaultType = "error"
+)
+
+// DiskFault describes a fault to inject on a disk. All faults can be injected on device-mapper devices with linear
+// targets, other SCSI disks only support DiskFaultError.
+type DiskFault struct {
+	// Type is the kind of the fault
+	Type DiskFaultType
+	// Delay is the delay of the I/O for delay faults
+	Delay time.Duration
+	// UpInterval is how long the disk works between failures for flakey faults
+	UpInterval time.Duration
+	// DownInterval is how long the disk fails for flakey faults
+	DownInterval time.Duration
+}
+
+// Validate checks the fault has the parameters its type needs
+func (f DiskFault) Validate() error {
+	switch f.Type {
+	case DiskFaultDelay:
+		if f.Delay < time.Millisecond {
+			return fmt.Errorf("delay fault needs a delay of at least 1ms")
+		}
+	case DiskFaultFlakey:
+		if f.UpInterval < time.Second || f.DownInterval < time.Second {
+			return fmt.Errorf("flakey fault needs up and down intervals of at least 1s")
+		}
+	case DiskFaultReadOnly, DiskFaultError:
+	default:
+		return fmt.Errorf("unknown disk fault type %s", f.Type)
+	}
+	return nil
+}
+
+// DiskFaultState is a disk fault injected on a node, as recorded on the node
+type DiskFaultState struct {
+	// Node is the name of the node of the disk
+	Node string
+	// Device is the path of the disk the fault was injected on
+	Device string
+	// DMName is the name of the device-mapper device of the disk, empty for disks faulted through their SCSI state
+	DMName string
+}
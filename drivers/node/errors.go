@@ -2,6 +2,7 @@ package node
 
 import (
 	"fmt"
+	"strings"
 )
 
 // ErrFailedToTestConnection error type when failing to test connection
@@ -85,6 +86,31 @@ func (e *ErrFailedToRunCommand) Error() string {
 	return fmt.Sprintf("Failed to run command on: %v. Cause: %v", e.Addr, e.Cause)
 }
 
+// ErrFailedToCopyFile error type when failing to copy a file to or from a node
+type ErrFailedToCopyFile struct {
+	Node  Node
+	Path  string
+	Cause string
+}
+
+func (e *ErrFailedToCopyFile) Error() string {
+	return fmt.Sprintf("Failed to copy file %v of node: %v. Cause: %v", e.Path, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToRunCommandOnNodes error type when a command fails on some of the nodes it is run on
+type ErrFailedToRunCommandOnNodes struct {
+	Failed []CommandResult
+	Total  int
+}
+
+func (e *ErrFailedToRunCommandOnNodes) Error() string {
+	var causes []string
+	for _, r := range e.Failed {
+		causes = append(causes, fmt.Sprintf("%s: %v", r.Node.Name, r.Err))
+	}
+	return fmt.Sprintf("Failed to run command on %d of %d nodes. Cause: %s", len(e.Failed), e.Total, strings.Join(causes, "; "))
+}
+
 // ErrFailedToYankDrive error type when we fail to simulate drive failure
 type ErrFailedToYankDrive struct {
 	Node  Node
@@ -124,3 +150,114 @@ type ErrFailedToDeleteNode struct {
 func (e *ErrFailedToDeleteNode) Error() string {
 	return fmt.Sprintf("Failed to delete node: %v. Cause: %v", e.Node.Name, e.Cause)
 }
+
+// ErrFailedToInjectNetworkFault error type when failing to inject a network fault on a node
+type ErrFailedToInjectNetworkFault struct {
+	Node  Node
+	Fault NetworkFaultType
+	Cause string
+}
+
+func (e *ErrFailedToInjectNetworkFault) Error() string {
+	return fmt.Sprintf("Failed to inject %v network fault on node: %v. Cause: %v", e.Fault, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToRemoveNetworkFault error type when failing to remove a network fault from a node
+type ErrFailedToRemoveNetworkFault struct {
+	Node  Node
+	ID    string
+	Cause string
+}
+
+func (e *ErrFailedToRemoveNetworkFault) Error() string {
+	return fmt.Sprintf("Failed to remove network fault %v from node: %v. Cause: %v", e.ID, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToInjectDiskFault error type when failing to inject a disk fault on a node
+type ErrFailedToInjectDiskFault struct {
+	Node   Node
+	Device string
+	Cause  string
+}
+
+func (e *ErrFailedToInjectDiskFault) Error() string {
+	return fmt.Sprintf("Failed to inject fault on disk %v of node: %v. Cause: %v", e.Device, e.Node.Name, e.Cause)
+}
+
+// ErrDiskNotFaultable error type when the fault cannot be injected on a disk of a node, e.g. since it is a
+// device-mapper device without linear targets
+type ErrDiskNotFaultable struct {
+	Node   Node
+	Device string
+	Cause  string
+}
+
+func (e *ErrDiskNotFaultable) Error() string {
+	return fmt.Sprintf("Cannot inject fault on disk %v of node: %v. Cause: %v", e.Device, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToRecoverDiskFault error type when failing to restore a faulty disk of a node
+type ErrFailedToRecoverDiskFault struct {
+	Node   Node
+	Device string
+	Cause  string
+}
+
+func (e *ErrFailedToRecoverDiskFault) Error() string {
+	return fmt.Sprintf("Failed to recover disk %v of node: %v. Cause: %v", e.Device, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToSignalProcess error type when failing to send a signal to processes of a node
+type ErrFailedToSignalProcess struct {
+	Node    Node
+	Process string
+	Cause   string
+}
+
+func (e *ErrFailedToSignalProcess) Error() string {
+	return fmt.Sprintf("Failed to signal process %v on node: %v. Cause: %v", e.Process, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToInjectProcessChaos error type when failing to inject or revert a process chaos on a node
+type ErrFailedToInjectProcessChaos struct {
+	Node  Node
+	Chaos ProcessChaosType
+	Cause string
+}
+
+func (e *ErrFailedToInjectProcessChaos) Error() string {
+	return fmt.Sprintf("Failed to inject %v chaos on node: %v. Cause: %v", e.Chaos, e.Node.Name, e.Cause)
+}
+
+// ErrFailedToSkewClock error type when failing to skew or restore the clock of a node
+type ErrFailedToSkewClock struct {
+	Node  Node
+	Cause string
+}
+
+func (e *ErrFailedToSkewClock) Error() string {
+	return fmt.Sprintf("Failed to skew clock of node: %v. Cause: %v", e.Node.Name, e.Cause)
+}
+
+// ErrFailedToHotplugVMDevice error type when failing to detach or attach a device of the VM of a node
+type ErrFailedToHotplugVMDevice struct {
+	Node   Node
+	Device VMDevice
+	Cause  string
+}
+
+func (e *ErrFailedToHotplugVMDevice) Error() string {
+	return fmt.Sprintf("Failed to hotplug %v of node: %v. Cause: %v", e.Device, e.Node.Name, e.Cause)
+}
+
+// ErrVMDeviceInUse error type when refusing to detach a device the node cannot do without, like the NIC torpedo
+// reaches the node through
+type ErrVMDeviceInUse struct {
+	Node   Node
+	Device VMDevice
+	Cause  string
+}
+
+func (e *ErrVMDeviceInUse) Error() string {
+	return fmt.Sprintf("Refusing to detach %v of node: %v. Cause: %v", e.Device, e.Node.Name, e.Cause)
+}
@@ -3,9 +3,11 @@ package gke
 import (
 	"github.com/libopenstorage/cloudops"
 	"github.com/libopenstorage/cloudops/gce"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/node/ssh"
 	"github.com/portworx/torpedo/pkg/log"
+	container "google.golang.org/api/container/v1"
 	"os"
 	"time"
 )
@@ -17,14 +19,22 @@ const (
 
 type gke struct {
 	ssh.SSH
-	ops           cloudops.Ops
-	instanceGroup string
+	ops              cloudops.Ops
+	instanceGroup    string
+	containerService *container.Service
+	locationPath     string
+	clusterPath      string
 }
 
 func (g *gke) String() string {
 	return DriverName
 }
 
+func (g *gke) Capabilities() driver_api.Capabilities {
+	return g.SSH.Capabilities().With(node.CapabilityASGResize, node.CapabilityClusterUpgrade, node.CapabilityDeleteNode,
+		node.CapabilityNodePools)
+}
+
 func (g *gke) Init(nodeOpts node.InitOptions) error {
 	g.SSH.Init(nodeOpts)
 
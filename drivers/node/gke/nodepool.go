@@ -0,0 +1,191 @@
+package gke
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/option"
+)
+
+const (
+	// nodePoolLabel is the label GKE sets to the name of the node pool on its nodes
+	nodePoolLabel = "cloud.google.com/gke-nodepool"
+	// operationRetryInterval is how often a node pool operation is checked for completion
+	operationRetryInterval = 30 * time.Second
+)
+
+// getContainerService returns the GKE API client and the path of the cluster, taken from the environment like the
+// cloudops client does, or from the metadata server
+func (g *gke) getContainerService() (*container.Service, string, error) {
+	if g.containerService != nil {
+		return g.containerService, g.clusterPath, nil
+	}
+
+	project, location, cluster := os.Getenv("GCE_INSTANCE_PROJECT"), os.Getenv("GKE_CLUSTER_LOCATION"), os.Getenv("GKE_CLUSTER_NAME")
+	if len(project) == 0 || len(location) == 0 || len(cluster) == 0 {
+		var err error
+		if project, err = metadata.ProjectID(); err != nil {
+			return nil, "", fmt.Errorf("failed to get project from metadata. Err: %v", err)
+		}
+		if location, err = metadata.InstanceAttributeValue("cluster-location"); err != nil {
+			return nil, "", fmt.Errorf("failed to get cluster location from metadata. Err: %v", err)
+		}
+		if cluster, err = metadata.InstanceAttributeValue("cluster-name"); err != nil {
+			return nil, "", fmt.Errorf("failed to get cluster name from metadata. Err: %v", err)
+		}
+	}
+
+	svc, err := container.NewService(context.Background(), option.WithScopes(container.CloudPlatformScope))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to create Container service: %v", err)
+	}
+	g.containerService = svc
+	g.locationPath = fmt.Sprintf("projects/%s/locations/%s", project, location)
+	g.clusterPath = fmt.Sprintf("%s/clusters/%s", g.locationPath, cluster)
+	return g.containerService, g.clusterPath, nil
+}
+
+// ListNodePools returns the node pools of the cluster
+func (g *gke) ListNodePools() ([]node.NodePool, error) {
+	svc, clusterPath, err := g.getContainerService()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := svc.Projects.Locations.Clusters.NodePools.List(clusterPath).Do()
+	if err != nil {
+		log.Errorf("failed to list node pools of cluster %s. Error: %v", clusterPath, err)
+		return nil, err
+	}
+	cluster, err := svc.Projects.Locations.Clusters.Get(clusterPath).Do()
+	if err != nil {
+		log.Errorf("failed to get cluster %s. Error: %v", clusterPath, err)
+		return nil, err
+	}
+	members, err := node.GetNodePoolNodes(nodePoolLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	var pools []node.NodePool
+	for _, p := range resp.NodePools {
+		size, err := g.ops.GetInstanceGroupSize(p.Name)
+		if err != nil {
+			log.Errorf("failed to get size of node pool %s. Error: %v", p.Name, err)
+			return nil, err
+		}
+		pool := node.NodePool{
+			Name:    p.Name,
+			Size:    size,
+			Zones:   p.Locations,
+			Version: p.Version,
+			Nodes:   members[p.Name],
+		}
+		if len(pool.Zones) == 0 {
+			pool.Zones = cluster.Locations
+		}
+		if p.Config != nil {
+			pool.InstanceType = p.Config.MachineType
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// SetNodePoolSize sets the node count of the node pool, which is sized per zone
+func (g *gke) SetNodePoolSize(name string, size int64, timeout time.Duration) error {
+	pools, err := g.ListNodePools()
+	if err != nil {
+		return err
+	}
+	pool, err := node.GetNodePool(pools, name)
+	if err != nil {
+		return err
+	}
+	perZoneCount, err := pool.PerZoneSize(size)
+	if err != nil {
+		return err
+	}
+	// GCP SDK requires per zone cluster size
+	if err := g.ops.SetInstanceGroupSize(name, perZoneCount, timeout); err != nil {
+		log.Errorf("failed to set size of node pool %s. Error: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+// AddNodePool adds a node pool with the machine type of the spec in the zones of the cluster
+func (g *gke) AddNodePool(spec node.NodePoolSpec, timeout time.Duration) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	svc, clusterPath, err := g.getContainerService()
+	if err != nil {
+		return err
+	}
+	log.Infof("Adding node pool %s of %d %s nodes per zone", spec.Name, spec.PerZoneCount, spec.InstanceType)
+	op, err := svc.Projects.Locations.Clusters.NodePools.Create(clusterPath, &container.CreateNodePoolRequest{
+		NodePool: &container.NodePool{
+			Name:             spec.Name,
+			InitialNodeCount: spec.PerZoneCount,
+			Config:           &container.NodeConfig{MachineType: spec.InstanceType},
+		},
+	}).Do()
+	if err != nil {
+		log.Errorf("failed to add node pool %s. Error: %v", spec.Name, err)
+		return err
+	}
+	return g.waitForOperation(op, timeout)
+}
+
+// DeleteNodePool deletes the node pool from the cluster
+func (g *gke) DeleteNodePool(name string, timeout time.Duration) error {
+	svc, clusterPath, err := g.getContainerService()
+	if err != nil {
+		return err
+	}
+	log.Infof("Deleting node pool %s", name)
+	op, err := svc.Projects.Locations.Clusters.NodePools.Delete(fmt.Sprintf("%s/nodePools/%s", clusterPath, name)).Do()
+	if err != nil {
+		log.Errorf("failed to delete node pool %s. Error: %v", name, err)
+		return err
+	}
+	return g.waitForOperation(op, timeout)
+}
+
+// UpgradeNodePool upgrades the nodes of the node pool to the version
+func (g *gke) UpgradeNodePool(name string, version string, timeout time.Duration) error {
+	log.Infof("Upgrading node pool %s to version %s", name, version)
+	if err := g.ops.SetInstanceGroupVersion(name, version, timeout); err != nil {
+		log.Errorf("failed to set version for node pool %s. Error: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+// waitForOperation waits for the node pool operation to be done and returns its error, if any
+func (g *gke) waitForOperation(op *container.Operation, timeout time.Duration) error {
+	// operations are under the location of the cluster, not under the cluster itself
+	opPath := fmt.Sprintf("%s/operations/%s", g.locationPath, op.Name)
+	t := func() (interface{}, bool, error) {
+		current, err := g.containerService.Projects.Locations.Operations.Get(opPath).Do()
+		if err != nil {
+			return nil, true, err
+		}
+		if current.Status != "DONE" {
+			return nil, true, fmt.Errorf("operation %s is in %s state. Waiting to become DONE", op.Name, current.Status)
+		}
+		if current.StatusMessage != "" {
+			return nil, false, fmt.Errorf("operation %s failed: %s", op.Name, current.StatusMessage)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, operationRetryInterval)
+	return err
+}
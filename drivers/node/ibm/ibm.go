@@ -6,8 +6,10 @@ import (
 
 	"github.com/portworx/torpedo/pkg/log"
 
+	v2 "github.com/IBM-Cloud/bluemix-go/api/container/containerv2"
 	"github.com/libopenstorage/cloudops"
 	iks "github.com/libopenstorage/cloudops/ibm"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/node/ssh"
 )
@@ -21,12 +23,18 @@ type ibm struct {
 	ssh.SSH
 	ops           cloudops.Ops
 	instanceGroup string
+	clusterClient v2.ContainerServiceAPI
+	clusterName   string
 }
 
 func (i *ibm) String() string {
 	return DriverName
 }
 
+func (i *ibm) Capabilities() driver_api.Capabilities {
+	return i.SSH.Capabilities().With(node.CapabilityASGResize, node.CapabilityNodePools)
+}
+
 func (i *ibm) Init(nodeOpts node.InitOptions) error {
 	i.SSH.Init(nodeOpts)
 
@@ -0,0 +1,262 @@
+package ibm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	bluemix "github.com/IBM-Cloud/bluemix-go"
+	v2 "github.com/IBM-Cloud/bluemix-go/api/container/containerv2"
+	"github.com/IBM-Cloud/bluemix-go/session"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// nodePoolLabel is the label IKS sets to the name of the worker pool on its nodes
+	nodePoolLabel = "ibm-cloud.kubernetes.io/worker-pool-name"
+	// vpcProviderName is the infrastructure provider of VPC clusters
+	vpcProviderName = "vpc-gen2"
+	// workerHealthyState is the health state of a worker which is ready
+	workerHealthyState = "normal"
+	// workerRetryInterval is how often the workers of a pool are checked while they change
+	workerRetryInterval = 30 * time.Second
+)
+
+// getClusterClient returns the IKS API client and the cluster. The cluster is taken from the IBM_CLUSTER_NAME env
+// var like the cloudops client does, or from the cluster-info config map.
+func (i *ibm) getClusterClient() (v2.ContainerServiceAPI, string, error) {
+	if i.clusterClient != nil {
+		return i.clusterClient, i.clusterName, nil
+	}
+
+	clusterName := os.Getenv("IBM_CLUSTER_NAME")
+	if len(clusterName) == 0 {
+		cm, err := core.Instance().GetConfigMap("cluster-info", "kube-system")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get cluster info. Err: %v", err)
+		}
+		var clusterConfig struct {
+			ClusterID string `json:"cluster_id"`
+		}
+		if err := json.Unmarshal([]byte(cm.Data["cluster-config.json"]), &clusterConfig); err != nil {
+			return nil, "", fmt.Errorf("failed to parse cluster info. Err: %v", err)
+		}
+		clusterName = clusterConfig.ClusterID
+	}
+
+	// the API key and region are taken from the environment by the session
+	sess, err := session.New(new(bluemix.Config))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get session. Err: %v", err)
+	}
+	client, err := v2.New(sess)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get ibm cluster client. Err: %v", err)
+	}
+	i.clusterClient = client
+	i.clusterName = clusterName
+	return i.clusterClient, i.clusterName, nil
+}
+
+// ListNodePools returns the worker pools of the cluster
+func (i *ibm) ListNodePools() ([]node.NodePool, error) {
+	client, cluster, err := i.getClusterClient()
+	if err != nil {
+		return nil, err
+	}
+	members, err := node.GetNodePoolNodes(nodePoolLabel)
+	if err != nil {
+		return nil, err
+	}
+	workerPools, err := client.WorkerPools().ListWorkerPools(cluster, clusterTarget())
+	if err != nil {
+		log.Errorf("failed to list worker pools of cluster %s. Error: %v", cluster, err)
+		return nil, err
+	}
+
+	var pools []node.NodePool
+	for _, p := range workerPools {
+		pool := node.NodePool{
+			Name:         p.PoolName,
+			InstanceType: p.Flavor,
+			Size:         int64(p.WorkerCount * len(p.Zones)),
+			Nodes:        members[p.PoolName],
+		}
+		for _, zone := range p.Zones {
+			pool.Zones = append(pool.Zones, zone.ID)
+		}
+		workers, err := client.Workers().ListByWorkerPool(cluster, p.ID, false, clusterTarget())
+		if err != nil {
+			log.Errorf("failed to list workers of pool %s. Error: %v", p.PoolName, err)
+			return nil, err
+		}
+		if len(workers) > 0 {
+			pool.Version = workers[0].KubeVersion.Actual
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// SetNodePoolSize sets the node count of the worker pool, which is sized per zone
+func (i *ibm) SetNodePoolSize(name string, size int64, timeout time.Duration) error {
+	pools, err := i.ListNodePools()
+	if err != nil {
+		return err
+	}
+	pool, err := node.GetNodePool(pools, name)
+	if err != nil {
+		return err
+	}
+	perZoneCount, err := pool.PerZoneSize(size)
+	if err != nil {
+		return err
+	}
+	// IBM SDK requires per zone cluster size
+	if err := i.ops.SetInstanceGroupSize(name, perZoneCount, timeout); err != nil {
+		log.Errorf("failed to set size of node pool %s. Error: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+// AddNodePool adds a worker pool with the flavor of the spec, in the VPC and zones of the first worker pool
+func (i *ibm) AddNodePool(spec node.NodePoolSpec, timeout time.Duration) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	client, cluster, err := i.getClusterClient()
+	if err != nil {
+		return err
+	}
+	workerPools, err := client.WorkerPools().ListWorkerPools(cluster, clusterTarget())
+	if err != nil {
+		return err
+	}
+	if len(workerPools) == 0 {
+		return fmt.Errorf("cluster %s has no worker pool to take the VPC and zones from", cluster)
+	}
+	template := workerPools[0]
+
+	var zones []v2.Zone
+	for _, zone := range template.Zones {
+		z := v2.Zone{ID: zone.ID}
+		for _, subnet := range zone.Subnets {
+			if subnet.Primary {
+				z.SubnetID = subnet.ID
+			}
+		}
+		zones = append(zones, z)
+	}
+
+	log.Infof("Adding worker pool %s of %d %s workers per zone", spec.Name, spec.PerZoneCount, spec.InstanceType)
+	_, err = client.WorkerPools().CreateWorkerPool(v2.WorkerPoolRequest{
+		Cluster: cluster,
+		WorkerPoolConfig: v2.WorkerPoolConfig{
+			Name:        spec.Name,
+			Flavor:      spec.InstanceType,
+			Isolation:   template.Isolation,
+			VpcID:       template.VpcID,
+			WorkerCount: int(spec.PerZoneCount),
+			Zones:       zones,
+		},
+	}, clusterTarget())
+	if err != nil {
+		log.Errorf("failed to add worker pool %s. Error: %v", spec.Name, err)
+		return err
+	}
+	return i.waitForHealthyWorkers(spec.Name, int(spec.PerZoneCount)*len(zones), nil, timeout)
+}
+
+// DeleteNodePool deletes the worker pool and waits for it to be gone
+func (i *ibm) DeleteNodePool(name string, timeout time.Duration) error {
+	client, cluster, err := i.getClusterClient()
+	if err != nil {
+		return err
+	}
+	log.Infof("Deleting worker pool %s", name)
+	if err := client.WorkerPools().DeleteWorkerPool(cluster, name, clusterTarget()); err != nil {
+		log.Errorf("failed to delete worker pool %s. Error: %v", name, err)
+		return err
+	}
+
+	t := func() (interface{}, bool, error) {
+		workerPools, err := client.WorkerPools().ListWorkerPools(cluster, clusterTarget())
+		if err != nil {
+			return nil, true, err
+		}
+		for _, p := range workerPools {
+			if p.PoolName == name {
+				return nil, true, fmt.Errorf("worker pool %s is still in %s state", name, p.Lifecycle.ActualState)
+			}
+		}
+		return nil, false, nil
+	}
+	_, err = task.DoRetryWithTimeout(t, timeout, workerRetryInterval)
+	return err
+}
+
+// UpgradeNodePool replaces the workers of the worker pool which are not at the version yet, one at a time. IKS
+// replaces a worker with one at the version of the cluster master, so the master must be upgraded to the version
+// first.
+func (i *ibm) UpgradeNodePool(name string, version string, timeout time.Duration) error {
+	client, cluster, err := i.getClusterClient()
+	if err != nil {
+		return err
+	}
+	workers, err := client.Workers().ListByWorkerPool(cluster, name, false, clusterTarget())
+	if err != nil {
+		log.Errorf("failed to list workers of pool %s. Error: %v", name, err)
+		return err
+	}
+
+	for _, w := range workers {
+		if strings.HasPrefix(w.KubeVersion.Actual, version) {
+			continue
+		}
+		log.Infof("Replacing worker %s of pool %s at version %s to upgrade it to %s", w.ID, name, w.KubeVersion.Actual, version)
+		if _, err := client.Workers().ReplaceWokerNode(cluster, w.ID, clusterTarget()); err != nil {
+			log.Errorf("failed to replace worker %s. Error: %v", w.ID, err)
+			return err
+		}
+		if err := i.waitForHealthyWorkers(name, len(workers), &w, timeout); err != nil {
+			return err
+		}
+	}
+	log.Infof("Worker pool %s upgraded to version %s", name, version)
+	return nil
+}
+
+// waitForHealthyWorkers waits for the worker pool to have the count of healthy workers, without the replaced one
+func (i *ibm) waitForHealthyWorkers(pool string, count int, replaced *v2.Worker, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		workers, err := i.clusterClient.Workers().ListByWorkerPool(i.clusterName, pool, false, clusterTarget())
+		if err != nil {
+			return nil, true, err
+		}
+		healthy := 0
+		for _, w := range workers {
+			if replaced != nil && w.ID == replaced.ID {
+				return nil, true, fmt.Errorf("worker %s of pool %s is not replaced yet", w.ID, pool)
+			}
+			if w.Health.State == workerHealthyState {
+				healthy++
+			}
+		}
+		if healthy != count {
+			return nil, true, fmt.Errorf("worker pool %s has %d of %d healthy workers", pool, healthy, count)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, workerRetryInterval)
+	return err
+}
+
+func clusterTarget() v2.ClusterTargetHeader {
+	return v2.ClusterTargetHeader{Provider: vpcProviderName}
+}
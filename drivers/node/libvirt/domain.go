@@ -0,0 +1,162 @@
+package libvirt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/portworx/torpedo/drivers/node"
+)
+
+// domainXML is the part of the definition of a domain the driver reads
+type domainXML struct {
+	Devices struct {
+		Disks      []deviceXML `xml:"disk"`
+		Interfaces []deviceXML `xml:"interface"`
+	} `xml:"devices"`
+}
+
+// deviceXML is a disk or interface element of a domain definition
+type deviceXML struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Target  struct {
+		Dev string `xml:"dev,attr"`
+	} `xml:"target"`
+	MAC struct {
+		Address string `xml:"address,attr"`
+	} `xml:"mac"`
+	Source struct {
+		File    string `xml:"file,attr"`
+		Dev     string `xml:"dev,attr"`
+		Volume  string `xml:"volume,attr"`
+		Network string `xml:"network,attr"`
+		Bridge  string `xml:"bridge,attr"`
+	} `xml:"source"`
+	Inner string `xml:",innerxml"`
+}
+
+func (d *deviceXML) attr(name string) string {
+	for _, a := range d.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// definition returns the element of the device as it was in the domain definition
+func (d *deviceXML) definition() string {
+	var b bytes.Buffer
+	b.WriteString("<" + d.XMLName.Local)
+	for _, a := range d.Attrs {
+		b.WriteString(fmt.Sprintf(" %s=\"", a.Name.Local))
+		xml.EscapeText(&b, []byte(a.Value))
+		b.WriteString("\"")
+	}
+	b.WriteString(">" + d.Inner + "</" + d.XMLName.Local + ">")
+	return b.String()
+}
+
+// parseDomainNames parses the domain names printed one per line by virsh list --name
+func parseDomainNames(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		if name := strings.TrimSpace(line); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// matchDomainName returns whether the node is the domain by name. Either may be a fully qualified host name.
+func matchDomainName(nodeName, domainName string) bool {
+	if strings.EqualFold(nodeName, domainName) {
+		return true
+	}
+	short := func(name string) string {
+		return strings.SplitN(name, ".", 2)[0]
+	}
+	return strings.EqualFold(short(nodeName), short(domainName))
+}
+
+// matchDomainAddresses returns whether the node has one of the addresses of the NICs of the domain
+func matchDomainAddresses(n node.Node, addresses map[string][]string) bool {
+	nodeAddrs := append([]string{n.UsableAddr, n.GetMgmtIp(), n.GetDataIp()}, n.Addresses...)
+	for _, ips := range addresses {
+		for _, ip := range ips {
+			for _, addr := range nodeAddrs {
+				if len(addr) > 0 && addr == ip {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// parseDomainAddresses parses the addresses of the NICs printed by virsh domifaddr by lowercase MAC. Further
+// addresses of a NIC are printed with - as its MAC.
+func parseDomainAddresses(output string) map[string][]string {
+	addresses := make(map[string][]string)
+	mac := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] == "Name" || strings.HasPrefix(fields[0], "---") {
+			continue
+		}
+		if fields[1] != "-" {
+			mac = strings.ToLower(fields[1])
+		}
+		if len(mac) == 0 {
+			continue
+		}
+		ip := strings.SplitN(fields[3], "/", 2)[0]
+		addresses[mac] = append(addresses[mac], ip)
+	}
+	return addresses
+}
+
+// parseDomainDevices returns the disks and NICs of the domain definition printed by virsh dumpxml, and the
+// definition of each one by deviceKey. CD-ROMs and floppies are not disks.
+func parseDomainDevices(output string) ([]node.VMDevice, map[string]string, error) {
+	var dom domainXML
+	if err := xml.Unmarshal([]byte(output), &dom); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse domain definition: %v", err)
+	}
+
+	var devices []node.VMDevice
+	definitions := make(map[string]string)
+	for i := range dom.Devices.Disks {
+		disk := &dom.Devices.Disks[i]
+		if device := disk.attr("device"); device != "" && device != "disk" {
+			continue
+		}
+		source := disk.Source.File
+		if source == "" {
+			source = disk.Source.Dev
+		}
+		if source == "" {
+			source = disk.Source.Volume
+		}
+		d := node.VMDevice{Type: node.VMDeviceDisk, ID: disk.Target.Dev, Source: source}
+		devices = append(devices, d)
+		definitions[deviceKey(d)] = disk.definition()
+	}
+	for i := range dom.Devices.Interfaces {
+		nic := &dom.Devices.Interfaces[i]
+		source := nic.Source.Network
+		if source == "" {
+			source = nic.Source.Bridge
+		}
+		d := node.VMDevice{Type: node.VMDeviceNIC, ID: strings.ToLower(nic.MAC.Address), Source: source}
+		devices = append(devices, d)
+		definitions[deviceKey(d)] = nic.definition()
+	}
+	return devices, definitions, nil
+}
+
+func deviceKey(device node.VMDevice) string {
+	return fmt.Sprintf("%s/%s", device.Type, strings.ToLower(device.ID))
+}
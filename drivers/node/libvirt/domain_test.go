@@ -0,0 +1,93 @@
+package libvirt
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/require"
+)
+
+const testDomainXML = `<domain type='kvm' id='3'>
+  <name>worker-1</name>
+  <devices>
+    <disk type='file' device='disk'>
+      <driver name='qemu' type='qcow2'/>
+      <source file='/var/lib/libvirt/images/worker-1.qcow2'/>
+      <target dev='vda' bus='virtio'/>
+    </disk>
+    <disk type='block' device='disk'>
+      <driver name='qemu' type='raw' cache='none'/>
+      <source dev='/dev/vg0/worker-1-px'/>
+      <target dev='vdb' bus='virtio'/>
+      <serial>px&amp;1</serial>
+    </disk>
+    <disk type='file' device='cdrom'>
+      <target dev='sda' bus='sata'/>
+    </disk>
+    <interface type='network'>
+      <mac address='52:54:00:AB:cd:01'/>
+      <source network='default'/>
+      <model type='virtio'/>
+    </interface>
+  </devices>
+</domain>`
+
+func TestParseDomainNames(t *testing.T) {
+	require.Equal(t, []string{"worker-1", "worker-2"}, parseDomainNames("worker-1\n worker-2 \n\n"))
+	require.Empty(t, parseDomainNames("\n"))
+}
+
+func TestParseDomainDevices(t *testing.T) {
+	devices, definitions, err := parseDomainDevices(testDomainXML)
+	require.NoError(t, err)
+	require.Equal(t, []node.VMDevice{
+		{Type: node.VMDeviceDisk, ID: "vda", Source: "/var/lib/libvirt/images/worker-1.qcow2"},
+		{Type: node.VMDeviceDisk, ID: "vdb", Source: "/dev/vg0/worker-1-px"},
+		{Type: node.VMDeviceNIC, ID: "52:54:00:ab:cd:01", Source: "default"},
+	}, devices)
+	require.Len(t, definitions, 3)
+
+	// the saved definition must parse back to the same device
+	var disk deviceXML
+	require.NoError(t, xml.Unmarshal([]byte(definitions["disk/vdb"]), &disk))
+	require.Equal(t, "block", disk.attr("type"))
+	require.Equal(t, "vdb", disk.Target.Dev)
+	require.Contains(t, definitions["disk/vdb"], "px&amp;1")
+
+	nic, ok := definitions[deviceKey(node.VMDevice{Type: node.VMDeviceNIC, ID: "52:54:00:AB:CD:01"})]
+	require.True(t, ok)
+	require.Contains(t, nic, "network='default'")
+
+	_, _, err = parseDomainDevices("not xml")
+	require.Error(t, err)
+}
+
+func TestMatchDomainName(t *testing.T) {
+	require.True(t, matchDomainName("worker-1", "worker-1"))
+	require.True(t, matchDomainName("worker-1.lab.example.com", "worker-1"))
+	require.True(t, matchDomainName("Worker-1", "worker-1.lab"))
+	require.False(t, matchDomainName("worker-1", "worker-10"))
+}
+
+func TestParseDomainAddresses(t *testing.T) {
+	out := ` Name       MAC address          Protocol     Address
+-------------------------------------------------------------------------------
+ vnet0      52:54:00:2B:44:9a    ipv4         192.168.122.120/24
+ -          -                    ipv6         fe80::5054:ff:fe2b:449a/64
+ vnet1      52:54:00:aa:bb:cc    ipv4         10.0.0.12/16
+
+ Name       MAC address          Protocol     Address
+-------------------------------------------------------------------------------
+ vnet1      52:54:00:aa:bb:cc    ipv4         10.0.0.12/0
+`
+	addresses := parseDomainAddresses(out)
+	require.Equal(t, map[string][]string{
+		"52:54:00:2b:44:9a": {"192.168.122.120", "fe80::5054:ff:fe2b:449a"},
+		"52:54:00:aa:bb:cc": {"10.0.0.12", "10.0.0.12"},
+	}, addresses)
+
+	require.True(t, matchDomainAddresses(node.Node{Name: "worker-1", Addresses: []string{"10.0.0.12"}}, addresses))
+	require.True(t, matchDomainAddresses(node.Node{Name: "worker-1", UsableAddr: "192.168.122.120"}, addresses))
+	require.False(t, matchDomainAddresses(node.Node{Name: "worker-2", Addresses: []string{"10.0.0.13"}}, addresses))
+}
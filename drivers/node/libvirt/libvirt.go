@@ -0,0 +1,482 @@
+package libvirt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/sched-ops/task"
+	driver_api "github.com/portworx/torpedo/drivers/api"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/node/ssh"
+	"github.com/portworx/torpedo/pkg/log"
+	ssh_pkg "golang.org/x/crypto/ssh"
+)
+
+const (
+	// DriverName is the name of the libvirt driver
+	DriverName = "libvirt"
+)
+
+const (
+	libvirtHosts  = "LIBVIRT_HOSTS"
+	libvirtUser   = "LIBVIRT_USER"
+	libvirtSSHKey = "LIBVIRT_SSH_KEY"
+	libvirtURI    = "LIBVIRT_URI"
+)
+
+const (
+	// DefaultUsername is the default username used to ssh to the KVM hosts
+	DefaultUsername = "root"
+	// DefaultURI is the default URI virsh connects to on the KVM hosts
+	DefaultURI = "qemu:///system"
+	// VMReadyTimeout Timeout for checking VM power state
+	VMReadyTimeout = 3 * time.Minute
+	// VMReadyRetryInterval interval for retry when checking VM power state
+	VMReadyRetryInterval = 5 * time.Second
+	// deviceXMLDir is where the definition of a detached device is kept on its KVM host, so it can be attached
+	// back even if torpedo restarts while the device is detached
+	deviceXMLDir  = "/var/tmp"
+	domainRunning = "running"
+)
+
+// domain is the libvirt domain of a VM and the KVM host it runs on
+type domain struct {
+	host string
+	name string
+}
+
+// libvirt drives the VMs of the nodes through virsh on their KVM hosts and the nodes themselves through ssh
+type libvirt struct {
+	ssh.SSH
+	hosts     []string
+	uri       string
+	sshConfig *ssh_pkg.ClientConfig
+	lock      sync.Mutex
+	domains   map[string]*domain
+}
+
+func (l *libvirt) String() string {
+	return DriverName
+}
+
+func (l *libvirt) Capabilities() driver_api.Capabilities {
+	return l.SSH.Capabilities().With(node.CapabilityPowerCycle, node.CapabilityVMReset, node.CapabilityVMDeviceHotplug)
+}
+
+// Init initializes the libvirt driver
+func (l *libvirt) Init(nodeOpts node.InitOptions) error {
+	log.Infof("Using the libvirt node driver")
+
+	for _, host := range strings.Split(os.Getenv(libvirtHosts), ",") {
+		if host = strings.TrimSpace(host); len(host) > 0 {
+			l.hosts = append(l.hosts, host)
+		}
+	}
+	if len(l.hosts) == 0 {
+		return fmt.Errorf("KVM hosts not provided as env var: %s", libvirtHosts)
+	}
+
+	l.uri = DefaultURI
+	if uri := os.Getenv(libvirtURI); len(uri) != 0 {
+		l.uri = uri
+	}
+
+	username := DefaultUsername
+	if u := os.Getenv(libvirtUser); len(u) != 0 {
+		username = u
+	}
+	keyPath := ssh.DefaultSSHKey
+	if k := os.Getenv(libvirtSSHKey); len(k) != 0 {
+		keyPath = k
+	}
+	buf, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read ssh key %s of the KVM hosts: %v", keyPath, err)
+	}
+	signer, err := ssh_pkg.ParsePrivateKey(buf)
+	if err != nil {
+		return fmt.Errorf("failed to parse ssh key %s of the KVM hosts: %v", keyPath, err)
+	}
+	l.sshConfig = &ssh_pkg.ClientConfig{
+		User:            username,
+		Auth:            []ssh_pkg.AuthMethod{ssh_pkg.PublicKeys(signer)},
+		HostKeyCallback: ssh_pkg.InsecureIgnoreHostKey(),
+		Timeout:         30 * time.Second,
+	}
+
+	if err := l.connect(); err != nil {
+		return err
+	}
+	return l.SSH.Init(nodeOpts)
+}
+
+// connect maps the nodes to the domains on the KVM hosts. A node maps to the domain with its name or host name,
+// or else to the domain libvirt knows one of its addresses for, from the DHCP leases of the networks or the ARP
+// table of the KVM host by the MACs of the NICs of the domain.
+func (l *libvirt) connect() error {
+	nodes := node.GetNodes()
+	var candidates []*domain
+	for _, host := range l.hosts {
+		out, err := l.virsh(host, "list --all --name")
+		if err != nil {
+			return err
+		}
+		for _, name := range parseDomainNames(out) {
+			candidates = append(candidates, &domain{host: host, name: name})
+		}
+	}
+
+	domains := make(map[string]*domain)
+	claimed := make(map[*domain]bool)
+	var unmatched []node.Node
+	for _, n := range nodes {
+		var found *domain
+		for _, dom := range candidates {
+			if !claimed[dom] && matchDomainName(n.Name, dom.name) {
+				found = dom
+				break
+			}
+		}
+		if found == nil {
+			unmatched = append(unmatched, n)
+			continue
+		}
+		domains[n.Name] = found
+		claimed[found] = true
+	}
+
+	if len(unmatched) > 0 {
+		addresses := make(map[*domain]map[string][]string)
+		for _, n := range unmatched {
+			for _, dom := range candidates {
+				if claimed[dom] {
+					continue
+				}
+				if _, ok := addresses[dom]; !ok {
+					addrs, err := l.domainAddresses(dom)
+					if err != nil {
+						log.Warnf("Failed to get addresses of domain %s on %s: %v", dom.name, dom.host, err)
+					}
+					addresses[dom] = addrs
+				}
+				if matchDomainAddresses(n, addresses[dom]) {
+					log.Infof("Node %s is domain %s on %s by its address", n.Name, dom.name, dom.host)
+					domains[n.Name] = dom
+					claimed[dom] = true
+					break
+				}
+			}
+		}
+	}
+	log.Infof("Found %d of %d nodes as libvirt domains on KVM hosts %v", len(domains), len(nodes), l.hosts)
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.domains = domains
+	return nil
+}
+
+// TestConnection tests the connection to the given node
+func (l *libvirt) TestConnection(n node.Node, options node.ConnectionOpts) error {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return &node.ErrFailedToTestConnection{Node: n, Cause: err.Error()}
+	}
+	t := func() (interface{}, bool, error) {
+		state, err := l.domainState(dom)
+		if err != nil || state != domainRunning {
+			return nil, true, &node.ErrFailedToTestConnection{
+				Node:  n,
+				Cause: fmt.Sprintf("Failed to test connection to VM: %s Current Status: %v, error: %v", dom.name, state, err),
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, VMReadyTimeout, VMReadyRetryInterval); err != nil {
+		return err
+	}
+	// Check if VM is not just running but also usable
+	_, err = l.RunCommand(n, "hostname", node.ConnectionOpts{
+		Timeout:         VMReadyTimeout,
+		TimeBeforeRetry: VMReadyRetryInterval,
+	})
+	return err
+}
+
+// AddMachine looks the domain up on the KVM hosts and adds it to the known domains
+func (l *libvirt) AddMachine(vmName string) error {
+	log.Infof("Adding VM: %s into known domains", vmName)
+	for _, host := range l.hosts {
+		out, err := l.virsh(host, "list --all --name")
+		if err != nil {
+			return err
+		}
+		for _, name := range parseDomainNames(out) {
+			if name == vmName {
+				l.lock.Lock()
+				defer l.lock.Unlock()
+				l.domains[vmName] = &domain{host: host, name: vmName}
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("VM %s not found on KVM hosts %v", vmName, l.hosts)
+}
+
+// RebootNode reboots the guest of the VM, or hard resets the VM if forced
+func (l *libvirt) RebootNode(n node.Node, options node.RebootNodeOpts) error {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return &node.ErrFailedToRebootNode{Node: n, Cause: err.Error()}
+	}
+	cmd := "reboot"
+	if options.Force {
+		cmd = "reset"
+	}
+	log.Infof("Rebooting VM: %s with virsh %s", dom.name, cmd)
+	defer l.EvictConnections(n)
+	if _, err := l.virsh(dom.host, fmt.Sprintf("%s %s", cmd, dom.name)); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to reboot VM %s. cause %v", dom.name, err),
+		}
+	}
+	return nil
+}
+
+// ShutdownNode shuts the guest of the VM down, or powers the VM off if forced
+func (l *libvirt) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return &node.ErrFailedToShutdownNode{Node: n, Cause: err.Error()}
+	}
+	cmd := "shutdown"
+	if options.Force {
+		cmd = "destroy"
+	}
+	log.Infof("Shutting down VM: %s with virsh %s", dom.name, cmd)
+	defer l.EvictConnections(n)
+	if _, err := l.virsh(dom.host, fmt.Sprintf("%s %s", cmd, dom.name)); err != nil {
+		return &node.ErrFailedToShutdownNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to shutdown VM %s. cause %v", dom.name, err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVM powers on the VM if not already on
+func (l *libvirt) PowerOnVM(n node.Node) error {
+	if err := l.PowerOnVMByName(n.Name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power on VM %s. cause %v", n.Name, err),
+		}
+	}
+	return nil
+}
+
+// PowerOnVMByName powers on VM by using name
+func (l *libvirt) PowerOnVMByName(vmName string) error {
+	dom, err := l.getDomain(vmName)
+	if err != nil {
+		return err
+	}
+	state, err := l.domainState(dom)
+	if err != nil {
+		return err
+	}
+	if state == domainRunning {
+		log.Warn("VM is already in running state: ", dom.name)
+		return nil
+	}
+	log.Infof("Powering on VM: %s", dom.name)
+	_, err = l.virsh(dom.host, "start "+dom.name)
+	return err
+}
+
+// PowerOffVM pulls the plug of the VM
+func (l *libvirt) PowerOffVM(n node.Node) error {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return err
+	}
+	log.Infof("Powering off VM: %s", dom.name)
+	defer l.EvictConnections(n)
+	if _, err := l.virsh(dom.host, "destroy "+dom.name); err != nil {
+		return &node.ErrFailedToRebootNode{
+			Node:  n,
+			Cause: fmt.Sprintf("failed to power off VM %s. cause %v", dom.name, err),
+		}
+	}
+	return nil
+}
+
+// ResetVM presses the reset button of the VM
+func (l *libvirt) ResetVM(n node.Node) error {
+	return l.RebootNode(n, node.RebootNodeOpts{Force: true})
+}
+
+// GetVMDevices returns the disks and NICs in the live definition of the domain
+func (l *libvirt) GetVMDevices(n node.Node) ([]node.VMDevice, error) {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return nil, err
+	}
+	out, err := l.virsh(dom.host, "dumpxml "+dom.name)
+	if err != nil {
+		return nil, err
+	}
+	devices, _, err := parseDomainDevices(out)
+	return devices, err
+}
+
+// DetachVMDevice saves the definition of the device on the KVM host and detaches it from the live domain
+func (l *libvirt) DetachVMDevice(n node.Node, device node.VMDevice) error {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: err.Error()}
+	}
+	out, err := l.virsh(dom.host, "dumpxml "+dom.name)
+	if err != nil {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: err.Error()}
+	}
+	_, definitions, err := parseDomainDevices(out)
+	if err != nil {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: err.Error()}
+	}
+	definition, ok := definitions[deviceKey(device)]
+	if !ok {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: "device not attached to VM " + dom.name}
+	}
+	if device.Type == node.VMDeviceNIC {
+		// without the NIC torpedo reaches the node through, the node could neither be checked nor recovered
+		mac, err := l.getManagementMAC(n, dom)
+		if err != nil {
+			return &node.ErrVMDeviceInUse{Node: n, Device: device, Cause: err.Error()}
+		}
+		if strings.EqualFold(mac, device.ID) {
+			return &node.ErrVMDeviceInUse{Node: n, Device: device, Cause: "torpedo reaches the node through the NIC"}
+		}
+	}
+
+	file := deviceXMLFile(dom.name, device)
+	log.Infof("Detaching %s from VM %s, its definition is kept in %s on %s", device, dom.name, file, dom.host)
+	cmd := fmt.Sprintf("cat > %s <<'TORPEDO_EOF'\n%s\nTORPEDO_EOF\nvirsh -c %s detach-device %s %s --live",
+		file, definition, l.uri, dom.name, file)
+	if _, err := l.runOnHost(dom.host, cmd); err != nil {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: err.Error()}
+	}
+	return nil
+}
+
+// AttachVMDevice attaches the device back to the live domain from the definition saved on detach
+func (l *libvirt) AttachVMDevice(n node.Node, device node.VMDevice) error {
+	dom, err := l.getDomain(n.Name)
+	if err != nil {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: err.Error()}
+	}
+	file := deviceXMLFile(dom.name, device)
+	log.Infof("Attaching %s back to VM %s", device, dom.name)
+	cmd := fmt.Sprintf("[ -s %s ] || { echo 'no saved definition, device was not detached' >&2; exit 1; }; "+
+		"virsh -c %s attach-device %s %s --live && rm -f %s", file, l.uri, dom.name, file, file)
+	if _, err := l.runOnHost(dom.host, cmd); err != nil {
+		return &node.ErrFailedToHotplugVMDevice{Node: n, Device: device, Cause: err.Error()}
+	}
+	return nil
+}
+
+// getManagementMAC returns the MAC of the NIC with the address torpedo reaches the node through, as the node or
+// else libvirt knows it
+func (l *libvirt) getManagementMAC(n node.Node, dom *domain) (string, error) {
+	addr := n.UsableAddr
+	if len(addr) == 0 {
+		addr = n.GetMgmtIp()
+	}
+	out, err := l.RunCommand(n, fmt.Sprintf("cat /sys/class/net/$(ip -o addr show to %s | awk '{print $2}' | head -1)/address", addr),
+		node.ConnectionOpts{Timeout: time.Minute, TimeBeforeRetry: VMReadyRetryInterval})
+	if mac := strings.TrimSpace(out); err == nil && len(mac) > 0 {
+		return strings.ToLower(mac), nil
+	}
+	addrs, addrErr := l.domainAddresses(dom)
+	if addrErr != nil {
+		return "", fmt.Errorf("failed to get the NIC with address %s from the node: %v, nor from libvirt: %v", addr, err, addrErr)
+	}
+	for mac, ips := range addrs {
+		for _, ip := range ips {
+			if ip == addr {
+				return mac, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no NIC of VM %s has address %s", dom.name, addr)
+}
+
+// domainAddresses returns the IP addresses of the NICs of the domain by MAC, from the DHCP leases of the networks
+// and the ARP table of the KVM host. Sources libvirt does not support on the host are skipped.
+func (l *libvirt) domainAddresses(dom *domain) (map[string][]string, error) {
+	out, err := l.runOnHost(dom.host, fmt.Sprintf("virsh -c %[1]s domifaddr %[2]s 2>/dev/null; virsh -c %[1]s domifaddr %[2]s --source arp 2>/dev/null; true",
+		l.uri, dom.name))
+	if err != nil {
+		return nil, err
+	}
+	return parseDomainAddresses(out), nil
+}
+
+func (l *libvirt) getDomain(name string) (*domain, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	dom, ok := l.domains[name]
+	if !ok {
+		return nil, fmt.Errorf("could not fetch VM for node: %s", name)
+	}
+	return dom, nil
+}
+
+func (l *libvirt) domainState(dom *domain) (string, error) {
+	out, err := l.virsh(dom.host, "domstate "+dom.name)
+	return strings.TrimSpace(out), err
+}
+
+// virsh runs the virsh command on the KVM host
+func (l *libvirt) virsh(host, args string) (string, error) {
+	return l.runOnHost(host, fmt.Sprintf("virsh -c %s %s", l.uri, args))
+}
+
+// runOnHost runs the command on the KVM host over a new ssh connection
+func (l *libvirt) runOnHost(host, cmd string) (string, error) {
+	client, err := ssh_pkg.Dial("tcp", net.JoinHostPort(host, "22"), l.sshConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to KVM host %s: %v", host, err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to open session on KVM host %s: %v", host, err)
+	}
+	defer session.Close()
+	out, err := session.CombinedOutput(cmd)
+	if err != nil {
+		return string(out), fmt.Errorf("failed to run [%s] on KVM host %s: %v. Output: %s", cmd, host, err, out)
+	}
+	return string(out), nil
+}
+
+func deviceXMLFile(domainName string, device node.VMDevice) string {
+	id := strings.Replace(device.ID, ":", "", -1)
+	return fmt.Sprintf("%s/torpedo-%s-%s-%s.xml", deviceXMLDir, domainName, device.Type, id)
+}
+
+func init() {
+	l := &libvirt{
+		SSH:     *ssh.New(),
+		domains: make(map[string]*domain),
+	}
+
+	node.Register(DriverName, l)
+}
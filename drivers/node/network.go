@@ -0,0 +1,103 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+// NetworkFaultType identifies the kind of a network fault
+type NetworkFaultType string
+
+const (
+	// NetworkFaultPartition drops all traffic to and from the peers, or only the traffic on the given ports
+	NetworkFaultPartition NetworkFaultType = "partition"
+	// NetworkFaultDelay delays outgoing packets by Delay, with Jitter
+	NetworkFaultDelay NetworkFaultType = "delay"
+	// NetworkFaultLoss drops Percent of outgoing packets
+	NetworkFaultLoss NetworkFaultType = "loss"
+	// NetworkFaultBandwidth limits outgoing traffic to Rate
+	NetworkFaultBandwidth NetworkFaultType = "bandwidth"
+	// NetworkFaultCorrupt corrupts Percent of outgoing packets
+	NetworkFaultCorrupt NetworkFaultType = "corrupt"
+	// NetworkFaultDuplicate duplicates Percent of outgoing packets
+	NetworkFaultDuplicate NetworkFaultType = "duplicate"
+	// NetworkFaultReorder sends Percent of outgoing packets immediately and delays the others by Delay
+	NetworkFaultReorder NetworkFaultType = "reorder"
+)
+
+// NetworkFault describes a network fault to inject on a node. Faults apply to all traffic of the node unless
+// restricted to peers and ports.
+type NetworkFault struct {
+	// Type is the kind of the fault
+	Type NetworkFaultType
+	// Peers are the addresses the fault applies to, all destinations if empty
+	Peers []string
+	// Ports are the TCP and UDP ports the fault applies to, all ports if empty. For a partition both traffic to
+	// the ports of the peers and traffic from the peers to the ports of the node is blocked.
+	Ports []int
+	// Interface is the network interface to shape traffic on. It is detected from the route to the first peer,
+	// or the default route, if empty.
+	Interface string
+	// Percent is the percentage of packets lost, corrupted, duplicated or reordered
+	Percent int
+	// Delay is the delay of the packets for delay and reorder faults
+	Delay time.Duration
+	// Jitter is the variation of the delay
+	Jitter time.Duration
+	// Rate is the bandwidth limit in tc units, e.g. 1mbit
+	Rate string
+	// Duration is how long the fault lasts, until it is removed if 0. The node removes the fault itself once the
+	// duration is over, so faults cutting the node off, e.g. from ssh, are removed even if it cannot be reached.
+	Duration time.Duration
+}
+
+// Validate checks the fault has the parameters its type needs
+func (f NetworkFault) Validate() error {
+	if f.Duration < 0 {
+		return fmt.Errorf("%s fault needs a positive duration, got %v", f.Type, f.Duration)
+	}
+	switch f.Type {
+	case NetworkFaultPartition:
+		if len(f.Peers) == 0 {
+			return fmt.Errorf("partition needs peers")
+		}
+	case NetworkFaultDelay:
+		if f.Delay <= 0 {
+			return fmt.Errorf("delay fault needs a delay")
+		}
+	case NetworkFaultReorder:
+		if f.Delay <= 0 {
+			return fmt.Errorf("reorder fault needs a delay")
+		}
+		fallthrough
+	case NetworkFaultLoss, NetworkFaultCorrupt, NetworkFaultDuplicate:
+		if f.Percent <= 0 || f.Percent > 100 {
+			return fmt.Errorf("%s fault needs a percentage between 1 and 100, got %d", f.Type, f.Percent)
+		}
+	case NetworkFaultBandwidth:
+		if len(f.Rate) == 0 {
+			return fmt.Errorf("bandwidth fault needs a rate")
+		}
+	default:
+		return fmt.Errorf("unknown network fault type %s", f.Type)
+	}
+	return nil
+}
+
+// NetworkRule is a network fault injected on a node. It holds what is needed to remove the fault again.
+type NetworkRule struct {
+	// ID identifies the rule. Firewall rules of the fault are tagged with it.
+	ID string
+	// Node is the node the fault is injected on
+	Node Node
+	// Fault is the injected fault
+	Fault NetworkFault
+	// Interface is the network interface traffic is shaped on, empty for partitions
+	Interface string
+	// Handle is the traffic control class of the rule, empty for partitions
+	Handle string
+}
+
+func (r *NetworkRule) String() string {
+	return fmt.Sprintf("%s (%s on %s)", r.ID, r.Fault.Type, r.Node.Name)
+}
@@ -2,9 +2,11 @@ package node
 
 import (
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/libopenstorage/openstorage/api"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/pkg/errors"
 )
 
@@ -21,6 +23,43 @@ const (
 	TypeWorker Type = "Worker"
 )
 
+const (
+	// CapabilityReboot is the capability to reboot nodes
+	CapabilityReboot driver_api.Capability = "reboot"
+	// CapabilityCrash is the capability to crash nodes
+	CapabilityCrash driver_api.Capability = "crash"
+	// CapabilityShutdown is the capability to shut down nodes
+	CapabilityShutdown driver_api.Capability = "shutdown"
+	// CapabilityPowerCycle is the capability to power VMs off and on
+	CapabilityPowerCycle driver_api.Capability = "power-cycle"
+	// CapabilityDeleteNode is the capability to delete nodes from the cluster
+	CapabilityDeleteNode driver_api.Capability = "delete-node"
+	// CapabilityRunCommand is the capability to run arbitrary commands on nodes
+	CapabilityRunCommand driver_api.Capability = "run-command"
+	// CapabilitySystemctl is the capability to manage services on nodes
+	CapabilitySystemctl driver_api.Capability = "systemctl"
+	// CapabilityDriveFailure is the capability to yank and recover drives on nodes
+	CapabilityDriveFailure driver_api.Capability = "drive-failure"
+	// CapabilityNetworkFault is the capability to inject network errors on nodes
+	CapabilityNetworkFault driver_api.Capability = "network-fault"
+	// CapabilityDiskFault is the capability to inject faults on disks of nodes
+	CapabilityDiskFault driver_api.Capability = "disk-fault"
+	// CapabilityProcessChaos is the capability to signal, pause, starve and throttle processes on nodes
+	CapabilityProcessChaos driver_api.Capability = "process-chaos"
+	// CapabilityClockSkew is the capability to move the clock of nodes
+	CapabilityClockSkew driver_api.Capability = "clock-skew"
+	// CapabilityVMReset is the capability to hard reset VMs without shutting down their guests
+	CapabilityVMReset driver_api.Capability = "vm-reset"
+	// CapabilityVMDeviceHotplug is the capability to hot-unplug and plug the disks and NICs of VMs
+	CapabilityVMDeviceHotplug driver_api.Capability = "vm-device-hotplug"
+	// CapabilityASGResize is the capability to resize the node groups of the cluster
+	CapabilityASGResize driver_api.Capability = "asg-resize"
+	// CapabilityClusterUpgrade is the capability to upgrade the cluster and its node pools
+	CapabilityClusterUpgrade driver_api.Capability = "cluster-upgrade"
+	// CapabilityNodePools is the capability to list, scale, add, delete and upgrade the node pools of the cluster
+	CapabilityNodePools driver_api.Capability = "node-pools"
+)
+
 const (
 	// File identifies a search on find command to look for files only
 	File FindType = "f"
@@ -136,6 +175,9 @@ type Driver interface {
 	// String returns the string name of this driver.
 	String() string
 
+	// Capabilities returns the set of optional operations supported by the driver
+	Capabilities() driver_api.Capabilities
+
 	// RebootNode reboots the given node
 	RebootNode(node Node, options RebootNodeOpts) error
 
@@ -151,6 +193,19 @@ type Driver interface {
 	// RunCommandWithNoRetry runs the given command on the node but with no retry
 	RunCommandWithNoRetry(node Node, command string, options ConnectionOpts) (string, error)
 
+	// RunCommandOnNodes runs the given command on the nodes in parallel and returns the result of each node in the
+	// order of the nodes. It returns an error if the command failed on any node.
+	RunCommandOnNodes(nodes []Node, command string, options ParallelCommandOpts) ([]CommandResult, error)
+
+	// StreamCommand runs the given command on the node and writes its output to the writer as the node writes it
+	StreamCommand(node Node, command string, options ConnectionOpts, output io.Writer) error
+
+	// CopyFromNode copies the file at the path on the node to the local path and verifies its checksum
+	CopyFromNode(node Node, path, localPath string, options CopyOpts) (*CopiedFile, error)
+
+	// CopyToNode copies the file at the local path to the path on the node and verifies its checksum
+	CopyToNode(node Node, localPath, path string, options CopyOpts) (*CopiedFile, error)
+
 	// ShutdownNode shuts down the given node
 	ShutdownNode(node Node, options ShutdownNodeOpts) error
 
@@ -188,6 +243,22 @@ type Driver interface {
 	// GetZones returns list of zones in which ASG cluster is running
 	GetZones() ([]string, error)
 
+	// ListNodePools returns the node pools of the cluster
+	ListNodePools() ([]NodePool, error)
+
+	// SetNodePoolSize sets the number of nodes of the node pool over all its zones. Clouds which size pools per zone
+	// need a size which is a multiple of the number of zones of the pool.
+	SetNodePoolSize(name string, size int64, timeout time.Duration) error
+
+	// AddNodePool adds a node pool to the cluster and waits for its nodes to be provisioned
+	AddNodePool(spec NodePoolSpec, timeout time.Duration) error
+
+	// DeleteNodePool deletes the node pool and its nodes from the cluster
+	DeleteNodePool(name string, timeout time.Duration) error
+
+	// UpgradeNodePool upgrades the nodes of the node pool to the kubernetes version
+	UpgradeNodePool(name string, version string, timeout time.Duration) error
+
 	// PowerOnVM powers VM
 	PowerOnVM(node Node) error
 
@@ -203,6 +274,18 @@ type Driver interface {
 	// PowerOnVMByName power on the VM using the vm name
 	PowerOnVMByName(vmName string) error
 
+	// ResetVM hard resets the VM of the node without shutting down its guest
+	ResetVM(node Node) error
+
+	// GetVMDevices returns the disks and NICs attached to the VM of the node
+	GetVMDevices(node Node) ([]VMDevice, error)
+
+	// DetachVMDevice hot-unplugs the device from the running VM of the node
+	DetachVMDevice(node Node, device VMDevice) error
+
+	// AttachVMDevice hot-plugs a device detached with DetachVMDevice back into the running VM of the node
+	AttachVMDevice(node Node, device VMDevice) error
+
 	// IsNodeRebootedInGivenTimeRange check if node is rebooted within given time range
 	IsNodeRebootedInGivenTimeRange(Node, time.Duration) (bool, error)
 
@@ -214,6 +297,47 @@ type Driver interface {
 	// delayInMilliseconds => 1 to 1000
 	InjectNetworkError(nodes []Node, errorInjectionType string, operationType string, dropPercentage int, delayInMilliseconds int) error
 
+	// InjectNetworkFault injects the network fault on the node and returns the rule to remove it with
+	InjectNetworkFault(n Node, fault NetworkFault) (*NetworkRule, error)
+
+	// RemoveNetworkFault removes the network fault of the rule from its node
+	RemoveNetworkFault(rule *NetworkRule) error
+
+	// PartitionNodes drops the traffic between the two sets of nodes, only on the given ports if any, and returns
+	// the rules to heal the partition with. The nodes heal the partition themselves after the duration, if not 0.
+	PartitionNodes(sideA, sideB []Node, ports []int, duration time.Duration) ([]*NetworkRule, error)
+
+	// PrepareDiskForFaults wraps the unused disk in a device-mapper device faults can be injected on and returns its path
+	PrepareDiskForFaults(n Node, device string) (string, error)
+
+	// InjectDiskFault replaces the table of the device-mapper disk with the faulty one, or takes other SCSI disks
+	// offline for error faults. Injecting a fault on a disk which already has one replaces the fault. It returns
+	// ErrDiskNotFaultable if the fault cannot be injected on the disk.
+	InjectDiskFault(n Node, device string, fault DiskFault) error
+
+	// RecoverDiskFault restores the original table or SCSI state of the disk
+	RecoverDiskFault(n Node, device string) error
+
+	// GetDiskFaults returns the disk faults injected on the node, as recorded on the node
+	GetDiskFaults(n Node) ([]*DiskFaultState, error)
+
+	// SignalProcess sends the signal to all processes with the given name on the node and returns their PIDs
+	SignalProcess(n Node, name string, signal ProcessSignal) ([]int, error)
+
+	// InjectProcessChaos injects the chaos on the node and returns the handle to revert it early with
+	InjectProcessChaos(n Node, chaos ProcessChaos) (*ProcessChaosHandle, error)
+
+	// RevertProcessChaos reverts the chaos before its duration is over
+	RevertProcessChaos(handle *ProcessChaosHandle) error
+
+	// SkewClock stops time sync on the node and moves its clock by the offset. The clock is restored and time sync
+	// started again after the duration, or earlier with RestoreClock.
+	SkewClock(n Node, offset time.Duration, duration time.Duration) (*ClockSkewHandle, error)
+
+	// RestoreClock moves the clock of the node back by the offset of the skew and starts time sync again. It does
+	// nothing if the clock was already restored.
+	RestoreClock(handle *ClockSkewHandle) error
+
 	// GetDeviceMapperCount return devicemapper count
 	GetDeviceMapperCount(Node, time.Duration) (int, error)
 
@@ -259,6 +383,10 @@ func (d *notSupportedDriver) String() string {
 	return fmt.Sprint("Operation String() is not supported")
 }
 
+func (d *notSupportedDriver) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities()
+}
+
 func (d *notSupportedDriver) RebootNode(node Node, options RebootNodeOpts) error {
 	return &errors.ErrNotSupported{
 		Type:      "Function",
@@ -287,6 +415,34 @@ func (d *notSupportedDriver) RunCommandWithNoRetry(node Node, command string, op
 	}
 }
 
+func (d *notSupportedDriver) RunCommandOnNodes(nodes []Node, command string, options ParallelCommandOpts) ([]CommandResult, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RunCommandOnNodes()",
+	}
+}
+
+func (d *notSupportedDriver) StreamCommand(node Node, command string, options ConnectionOpts, output io.Writer) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "StreamCommand()",
+	}
+}
+
+func (d *notSupportedDriver) CopyFromNode(node Node, path, localPath string, options CopyOpts) (*CopiedFile, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CopyFromNode()",
+	}
+}
+
+func (d *notSupportedDriver) CopyToNode(node Node, localPath, path string, options CopyOpts) (*CopiedFile, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CopyToNode()",
+	}
+}
+
 func (d *notSupportedDriver) ShutdownNode(node Node, options ShutdownNodeOpts) error {
 	return &errors.ErrNotSupported{
 		Type:      "Function",
@@ -457,3 +613,150 @@ func (d *notSupportedDriver) InjectNetworkError(nodes []Node, errorInjectionType
 		Operation: "InjectNetworkError()",
 	}
 }
+
+func (d *notSupportedDriver) InjectNetworkFault(n Node, fault NetworkFault) (*NetworkRule, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "InjectNetworkFault()",
+	}
+}
+
+func (d *notSupportedDriver) RemoveNetworkFault(rule *NetworkRule) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RemoveNetworkFault()",
+	}
+}
+
+func (d *notSupportedDriver) PartitionNodes(sideA, sideB []Node, ports []int, duration time.Duration) ([]*NetworkRule, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "PartitionNodes()",
+	}
+}
+
+func (d *notSupportedDriver) PrepareDiskForFaults(n Node, device string) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "PrepareDiskForFaults()",
+	}
+}
+
+func (d *notSupportedDriver) InjectDiskFault(n Node, device string, fault DiskFault) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "InjectDiskFault()",
+	}
+}
+
+func (d *notSupportedDriver) RecoverDiskFault(n Node, device string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RecoverDiskFault()",
+	}
+}
+
+func (d *notSupportedDriver) GetDiskFaults(n Node) ([]*DiskFaultState, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GetDiskFaults()",
+	}
+}
+
+func (d *notSupportedDriver) SignalProcess(n Node, name string, signal ProcessSignal) ([]int, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "SignalProcess()",
+	}
+}
+
+func (d *notSupportedDriver) InjectProcessChaos(n Node, chaos ProcessChaos) (*ProcessChaosHandle, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "InjectProcessChaos()",
+	}
+}
+
+func (d *notSupportedDriver) RevertProcessChaos(handle *ProcessChaosHandle) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RevertProcessChaos()",
+	}
+}
+
+func (d *notSupportedDriver) SkewClock(n Node, offset time.Duration, duration time.Duration) (*ClockSkewHandle, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "SkewClock()",
+	}
+}
+
+func (d *notSupportedDriver) RestoreClock(handle *ClockSkewHandle) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RestoreClock()",
+	}
+}
+
+func (d *notSupportedDriver) ResetVM(node Node) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ResetVM()",
+	}
+}
+
+func (d *notSupportedDriver) GetVMDevices(node Node) ([]VMDevice, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GetVMDevices()",
+	}
+}
+
+func (d *notSupportedDriver) DetachVMDevice(node Node, device VMDevice) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DetachVMDevice()",
+	}
+}
+
+func (d *notSupportedDriver) AttachVMDevice(node Node, device VMDevice) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "AttachVMDevice()",
+	}
+}
+
+func (d *notSupportedDriver) ListNodePools() ([]NodePool, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ListNodePools()",
+	}
+}
+
+func (d *notSupportedDriver) SetNodePoolSize(name string, size int64, timeout time.Duration) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "SetNodePoolSize()",
+	}
+}
+
+func (d *notSupportedDriver) AddNodePool(spec NodePoolSpec, timeout time.Duration) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "AddNodePool()",
+	}
+}
+
+func (d *notSupportedDriver) DeleteNodePool(name string, timeout time.Duration) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DeleteNodePool()",
+	}
+}
+
+func (d *notSupportedDriver) UpgradeNodePool(name string, version string, timeout time.Duration) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "UpgradeNodePool()",
+	}
+}
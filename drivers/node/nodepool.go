@@ -0,0 +1,117 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodePool is a group of nodes of a managed cluster with the same instance type, which is scaled and upgraded as one
+type NodePool struct {
+	// Name is the name of the pool in the cloud
+	Name string
+	// InstanceType is the machine type, VM size, shape or flavor of the nodes of the pool
+	InstanceType string
+	// Size is the number of nodes the pool is set to over all its zones
+	Size int64
+	// Zones are the zones the nodes of the pool are spread over
+	Zones []string
+	// Version is the kubernetes version of the nodes of the pool
+	Version string
+	// Nodes are the names of the kubernetes nodes of the pool
+	Nodes []string
+}
+
+// ZoneCount returns the number of zones of the pool. Like PX, a pool without zones is treated as being in one zone.
+func (p NodePool) ZoneCount() int64 {
+	if len(p.Zones) == 0 {
+		return 1
+	}
+	return int64(len(p.Zones))
+}
+
+// PerZoneSize returns the node count per zone which gives the pool the size over all its zones, for clouds which
+// size pools per zone. The size must be a multiple of the number of zones of the pool.
+func (p NodePool) PerZoneSize(size int64) (int64, error) {
+	if size%p.ZoneCount() != 0 {
+		return 0, fmt.Errorf("size %d of node pool %s is not a multiple of its %d zones", size, p.Name, p.ZoneCount())
+	}
+	return size / p.ZoneCount(), nil
+}
+
+// HasNode returns whether the kubernetes node with the given name is in the pool
+func (p NodePool) HasNode(name string) bool {
+	for _, n := range p.Nodes {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NodePoolSpec describes a node pool to add to a managed cluster. Settings which are not in the spec, like the
+// subnets and the image of the nodes, are taken from an existing pool of the cluster.
+type NodePoolSpec struct {
+	// Name is the name of the pool
+	Name string
+	// InstanceType is the machine type, VM size, shape or flavor of the nodes of the pool
+	InstanceType string
+	// PerZoneCount is the number of nodes of the pool in each zone
+	PerZoneCount int64
+}
+
+// Size returns the number of nodes of the pool over the given number of zones. Like PX, no zones are treated as
+// one zone.
+func (s NodePoolSpec) Size(zones int) int64 {
+	if zones == 0 {
+		zones = 1
+	}
+	return s.PerZoneCount * int64(zones)
+}
+
+// Validate checks the spec has everything needed to add the pool
+func (s NodePoolSpec) Validate() error {
+	if len(s.Name) == 0 || len(s.InstanceType) == 0 {
+		return fmt.Errorf("node pool needs a name and an instance type")
+	}
+	if s.PerZoneCount <= 0 {
+		return fmt.Errorf("node pool %s needs at least one node per zone", s.Name)
+	}
+	return nil
+}
+
+// GetNodePool returns the node pool with the given name
+func GetNodePool(pools []NodePool, name string) (NodePool, error) {
+	for _, pool := range pools {
+		if pool.Name == name {
+			return pool, nil
+		}
+	}
+	return NodePool{}, fmt.Errorf("node pool %s not found", name)
+}
+
+// GetNodePoolNodes returns the names of the kubernetes nodes of each node pool, by the value of the label the cloud
+// sets to the name of the pool on its nodes
+func GetNodePoolNodes(poolLabel string) (map[string][]string, error) {
+	nodes, err := core.Instance().GetNodes()
+	if err != nil {
+		return nil, err
+	}
+	return groupNodesByPool(nodes.Items, poolLabel), nil
+}
+
+// groupNodesByPool returns the sorted names of the nodes by the value of their pool label
+func groupNodesByPool(nodes []corev1.Node, poolLabel string) map[string][]string {
+	pools := make(map[string][]string)
+	for _, n := range nodes {
+		if pool, ok := n.Labels[poolLabel]; ok {
+			pools[pool] = append(pools[pool], n.Name)
+		}
+	}
+	for _, names := range pools {
+		sort.Strings(names)
+	}
+	return pools
+}
@@ -0,0 +1,68 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodePoolSize(t *testing.T) {
+	pool := NodePool{Name: "pool-1", Size: 6, Zones: []string{"zone-a", "zone-b", "zone-c"}}
+	require.Equal(t, int64(3), pool.ZoneCount())
+	perZone, err := pool.PerZoneSize(9)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), perZone)
+	_, err = pool.PerZoneSize(7)
+	require.Error(t, err)
+
+	// a pool without zones is in one zone
+	pool = NodePool{Name: "pool-2", Size: 5}
+	require.Equal(t, int64(1), pool.ZoneCount())
+	perZone, err = pool.PerZoneSize(5)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), perZone)
+
+	spec := NodePoolSpec{Name: "pool-3", InstanceType: "m5.xlarge", PerZoneCount: 2}
+	require.Equal(t, int64(6), spec.Size(3))
+	require.Equal(t, int64(2), spec.Size(0))
+}
+
+func TestNodePoolHasNode(t *testing.T) {
+	pool := NodePool{Name: "pool-1", Nodes: []string{"node-1", "node-2"}}
+	require.True(t, pool.HasNode("node-2"))
+	require.False(t, pool.HasNode("node-3"))
+}
+
+func TestNodePoolSpecValidate(t *testing.T) {
+	require.NoError(t, NodePoolSpec{Name: "pool-1", InstanceType: "m5.xlarge", PerZoneCount: 1}.Validate())
+	require.Error(t, NodePoolSpec{InstanceType: "m5.xlarge", PerZoneCount: 1}.Validate())
+	require.Error(t, NodePoolSpec{Name: "pool-1", PerZoneCount: 1}.Validate())
+	require.Error(t, NodePoolSpec{Name: "pool-1", InstanceType: "m5.xlarge"}.Validate())
+}
+
+func TestGetNodePool(t *testing.T) {
+	pools := []NodePool{{Name: "pool-1"}, {Name: "pool-2", Size: 3}}
+	pool, err := GetNodePool(pools, "pool-2")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), pool.Size)
+	_, err = GetNodePool(pools, "pool-3")
+	require.Error(t, err)
+}
+
+func TestGroupNodesByPool(t *testing.T) {
+	k8sNode := func(name string, labels map[string]string) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	nodes := []corev1.Node{
+		k8sNode("node-3", map[string]string{"agentpool": "pool-1"}),
+		k8sNode("node-1", map[string]string{"agentpool": "pool-1"}),
+		k8sNode("node-2", map[string]string{"agentpool": "pool-2"}),
+		k8sNode("master", nil),
+	}
+	require.Equal(t, map[string][]string{
+		"pool-1": {"node-1", "node-3"},
+		"pool-2": {"node-2"},
+	}, groupNodesByPool(nodes, "agentpool"))
+}
@@ -0,0 +1,263 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	oracleOps "github.com/libopenstorage/cloudops/oracle"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/containerengine"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// workRequestRetryInterval is how often a node pool work request is checked for completion
+	workRequestRetryInterval = 30 * time.Second
+)
+
+// getContainerEngine returns the OKE client. The compartment and cluster are taken from the environment like the
+// cloudops client does, or from the metadata service.
+func (o *oracle) getContainerEngine() (*containerengine.ContainerEngineClient, error) {
+	if o.containerEngine != nil {
+		return o.containerEngine, nil
+	}
+
+	o.compartmentID, o.clusterID = os.Getenv("COMPARTMENT_ID"), os.Getenv("CLUSTER_ID")
+	if len(o.compartmentID) == 0 || len(o.clusterID) == 0 {
+		metadata, err := oracleOps.GetMetadata()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance metadata. Err: %v", err)
+		}
+		o.compartmentID, _ = metadata[oracleOps.MetadataCompartmentIDkey].(string)
+		if okeMetadata, ok := metadata[oracleOps.MetadataKey].(map[string]interface{}); ok {
+			o.clusterID, _ = okeMetadata["oke-cluster-id"].(string)
+		}
+		if len(o.compartmentID) == 0 || len(o.clusterID) == 0 {
+			return nil, fmt.Errorf("compartment or cluster ID not found in instance metadata")
+		}
+	}
+
+	// the cloudops client sets the tenancy and region of the provider in the environment
+	client, err := containerengine.NewContainerEngineClientWithConfigurationProvider(
+		common.ConfigurationProviderEnvironmentVariables("PX_ORACLE", ""))
+	if err != nil {
+		return nil, err
+	}
+	o.containerEngine = &client
+	return o.containerEngine, nil
+}
+
+// ListNodePools returns the node pools of the cluster
+func (o *oracle) ListNodePools() ([]node.NodePool, error) {
+	client, err := o.getContainerEngine()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListNodePools(context.Background(), containerengine.ListNodePoolsRequest{
+		CompartmentId: &o.compartmentID,
+		ClusterId:     &o.clusterID,
+	})
+	if err != nil {
+		log.Errorf("failed to list node pools of cluster %s. Error: %v", o.clusterID, err)
+		return nil, err
+	}
+
+	var pools []node.NodePool
+	for _, p := range resp.Items {
+		if p.LifecycleState == containerengine.NodePoolLifecycleStateDeleted {
+			continue
+		}
+		details, err := client.GetNodePool(context.Background(), containerengine.GetNodePoolRequest{NodePoolId: p.Id})
+		if err != nil {
+			log.Errorf("failed to get node pool %s. Error: %v", *p.Name, err)
+			return nil, err
+		}
+		pool := node.NodePool{
+			Name:         *p.Name,
+			InstanceType: stringValue(p.NodeShape),
+			Version:      stringValue(p.KubernetesVersion),
+			Nodes:        getOKENodeNames(details.Nodes),
+		}
+		if p.NodeConfigDetails != nil {
+			if p.NodeConfigDetails.Size != nil {
+				pool.Size = int64(*p.NodeConfigDetails.Size)
+			}
+			for _, placement := range p.NodeConfigDetails.PlacementConfigs {
+				pool.Zones = append(pool.Zones, stringValue(placement.AvailabilityDomain))
+			}
+		}
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// SetNodePoolSize sets the node count of the node pool, which is sized per availability domain
+func (o *oracle) SetNodePoolSize(name string, size int64, timeout time.Duration) error {
+	pools, err := o.ListNodePools()
+	if err != nil {
+		return err
+	}
+	pool, err := node.GetNodePool(pools, name)
+	if err != nil {
+		return err
+	}
+	perZoneCount, err := pool.PerZoneSize(size)
+	if err != nil {
+		return err
+	}
+	if err := o.ops.SetInstanceGroupSize(name, perZoneCount, timeout); err != nil {
+		log.Errorf("failed to set size of node pool %s. Error: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+// AddNodePool adds a node pool with the shape of the spec. Its version, image, placement and, for flexible shapes,
+// shape config are taken from the first node pool of the cluster.
+func (o *oracle) AddNodePool(spec node.NodePoolSpec, timeout time.Duration) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	client, err := o.getContainerEngine()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pools, err := client.ListNodePools(ctx, containerengine.ListNodePoolsRequest{
+		CompartmentId:  &o.compartmentID,
+		ClusterId:      &o.clusterID,
+		LifecycleState: []containerengine.NodePoolLifecycleStateEnum{containerengine.NodePoolLifecycleStateActive},
+	})
+	if err != nil {
+		return err
+	}
+	if len(pools.Items) == 0 || pools.Items[0].NodeConfigDetails == nil {
+		return fmt.Errorf("cluster %s has no node pool to take the placement from", o.clusterID)
+	}
+	template := pools.Items[0]
+
+	placements := template.NodeConfigDetails.PlacementConfigs
+	size := int(spec.PerZoneCount) * len(placements)
+	details := containerengine.CreateNodePoolDetails{
+		CompartmentId:     &o.compartmentID,
+		ClusterId:         &o.clusterID,
+		Name:              &spec.Name,
+		NodeShape:         &spec.InstanceType,
+		KubernetesVersion: template.KubernetesVersion,
+		NodeSourceDetails: template.NodeSourceDetails,
+		SshPublicKey:      template.SshPublicKey,
+		NodeConfigDetails: &containerengine.CreateNodePoolNodeConfigDetails{
+			Size:             &size,
+			PlacementConfigs: placements,
+			NsgIds:           template.NodeConfigDetails.NsgIds,
+		},
+	}
+	if strings.HasSuffix(spec.InstanceType, ".Flex") && template.NodeShapeConfig != nil {
+		details.NodeShapeConfig = &containerengine.CreateNodeShapeConfigDetails{
+			Ocpus:       template.NodeShapeConfig.Ocpus,
+			MemoryInGBs: template.NodeShapeConfig.MemoryInGBs,
+		}
+	}
+
+	log.Infof("Adding node pool %s of %d %s nodes", spec.Name, size, spec.InstanceType)
+	resp, err := client.CreateNodePool(ctx, containerengine.CreateNodePoolRequest{CreateNodePoolDetails: details})
+	if err != nil {
+		log.Errorf("failed to add node pool %s. Error: %v", spec.Name, err)
+		return err
+	}
+	return o.waitForWorkRequest(resp.OpcWorkRequestId, timeout)
+}
+
+// DeleteNodePool deletes the node pool from the cluster
+func (o *oracle) DeleteNodePool(name string, timeout time.Duration) error {
+	client, err := o.getContainerEngine()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	pools, err := client.ListNodePools(ctx, containerengine.ListNodePoolsRequest{
+		CompartmentId: &o.compartmentID,
+		ClusterId:     &o.clusterID,
+		Name:          &name,
+	})
+	if err != nil {
+		return err
+	}
+	var poolID *string
+	for _, p := range pools.Items {
+		if p.LifecycleState != containerengine.NodePoolLifecycleStateDeleted {
+			poolID = p.Id
+		}
+	}
+	if poolID == nil {
+		return fmt.Errorf("no node pool found with name %s", name)
+	}
+
+	log.Infof("Deleting node pool %s", name)
+	resp, err := client.DeleteNodePool(ctx, containerengine.DeleteNodePoolRequest{NodePoolId: poolID})
+	if err != nil {
+		log.Errorf("failed to delete node pool %s. Error: %v", name, err)
+		return err
+	}
+	return o.waitForWorkRequest(resp.OpcWorkRequestId, timeout)
+}
+
+// UpgradeNodePool upgrades the nodes of the node pool to the kubernetes version
+func (o *oracle) UpgradeNodePool(name string, version string, timeout time.Duration) error {
+	log.Infof("Upgrading node pool %s to version %s", name, version)
+	if err := o.ops.SetInstanceGroupVersion(name, version, timeout); err != nil {
+		log.Errorf("failed to set version for node pool %s. Error: %v", name, err)
+		return err
+	}
+	return nil
+}
+
+// waitForWorkRequest waits for the node pool work request to succeed
+func (o *oracle) waitForWorkRequest(id *string, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		resp, err := o.containerEngine.GetWorkRequest(context.Background(), containerengine.GetWorkRequestRequest{WorkRequestId: id})
+		if err != nil {
+			return nil, true, err
+		}
+		switch resp.Status {
+		case containerengine.WorkRequestStatusSucceeded:
+			return nil, false, nil
+		case containerengine.WorkRequestStatusFailed, containerengine.WorkRequestStatusCanceled:
+			return nil, false, fmt.Errorf("work request %s is in [%s] state", *id, resp.Status)
+		}
+		return nil, true, fmt.Errorf("work request %s is in [%s] state", *id, resp.Status)
+	}
+	_, err := task.DoRetryWithTimeout(t, timeout, workRequestRetryInterval)
+	return err
+}
+
+// getOKENodeNames returns the names of the kubernetes nodes of the pool. OKE names nodes after their private IP, so
+// the registered node with the IP is looked up and the IP itself is used if there is none.
+func getOKENodeNames(okeNodes []containerengine.Node) []string {
+	var names []string
+	for _, okeNode := range okeNodes {
+		ip := stringValue(okeNode.PrivateIp)
+		name := ip
+		for _, n := range node.GetNodes() {
+			for _, addr := range n.Addresses {
+				if addr == ip {
+					name = n.Name
+				}
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -10,7 +10,9 @@ import (
 
 	"github.com/libopenstorage/cloudops"
 	oracleOps "github.com/libopenstorage/cloudops/oracle"
+	"github.com/oracle/oci-go-sdk/v65/containerengine"
 	"github.com/oracle/oci-go-sdk/v65/core"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 )
 
@@ -24,12 +26,20 @@ type oracle struct {
 	ops               cloudops.Ops
 	instanceID        string
 	instanceGroupName string
+	containerEngine   *containerengine.ContainerEngineClient
+	compartmentID     string
+	clusterID         string
 }
 
 func (o *oracle) String() string {
 	return DriverName
 }
 
+func (o *oracle) Capabilities() driver_api.Capabilities {
+	return o.SSH.Capabilities().With(node.CapabilityASGResize, node.CapabilityClusterUpgrade, node.CapabilityDeleteNode,
+		node.CapabilityNodePools)
+}
+
 // Init initializes the node driver for oracle under the given scheduler
 func (o *oracle) Init(nodeOpts node.InitOptions) error {
 	o.SSH.Init(nodeOpts)
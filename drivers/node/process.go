@@ -0,0 +1,102 @@
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProcessSignal is a signal sent to processes on a node
+type ProcessSignal string
+
+const (
+	// SignalKill kills the processes
+	SignalKill ProcessSignal = "KILL"
+	// SignalStop pauses the processes
+	SignalStop ProcessSignal = "STOP"
+	// SignalCont resumes paused processes
+	SignalCont ProcessSignal = "CONT"
+)
+
+// ProcessChaosType identifies the kind of a process chaos
+type ProcessChaosType string
+
+const (
+	// ProcessChaosPause pauses the Target processes for Duration
+	ProcessChaosPause ProcessChaosType = "pause"
+	// ProcessChaosCPU runs Workers CPU hogs for Duration
+	ProcessChaosCPU ProcessChaosType = "cpu-pressure"
+	// ProcessChaosMemory runs Workers memory hogs using MemoryPercent of the available memory in total for Duration
+	ProcessChaosMemory ProcessChaosType = "memory-pressure"
+	// ProcessChaosIO runs Workers disk write hogs for Duration
+	ProcessChaosIO ProcessChaosType = "io-pressure"
+	// ProcessChaosThrottle limits the CPU and memory of the Target systemd service through its cgroup for Duration
+	ProcessChaosThrottle ProcessChaosType = "throttle"
+)
+
+// ProcessChaos describes a chaos to inject on the processes of a node. All chaos is reverted on the node itself
+// after Duration, even if the test stops before reverting it.
+type ProcessChaos struct {
+	// Type is the kind of the chaos
+	Type ProcessChaosType
+	// Target is the name of the processes to pause or the systemd service to throttle
+	Target string
+	// Duration is how long the chaos lasts
+	Duration time.Duration
+	// Workers is the number of hogs for pressure chaos
+	Workers int
+	// MemoryPercent is the percentage of the available memory the memory hogs use
+	MemoryPercent int
+	// CPUQuotaPercent is the CPU time the throttled service gets, in percent of one CPU
+	CPUQuotaPercent int
+	// MemoryHighBytes is the memory above which the throttled service is reclaimed from, unlimited if 0
+	MemoryHighBytes uint64
+}
+
+// Validate checks the chaos has the parameters its type needs
+func (c ProcessChaos) Validate() error {
+	if c.Duration < time.Second {
+		return fmt.Errorf("%s chaos needs a duration of at least 1s", c.Type)
+	}
+	switch c.Type {
+	case ProcessChaosPause:
+		if len(c.Target) == 0 {
+			return fmt.Errorf("pause chaos needs a target process")
+		}
+	case ProcessChaosCPU, ProcessChaosIO:
+		if c.Workers <= 0 {
+			return fmt.Errorf("%s chaos needs workers", c.Type)
+		}
+	case ProcessChaosMemory:
+		if c.Workers <= 0 || c.MemoryPercent <= 0 || c.MemoryPercent > 100 {
+			return fmt.Errorf("memory pressure chaos needs workers and a memory percentage between 1 and 100")
+		}
+	case ProcessChaosThrottle:
+		if len(c.Target) == 0 {
+			return fmt.Errorf("throttle chaos needs a target service")
+		}
+		if c.CPUQuotaPercent <= 0 && c.MemoryHighBytes == 0 {
+			return fmt.Errorf("throttle chaos needs a CPU quota or a memory limit")
+		}
+	default:
+		return fmt.Errorf("unknown process chaos type %s", c.Type)
+	}
+	return nil
+}
+
+// ProcessChaosHandle is a process chaos injected on a node
+type ProcessChaosHandle struct {
+	// ID identifies the chaos. The transient systemd unit reverting it is named after it.
+	ID string
+	// Node is the node the chaos is injected on
+	Node Node
+	// Chaos is the injected chaos
+	Chaos ProcessChaos
+	// PIDs are the paused processes
+	PIDs []int
+	// Until is when the chaos reverts itself
+	Until time.Time
+}
+
+func (h *ProcessChaosHandle) String() string {
+	return fmt.Sprintf("%s (%s %s on %s until %s)", h.ID, h.Chaos.Type, h.Chaos.Target, h.Node.Name, h.Until.Format(time.RFC3339))
+}
@@ -0,0 +1,121 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	clockSkewPrefix = "torpedo-clock-"
+	// clockSkewScriptDir is where the script restoring a skewed clock is kept on its node. The script deletes itself
+	// once it restored the clock, so the clock is never moved back twice.
+	clockSkewScriptDir = "/var/tmp"
+)
+
+// ntpServices are the time sync services stopped while a clock is skewed
+var ntpServices = []string{"chronyd", "chrony", "ntpd", "ntp", "systemd-timesyncd"}
+
+// SkewClock stops the active time sync services, moves the clock by the offset and schedules the restore in a
+// transient systemd timer. The timer runs on the monotonic clock, so the skew does not change when it fires.
+func (s *SSH) SkewClock(n node.Node, offset time.Duration, duration time.Duration) (*node.ClockSkewHandle, error) {
+	if offset.Seconds() == 0 || duration < time.Second {
+		return nil, &node.ErrFailedToSkewClock{Node: n, Cause: fmt.Sprintf("invalid offset %v or duration %v", offset, duration)}
+	}
+	handle := &node.ClockSkewHandle{
+		ID:     clockSkewPrefix + uuid.New()[:8],
+		Node:   n,
+		Offset: offset,
+		Until:  time.Now().Add(duration),
+	}
+
+	stopCmd := fmt.Sprintf("echo ntp=$(timedatectl show -p NTP --value 2>/dev/null); "+
+		"for s in %s; do if sudo systemctl is-active --quiet $s; then sudo systemctl stop $s && echo $s; fi; done; "+
+		"sudo timedatectl set-ntp false 2>/dev/null; true", strings.Join(ntpServices, " "))
+	out, err := s.RunCommand(n, stopCmd, clockOpts())
+	if err != nil {
+		return nil, &node.ErrFailedToSkewClock{Node: n, Cause: err.Error()}
+	}
+	handle.NTPServices, handle.NTPEnabled = parseNTPState(out)
+
+	skewCmd, _ := clockSkewCommands(handle, int(duration.Seconds()))
+	log.Infof("Skewing clock %s", handle)
+	if _, err := s.RunCommand(n, skewCmd, clockOpts()); err != nil {
+		// time sync is started again since the clock was not moved
+		restartCmd := fmt.Sprintf("sudo systemctl start %s 2>/dev/null; true", strings.Join(handle.NTPServices, " "))
+		if _, restartErr := s.RunCommand(n, restartCmd, clockOpts()); restartErr != nil {
+			log.Warnf("Failed to start time sync on %s again: %v", n.Name, restartErr)
+		}
+		return nil, &node.ErrFailedToSkewClock{Node: n, Cause: err.Error()}
+	}
+	return handle, nil
+}
+
+// RestoreClock runs the restore script of the skew if it did not run yet and stops its timer
+func (s *SSH) RestoreClock(handle *node.ClockSkewHandle) error {
+	_, restoreCmd := clockSkewCommands(handle, 0)
+	log.Infof("Restoring clock %s", handle)
+	if _, err := s.RunCommand(handle.Node, restoreCmd, clockOpts()); err != nil {
+		return &node.ErrFailedToSkewClock{Node: handle.Node, Cause: err.Error()}
+	}
+	return nil
+}
+
+func clockOpts() node.ConnectionOpts {
+	return node.ConnectionOpts{
+		Timeout:         1 * time.Minute,
+		TimeBeforeRetry: 10 * time.Second,
+	}
+}
+
+// clockSkewCommands returns the command writing the restore script, moving the clock and scheduling the restore
+// after the given seconds, and the command restoring the clock
+func clockSkewCommands(handle *node.ClockSkewHandle, seconds int) (string, string) {
+	script := fmt.Sprintf("%s/%s.sh", clockSkewScriptDir, handle.ID)
+	offset := int64(handle.Offset.Seconds())
+	lines := []string{
+		"#!/bin/bash",
+		fmt.Sprintf("[ -f %s ] || exit 0", script),
+		fmt.Sprintf("date -s @$(( $(date +%%s) - (%d) ))", offset),
+		fmt.Sprintf("rm -f %s", script),
+	}
+	if handle.NTPEnabled {
+		lines = append(lines, "timedatectl set-ntp true")
+	}
+	if len(handle.NTPServices) > 0 {
+		lines = append(lines, fmt.Sprintf("systemctl start %s", strings.Join(handle.NTPServices, " ")))
+	}
+	var quoted []string
+	for _, line := range lines {
+		quoted = append(quoted, fmt.Sprintf("'%s'", line))
+	}
+
+	skew := fmt.Sprintf("printf '%%s\\n' %s | sudo tee %s >/dev/null && "+
+		"(sudo date -s @$(( $(date +%%s) + (%d) )) || { sudo rm -f %s; exit 1; }) && "+
+		"sudo systemd-run --unit %s --on-active=%ds /bin/bash %s",
+		strings.Join(quoted, " "), script, offset, script, handle.ID, seconds, script)
+	restore := fmt.Sprintf("[ ! -f %s ] || sudo /bin/bash %s; sudo systemctl stop %s.timer %s.service 2>/dev/null; true",
+		script, script, handle.ID, handle.ID)
+	return skew, restore
+}
+
+// parseNTPState parses the output of the command stopping time sync, 'ntp=<yes|no>' followed by the stopped services
+func parseNTPState(output string) ([]string, bool) {
+	var services []string
+	enabled := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case len(line) == 0:
+		case strings.HasPrefix(line, "ntp="):
+			enabled = strings.TrimPrefix(line, "ntp=") == "yes"
+		default:
+			services = append(services, line)
+		}
+	}
+	return services, enabled
+}
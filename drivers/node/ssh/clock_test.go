@@ -0,0 +1,35 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNTPState(t *testing.T) {
+	services, enabled := parseNTPState("ntp=yes\nchronyd\n")
+	require.True(t, enabled)
+	require.Equal(t, []string{"chronyd"}, services)
+
+	services, enabled = parseNTPState("ntp=\n")
+	require.False(t, enabled)
+	require.Empty(t, services)
+}
+
+func TestClockSkewCommands(t *testing.T) {
+	handle := &node.ClockSkewHandle{
+		ID:          "torpedo-clock-1",
+		Offset:      -5 * time.Minute,
+		NTPServices: []string{"chronyd"},
+		NTPEnabled:  true,
+	}
+	skew, restore := clockSkewCommands(handle, 600)
+	require.Contains(t, skew, "'[ -f /var/tmp/torpedo-clock-1.sh ] || exit 0' 'date -s @$(( $(date +%s) - (-300) ))' "+
+		"'rm -f /var/tmp/torpedo-clock-1.sh' 'timedatectl set-ntp true' 'systemctl start chronyd'")
+	require.Contains(t, skew, "(sudo date -s @$(( $(date +%s) + (-300) )) || { sudo rm -f /var/tmp/torpedo-clock-1.sh; exit 1; })")
+	require.Contains(t, skew, "sudo systemd-run --unit torpedo-clock-1 --on-active=600s /bin/bash /var/tmp/torpedo-clock-1.sh")
+	require.Equal(t, "[ ! -f /var/tmp/torpedo-clock-1.sh ] || sudo /bin/bash /var/tmp/torpedo-clock-1.sh; "+
+		"sudo systemctl stop torpedo-clock-1.timer torpedo-clock-1.service 2>/dev/null; true", restore)
+}
@@ -0,0 +1,40 @@
+package ssh
+
+import (
+	"io"
+
+	"github.com/portworx/torpedo/drivers/node"
+)
+
+// RunCommandOnNodes runs the command on the nodes with at most options.Concurrency nodes at a time. Commands are
+// retried on each node like RunCommand does, unless their output is streamed.
+func (s *SSH) RunCommandOnNodes(nodes []node.Node, command string, options node.ParallelCommandOpts) ([]node.CommandResult, error) {
+	return node.RunOnNodes(nodes, options.Concurrency, func(n node.Node) (string, error) {
+		if options.OnOutput == nil {
+			return s.RunCommand(n, command, options.ConnectionOpts)
+		}
+		w := node.NewLineWriter(func(line string) {
+			options.OnOutput(n, line)
+		})
+		err := s.StreamCommand(n, command, options.ConnectionOpts, w)
+		w.Flush()
+		return w.String(), err
+	})
+}
+
+// StreamCommand runs the command on the node once and writes its output to the writer as the node writes it. The
+// command is not retried, since its output may already have been written. Commands run in the debug pod are written
+// once they are done, since the pod exec does not stream.
+func (s *SSH) StreamCommand(n node.Node, command string, options node.ConnectionOpts, output io.Writer) error {
+	if s.IsUsingSSH() {
+		return s.doCmdSSHWithOutput(n, options, command, options.IgnoreError, output)
+	}
+	out, err := s.doCmdUsingPodWithoutRetry(n, command)
+	if _, werr := io.WriteString(output, out); werr != nil {
+		return werr
+	}
+	if options.IgnoreError {
+		return nil
+	}
+	return err
+}
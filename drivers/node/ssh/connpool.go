@@ -0,0 +1,207 @@
+package ssh
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/portworx/torpedo/pkg/log"
+	ssh_pkg "golang.org/x/crypto/ssh"
+)
+
+const (
+	// keepaliveInterval is how often idle pooled connections are checked to be alive
+	keepaliveInterval = 30 * time.Second
+	// keepaliveTimeout is how long a keepalive request may take before its connection is considered dead
+	keepaliveTimeout = 15 * time.Second
+	// sessionOpenTimeout is how long opening a session may take before its connection is considered dead
+	sessionOpenTimeout = 30 * time.Second
+	// connIdleTimeout is how long a pooled connection is kept without being used
+	connIdleTimeout = 10 * time.Minute
+	// maxSessionsPerConn is how many sessions are opened at once on a pooled connection, below the default
+	// MaxSessions of 10 of sshd
+	maxSessionsPerConn = 8
+)
+
+// connPool keeps ssh connections per node address, so commands on a node share connections instead of dialing
+// one each. A connection is shared by at most maxSessionsPerConn sessions at once, more are opened as needed.
+// Connections are kept alive with keepalive requests and closed once they fail or are idle for too long.
+type connPool struct {
+	sync.Mutex
+	conns map[string][]*pooledConn
+}
+
+type pooledConn struct {
+	addr     string
+	client   *ssh_pkg.Client
+	sessions int
+	// maxSessions is how many sessions the node accepts on the connection at once
+	maxSessions int
+	lastUsed    time.Time
+}
+
+func newConnPool() *connPool {
+	return &connPool{conns: make(map[string][]*pooledConn)}
+}
+
+// acquire returns a pooled connection to the address with room for another session, if there is one. The
+// connection must be released once the session is done.
+func (p *connPool) acquire(addr string) *pooledConn {
+	p.Lock()
+	defer p.Unlock()
+	for _, conn := range p.conns[addr] {
+		if conn.sessions < conn.maxSessions {
+			conn.sessions++
+			conn.lastUsed = time.Now()
+			return conn
+		}
+	}
+	return nil
+}
+
+// add adds the connection to the address to the pool and acquires it
+func (p *connPool) add(addr string, client *ssh_pkg.Client) *pooledConn {
+	p.Lock()
+	defer p.Unlock()
+	conn := &pooledConn{addr: addr, client: client, sessions: 1, maxSessions: maxSessionsPerConn, lastUsed: time.Now()}
+	p.conns[addr] = append(p.conns[addr], conn)
+	go p.keepalive(conn)
+	return conn
+}
+
+// release marks a session on the connection as done
+func (p *connPool) release(conn *pooledConn) {
+	p.Lock()
+	defer p.Unlock()
+	if conn.sessions > 0 {
+		conn.sessions--
+	}
+	conn.lastUsed = time.Now()
+}
+
+// reject releases the connection after the node rejected a session on it, and opens no more sessions on it than
+// it has now, as the node allows no more
+func (p *connPool) reject(conn *pooledConn) {
+	p.Lock()
+	defer p.Unlock()
+	if conn.sessions > 0 {
+		conn.sessions--
+	}
+	if conn.sessions > 0 {
+		conn.maxSessions = conn.sessions
+	}
+}
+
+// evict closes the connection and removes it from the pool. Sessions running on it fail.
+func (p *connPool) evict(conn *pooledConn) {
+	p.Lock()
+	p.remove(conn)
+	p.Unlock()
+	conn.client.Close()
+}
+
+// evictAddrs closes all the connections to the addresses and removes them from the pool, e.g. once their node
+// was rebooted and their sockets will never be closed by it
+func (p *connPool) evictAddrs(addrs []string) {
+	var evicted []*pooledConn
+	p.Lock()
+	for _, addr := range addrs {
+		evicted = append(evicted, p.conns[addr]...)
+		delete(p.conns, addr)
+	}
+	p.Unlock()
+	for _, conn := range evicted {
+		conn.client.Close()
+	}
+}
+
+// remove removes the connection from the pool. The pool must be locked.
+func (p *connPool) remove(conn *pooledConn) {
+	conns := p.conns[conn.addr]
+	for i, c := range conns {
+		if c == conn {
+			conns = append(conns[:i:i], conns[i+1:]...)
+			break
+		}
+	}
+	if len(conns) == 0 {
+		delete(p.conns, conn.addr)
+	} else {
+		p.conns[conn.addr] = conns
+	}
+}
+
+// contains returns whether the connection is in the pool. The pool must be locked.
+func (p *connPool) contains(conn *pooledConn) bool {
+	for _, c := range p.conns[conn.addr] {
+		if c == conn {
+			return true
+		}
+	}
+	return false
+}
+
+// keepalive sends keepalive requests on the connection until it fails or is idle for too long
+func (p *connPool) keepalive(conn *pooledConn) {
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.Lock()
+		pooled := p.contains(conn)
+		idle := time.Since(conn.lastUsed)
+		busy := conn.sessions > 0
+		p.Unlock()
+		if !pooled {
+			return
+		}
+		if !busy && idle > connIdleTimeout {
+			log.Debugf("Closing ssh connection to %s idle for %v", conn.addr, idle.Round(time.Second))
+			p.evict(conn)
+			return
+		}
+		err := withDeadline(keepaliveTimeout, func() error {
+			_, _, err := conn.client.SendRequest("keepalive@openssh.com", true, nil)
+			return err
+		})
+		if err != nil {
+			log.Debugf("Closing ssh connection to %s after failed keepalive. Err: %v", conn.addr, err)
+			p.evict(conn)
+			return
+		}
+	}
+}
+
+// newSession opens a session on the connection. Opening the session gives up after sessionOpenTimeout, as a
+// connection to a node which died without closing its sockets blocks until the TCP timeout.
+func newSession(conn *pooledConn) (*ssh_pkg.Session, error) {
+	type opened struct {
+		session *ssh_pkg.Session
+		err     error
+	}
+	openedCh := make(chan opened, 1)
+	go func() {
+		session, err := conn.client.NewSession()
+		openedCh <- opened{session, err}
+	}()
+	select {
+	case o := <-openedCh:
+		return o.session, o.err
+	case <-time.After(sessionOpenTimeout):
+		return nil, fmt.Errorf("timed out opening session after %v", sessionOpenTimeout)
+	}
+}
+
+// withDeadline runs the function and returns its error, or a timeout error if it does not return in time. The
+// function keeps running in the background after a timeout, until the connection it blocks on is closed.
+func withDeadline(timeout time.Duration, f func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+}
@@ -0,0 +1,74 @@
+package ssh
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	ssh_pkg "golang.org/x/crypto/ssh"
+)
+
+// fakeConn is an ssh connection whose channel opens are rejected
+type fakeConn struct {
+	ssh_pkg.Conn
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newFakeClient() *ssh_pkg.Client {
+	conn := &fakeConn{closed: make(chan struct{})}
+	chans := make(chan ssh_pkg.NewChannel)
+	reqs := make(chan *ssh_pkg.Request)
+	close(chans)
+	close(reqs)
+	return ssh_pkg.NewClient(conn, chans, reqs)
+}
+
+func (c *fakeConn) OpenChannel(name string, data []byte) (ssh_pkg.Channel, <-chan *ssh_pkg.Request, error) {
+	return nil, nil, &ssh_pkg.OpenChannelError{Reason: ssh_pkg.Prohibited, Message: "too many sessions"}
+}
+
+func (c *fakeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *fakeConn) Wait() error {
+	<-c.closed
+	return nil
+}
+
+func TestConnPool(t *testing.T) {
+	pool := newConnPool()
+	require.Nil(t, pool.acquire("10.0.0.1"))
+
+	first := pool.add("10.0.0.1", newFakeClient())
+	for i := 1; i < maxSessionsPerConn; i++ {
+		require.Equal(t, first, pool.acquire("10.0.0.1"))
+	}
+	// the connection is full, so another one is needed
+	require.Nil(t, pool.acquire("10.0.0.1"))
+	second := pool.add("10.0.0.1", newFakeClient())
+	pool.release(first)
+	require.Equal(t, first, pool.acquire("10.0.0.1"))
+
+	// the node rejects sessions beyond the ones open on the connection
+	_, err := newSession(second)
+	_, rejected := err.(*ssh_pkg.OpenChannelError)
+	require.True(t, rejected)
+	require.Equal(t, second, pool.acquire("10.0.0.1"))
+	require.Equal(t, second, pool.acquire("10.0.0.1"))
+	pool.reject(second)
+	require.Equal(t, 2, second.sessions)
+	require.Nil(t, pool.acquire("10.0.0.1"))
+
+	pool.evict(second)
+	pool.Lock()
+	require.Len(t, pool.conns["10.0.0.1"], 1)
+	pool.Unlock()
+
+	pool.add("10.0.0.2", newFakeClient())
+	pool.evictAddrs([]string{"10.0.0.1", "10.0.0.2"})
+	require.Nil(t, pool.acquire("10.0.0.1"))
+	require.Nil(t, pool.acquire("10.0.0.2"))
+}
@@ -0,0 +1,358 @@
+package ssh
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	ssh_pkg "golang.org/x/crypto/ssh"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// sudoSFTPServerCommand runs the SFTP server of the node as root, for copies of files only root may access. The
+// server is not on the PATH and its location differs between distributions.
+const sudoSFTPServerCommand = `sudo -n sh -c 'for p in /usr/lib/openssh/sftp-server /usr/libexec/openssh/sftp-server /usr/lib/ssh/sftp-server /usr/libexec/sftp-server; do [ -x "$p" ] && exec "$p"; done; echo "no sftp-server found" >&2; exit 127'`
+
+// CopyFromNode copies the file on the node to the local path over SFTP, or as a tar stream from the debug pod when
+// not using ssh. The checksum of the copy is verified against the one of the file on the node.
+func (s *SSH) CopyFromNode(n node.Node, path, localPath string, options node.CopyOpts) (*node.CopiedFile, error) {
+	copied, err := s.copyFromNode(n, path, localPath, options)
+	if err != nil {
+		return nil, &node.ErrFailedToCopyFile{Node: n, Path: path, Cause: err.Error()}
+	}
+	log.Infof("Copied %s (%d bytes) of node %s to %s", path, copied.Size, n.Name, localPath)
+	return copied, nil
+}
+
+// CopyToNode copies the local file to the path on the node over SFTP, or as a tar stream to the debug pod when not
+// using ssh. The checksum of the copy is verified against the one of the local file.
+func (s *SSH) CopyToNode(n node.Node, localPath, path string, options node.CopyOpts) (*node.CopiedFile, error) {
+	copied, err := s.copyToNode(n, localPath, path, options)
+	if err != nil {
+		return nil, &node.ErrFailedToCopyFile{Node: n, Path: path, Cause: err.Error()}
+	}
+	log.Infof("Copied %s (%d bytes) to %s of node %s", localPath, copied.Size, path, n.Name)
+	return copied, nil
+}
+
+func (s *SSH) copyFromNode(n node.Node, path, localPath string, options node.CopyOpts) (*node.CopiedFile, error) {
+	size, checksum, err := s.getFileChecksum(n, path, options.ConnectionOpts)
+	if err != nil {
+		return nil, err
+	}
+	if size > options.GetMaxSize() {
+		return nil, fmt.Errorf("file of %d bytes is larger than the maximum of %d bytes", size, options.GetMaxSize())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	w := io.MultiWriter(f, hash)
+
+	if s.IsUsingSSH() {
+		err = s.sftpDownload(n, path, w, size, options.ConnectionOpts)
+	} else {
+		err = s.tarDownload(n, path, w, size)
+	}
+	if err != nil {
+		os.Remove(localPath)
+		return nil, err
+	}
+	if localChecksum := hex.EncodeToString(hash.Sum(nil)); localChecksum != checksum {
+		os.Remove(localPath)
+		return nil, fmt.Errorf("checksum %s of the copy does not match checksum %s on the node", localChecksum, checksum)
+	}
+	return &node.CopiedFile{Path: path, LocalPath: localPath, Size: size, SHA256: checksum}, nil
+}
+
+func (s *SSH) copyToNode(n node.Node, localPath, path string, options node.CopyOpts) (*node.CopiedFile, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > options.GetMaxSize() {
+		return nil, fmt.Errorf("file of %d bytes is larger than the maximum of %d bytes", info.Size(), options.GetMaxSize())
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if s.IsUsingSSH() {
+		err = s.sftpUpload(n, f, path, options.ConnectionOpts)
+	} else {
+		err = s.tarUpload(n, f, info, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	size, remoteChecksum, err := s.getFileChecksum(n, path, options.ConnectionOpts)
+	if err != nil {
+		return nil, err
+	}
+	if remoteChecksum != checksum {
+		return nil, fmt.Errorf("checksum %s on the node does not match checksum %s of the local file", remoteChecksum, checksum)
+	}
+	return &node.CopiedFile{Path: path, LocalPath: localPath, Size: size, SHA256: checksum}, nil
+}
+
+// getFileChecksum returns the size and the sha256 checksum of the file on the node
+func (s *SSH) getFileChecksum(n node.Node, path string, options node.ConnectionOpts) (int64, string, error) {
+	cmd := fileChecksumCommand(path, options.Sudo)
+	options.IgnoreError = false
+	// the quoted path does not survive the quoting of commands run with sudo, so sudo is part of the command
+	options.Sudo = false
+	out, err := s.RunCommand(n, cmd, options)
+	if err != nil {
+		return 0, "", err
+	}
+	return parseFileChecksum(out)
+}
+
+// fileChecksumCommand returns the command printing the size and the sha256 checksum of the file
+func fileChecksumCommand(path string, sudo bool) string {
+	prefix := ""
+	if sudo {
+		prefix = "sudo -n "
+	}
+	return fmt.Sprintf("%sstat -c %%s %s && %ssha256sum %s", prefix, quotePath(path), prefix, quotePath(path))
+}
+
+// parseFileChecksum parses the output of stat -c %s and sha256sum of a file
+func parseFileChecksum(out string) (int64, string, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 {
+		return 0, "", fmt.Errorf("unexpected output of stat and sha256sum: %q", out)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(lines[0]), 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to parse file size %q. Err: %v", lines[0], err)
+	}
+	fields := strings.Fields(lines[1])
+	if len(fields) == 0 || len(fields[0]) != sha256.Size*2 {
+		return 0, "", fmt.Errorf("failed to parse checksum %q", lines[1])
+	}
+	return size, fields[0], nil
+}
+
+func (s *SSH) sftpDownload(n node.Node, path string, w io.Writer, size int64, options node.ConnectionOpts) error {
+	client, err := s.getSFTPClient(n, options)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	f, err := client.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// a file written to while it is copied fails the checksum check, so only its size at the time is copied
+	_, err = io.Copy(w, io.LimitReader(f, size))
+	return err
+}
+
+func (s *SSH) sftpUpload(n node.Node, r io.Reader, path string, options node.ConnectionOpts) error {
+	client, err := s.getSFTPClient(n, options)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.MkdirAll(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := client.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.ReadFrom(r)
+	return err
+}
+
+// sftpClient is an SFTP client on a session of a pooled connection to a node
+type sftpClient struct {
+	*sftp.Client
+	session *ssh_pkg.Session
+	conn    *pooledConn
+	pool    *connPool
+}
+
+// Close closes the client and its session and releases its connection, which stays open
+func (c *sftpClient) Close() error {
+	err := c.Client.Close()
+	c.session.Close()
+	c.pool.release(c.conn)
+	return err
+}
+
+// getSFTPClient returns an SFTP client on a session of a pooled connection to the node
+func (s *SSH) getSFTPClient(n node.Node, options node.ConnectionOpts) (*sftpClient, error) {
+	session, conn, err := s.newSession(n, options)
+	if err != nil {
+		return nil, err
+	}
+	client, err := startSFTP(session, options.Sudo)
+	if err != nil {
+		session.Close()
+		s.conns.release(conn)
+		return nil, err
+	}
+	return &sftpClient{Client: client, session: session, conn: conn, pool: s.conns}, nil
+}
+
+// startSFTP starts the SFTP subsystem on the session, or the SFTP server as root with sudo, and returns a client
+// talking to it
+func startSFTP(session *ssh_pkg.Session, sudo bool) (*sftp.Client, error) {
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if sudo {
+		if err := session.Start(sudoSFTPServerCommand); err != nil {
+			return nil, fmt.Errorf("failed to start sftp server with sudo. Err: %v", err)
+		}
+	} else if err := session.RequestSubsystem("sftp"); err != nil {
+		return nil, fmt.Errorf("failed to start sftp subsystem. Err: %v", err)
+	}
+	return sftp.NewClientPipe(stdout, stdin)
+}
+
+// tarDownload streams the file from the node as a tar archive through the debug pod
+func (s *SSH) tarDownload(n node.Node, path string, w io.Writer, size int64) error {
+	pr, pw := io.Pipe()
+	cmd := fmt.Sprintf("tar -C %s -cf - %s", quotePath(filepath.Dir(path)), quotePath(filepath.Base(path)))
+	errCh := make(chan error, 1)
+	go func() {
+		err := s.execInDebugPod(n, cmd, nil, pw)
+		pw.CloseWithError(err)
+		errCh <- err
+	}()
+
+	tr := tar.NewReader(pr)
+	_, err := tr.Next()
+	if err == nil {
+		_, err = io.Copy(w, io.LimitReader(tr, size))
+	}
+	// drain the rest of the archive so the exec finishes
+	io.Copy(ioutil.Discard, pr)
+	if execErr := <-errCh; execErr != nil {
+		return execErr
+	}
+	return err
+}
+
+// tarUpload streams the file to the node as a tar archive through the debug pod
+func (s *SSH) tarUpload(n node.Node, r io.Reader, info os.FileInfo, path string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := tw.WriteHeader(&tar.Header{
+			Name:    filepath.Base(path),
+			Mode:    int64(info.Mode().Perm()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+		if err == nil {
+			_, err = io.Copy(tw, r)
+		}
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	dir := quotePath(filepath.Dir(path))
+	return s.execInDebugPod(n, fmt.Sprintf("mkdir -p %s && tar -C %s -xf -", dir, dir), pr, ioutil.Discard)
+}
+
+// execInDebugPod runs the command on the host of the debug pod of the node with the given stdin and stdout. Unlike
+// RunCommandInPod, the output is streamed instead of kept in memory, and stdin can be given.
+func (s *SSH) execInDebugPod(n node.Node, cmd string, stdin io.Reader, stdout io.Writer) error {
+	debugPod, err := s.getDebugPod(n)
+	if err != nil {
+		return err
+	}
+	config, err := getRestConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(debugPod.Name).
+		Namespace(debugPod.Namespace).
+		SubResource("exec")
+	req.VersionedParams(&v1.PodExecOptions{
+		Container: debugPod.Spec.Containers[0].Name,
+		Command:   []string{"nsenter", "--mount=/hostproc/1/ns/mnt", "/bin/bash", "-c", cmd},
+		Stdin:     stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to init executor: %v", err)
+	}
+	var stderr bytes.Buffer
+	if err := exec.Stream(remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	}); err != nil {
+		return fmt.Errorf("failed to run command %s in pod %s: %v: %s", cmd, debugPod.Name, err, stderr.String())
+	}
+	return nil
+}
+
+func getRestConfig() (*rest.Config, error) {
+	if kubeconfig := os.Getenv("KUBECONFIG"); len(kubeconfig) > 0 {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+	return rest.InClusterConfig()
+}
+
+// quotePath quotes the path for the shell of the node
+func quotePath(path string) string {
+	return "'" + strings.Replace(path, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,33 @@
+package ssh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFileChecksum(t *testing.T) {
+	size, checksum, err := parseFileChecksum("1048576\n" +
+		"30e14955ebf1352266dc2ff8067e68104607e750abb9d3b36582b8af909fcb58  /var/cores/core-px-storage.1234\n")
+	require.NoError(t, err)
+	require.Equal(t, int64(1048576), size)
+	require.Equal(t, "30e14955ebf1352266dc2ff8067e68104607e750abb9d3b36582b8af909fcb58", checksum)
+
+	_, _, err = parseFileChecksum("stat: cannot stat '/var/cores/missing': No such file or directory")
+	require.Error(t, err)
+
+	_, _, err = parseFileChecksum("12\nnot-a-checksum  /tmp/file")
+	require.Error(t, err)
+}
+
+func TestFileChecksumCommand(t *testing.T) {
+	require.Equal(t, `stat -c %s '/var/cores/core-px' && sha256sum '/var/cores/core-px'`,
+		fileChecksumCommand("/var/cores/core-px", false))
+	require.Equal(t, `sudo -n stat -c %s '/var/cores/core-px' && sudo -n sha256sum '/var/cores/core-px'`,
+		fileChecksumCommand("/var/cores/core-px", true))
+}
+
+func TestQuotePath(t *testing.T) {
+	require.Equal(t, `'/var/cores/core-px'`, quotePath("/var/cores/core-px"))
+	require.Equal(t, `'/tmp/it'\''s'`, quotePath("/tmp/it's"))
+}
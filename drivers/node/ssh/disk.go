@@ -0,0 +1,269 @@
+package ssh
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	diskFaultPrefix = "torpedo-"
+	// diskFaultStateDir is where the original table or SCSI state of a faulty disk is kept on its node, so it can be
+	// recovered even if torpedo restarts while the fault is injected
+	diskFaultStateDir = "/var/tmp"
+	// diskFaultTableSuffix and diskFaultSCSISuffix are the suffixes of the files keeping the original table of a
+	// device-mapper disk and the original state of a SCSI disk
+	diskFaultTableSuffix = ".table"
+	diskFaultSCSISuffix  = ".scsi-state"
+)
+
+// PrepareDiskForFaults wraps the disk in a linear device-mapper device named torpedo-<disk>. The returned path must
+// be used instead of the disk, e.g. to create a storage pool on.
+func (s *SSH) PrepareDiskForFaults(n node.Node, device string) (string, error) {
+	dmName := diskFaultPrefix + path.Base(device)
+	cmd := fmt.Sprintf("sudo dmsetup info %s >/dev/null 2>&1 || sudo dmsetup create %s --table \"0 $(sudo blockdev --getsz %s) linear %s 0\"",
+		dmName, dmName, device, device)
+	if _, err := s.RunCommand(n, cmd, s.diskFaultOpts()); err != nil {
+		return "", &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	log.Infof("Wrapped disk %s of node %s in device-mapper device %s", device, n.Name, dmName)
+	return "/dev/mapper/" + dmName, nil
+}
+
+// InjectDiskFault loads a table with the dm-delay, dm-flakey or dm-error targets of the fault in place of the
+// linear targets of the original table of a device-mapper disk. Other SCSI disks are taken offline for error faults.
+func (s *SSH) InjectDiskFault(n node.Node, device string, fault node.DiskFault) error {
+	if err := fault.Validate(); err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	dmName, err := s.getDMName(n, device)
+	if err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	if len(dmName) == 0 {
+		return s.injectSCSIDiskFault(n, device, fault)
+	}
+
+	// the original table is saved on the node before the first fault and reused by later ones
+	tableFile := diskFaultStateFile(dmName, diskFaultTableSuffix)
+	original, err := s.RunCommand(n, fmt.Sprintf("sudo dmsetup table %s", dmName), s.diskFaultOpts())
+	if err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	saved, err := s.RunCommand(n, fmt.Sprintf("cat %s 2>/dev/null; true", tableFile), s.diskFaultOpts())
+	if err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	if len(strings.TrimSpace(saved)) > 0 {
+		original = saved
+	}
+	table, err := faultyTable(original, fault)
+	if err != nil {
+		return &node.ErrDiskNotFaultable{Node: n, Device: device, Cause: err.Error()}
+	}
+	if len(strings.TrimSpace(saved)) == 0 {
+		if err := s.saveDiskFaultState(n, tableFile, original); err != nil {
+			return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+		}
+	}
+
+	log.Infof("Injecting %s fault on disk %s of node %s", fault.Type, device, n.Name)
+	if err := s.loadDMTable(n, dmName, table); err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	return nil
+}
+
+// injectSCSIDiskFault takes the SCSI disk offline, which fails all its I/O, and saves its original state
+func (s *SSH) injectSCSIDiskFault(n node.Node, device string, fault node.DiskFault) error {
+	disk, err := s.getSCSIDisk(n, device)
+	if err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	if len(disk) == 0 {
+		return &node.ErrDiskNotFaultable{Node: n, Device: device, Cause: "neither a device-mapper device nor a SCSI disk"}
+	}
+	if fault.Type != node.DiskFaultError {
+		return &node.ErrDiskNotFaultable{Node: n, Device: device,
+			Cause: fmt.Sprintf("only %s faults can be injected on SCSI disks which are not device-mapper devices", node.DiskFaultError)}
+	}
+	stateFile := diskFaultStateFile(disk, diskFaultSCSISuffix)
+	cmd := fmt.Sprintf("[ -s %s ] || cat %s | sudo tee %s >/dev/null", stateFile, scsiStatePath(disk), stateFile)
+	if _, err := s.RunCommand(n, cmd, s.diskFaultOpts()); err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+
+	log.Infof("Injecting %s fault on disk %s of node %s by taking it offline", fault.Type, device, n.Name)
+	if _, err := s.RunCommand(n, fmt.Sprintf("echo offline | sudo tee %s", scsiStatePath(disk)), s.diskFaultOpts()); err != nil {
+		return &node.ErrFailedToInjectDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	return nil
+}
+
+// RecoverDiskFault loads the original table or SCSI state saved on the node back and deletes the saved copy
+func (s *SSH) RecoverDiskFault(n node.Node, device string) error {
+	dmName, err := s.getDMName(n, device)
+	if err != nil {
+		return &node.ErrFailedToRecoverDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	name, suffix := dmName, diskFaultTableSuffix
+	if len(dmName) == 0 {
+		if name, err = s.getSCSIDisk(n, device); err != nil {
+			return &node.ErrFailedToRecoverDiskFault{Node: n, Device: device, Cause: err.Error()}
+		}
+		if len(name) == 0 {
+			return &node.ErrFailedToRecoverDiskFault{Node: n, Device: device, Cause: "neither a device-mapper device nor a SCSI disk"}
+		}
+		suffix = diskFaultSCSISuffix
+	}
+	stateFile := diskFaultStateFile(name, suffix)
+	original, err := s.RunCommand(n, fmt.Sprintf("cat %s", stateFile), s.diskFaultOpts())
+	if err != nil {
+		return &node.ErrFailedToRecoverDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	if len(strings.TrimSpace(original)) == 0 {
+		return &node.ErrFailedToRecoverDiskFault{Node: n, Device: device, Cause: "no saved state, disk has no fault"}
+	}
+
+	log.Infof("Recovering disk %s of node %s", device, n.Name)
+	if len(dmName) > 0 {
+		err = s.loadDMTable(n, dmName, original)
+	} else {
+		_, err = s.RunCommand(n, fmt.Sprintf("echo %s | sudo tee %s", strings.TrimSpace(original), scsiStatePath(name)), s.diskFaultOpts())
+	}
+	if err != nil {
+		return &node.ErrFailedToRecoverDiskFault{Node: n, Device: device, Cause: err.Error()}
+	}
+	if _, err := s.RunCommand(n, fmt.Sprintf("sudo rm -f %s", stateFile), s.diskFaultOpts()); err != nil {
+		log.Warnf("Failed to remove saved state %s of node %s: %v", stateFile, n.Name, err)
+	}
+	return nil
+}
+
+// GetDiskFaults returns the disk faults of the node from the original tables and SCSI states saved on it, sorted
+// by device
+func (s *SSH) GetDiskFaults(n node.Node) ([]*node.DiskFaultState, error) {
+	cmd := fmt.Sprintf("ls -1 %s/%s*%s %s/%s*%s 2>/dev/null; true", diskFaultStateDir, diskFaultPrefix, diskFaultTableSuffix,
+		diskFaultStateDir, diskFaultPrefix, diskFaultSCSISuffix)
+	out, err := s.RunCommand(n, cmd, s.diskFaultOpts())
+	if err != nil {
+		return nil, err
+	}
+	return parseDiskFaultStates(n.Name, out), nil
+}
+
+// getDMName returns the device-mapper name of the disk, or an empty name if it is not a device-mapper device
+func (s *SSH) getDMName(n node.Node, device string) (string, error) {
+	out, err := s.RunCommand(n, fmt.Sprintf("sudo dmsetup info -c --noheadings -o name %s 2>/dev/null; true", device), s.diskFaultOpts())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// getSCSIDisk returns the kernel name of the disk if it is a SCSI disk whose state can be changed, or an empty name
+func (s *SSH) getSCSIDisk(n node.Node, device string) (string, error) {
+	cmd := fmt.Sprintf("d=$(basename $(readlink -f %s)); [ ! -f /sys/block/$d/device/state ] || echo $d", device)
+	out, err := s.RunCommand(n, cmd, s.diskFaultOpts())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// saveDiskFaultState saves the original table or SCSI state of a disk in the file on the node
+func (s *SSH) saveDiskFaultState(n node.Node, file, state string) error {
+	cmd := fmt.Sprintf("%s | sudo tee %s >/dev/null", printLinesCommand(state), file)
+	_, err := s.RunCommand(n, cmd, s.diskFaultOpts())
+	return err
+}
+
+// loadDMTable replaces the live table of the device-mapper device. Suspending flushes the I/O in flight first.
+func (s *SSH) loadDMTable(n node.Node, dmName, table string) error {
+	cmd := fmt.Sprintf("%s | sudo dmsetup load %s && sudo dmsetup suspend %s && sudo dmsetup resume %s",
+		printLinesCommand(table), dmName, dmName, dmName)
+	_, err := s.RunCommand(n, cmd, s.diskFaultOpts())
+	return err
+}
+
+// printLinesCommand returns the command printing the lines of the text, e.g. of a device-mapper table
+func printLinesCommand(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		lines = append(lines, fmt.Sprintf("'%s'", strings.TrimSpace(line)))
+	}
+	return fmt.Sprintf("printf '%%s\\n' %s", strings.Join(lines, " "))
+}
+
+func (s *SSH) diskFaultOpts() node.ConnectionOpts {
+	return node.ConnectionOpts{
+		Timeout:         2 * time.Minute,
+		TimeBeforeRetry: 10 * time.Second,
+	}
+}
+
+func diskFaultStateFile(name, suffix string) string {
+	return fmt.Sprintf("%s/%s%s%s", diskFaultStateDir, diskFaultPrefix, name, suffix)
+}
+
+func scsiStatePath(disk string) string {
+	return fmt.Sprintf("/sys/block/%s/device/state", disk)
+}
+
+// parseDiskFaultStates returns the disk faults of the node from the list of files saved by InjectDiskFault
+func parseDiskFaultStates(nodeName, output string) []*node.DiskFaultState {
+	var states []*node.DiskFaultState
+	for _, file := range strings.Fields(output) {
+		name := strings.TrimPrefix(path.Base(file), diskFaultPrefix)
+		switch {
+		case strings.HasSuffix(name, diskFaultTableSuffix):
+			dmName := strings.TrimSuffix(name, diskFaultTableSuffix)
+			states = append(states, &node.DiskFaultState{Node: nodeName, Device: "/dev/mapper/" + dmName, DMName: dmName})
+		case strings.HasSuffix(name, diskFaultSCSISuffix):
+			states = append(states, &node.DiskFaultState{Node: nodeName, Device: "/dev/" + strings.TrimSuffix(name, diskFaultSCSISuffix)})
+		}
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Device < states[j].Device })
+	return states
+}
+
+// faultyTable returns the table with each linear target '<start> <length> linear <device> <offset>' of the original
+// table replaced by the target of the fault on the same device and offset
+func faultyTable(original string, fault node.DiskFault) (string, error) {
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(original), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 5 || fields[2] != "linear" {
+			return "", fmt.Errorf("only linear targets can be made faulty, got: %s", line)
+		}
+		start, length, device, offset := fields[0], fields[1], fields[3], fields[4]
+		var target string
+		switch fault.Type {
+		case node.DiskFaultDelay:
+			target = fmt.Sprintf("delay %s %s %d", device, offset, fault.Delay.Milliseconds())
+		case node.DiskFaultFlakey:
+			target = fmt.Sprintf("flakey %s %s %d %d", device, offset,
+				int(fault.UpInterval.Seconds()), int(fault.DownInterval.Seconds()))
+		case node.DiskFaultReadOnly:
+			// a flakey target which is always down and only fails writes
+			target = fmt.Sprintf("flakey %s %s 0 1 1 error_writes", device, offset)
+		case node.DiskFaultError:
+			target = "error"
+		default:
+			return "", fmt.Errorf("unknown disk fault type %s", fault.Type)
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", start, length, target))
+	}
+	if len(lines) == 0 {
+		return "", fmt.Errorf("empty table")
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,47 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultyTable(t *testing.T) {
+	original := "0 2097152 linear 8:16 0\n2097152 1048576 linear 8:32 2048\n"
+
+	table, err := faultyTable(original, node.DiskFault{Type: node.DiskFaultDelay, Delay: 500 * time.Millisecond})
+	require.NoError(t, err)
+	require.Equal(t, "0 2097152 delay 8:16 0 500\n2097152 1048576 delay 8:32 2048 500", table)
+
+	table, err = faultyTable(original, node.DiskFault{Type: node.DiskFaultFlakey, UpInterval: 30 * time.Second, DownInterval: 5 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, "0 2097152 flakey 8:16 0 30 5\n2097152 1048576 flakey 8:32 2048 30 5", table)
+
+	table, err = faultyTable(original, node.DiskFault{Type: node.DiskFaultReadOnly})
+	require.NoError(t, err)
+	require.Equal(t, "0 2097152 flakey 8:16 0 0 1 1 error_writes\n2097152 1048576 flakey 8:32 2048 0 1 1 error_writes", table)
+
+	table, err = faultyTable(original, node.DiskFault{Type: node.DiskFaultError})
+	require.NoError(t, err)
+	require.Equal(t, "0 2097152 error\n2097152 1048576 error", table)
+
+	_, err = faultyTable("0 2097152 striped 2 128 8:16 0 8:32 0", node.DiskFault{Type: node.DiskFaultError})
+	require.Error(t, err)
+	_, err = faultyTable("", node.DiskFault{Type: node.DiskFaultError})
+	require.Error(t, err)
+}
+
+func TestParseDiskFaultStates(t *testing.T) {
+	output := "/var/tmp/torpedo-torpedo-sdc.table\n/var/tmp/torpedo-sdb.scsi-state\n/var/tmp/torpedo-other\n"
+	states := parseDiskFaultStates("node1", output)
+	require.Equal(t, []*node.DiskFaultState{
+		{Node: "node1", Device: "/dev/mapper/torpedo-sdc", DMName: "torpedo-sdc"},
+		{Node: "node1", Device: "/dev/sdb"},
+	}, states)
+	require.Empty(t, parseDiskFaultStates("node1", ""))
+
+	require.Equal(t, "printf '%s\\n' '0 2097152 linear 8:16 0' '2097152 1048576 linear 8:32 2048'",
+		printLinesCommand("0 2097152 linear 8:16 0\n 2097152 1048576 linear 8:32 2048\n"))
+}
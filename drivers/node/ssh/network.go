@@ -0,0 +1,374 @@
+package ssh
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	networkRulePrefix = "torpedo-net-"
+	// networkRevertScriptDir is where the script removing a fault with a duration is kept on its node. The script
+	// deletes itself once it removed the fault, so a fault is never removed twice.
+	networkRevertScriptDir = "/var/tmp"
+	// nftTable is the nftables table holding the partition rules on nodes without iptables
+	nftTable = "inet torpedo"
+	// tcRootHandle is the htb qdisc the shaping classes of the rules are added to. Traffic not matched by the
+	// filter of a rule goes to the missing default class, i.e. is not shaped.
+	tcRootHandle  = "1:"
+	tcDefaultRate = "10gbit"
+)
+
+var (
+	routeDeviceRegex = regexp.MustCompile(`\bdev\s+(\S+)`)
+	htbClassRegex    = regexp.MustCompile(`class htb 1:([0-9a-f]+)\s`)
+	networkOpts      = node.ConnectionOpts{
+		Timeout:         1 * time.Minute,
+		TimeBeforeRetry: 10 * time.Second,
+	}
+)
+
+// InjectNetworkFault injects the network fault on the node. Partitions are added as firewall rules tagged with the
+// rule ID, the other faults as an htb class with a netem qdisc and filters matching the peers and ports of the fault.
+func (s *SSH) InjectNetworkFault(n node.Node, fault node.NetworkFault) (*node.NetworkRule, error) {
+	if err := fault.Validate(); err != nil {
+		return nil, &node.ErrFailedToInjectNetworkFault{Node: n, Fault: fault.Type, Cause: err.Error()}
+	}
+	rule := &node.NetworkRule{
+		ID:    networkRulePrefix + uuid.New()[:8],
+		Node:  n,
+		Fault: fault,
+	}
+
+	var cmd string
+	if fault.Type == node.NetworkFaultPartition {
+		cmd = firewallAddCommand(rule)
+	} else {
+		iface, err := s.getNetworkInterface(n, fault)
+		if err != nil {
+			return nil, &node.ErrFailedToInjectNetworkFault{Node: n, Fault: fault.Type, Cause: err.Error()}
+		}
+		handle, err := s.getFreeTCHandle(n, iface)
+		if err != nil {
+			return nil, &node.ErrFailedToInjectNetworkFault{Node: n, Fault: fault.Type, Cause: err.Error()}
+		}
+		rule.Interface = iface
+		rule.Handle = strconv.FormatInt(int64(handle), 16)
+		if cmd, err = tcAddCommand(rule, handle); err != nil {
+			return nil, &node.ErrFailedToInjectNetworkFault{Node: n, Fault: fault.Type, Cause: err.Error()}
+		}
+	}
+
+	if fault.Duration > 0 {
+		deleteCmd, err := networkDeleteCommand(rule)
+		if err != nil {
+			return nil, &node.ErrFailedToInjectNetworkFault{Node: n, Fault: fault.Type, Cause: err.Error()}
+		}
+		cmd, _ = networkRevertCommands(rule, cmd, deleteCmd)
+	}
+
+	log.Infof("Injecting network fault %s", rule)
+	if _, err := s.RunCommand(n, cmd, networkOpts); err != nil {
+		return nil, &node.ErrFailedToInjectNetworkFault{Node: n, Fault: fault.Type, Cause: err.Error()}
+	}
+	return rule, nil
+}
+
+// RemoveNetworkFault removes the firewall rules tagged with the rule ID, or the shaping class of the rule. A fault
+// with a duration is removed by running its revert script, unless the node already removed it.
+func (s *SSH) RemoveNetworkFault(rule *node.NetworkRule) error {
+	cmd, err := networkDeleteCommand(rule)
+	if err != nil {
+		return &node.ErrFailedToRemoveNetworkFault{Node: rule.Node, ID: rule.ID, Cause: err.Error()}
+	}
+	if rule.Fault.Duration > 0 {
+		_, cmd = networkRevertCommands(rule, "", cmd)
+	}
+	log.Infof("Removing network fault %s", rule)
+	if _, err := s.RunCommand(rule.Node, cmd, networkOpts); err != nil {
+		return &node.ErrFailedToRemoveNetworkFault{Node: rule.Node, ID: rule.ID, Cause: err.Error()}
+	}
+	return nil
+}
+
+// PartitionNodes drops the traffic between the two sets of nodes on both sides. If a rule fails to be added the
+// rules added so far are removed. The nodes remove the rules themselves after the duration, if it is not 0.
+func (s *SSH) PartitionNodes(sideA, sideB []node.Node, ports []int, duration time.Duration) ([]*node.NetworkRule, error) {
+	var rules []*node.NetworkRule
+	partition := func(nodes, peers []node.Node) error {
+		var addresses []string
+		for _, peer := range peers {
+			addresses = append(addresses, peer.Addresses...)
+		}
+		for _, n := range nodes {
+			rule, err := s.InjectNetworkFault(n, node.NetworkFault{
+				Type:     node.NetworkFaultPartition,
+				Peers:    addresses,
+				Ports:    ports,
+				Duration: duration,
+			})
+			if err != nil {
+				return err
+			}
+			rules = append(rules, rule)
+		}
+		return nil
+	}
+
+	err := partition(sideA, sideB)
+	if err == nil {
+		err = partition(sideB, sideA)
+	}
+	if err != nil {
+		for _, rule := range rules {
+			if removeErr := s.RemoveNetworkFault(rule); removeErr != nil {
+				log.Warnf("Failed to remove network fault %s: %v", rule, removeErr)
+			}
+		}
+		return nil, err
+	}
+	return rules, nil
+}
+
+// getNetworkInterface returns the interface of the fault, or the one of the route to its first peer or the default route
+func (s *SSH) getNetworkInterface(n node.Node, fault node.NetworkFault) (string, error) {
+	if len(fault.Interface) > 0 {
+		return fault.Interface, nil
+	}
+	cmd := "ip -o route show default"
+	if len(fault.Peers) > 0 {
+		cmd = fmt.Sprintf("ip -o route get %s", fault.Peers[0])
+	}
+	out, err := s.RunCommand(n, cmd, networkOpts)
+	if err != nil {
+		return "", err
+	}
+	return parseRouteDevice(out)
+}
+
+// getFreeTCHandle returns the lowest class minor above the ones of the htb root qdisc of the interface
+func (s *SSH) getFreeTCHandle(n node.Node, iface string) (int, error) {
+	out, err := s.RunCommand(n, fmt.Sprintf("sudo tc class show dev %s", iface), networkOpts)
+	if err != nil {
+		return 0, err
+	}
+	classes := parseHTBClasses(out)
+	if len(classes) == 0 {
+		return 1, nil
+	}
+	return classes[len(classes)-1] + 1, nil
+}
+
+// firewallAddCommand returns the command adding the drop rules of a partition with iptables, or nftables on nodes
+// without iptables
+func firewallAddCommand(rule *node.NetworkRule) string {
+	var iptablesCmds, nftCmds []string
+	nftCmds = append(nftCmds,
+		fmt.Sprintf("sudo nft add table %s", nftTable),
+		fmt.Sprintf("sudo nft add chain %s input '{ type filter hook input priority -10 ; }'", nftTable),
+		fmt.Sprintf("sudo nft add chain %s output '{ type filter hook output priority -10 ; }'", nftTable))
+
+	for _, peer := range rule.Fault.Peers {
+		iptables, family := "iptables", "ip"
+		if isIPv6(peer) {
+			iptables, family = "ip6tables", "ip6"
+		}
+		// ports are matched for both TCP and UDP
+		iptablesMatches, nftMatches := []string{""}, []string{""}
+		if len(rule.Fault.Ports) > 0 {
+			iptablesMatches, nftMatches = nil, nil
+			for _, protocol := range []string{"tcp", "udp"} {
+				iptablesMatches = append(iptablesMatches, fmt.Sprintf(" -p %s -m multiport --dports %s", protocol, joinPorts(rule.Fault.Ports, ",")))
+				nftMatches = append(nftMatches, fmt.Sprintf(" %s dport { %s }", protocol, joinPorts(rule.Fault.Ports, ", ")))
+			}
+		}
+		comment := fmt.Sprintf("-m comment --comment %s", rule.ID)
+		for i := range iptablesMatches {
+			iptablesCmds = append(iptablesCmds,
+				fmt.Sprintf("sudo %s -I INPUT -s %s%s %s -j DROP", iptables, peer, iptablesMatches[i], comment),
+				fmt.Sprintf("sudo %s -I OUTPUT -d %s%s %s -j DROP", iptables, peer, iptablesMatches[i], comment))
+			nftCmds = append(nftCmds,
+				fmt.Sprintf(`sudo nft add rule %s input '%s saddr %s%s drop comment "%s"'`, nftTable, family, peer, nftMatches[i], rule.ID),
+				fmt.Sprintf(`sudo nft add rule %s output '%s daddr %s%s drop comment "%s"'`, nftTable, family, peer, nftMatches[i], rule.ID))
+		}
+	}
+	return fmt.Sprintf("if command -v iptables >/dev/null 2>&1; then %s; else %s; fi",
+		strings.Join(iptablesCmds, " && "), strings.Join(nftCmds, " && "))
+}
+
+// networkDeleteCommand returns the command removing the firewall rules or the shaping class of the rule
+func networkDeleteCommand(rule *node.NetworkRule) (string, error) {
+	if rule.Fault.Type == node.NetworkFaultPartition {
+		return firewallDeleteCommand(rule.ID), nil
+	}
+	handle, err := strconv.ParseInt(rule.Handle, 16, 32)
+	if err != nil {
+		return "", err
+	}
+	return tcDeleteCommand(rule.Interface, int(handle)), nil
+}
+
+// networkRevertCommands returns the command writing the revert script of the rule, scheduling it after the
+// duration of the fault in a transient systemd timer and running the add command, and the command reverting the
+// fault before the timer fires. The script stays in place if the delete command fails so it can be retried.
+func networkRevertCommands(rule *node.NetworkRule, addCmd, deleteCmd string) (string, string) {
+	script := fmt.Sprintf("%s/%s.sh", networkRevertScriptDir, rule.ID)
+	content := fmt.Sprintf("#!/bin/bash\n[ -f %s ] || exit 0\n(%s) && rm -f %s\n", script, deleteCmd, script)
+	seconds := int(math.Ceil(rule.Fault.Duration.Seconds()))
+	stopTimer := fmt.Sprintf("sudo systemctl stop %s.timer %s.service 2>/dev/null", rule.ID, rule.ID)
+
+	inject := fmt.Sprintf("echo %s | base64 -d | sudo tee %s >/dev/null && "+
+		"sudo systemd-run --unit %s --on-active=%ds /bin/bash %s && "+
+		"{ %s || { sudo /bin/bash %s; %s; exit 1; }; }",
+		base64.StdEncoding.EncodeToString([]byte(content)), script, rule.ID, seconds, script, addCmd, script, stopTimer)
+	revert := fmt.Sprintf("[ ! -f %s ] || sudo /bin/bash %s; rc=$?; %s; exit $rc", script, script, stopTimer)
+	return inject, revert
+}
+
+// firewallDeleteCommand returns the command deleting all iptables and nftables rules tagged with the rule ID
+func firewallDeleteCommand(id string) string {
+	iptables := fmt.Sprintf("for t in iptables ip6tables; do command -v $t >/dev/null 2>&1 || continue; "+
+		"sudo $t-save | grep -e '--comment %s' | sed 's/^-A /-D /' | while read -r r; do sudo $t $r; done; done", id)
+	nft := fmt.Sprintf("if command -v nft >/dev/null 2>&1; then for c in input output; do "+
+		"for h in $(sudo nft -a list chain %s $c 2>/dev/null | grep '\"%s\"' | awk '{print $NF}'); do "+
+		"sudo nft delete rule %s $c handle $h; done; done; fi", nftTable, id, nftTable)
+	return iptables + "; " + nft
+}
+
+// tcAddCommand returns the command adding the htb class, netem qdisc and filters of the rule with the given class minor
+func tcAddCommand(rule *node.NetworkRule, handle int) (string, error) {
+	fault := rule.Fault
+	iface := rule.Interface
+	classID := fmt.Sprintf("%s%x", tcRootHandle, handle)
+	rate := tcDefaultRate
+	if fault.Type == node.NetworkFaultBandwidth {
+		rate = fault.Rate
+	}
+
+	cmds := []string{
+		fmt.Sprintf("(sudo tc qdisc show dev %s | grep -q 'qdisc htb %s root' || sudo tc qdisc add dev %s root handle %s htb default ffff)",
+			iface, tcRootHandle, iface, tcRootHandle),
+		fmt.Sprintf("sudo tc class add dev %s parent %s classid %s htb rate %s", iface, tcRootHandle, classID, rate),
+	}
+	if args := netemArgs(fault); len(args) > 0 {
+		cmds = append(cmds, fmt.Sprintf("sudo tc qdisc add dev %s parent %s handle %x: netem %s", iface, classID, handle, args))
+	}
+
+	matches, protocol, err := tcFilterMatches(fault.Peers, fault.Ports)
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		cmds = append(cmds, fmt.Sprintf("sudo tc filter add dev %s parent %s protocol %s prio %d u32 %s flowid %s",
+			iface, tcRootHandle, protocol, handle, match, classID))
+	}
+	return strings.Join(cmds, " && "), nil
+}
+
+// tcDeleteCommand returns the command deleting the filters and the class of the rule with the given class minor
+func tcDeleteCommand(iface string, handle int) string {
+	return fmt.Sprintf("sudo tc filter del dev %s parent %s prio %d; sudo tc class del dev %s classid %s%x",
+		iface, tcRootHandle, handle, iface, tcRootHandle, handle)
+}
+
+// netemArgs returns the netem parameters of the fault, empty if it needs no netem qdisc
+func netemArgs(fault node.NetworkFault) string {
+	switch fault.Type {
+	case node.NetworkFaultDelay:
+		args := fmt.Sprintf("delay %dms", fault.Delay.Milliseconds())
+		if fault.Jitter > 0 {
+			args += fmt.Sprintf(" %dms", fault.Jitter.Milliseconds())
+		}
+		return args
+	case node.NetworkFaultLoss:
+		return fmt.Sprintf("loss %d%%", fault.Percent)
+	case node.NetworkFaultCorrupt:
+		return fmt.Sprintf("corrupt %d%%", fault.Percent)
+	case node.NetworkFaultDuplicate:
+		return fmt.Sprintf("duplicate %d%%", fault.Percent)
+	case node.NetworkFaultReorder:
+		return fmt.Sprintf("delay %dms reorder %d%%", fault.Delay.Milliseconds(), fault.Percent)
+	}
+	return ""
+}
+
+// tcFilterMatches returns the u32 matches selecting the traffic to the peers and ports, and the filter protocol.
+// All peers must be of the same address family since the filters of a rule share their priority.
+func tcFilterMatches(peers []string, ports []int) ([]string, string, error) {
+	if len(peers) == 0 && len(ports) == 0 {
+		return []string{"match u32 0 0"}, "all", nil
+	}
+
+	protocol, ipMatch, mask := "ip", "ip", "32"
+	for i, peer := range peers {
+		if isIPv6(peer) != isIPv6(peers[0]) {
+			return nil, "", fmt.Errorf("peers %s and %s are of different address families", peers[0], peers[i])
+		}
+	}
+	if len(peers) > 0 && isIPv6(peers[0]) {
+		protocol, ipMatch, mask = "ipv6", "ip6", "128"
+	}
+
+	dsts := []string{""}
+	if len(peers) > 0 {
+		dsts = nil
+		for _, peer := range peers {
+			dsts = append(dsts, fmt.Sprintf("match %s dst %s/%s", ipMatch, peer, mask))
+		}
+	}
+	var matches []string
+	for _, dst := range dsts {
+		if len(ports) == 0 {
+			matches = append(matches, dst)
+			continue
+		}
+		for _, port := range ports {
+			matches = append(matches, strings.TrimSpace(fmt.Sprintf("%s match %s dport %d 0xffff", dst, ipMatch, port)))
+		}
+	}
+	return matches, protocol, nil
+}
+
+// parseRouteDevice returns the device of the first route in the output of ip route
+func parseRouteDevice(output string) (string, error) {
+	match := routeDeviceRegex.FindStringSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("no device in route: %s", output)
+	}
+	return match[1], nil
+}
+
+// parseHTBClasses returns the sorted minors of the classes of the htb root qdisc in the output of tc class show
+func parseHTBClasses(output string) []int {
+	var classes []int
+	for _, match := range htbClassRegex.FindAllStringSubmatch(output, -1) {
+		if minor, err := strconv.ParseInt(match[1], 16, 32); err == nil {
+			classes = append(classes, int(minor))
+		}
+	}
+	sort.Ints(classes)
+	return classes
+}
+
+func isIPv6(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && ip.To4() == nil
+}
+
+func joinPorts(ports []int, sep string) string {
+	var s []string
+	for _, port := range ports {
+		s = append(s, strconv.Itoa(port))
+	}
+	return strings.Join(s, sep)
+}
@@ -0,0 +1,118 @@
+package ssh
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRouteDevice(t *testing.T) {
+	dev, err := parseRouteDevice("10.13.1.7 via 10.13.0.1 dev ens192 src 10.13.1.5 uid 0 \\    cache")
+	require.NoError(t, err)
+	require.Equal(t, "ens192", dev)
+
+	dev, err = parseRouteDevice("default via 192.168.1.1 dev eth0 proto dhcp metric 100")
+	require.NoError(t, err)
+	require.Equal(t, "eth0", dev)
+
+	_, err = parseRouteDevice("")
+	require.Error(t, err)
+}
+
+func TestParseHTBClasses(t *testing.T) {
+	output := `class htb 1:a root leaf a: prio 0 rate 10Gbit ceil 10Gbit burst 0b cburst 0b
+class htb 1:2 root leaf 2: prio 0 rate 1Mbit ceil 1Mbit burst 1600b cburst 1600b
+`
+	require.Equal(t, []int{2, 10}, parseHTBClasses(output))
+	require.Empty(t, parseHTBClasses(""))
+}
+
+func TestNetemArgs(t *testing.T) {
+	require.Equal(t, "delay 200ms 50ms", netemArgs(node.NetworkFault{
+		Type: node.NetworkFaultDelay, Delay: 200 * time.Millisecond, Jitter: 50 * time.Millisecond}))
+	require.Equal(t, "corrupt 5%", netemArgs(node.NetworkFault{Type: node.NetworkFaultCorrupt, Percent: 5}))
+	require.Equal(t, "delay 10ms reorder 25%", netemArgs(node.NetworkFault{
+		Type: node.NetworkFaultReorder, Delay: 10 * time.Millisecond, Percent: 25}))
+	require.Empty(t, netemArgs(node.NetworkFault{Type: node.NetworkFaultBandwidth, Rate: "1mbit"}))
+}
+
+func TestTCFilterMatches(t *testing.T) {
+	matches, protocol, err := tcFilterMatches(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "all", protocol)
+	require.Equal(t, []string{"match u32 0 0"}, matches)
+
+	matches, protocol, err = tcFilterMatches([]string{"10.0.0.1", "10.0.0.2"}, []int{9001})
+	require.NoError(t, err)
+	require.Equal(t, "ip", protocol)
+	require.Equal(t, []string{
+		"match ip dst 10.0.0.1/32 match ip dport 9001 0xffff",
+		"match ip dst 10.0.0.2/32 match ip dport 9001 0xffff",
+	}, matches)
+
+	matches, protocol, err = tcFilterMatches(nil, []int{9019})
+	require.NoError(t, err)
+	require.Equal(t, "ip", protocol)
+	require.Equal(t, []string{"match ip dport 9019 0xffff"}, matches)
+
+	matches, protocol, err = tcFilterMatches([]string{"fd00::1"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "ipv6", protocol)
+	require.Equal(t, []string{"match ip6 dst fd00::1/128"}, matches)
+
+	_, _, err = tcFilterMatches([]string{"10.0.0.1", "fd00::1"}, nil)
+	require.Error(t, err)
+}
+
+func TestTCCommands(t *testing.T) {
+	rule := &node.NetworkRule{
+		ID:        "torpedo-net-1",
+		Interface: "eth0",
+		Fault:     node.NetworkFault{Type: node.NetworkFaultLoss, Percent: 10, Peers: []string{"10.0.0.1"}},
+	}
+	cmd, err := tcAddCommand(rule, 11)
+	require.NoError(t, err)
+	require.Contains(t, cmd, "sudo tc class add dev eth0 parent 1: classid 1:b htb rate 10gbit")
+	require.Contains(t, cmd, "sudo tc qdisc add dev eth0 parent 1:b handle b: netem loss 10%")
+	require.Contains(t, cmd, "sudo tc filter add dev eth0 parent 1: protocol ip prio 11 u32 match ip dst 10.0.0.1/32 flowid 1:b")
+
+	require.Equal(t, "sudo tc filter del dev eth0 parent 1: prio 11; sudo tc class del dev eth0 classid 1:b",
+		tcDeleteCommand("eth0", 11))
+}
+
+func TestFirewallCommands(t *testing.T) {
+	rule := &node.NetworkRule{
+		ID:    "torpedo-net-1",
+		Fault: node.NetworkFault{Type: node.NetworkFaultPartition, Peers: []string{"10.0.0.1"}, Ports: []int{9001, 9019}},
+	}
+	cmd := firewallAddCommand(rule)
+	require.Contains(t, cmd, "sudo iptables -I INPUT -s 10.0.0.1 -p tcp -m multiport --dports 9001,9019 -m comment --comment torpedo-net-1 -j DROP")
+	require.Contains(t, cmd, "sudo iptables -I OUTPUT -d 10.0.0.1 -p tcp -m multiport --dports 9001,9019 -m comment --comment torpedo-net-1 -j DROP")
+	require.Contains(t, cmd, `sudo nft add rule inet torpedo input 'ip saddr 10.0.0.1 tcp dport { 9001, 9019 } drop comment "torpedo-net-1"'`)
+	require.Contains(t, cmd, "sudo iptables -I INPUT -s 10.0.0.1 -p udp -m multiport --dports 9001,9019 -m comment --comment torpedo-net-1 -j DROP")
+	require.Contains(t, cmd, `sudo nft add rule inet torpedo output 'ip daddr 10.0.0.1 udp dport { 9001, 9019 } drop comment "torpedo-net-1"'`)
+
+	require.Contains(t, firewallDeleteCommand("torpedo-net-1"), "grep -e '--comment torpedo-net-1'")
+}
+
+func TestNetworkRevertCommands(t *testing.T) {
+	rule := &node.NetworkRule{
+		ID:    "torpedo-net-1",
+		Fault: node.NetworkFault{Type: node.NetworkFaultPartition, Peers: []string{"10.0.0.1"}, Duration: 90500 * time.Millisecond},
+	}
+	deleteCmd, err := networkDeleteCommand(rule)
+	require.NoError(t, err)
+	inject, revert := networkRevertCommands(rule, "add", deleteCmd)
+
+	script := "#!/bin/bash\n[ -f /var/tmp/torpedo-net-1.sh ] || exit 0\n(" + deleteCmd + ") && rm -f /var/tmp/torpedo-net-1.sh\n"
+	require.Contains(t, inject, "echo "+base64.StdEncoding.EncodeToString([]byte(script))+" | base64 -d | sudo tee /var/tmp/torpedo-net-1.sh")
+	require.Contains(t, inject, "sudo systemd-run --unit torpedo-net-1 --on-active=91s /bin/bash /var/tmp/torpedo-net-1.sh && { add || ")
+	require.Equal(t, "[ ! -f /var/tmp/torpedo-net-1.sh ] || sudo /bin/bash /var/tmp/torpedo-net-1.sh; rc=$?; "+
+		"sudo systemctl stop torpedo-net-1.timer torpedo-net-1.service 2>/dev/null; exit $rc", revert)
+
+	_, err = networkDeleteCommand(&node.NetworkRule{ID: "torpedo-net-2", Fault: node.NetworkFault{Type: node.NetworkFaultLoss}, Handle: "x"})
+	require.Error(t, err)
+}
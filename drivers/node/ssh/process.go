@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const processChaosPrefix = "torpedo-chaos-"
+
+var processOpts = node.ConnectionOpts{
+	Timeout:         1 * time.Minute,
+	TimeBeforeRetry: 10 * time.Second,
+}
+
+// SignalProcess sends the signal to the processes whose name is exactly the given one
+func (s *SSH) SignalProcess(n node.Node, name string, signal node.ProcessSignal) ([]int, error) {
+	pids, err := s.getPIDs(n, name)
+	if err != nil {
+		return nil, &node.ErrFailedToSignalProcess{Node: n, Process: name, Cause: err.Error()}
+	}
+	log.Infof("Sending SIG%s to %s processes %v on node %s", signal, name, pids, n.Name)
+	if _, err := s.RunCommand(n, fmt.Sprintf("sudo kill -%s %s", signal, joinPIDs(pids)), processOpts); err != nil {
+		return nil, &node.ErrFailedToSignalProcess{Node: n, Process: name, Cause: err.Error()}
+	}
+	return pids, nil
+}
+
+// InjectProcessChaos injects the chaos and schedules its revert in a transient systemd unit on the node, so the
+// chaos is reverted after its duration even if the test does not get to revert it
+func (s *SSH) InjectProcessChaos(n node.Node, chaos node.ProcessChaos) (*node.ProcessChaosHandle, error) {
+	if err := chaos.Validate(); err != nil {
+		return nil, &node.ErrFailedToInjectProcessChaos{Node: n, Chaos: chaos.Type, Cause: err.Error()}
+	}
+	handle := &node.ProcessChaosHandle{
+		ID:    processChaosPrefix + uuid.New()[:8],
+		Node:  n,
+		Chaos: chaos,
+		Until: time.Now().Add(chaos.Duration),
+	}
+	if chaos.Type == node.ProcessChaosPause {
+		pids, err := s.getPIDs(n, chaos.Target)
+		if err != nil {
+			return nil, &node.ErrFailedToInjectProcessChaos{Node: n, Chaos: chaos.Type, Cause: err.Error()}
+		}
+		handle.PIDs = pids
+	}
+
+	cmd, _ := processChaosCommands(handle)
+	log.Infof("Injecting process chaos %s", handle)
+	if _, err := s.RunCommand(n, cmd, processOpts); err != nil {
+		return nil, &node.ErrFailedToInjectProcessChaos{Node: n, Chaos: chaos.Type, Cause: err.Error()}
+	}
+	return handle, nil
+}
+
+// RevertProcessChaos reverts the chaos and stops the unit which would have reverted it
+func (s *SSH) RevertProcessChaos(handle *node.ProcessChaosHandle) error {
+	_, cmd := processChaosCommands(handle)
+	log.Infof("Reverting process chaos %s", handle)
+	if _, err := s.RunCommand(handle.Node, cmd, processOpts); err != nil {
+		return &node.ErrFailedToInjectProcessChaos{Node: handle.Node, Chaos: handle.Chaos.Type, Cause: err.Error()}
+	}
+	return nil
+}
+
+// getPIDs returns the PIDs of the processes whose name is exactly the given one
+func (s *SSH) getPIDs(n node.Node, name string) ([]int, error) {
+	out, err := s.RunCommand(n, fmt.Sprintf("sudo pgrep -x %s || true", name), processOpts)
+	if err != nil {
+		return nil, err
+	}
+	pids, err := parsePIDs(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("no %s processes running", name)
+	}
+	return pids, nil
+}
+
+// processChaosCommands returns the commands injecting and reverting the chaos of the handle
+func processChaosCommands(handle *node.ProcessChaosHandle) (string, string) {
+	chaos := handle.Chaos
+	unit := handle.ID
+	seconds := int(chaos.Duration.Seconds())
+	stopUnit := fmt.Sprintf("sudo systemctl stop %s.timer %s.service 2>/dev/null; true", unit, unit)
+
+	switch chaos.Type {
+	case node.ProcessChaosPause:
+		pids := joinPIDs(handle.PIDs)
+		return fmt.Sprintf("sudo kill -STOP %s && sudo systemd-run --unit %s --on-active=%ds /bin/kill -CONT %s",
+				pids, unit, seconds, pids),
+			fmt.Sprintf("sudo kill -CONT %s; %s", pids, stopUnit)
+	case node.ProcessChaosThrottle:
+		service := chaos.Target
+		if !strings.Contains(service, ".") {
+			service += ".service"
+		}
+		var limits, reset []string
+		if chaos.CPUQuotaPercent > 0 {
+			limits = append(limits, fmt.Sprintf("CPUQuota=%d%%", chaos.CPUQuotaPercent))
+			reset = append(reset, "CPUQuota=")
+		}
+		if chaos.MemoryHighBytes > 0 {
+			limits = append(limits, fmt.Sprintf("MemoryHigh=%d", chaos.MemoryHighBytes))
+			reset = append(reset, "MemoryHigh=infinity")
+		}
+		resetCmd := fmt.Sprintf("set-property --runtime %s %s", service, strings.Join(reset, " "))
+		return fmt.Sprintf("sudo systemctl set-property --runtime %s %s && sudo systemd-run --unit %s --on-active=%ds /bin/systemctl %s",
+				service, strings.Join(limits, " "), unit, seconds, resetCmd),
+			fmt.Sprintf("sudo systemctl %s; %s", resetCmd, stopUnit)
+	}
+
+	var stressArgs string
+	switch chaos.Type {
+	case node.ProcessChaosCPU:
+		stressArgs = fmt.Sprintf("--cpu %d", chaos.Workers)
+	case node.ProcessChaosMemory:
+		stressArgs = fmt.Sprintf("--vm %d --vm-bytes %d%% --vm-keep", chaos.Workers, chaos.MemoryPercent)
+	case node.ProcessChaosIO:
+		stressArgs = fmt.Sprintf("--hdd %d --temp-path /var/tmp", chaos.Workers)
+	}
+	// the unit is stopped a bit after stress-ng should have exited on its own
+	return fmt.Sprintf("command -v stress-ng >/dev/null 2>&1 || { echo 'stress-ng is not installed' >&2; exit 1; }; "+
+			"sudo systemd-run --unit %s -p RuntimeMaxSec=%d stress-ng %s --timeout %ds",
+			unit, seconds+30, stressArgs, seconds),
+		stopUnit
+}
+
+// parsePIDs parses the PIDs printed one per line by pgrep
+func parsePIDs(output string) ([]int, error) {
+	var pids []int
+	for _, field := range strings.Fields(output) {
+		pid, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected pgrep output: %s", output)
+		}
+		pids = append(pids, pid)
+	}
+	return pids, nil
+}
+
+func joinPIDs(pids []int) string {
+	var s []string
+	for _, pid := range pids {
+		s = append(s, strconv.Itoa(pid))
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,44 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePIDs(t *testing.T) {
+	pids, err := parsePIDs("1234\n5678\n")
+	require.NoError(t, err)
+	require.Equal(t, []int{1234, 5678}, pids)
+
+	pids, err = parsePIDs("")
+	require.NoError(t, err)
+	require.Empty(t, pids)
+
+	_, err = parsePIDs("pgrep: invalid option")
+	require.Error(t, err)
+}
+
+func TestProcessChaosCommands(t *testing.T) {
+	handle := &node.ProcessChaosHandle{
+		ID:    "torpedo-chaos-1",
+		Chaos: node.ProcessChaos{Type: node.ProcessChaosPause, Target: "kubelet", Duration: 2 * time.Minute},
+		PIDs:  []int{42},
+	}
+	inject, revert := processChaosCommands(handle)
+	require.Equal(t, "sudo kill -STOP 42 && sudo systemd-run --unit torpedo-chaos-1 --on-active=120s /bin/kill -CONT 42", inject)
+	require.Contains(t, revert, "sudo kill -CONT 42;")
+	require.Contains(t, revert, "sudo systemctl stop torpedo-chaos-1.timer torpedo-chaos-1.service")
+
+	handle.Chaos = node.ProcessChaos{Type: node.ProcessChaosMemory, Workers: 2, MemoryPercent: 80, Duration: time.Minute}
+	inject, _ = processChaosCommands(handle)
+	require.Contains(t, inject, "sudo systemd-run --unit torpedo-chaos-1 -p RuntimeMaxSec=90 stress-ng --vm 2 --vm-bytes 80% --vm-keep --timeout 60s")
+
+	handle.Chaos = node.ProcessChaos{Type: node.ProcessChaosThrottle, Target: "kubelet", CPUQuotaPercent: 5, MemoryHighBytes: 1 << 20, Duration: time.Minute}
+	inject, revert = processChaosCommands(handle)
+	require.Equal(t, "sudo systemctl set-property --runtime kubelet.service CPUQuota=5% MemoryHigh=1048576 && "+
+		"sudo systemd-run --unit torpedo-chaos-1 --on-active=60s /bin/systemctl set-property --runtime kubelet.service CPUQuota= MemoryHigh=infinity", inject)
+	require.Contains(t, revert, "sudo systemctl set-property --runtime kubelet.service CPUQuota= MemoryHigh=infinity;")
+}
@@ -1,10 +1,12 @@
 package ssh
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/portworx/sched-ops/k8s/apps"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/task"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/scheduler"
 	k8s_driver "github.com/portworx/torpedo/drivers/scheduler/k8s"
@@ -13,6 +15,7 @@ import (
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
 	"github.com/portworx/torpedo/pkg/log"
 	ssh_pkg "golang.org/x/crypto/ssh"
+	"io"
 	"io/ioutil"
 	appsv1_api "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
@@ -50,6 +53,7 @@ type SSH struct {
 	sshConfig        *ssh_pkg.ClientConfig
 	specDir          string
 	execPodNamespace string
+	conns            *connPool
 	// TODO keyPath-based ssh
 }
 
@@ -62,6 +66,22 @@ func (s *SSH) String() string {
 	return DriverName
 }
 
+// Capabilities returns the operations which are performed over ssh or from the debug pod of a node
+func (s *SSH) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities(
+		node.CapabilityReboot,
+		node.CapabilityCrash,
+		node.CapabilityShutdown,
+		node.CapabilityRunCommand,
+		node.CapabilitySystemctl,
+		node.CapabilityDriveFailure,
+		node.CapabilityNetworkFault,
+		node.CapabilityDiskFault,
+		node.CapabilityProcessChaos,
+		node.CapabilityClockSkew,
+	)
+}
+
 // returns ssh.Signer from user you running app home path + cutted keyPath path.
 // (ex. pubkey,err := getKeyFile("/.ssh/id_rsa") )
 func getKeyFile(keypath string) (ssh_pkg.Signer, error) {
@@ -294,6 +314,7 @@ func (s *SSH) TestConnection(n node.Node, options node.ConnectionOpts) error {
 // RebootNode reboots given node
 func (s *SSH) RebootNode(n node.Node, options node.RebootNodeOpts) error {
 	log.Infof("Rebooting node %s", n.SchedulerNodeName)
+	defer s.EvictConnections(n)
 	rebootCmd := "sudo reboot"
 	if options.Force {
 		rebootCmd = rebootCmd + " -f"
@@ -321,18 +342,16 @@ func (s *SSH) RebootNode(n node.Node, options node.RebootNodeOpts) error {
 // dropPercentage => intger value from 1 to 100
 // delayInMilliseconds => 1 to 1000
 func (s *SSH) InjectNetworkError(nodes []node.Node, errorInjectionType string, operationType string, dropPercentage int, delayInMilliseconds int) error {
-	//tc qdisc add dev eth0 root netem loss 20%
-	//tc qdisc change dev eth0 root netem delay 5000ms 5000ms
-	var cmd string
+	//tc qdisc add dev <iface> root netem loss 20%
+	//tc qdisc change dev <iface> root netem delay 5000ms 5000ms
+	var netem string
 	dropInPercentage := strconv.Itoa(dropPercentage) + "%"
 	delayInMillisescond := strconv.Itoa(delayInMilliseconds) + "ms"
 	if errorInjectionType == "delay" {
-		cmd = fmt.Sprintf("%s %s  %s %s %s", "sudo tc qdisc", operationType, "dev eth0 root netem delay ",
-			delayInMillisescond, delayInMillisescond)
+		netem = fmt.Sprintf("delay %s %s", delayInMillisescond, delayInMillisescond)
 		log.Infof("Delay %v ", delayInMillisescond)
 	} else if errorInjectionType == "drop" {
-		cmd = fmt.Sprintf("%s %s  %s %s", "sudo tc qdisc", operationType, "dev eth0 root netem loss",
-			dropInPercentage)
+		netem = fmt.Sprintf("loss %s", dropInPercentage)
 		log.Infof("DropPercentage %v ", dropInPercentage)
 	} else {
 		return fmt.Errorf("Invalid network error injection type %v", errorInjectionType)
@@ -345,6 +364,14 @@ func (s *SSH) InjectNetworkError(nodes []node.Node, errorInjectionType string, o
 	}
 	for _, n := range nodes {
 		log.Infof("Error injection on Node name : %s of type : %s ", n.Name, errorInjectionType)
+		iface, err := s.getNetworkInterface(n, node.NetworkFault{})
+		if err != nil {
+			return &node.ErrFailedToSetNetworkErrorOnNode{
+				Node:  n,
+				Cause: err.Error(),
+			}
+		}
+		cmd := fmt.Sprintf("sudo tc qdisc %s dev %s root netem %s", operationType, iface, netem)
 		t := func() (interface{}, bool, error) {
 			out, err := s.doCmd(n, connectionOps, cmd, true)
 			return out, true, err
@@ -363,6 +390,7 @@ func (s *SSH) InjectNetworkError(nodes []node.Node, errorInjectionType string, o
 // CrashNode crashes given node
 func (s *SSH) CrashNode(n node.Node, options node.CrashNodeOpts) error {
 	log.Infof("Crashing node %s", n.SchedulerNodeName)
+	defer s.EvictConnections(n)
 	crashCmd := "echo c > /proc/sysrq-trigger"
 
 	t := func() (interface{}, bool, error) {
@@ -382,6 +410,7 @@ func (s *SSH) CrashNode(n node.Node, options node.CrashNodeOpts) error {
 
 // ShutdownNode shuts down given node
 func (s *SSH) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
+	defer s.EvictConnections(n)
 	shutdownCmd := "sudo shutdown"
 	if options.Force {
 		shutdownCmd = "halt"
@@ -404,8 +433,7 @@ func (s *SSH) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error {
 
 // YankDrive yanks given drive on given node
 func (s *SSH) YankDrive(n node.Node, driveNameToFail string, options node.ConnectionOpts) (string, error) {
-	// Currently only works for iSCSI drives
-	// TODO: Make it generic (Add support dev mapper devices)
+	// Currently only works for iSCSI drives, faults on device-mapper drives are injected with InjectDiskFault
 
 	//Get the scsi bus ID
 	busIDCmd := "lsscsi | grep " + driveNameToFail + " | awk -F\":\" '{print $1}'" + "| awk -F\"[\" '{print $2}'"
@@ -564,26 +592,29 @@ func (s *SSH) doCmd(n node.Node, options node.ConnectionOpts, cmd string, ignore
 
 func (s *SSH) doCmdUsingPodWithoutRetry(n node.Node, cmd string) (string, error) {
 	cmds := []string{"nsenter", "--mount=/hostproc/1/ns/mnt", "/bin/bash", "-c", cmd}
+	debugPod, err := s.getDebugPod(n)
+	if err != nil {
+		return "", err
+	}
+	return k8sCore.RunCommandInPod(cmds, debugPod.Name, "", debugPod.Namespace)
+}
+
+// getDebugPod returns the ready debug pod on the node
+func (s *SSH) getDebugPod(n node.Node) (*v1.Pod, error) {
 	allPodsForNode, err := k8sCore.GetPodsByNode(n.Name, s.execPodNamespace)
 	if err != nil {
 		log.Errorf("failed to get pods in node: %s err: %v", n.Name, err)
-		return "", err
+		return nil, err
 	}
-	var debugPod *v1.Pod
 	for _, pod := range allPodsForNode.Items {
 		if pod.Labels["name"] == execPodDaemonSetLabel && k8sCore.IsPodReady(pod) {
-			debugPod = &pod
-			break
+			return &pod, nil
 		}
 	}
-
-	if debugPod == nil {
-		return "", &node.ErrFailedToRunCommand{
-			Node:  n,
-			Cause: fmt.Sprintf("debug pod not found in node %v", n),
-		}
+	return nil, &node.ErrFailedToRunCommand{
+		Node:  n,
+		Cause: fmt.Sprintf("debug pod not found in node %v", n),
 	}
-	return k8sCore.RunCommandInPod(cmds, debugPod.Name, "", debugPod.Namespace)
 }
 
 func (s *SSH) doCmdUsingPod(n node.Node, options node.ConnectionOpts, cmd string, ignoreErr bool) (string, error) {
@@ -630,69 +661,95 @@ func (s *SSH) doCmdUsingPod(n node.Node, options node.ConnectionOpts, cmd string
 }
 
 func (s *SSH) doCmdSSH(n node.Node, options node.ConnectionOpts, cmd string, ignoreErr bool) (string, error) {
-	var out string
-	var sterr string
-	connection, err := s.getConnection(n, options)
-	if err != nil {
-		return "", &node.ErrFailedToRunCommand{
-			Addr:  n.UsableAddr,
-			Cause: fmt.Sprintf("failed to dial: %v", err),
-		}
-	}
+	var stdout bytes.Buffer
+	err := s.doCmdSSHWithOutput(n, options, cmd, ignoreErr, &stdout)
+	return stdout.String(), err
+}
 
-	session, err := connection.NewSession()
+// doCmdSSHWithOutput runs the command over a pooled connection to the node and writes its stdout to the writer as
+// the node writes it
+func (s *SSH) doCmdSSHWithOutput(n node.Node, options node.ConnectionOpts, cmd string, ignoreErr bool, stdout io.Writer) error {
+	session, conn, err := s.newSession(n, options)
 	if err != nil {
-		return "", &node.ErrFailedToRunCommand{
+		return &node.ErrFailedToRunCommand{
 			Addr:  n.UsableAddr,
-			Cause: fmt.Sprintf("failed to create session: %s", err),
+			Cause: err.Error(),
 		}
 	}
+	defer s.conns.release(conn)
 	defer session.Close()
 
-	stderr, err := session.StderrPipe()
-	if err != nil {
-		return "", fmt.Errorf("fail to setup stderr")
+	var stderr bytes.Buffer
+	session.Stdout = stdout
+	session.Stderr = &stderr
+	if options.Sudo {
+		cmd = fmt.Sprintf("sudo su -c '%s' -", cmd) // Hyphen necessary to preserve PATH for commands like "which pxctl"
 	}
+	err = session.Run(cmd)
 
-	stdout, err := session.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("fail to setup stdout")
+	if ignoreErr == false && err != nil {
+		return &node.ErrFailedToRunCommand{
+			Addr:  n.UsableAddr,
+			Cause: fmt.Sprintf("failed to run command due to: %v", stderr.String()),
+		}
 	}
-	if options.Sudo {
-		cmd = fmt.Sprintf("sudo su -c '%s' -", cmd) // Hyphen necessary to preserve PATH for commands like "which pxctl"
+	return nil
+}
+
+// newSession opens a session on a pooled connection to the node. The connection must be released once the session
+// is done. A connection which fails to open the session, e.g. as the node died without closing it, is evicted. A
+// connection the node rejects more sessions on, e.g. at its MaxSessions, is left to its other sessions. Either way
+// the session is opened on a newly dialed connection instead.
+func (s *SSH) newSession(n node.Node, options node.ConnectionOpts) (*ssh_pkg.Session, *pooledConn, error) {
+	conn, err := s.getConnection(n, options)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial: %v", err)
 	}
-	session.Start(cmd)
-	err = session.Wait()
-	if resp, err1 := ioutil.ReadAll(stdout); err1 == nil {
-		out = string(resp)
-	} else {
-		return "", fmt.Errorf("fail to read stdout")
+	session, err := newSession(conn)
+	if err == nil {
+		return session, conn, nil
 	}
-	if resp, err1 := ioutil.ReadAll(stderr); err1 == nil {
-		sterr = string(resp)
+	if _, rejected := err.(*ssh_pkg.OpenChannelError); rejected {
+		s.conns.reject(conn)
 	} else {
-		return "", fmt.Errorf("fail to read stderr")
+		s.conns.release(conn)
+		s.conns.evict(conn)
 	}
+	log.Debugf("Failed to create session on ssh connection to %s, dialing a new one. Err: %v", conn.addr, err)
 
-	if ignoreErr == false && err != nil {
-		return out, &node.ErrFailedToRunCommand{
-			Addr:  n.UsableAddr,
-			Cause: fmt.Sprintf("failed to run command due to: %v", sterr),
-		}
+	if conn, err = s.getConnectionOnUsableAddr(n, options); err != nil {
+		return nil, nil, fmt.Errorf("failed to dial: %v", err)
 	}
-	return out, nil
+	if session, err = newSession(conn); err != nil {
+		s.conns.release(conn)
+		s.conns.evict(conn)
+		return nil, nil, fmt.Errorf("failed to create session: %v", err)
+	}
+	return session, conn, nil
 }
 
-func (s *SSH) getConnection(n node.Node, options node.ConnectionOpts) (*ssh_pkg.Client, error) {
+// getConnection returns a pooled connection to the node with room for another session, dialing one if there is
+// none. The connection must be released once the session is done.
+func (s *SSH) getConnection(n node.Node, options node.ConnectionOpts) (*pooledConn, error) {
 	if n.Addresses == nil || len(n.Addresses) == 0 {
 		return nil, fmt.Errorf("no address available to connect")
 	}
+	for _, addr := range n.Addresses {
+		if conn := s.conns.acquire(addr); conn != nil {
+			return conn, nil
+		}
+	}
+
+	return s.getConnectionOnUsableAddr(n, options)
+}
 
-	addr, err := s.getConnectionOnUsableAddr(n, options)
-	return addr, err
+// EvictConnections closes the pooled connections to the node. Drivers call it once the node is rebooted, crashed
+// or shut down, as the node never closes those connections and commands on them would block until the TCP timeout.
+func (s *SSH) EvictConnections(n node.Node) {
+	s.conns.evictAddrs(n.Addresses)
 }
 
-func (s *SSH) getConnectionOnUsableAddr(n node.Node, options node.ConnectionOpts) (*ssh_pkg.Client, error) {
+func (s *SSH) getConnectionOnUsableAddr(n node.Node, options node.ConnectionOpts) (*pooledConn, error) {
 	var sshErr error
 	var cli interface{}
 	for _, addr := range n.Addresses {
@@ -704,7 +761,7 @@ func (s *SSH) getConnectionOnUsableAddr(n node.Node, options node.ConnectionOpts
 		}
 		if cli, sshErr = task.DoRetryWithTimeout(t, options.Timeout, options.TimeBeforeRetry); sshErr == nil {
 			n.UsableAddr = addr
-			return cli.(*ssh_pkg.Client), nil
+			return s.conns.add(addr, cli.(*ssh_pkg.Client)), nil
 		}
 	}
 	return nil, fmt.Errorf("no usable address found. Tried: %v. Error: %v"+
@@ -796,6 +853,7 @@ func getExecPodNamespace() (string, error) {
 func New() *SSH {
 	return &SSH{
 		Driver: node.NotSupportedDriver,
+		conns:  newConnPool(),
 	}
 }
 
@@ -0,0 +1,27 @@
+package node
+
+import "fmt"
+
+// VMDeviceType identifies the kind of a device of a VM
+type VMDeviceType string
+
+const (
+	// VMDeviceDisk is a disk of a VM, identified by its target device name, e.g. vdb
+	VMDeviceDisk VMDeviceType = "disk"
+	// VMDeviceNIC is a network interface of a VM, identified by its MAC address
+	VMDeviceNIC VMDeviceType = "nic"
+)
+
+// VMDevice is a device attached to the VM of a node
+type VMDevice struct {
+	// Type is the kind of the device
+	Type VMDeviceType
+	// ID is the target device name of a disk or the MAC address of a NIC
+	ID string
+	// Source is the image or block device backing a disk, or the network or bridge of a NIC
+	Source string
+}
+
+func (d VMDevice) String() string {
+	return fmt.Sprintf("%s %s (%s)", d.Type, d.ID, d.Source)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/portworx/sched-ops/task"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/node/ssh"
 	"github.com/portworx/torpedo/pkg/log"
@@ -57,6 +58,10 @@ func (v *vsphere) String() string {
 	return DriverName
 }
 
+func (v *vsphere) Capabilities() driver_api.Capabilities {
+	return v.SSH.Capabilities().With(node.CapabilityPowerCycle)
+}
+
 // InitVsphere initializes the vsphere driver for ssh
 func (v *vsphere) Init(nodeOpts node.InitOptions) error {
 	log.Infof("Using the vsphere node driver")
@@ -210,6 +215,7 @@ func (v *vsphere) RebootNode(n node.Node, options node.RebootNodeOpts) error {
 	v.connect()
 	vm := vmMap[n.Name]
 	log.Infof("Rebooting VM: %s  ", vm.Name())
+	defer v.EvictConnections(n)
 	err := vm.RebootGuest(v.ctx)
 	if err != nil {
 		return &node.ErrFailedToRebootNode{
@@ -278,6 +284,7 @@ func (v *vsphere) PowerOffVM(n node.Node) error {
 	vm := vmMap[n.Name]
 
 	log.Infof("\nPowering off VM: %s  ", vm.Name())
+	defer v.EvictConnections(n)
 	tsk, err := vm.PowerOff(v.ctx)
 	if err != nil {
 		return fmt.Errorf("Failed to power off %s: %v", vm.Name(), err)
@@ -301,6 +308,7 @@ func (v *vsphere) ShutdownNode(n node.Node, options node.ShutdownNodeOpts) error
 	vm := vmMap[n.Name]
 
 	log.Infof("Shutting down VM: %s  ", vm.Name())
+	defer v.EvictConnections(n)
 	err := vm.ShutdownGuest(v.ctx)
 	if err != nil {
 		return &node.ErrFailedToShutdownNode{
@@ -14,6 +14,7 @@ import (
 	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	apapi "github.com/libopenstorage/autopilot-api/pkg/apis/autopilot/v1alpha1"
 	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/scheduler"
 	"github.com/portworx/torpedo/drivers/scheduler/spec"
@@ -80,6 +81,10 @@ func (d *dcos) String() string {
 	return SchedName
 }
 
+func (d *dcos) Capabilities() api.Capabilities {
+	return api.NewCapabilities()
+}
+
 // GetEvents dumps events from event storage
 func (d *dcos) GetEvents() map[string][]scheduler.Event {
 	return nil
@@ -613,6 +618,22 @@ func (d *dcos) GetScaleFactorMap(ctx *scheduler.Context) (map[string]int32, erro
 	}
 }
 
+func (d *dcos) RolloutApplication(ctx *scheduler.Context, opts scheduler.RolloutOptions) error {
+	//RolloutApplication is not supported
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RolloutApplication()",
+	}
+}
+
+func (d *dcos) RollbackApplication(ctx *scheduler.Context, timeout time.Duration) error {
+	//RollbackApplication is not supported
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "RollbackApplication()",
+	}
+}
+
 func (d *dcos) StopSchedOnNode(node node.Node) error {
 	// TODO implement this method
 	return &errors.ErrNotSupported{
@@ -644,6 +665,14 @@ func (d *dcos) PrepareNodeToDecommission(n node.Node, provisioner string) error
 	}
 }
 
+func (d *dcos) DrainNode(n node.Node, opts scheduler.DrainOptions) (*scheduler.DrainReport, error) {
+	// DrainNode is not supported
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DrainNode()",
+	}
+}
+
 func (d *dcos) EnableSchedulingOnNode(n node.Node) error {
 	// TODO implement this method
 	return &errors.ErrNotSupported{
@@ -867,6 +896,22 @@ func (d *dcos) ValidateTopologyLabel(ctx *scheduler.Context) error {
 	}
 }
 
+func (d *dcos) ValidateZoneTopology(ctx *scheduler.Context) (*scheduler.TopologyReport, error) {
+	//ValidateZoneTopology is not supported
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ValidateZoneTopology()",
+	}
+}
+
+func (d *dcos) ValidateVolumePlacementStrategy(ctx *scheduler.Context) (*scheduler.PlacementReport, error) {
+	//ValidateVolumePlacementStrategy is not supported
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ValidateVolumePlacementStrategy()",
+	}
+}
+
 func (d *dcos) CreateCsiSnapshotClass(snapClassName string, deleionPolicy string) (*v1beta1.VolumeSnapshotClass, error) {
 	//CreateCsiSnapshotClass is not supported
 	return nil, &errors.ErrNotSupported{
@@ -350,6 +350,18 @@ func (e *ErrFailedToDecommissionNode) Error() string {
 	return fmt.Sprintf("Failed to decommission node: %v due to err: %v", e.Node, e.Cause)
 }
 
+// ErrFailedToDrainNode error type when fail to drain a node
+type ErrFailedToDrainNode struct {
+	// Node is the node that failed to drain
+	Node node.Node
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToDrainNode) Error() string {
+	return fmt.Sprintf("Failed to drain node: %v due to err: %v", e.Node.Name, e.Cause)
+}
+
 // ErrFailedToGetConfigMap error type for failing to get config map
 type ErrFailedToGetConfigMap struct {
 	// Name of config map
@@ -505,6 +517,32 @@ func (e *ErrTopologyLabelMismatch) Error() string {
 	return fmt.Sprintf("Failed to match topology label for pod: %v due to err: %v", e.PodName, e.Cause)
 }
 
+// ErrZoneTopologyMismatch error when volume, replica or pod placement violates the zone topology
+type ErrZoneTopologyMismatch struct {
+	// Volume is the name of the volume whose topology does not match
+	Volume string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrZoneTopologyMismatch) Error() string {
+	return fmt.Sprintf("Zone topology mismatch for volume: %v due to err: %v", e.Volume, e.Cause)
+}
+
+// ErrVolumePlacementMismatch error when the replica placement of a volume violates its volume placement strategy
+type ErrVolumePlacementMismatch struct {
+	// Volume is the name of the volume whose placement does not match
+	Volume string
+	// Strategy is the name of the volume placement strategy
+	Strategy string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrVolumePlacementMismatch) Error() string {
+	return fmt.Sprintf("Volume placement strategy: %v mismatch for volume: %v due to err: %v", e.Strategy, e.Volume, e.Cause)
+}
+
 // ErrFailedToCreateSnapshot error when snapshot create is failed
 type ErrFailedToCreateSnapshot struct {
 	// PvcName is name of the pvc for which snapshot create failed
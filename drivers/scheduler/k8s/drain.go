@@ -0,0 +1,296 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// mirrorPodAnnotation is the annotation of static pods mirrored by the kubelet
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+	// defaultDrainTimeout is the default time to wait for a node to be drained
+	defaultDrainTimeout = 10 * time.Minute
+	// reattachRetryInterval is the interval at which the volumes of evicted pods are checked to be re-attached
+	reattachRetryInterval = 2 * time.Second
+)
+
+// DrainNode cordons the given node and evicts its pods through the eviction API. Evictions rejected
+// due to a PodDisruptionBudget are retried until the drain timeout
+func (k *K8s) DrainNode(n node.Node, opts scheduler.DrainOptions) (*scheduler.DrainReport, error) {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultDrainTimeout
+	}
+	start := time.Now()
+	report := &scheduler.DrainReport{
+		Node:                n.Name,
+		PDBBlockedEvictions: make(map[string]int),
+		VolumeReattachTimes: make(map[string]time.Duration),
+	}
+
+	if err := k8sCore.CordonNode(n.Name, DefaultTimeout, DefaultRetryInterval); err != nil {
+		return report, &scheduler.ErrFailedToDrainNode{
+			Node:  n,
+			Cause: fmt.Sprintf("Failed to cordon node. Err: %v", err),
+		}
+	}
+
+	podList, err := k8sCore.GetPodsByNode(n.Name, "")
+	if err != nil {
+		return report, &scheduler.ErrFailedToDrainNode{
+			Node:  n,
+			Cause: fmt.Sprintf("Failed to get pods on the node. Err: %v", err),
+		}
+	}
+	var pending []corev1.Pod
+	for _, pod := range podList.Items {
+		if !isDrainablePod(pod) {
+			continue
+		}
+		if metav1.GetControllerOf(&pod) == nil {
+			podKey := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+			report.UnmanagedPods = append(report.UnmanagedPods, podKey)
+			if !opts.Force {
+				log.Warnf("Leaving pod %s on node %s as it has no controller which would recreate it", podKey, n.Name)
+				continue
+			}
+		}
+		pending = append(pending, pod)
+	}
+	log.Infof("Draining %d pod(s) from node %s", len(pending), n.Name)
+
+	clientset, err := k.getKubeClient("")
+	if err != nil {
+		return report, &scheduler.ErrFailedToDrainNode{
+			Node:  n,
+			Cause: err.Error(),
+		}
+	}
+	evictionVersion, err := getEvictionVersion(clientset)
+	if err != nil {
+		return report, &scheduler.ErrFailedToDrainNode{
+			Node:  n,
+			Cause: fmt.Sprintf("Failed to get the eviction API version. Err: %v", err),
+		}
+	}
+
+	var evicted []corev1.Pod
+	evictionTimes := make(map[string]time.Time)
+	for {
+		var blocked []corev1.Pod
+		for _, pod := range pending {
+			podKey := fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+			err := evictPod(clientset, evictionVersion, pod, opts.GracePeriod)
+			if err == nil || k8serrors.IsNotFound(err) {
+				evicted = append(evicted, pod)
+				evictionTimes[podKey] = time.Now()
+				report.EvictedPods = append(report.EvictedPods, podKey)
+				continue
+			}
+			if k8serrors.IsTooManyRequests(err) {
+				log.Warnf("Eviction of pod %s from node %s is blocked by a PodDisruptionBudget: %v", podKey, n.Name, err)
+				report.PDBBlockedEvictions[podKey]++
+				blocked = append(blocked, pod)
+				continue
+			}
+			report.Duration = time.Since(start)
+			return report, &scheduler.ErrFailedToDrainNode{
+				Node:  n,
+				Cause: fmt.Sprintf("Failed to evict pod %s. Err: %v", podKey, err),
+			}
+		}
+
+		pending = blocked
+		if len(pending) == 0 {
+			break
+		}
+		if time.Since(start) > timeout {
+			var blockedPods []string
+			for _, pod := range pending {
+				blockedPods = append(blockedPods, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+			}
+			report.Duration = time.Since(start)
+			return report, &scheduler.ErrFailedToDrainNode{
+				Node:  n,
+				Cause: fmt.Sprintf("Evictions of pods %v are still blocked by PodDisruptionBudgets after %v", blockedPods, timeout),
+			}
+		}
+		time.Sleep(DefaultRetryInterval)
+	}
+
+	for _, pod := range evicted {
+		remaining := timeout - time.Since(start)
+		if remaining < DefaultTimeout {
+			remaining = DefaultTimeout
+		}
+		if err := k8sCore.WaitForPodDeletion(pod.UID, pod.Namespace, remaining); err != nil {
+			report.Duration = time.Since(start)
+			return report, &scheduler.ErrFailedToDrainNode{
+				Node:  n,
+				Cause: fmt.Sprintf("Evicted pod %s/%s did not terminate. Err: %v", pod.Namespace, pod.Name, err),
+			}
+		}
+	}
+	report.Duration = time.Since(start)
+	log.Infof("Drained node %s in %v, evicted pods: %v", n.Name, report.Duration, report.EvictedPods)
+
+	if opts.WaitForReattach {
+		if err := k.waitForVolumeReattach(evicted, evictionTimes, timeout, report); err != nil {
+			return report, &scheduler.ErrFailedToDrainNode{
+				Node:  n,
+				Cause: err.Error(),
+			}
+		}
+	}
+	return report, nil
+}
+
+// getEvictionVersion returns the version of the policy group the cluster serves the eviction subresource of pods
+// in, like kubectl drain does
+func getEvictionVersion(clientset kubernetes.Interface) (string, error) {
+	resources, err := clientset.Discovery().ServerResourcesForGroupVersion("v1")
+	if err != nil {
+		return "", err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "pods/eviction" && resource.Group == "policy" && len(resource.Version) > 0 {
+			return resource.Version, nil
+		}
+	}
+	return "v1beta1", nil
+}
+
+// evictPod evicts the pod through the eviction API of the given policy version. policy/v1 evictions are posted
+// as JSON as the vendored client only has the v1beta1 type, which the v1 type is identical to.
+func evictPod(clientset kubernetes.Interface, version string, pod corev1.Pod, gracePeriod *int64) error {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriod,
+		},
+	}
+	if version != "v1" {
+		return clientset.PolicyV1beta1().Evictions(pod.Namespace).Evict(context.TODO(), eviction)
+	}
+	eviction.TypeMeta = metav1.TypeMeta{APIVersion: "policy/v1", Kind: "Eviction"}
+	body, err := json.Marshal(eviction)
+	if err != nil {
+		return err
+	}
+	return clientset.CoreV1().RESTClient().Post().
+		AbsPath("/api/v1").
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("eviction").
+		Body(body).
+		Do(context.TODO()).
+		Error()
+}
+
+// isDrainablePod returns false for pods that a drain leaves on the node: daemonset, mirror and completed pods
+func isDrainablePod(pod corev1.Pod) bool {
+	if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+		return false
+	}
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForVolumeReattach waits until the PVCs of the evicted pods are used by a running pod again and records
+// the time it took since the eviction in the drain report. All PVCs are polled together, so the time of every
+// PVC is that at which it was first seen re-attached.
+func (k *K8s) waitForVolumeReattach(evicted []corev1.Pod, evictionTimes map[string]time.Time, timeout time.Duration, report *scheduler.DrainReport) error {
+	evictedUIDs := make(map[string]bool)
+	for _, pod := range evicted {
+		evictedUIDs[string(pod.UID)] = true
+	}
+
+	type evictedPVC struct {
+		name      string
+		namespace string
+		evictedAt time.Time
+	}
+	pending := make(map[string]evictedPVC)
+	for _, pod := range evicted {
+		// pods without a controller are not recreated after eviction
+		if metav1.GetControllerOf(&pod) == nil {
+			continue
+		}
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim == nil {
+				continue
+			}
+			pvcKey := fmt.Sprintf("%s/%s", pod.Namespace, vol.PersistentVolumeClaim.ClaimName)
+			if _, ok := pending[pvcKey]; ok {
+				continue
+			}
+			pending[pvcKey] = evictedPVC{
+				name:      vol.PersistentVolumeClaim.ClaimName,
+				namespace: pod.Namespace,
+				evictedAt: evictionTimes[fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)],
+			}
+		}
+	}
+
+	t := func() (interface{}, bool, error) {
+		for pvcKey, pvc := range pending {
+			pods, err := k8sCore.GetPodsUsingPVC(pvc.name, pvc.namespace)
+			if err != nil {
+				return "", true, err
+			}
+			for _, p := range pods {
+				if !evictedUIDs[string(p.UID)] && k8sCore.IsPodRunning(p) {
+					report.VolumeReattachTimes[pvcKey] = time.Since(pvc.evictedAt)
+					delete(pending, pvcKey)
+					break
+				}
+			}
+		}
+		if len(pending) > 0 {
+			var pvcKeys []string
+			for pvcKey := range pending {
+				pvcKeys = append(pvcKeys, pvcKey)
+			}
+			sort.Strings(pvcKeys)
+			return "", true, fmt.Errorf("no running pod uses PVCs %v yet", pvcKeys)
+		}
+		return "", false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, timeout, reattachRetryInterval); err != nil {
+		return fmt.Errorf("volumes were not re-attached after eviction. Err: %v", err)
+	}
+
+	var pvcKeys []string
+	for pvcKey := range report.VolumeReattachTimes {
+		pvcKeys = append(pvcKeys, pvcKey)
+	}
+	sort.Strings(pvcKeys)
+	for _, pvcKey := range pvcKeys {
+		log.Infof("Volume of PVC %s re-attached %v after eviction", pvcKey, report.VolumeReattachTimes[pvcKey])
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsDrainablePod(t *testing.T) {
+	controller := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "mysql-0",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "mysql", Controller: &controller}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	require.True(t, isDrainablePod(pod))
+
+	daemonSetPod := pod.DeepCopy()
+	daemonSetPod.OwnerReferences[0].Kind = "DaemonSet"
+	require.False(t, isDrainablePod(*daemonSetPod))
+
+	mirrorPod := pod.DeepCopy()
+	mirrorPod.Annotations = map[string]string{mirrorPodAnnotation: "hash"}
+	require.False(t, isDrainablePod(*mirrorPod))
+
+	completedPod := pod.DeepCopy()
+	completedPod.Status.Phase = corev1.PodSucceeded
+	require.False(t, isDrainablePod(*completedPod))
+}
+
+func TestGetEvictionVersion(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	discovery := clientset.Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods"},
+			{Name: "pods/eviction", Group: "policy", Version: "v1beta1"},
+		},
+	}}
+	version, err := getEvictionVersion(clientset)
+	require.NoError(t, err)
+	require.Equal(t, "v1beta1", version)
+
+	discovery.Resources[0].APIResources[1].Version = "v1"
+	version, err = getEvictionVersion(clientset)
+	require.NoError(t, err)
+	require.Equal(t, "v1", version)
+
+	discovery.Resources[0].APIResources = discovery.Resources[0].APIResources[:1]
+	version, err = getEvictionVersion(clientset)
+	require.NoError(t, err)
+	require.Equal(t, "v1beta1", version)
+}
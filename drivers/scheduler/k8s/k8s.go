@@ -48,7 +48,9 @@ import (
 	"github.com/portworx/sched-ops/k8s/rbac"
 	"github.com/portworx/sched-ops/k8s/storage"
 	"github.com/portworx/sched-ops/k8s/stork"
+	"github.com/portworx/sched-ops/k8s/talisman"
 	"github.com/portworx/sched-ops/task"
+	talismanv1beta2 "github.com/portworx/talisman/pkg/apis/portworx/v1beta2"
 	"github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/scheduler"
@@ -199,6 +201,7 @@ var (
 	k8sBatch           = batch.Instance()
 	k8sMonitoring      = prometheus.Instance()
 	k8sPolicy          = policy.Instance()
+	k8sTalisman        = talisman.Instance()
 
 	// k8sExternalsnap is a instance of csisnapshot instance
 	k8sExternalsnap = csisnapshot.Instance()
@@ -248,6 +251,18 @@ func (k *K8s) String() string {
 	return SchedName
 }
 
+// Capabilities returns the optional operations supported by the k8s scheduler
+func (k *K8s) Capabilities() api.Capabilities {
+	return api.NewCapabilities(
+		scheduler.CapabilityScale,
+		scheduler.CapabilityRollout,
+		scheduler.CapabilityDrain,
+		scheduler.CapabilityTopology,
+		scheduler.CapabilityCSISnapshot,
+		scheduler.CapabilityAutopilot,
+	)
+}
+
 // Init Initialize the driver
 func (k *K8s) Init(schedOpts scheduler.InitOptions) error {
 	k.NodeDriverName = schedOpts.NodeDriverName
@@ -344,6 +359,7 @@ func (k *K8s) SetConfig(kubeconfigPath string) error {
 	k8sRbac.SetConfig(config)
 	k8sMonitoring.SetConfig(config)
 	k8sPolicy.SetConfig(config)
+	k8sTalisman.SetConfig(config)
 
 	return nil
 }
@@ -592,6 +608,10 @@ func decodeSpec(specContents []byte) (runtime.Object, error) {
 			return nil, err
 		}
 
+		if err := talismanv1beta2.AddToScheme(schemeObj); err != nil {
+			return nil, err
+		}
+
 		codecs := serializer.NewCodecFactory(schemeObj)
 		obj, _, err = codecs.UniversalDeserializer().Decode([]byte(specContents), nil, nil)
 		if err != nil {
@@ -646,6 +666,8 @@ func validateSpec(in interface{}) (interface{}, error) {
 		return specObj, nil
 	} else if specObj, ok := in.(*apapi.AutopilotRule); ok {
 		return specObj, nil
+	} else if specObj, ok := in.(*talismanv1beta2.VolumePlacementStrategy); ok {
+		return specObj, nil
 	} else if specObj, ok := in.(*corev1.ServiceAccount); ok {
 		return specObj, nil
 	} else if specObj, ok := in.(*rbacv1.ClusterRole); ok {
@@ -1360,6 +1382,24 @@ func (k *K8s) createStorageObject(spec interface{}, ns *corev1.Namespace, app *s
 		log.Infof("[%v] Created storage class: %v", app.Key, sc.Name)
 		return sc, nil
 
+	} else if obj, ok := spec.(*talismanv1beta2.VolumePlacementStrategy); ok {
+		vps, err := k8sTalisman.CreateVolumePlacementStrategy(obj)
+		if k8serrors.IsAlreadyExists(err) {
+			if vps, err = k8sTalisman.GetVolumePlacementStrategy(obj.Name); err == nil {
+				log.Infof("[%v] Found existing volume placement strategy: %v", app.Key, vps.Name)
+				return vps, nil
+			}
+		}
+		if err != nil {
+			return nil, &scheduler.ErrFailedToScheduleApp{
+				App:   app,
+				Cause: fmt.Sprintf("Failed to create volume placement strategy: %v. Err: %v", obj.Name, err),
+			}
+		}
+
+		log.Infof("[%v] Created volume placement strategy: %v", app.Key, vps.Name)
+		return vps, nil
+
 	} else if obj, ok := spec.(*corev1.PersistentVolumeClaim); ok {
 		obj.Namespace = ns.Name
 		k.substituteNamespaceInPVC(obj, ns.Name)
@@ -3120,6 +3160,21 @@ func (k *K8s) DeleteVolumes(ctx *scheduler.Context, options *scheduler.VolumeOpt
 
 				log.Infof("[%v] Destroyed storage class: %v", ctx.App.Key, obj.Name)
 			}
+		} else if obj, ok := specObj.(*talismanv1beta2.VolumePlacementStrategy); ok {
+			if options != nil && !options.SkipClusterScopedObjects {
+				if err := k8sTalisman.DeleteVolumePlacementStrategy(obj.Name); err != nil {
+					if k8serrors.IsNotFound(err) {
+						log.Infof("[%v] Volume placement strategy is not found: %v, skipping deletion", ctx.App.Key, obj.Name)
+						continue
+					}
+					return nil, &scheduler.ErrFailedToDestroyStorage{
+						App:   ctx.App,
+						Cause: fmt.Sprintf("Failed to destroy volume placement strategy: %v. Err: %v", obj.Name, err),
+					}
+				}
+
+				log.Infof("[%v] Destroyed volume placement strategy: %v", ctx.App.Key, obj.Name)
+			}
 		} else if obj, ok := specObj.(*corev1.PersistentVolumeClaim); ok {
 			pvcObj, err := k8sCore.GetPersistentVolumeClaim(obj.Name, obj.Namespace)
 			if err != nil {
@@ -4937,6 +4992,12 @@ func (k *K8s) collectEvents() error {
 }
 
 func (k *K8s) getKubeClient(kubeconfig string) (kubernetes.Interface, error) {
+	return GetKubeClient(kubeconfig)
+}
+
+// GetKubeClient returns a k8s client for the given kubeconfig, or for the KUBECONFIG env variable if it is
+// empty, falling back to the in-cluster config
+func GetKubeClient(kubeconfig string) (kubernetes.Interface, error) {
 	var cfg *rest.Config
 	var err error
 
@@ -0,0 +1,435 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/libopenstorage/openstorage/api"
+	talismanv1beta1 "github.com/portworx/talisman/pkg/apis/portworx/v1beta1"
+	talismanv1beta2 "github.com/portworx/talisman/pkg/apis/portworx/v1beta2"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// PlacementStrategyParam is the storage class parameter referencing a VolumePlacementStrategy
+	PlacementStrategyParam = "placement_strategy"
+)
+
+// placedVolume is a volume with the labels and the replica placement the placement rules are evaluated against
+type placedVolume struct {
+	name     string
+	labels   map[string]string
+	replicas []placedReplica
+}
+
+// placedReplica is a replica with the k8s node, driver node and storage pool labels of the place it is on
+type placedReplica struct {
+	scheduler.ReplicaPlacement
+	labels map[string]string
+}
+
+// placementViolation is a rule of a placement strategy the replicas of a volume do not satisfy
+type placementViolation struct {
+	rule        string
+	enforcement talismanv1beta1.EnforcementType
+	cause       string
+}
+
+// ValidateVolumePlacementStrategy validates the replica placement of every volume whose storage class references
+// a VolumePlacementStrategy against the replica and volume (anti-)affinity rules of the strategy. Rules are matched
+// against the k8s node labels, the volume driver node labels and the labels of the storage pool of each replica.
+// Volume (anti-)affinity is checked against the other volumes of the same app.
+func (k *K8s) ValidateVolumePlacementStrategy(ctx *scheduler.Context) (*scheduler.PlacementReport, error) {
+	volDriver, err := volume.Get(k.VolDriverName)
+	if err != nil {
+		return nil, err
+	}
+
+	vols, err := k.GetVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeLabels, err := getNodeLabels()
+	if err != nil {
+		return nil, err
+	}
+	nodeZones, err := getNodeZones()
+	if err != nil {
+		return nil, err
+	}
+
+	strategies := make(map[*placedVolume]*talismanv1beta2.VolumePlacementStrategy)
+	placedVols := make([]*placedVolume, 0, len(vols))
+	report := &scheduler.PlacementReport{}
+	for _, vol := range vols {
+		placed, err := getPlacedVolume(vol, volDriver, nodeLabels, nodeZones)
+		if err != nil {
+			return report, err
+		}
+		placedVols = append(placedVols, placed)
+
+		strategyName, err := getVolumePlacementStrategyName(vol)
+		if err != nil {
+			return report, err
+		}
+		if len(strategyName) == 0 {
+			continue
+		}
+		strategy, err := k8sTalisman.GetVolumePlacementStrategy(strategyName)
+		if err != nil {
+			return report, fmt.Errorf("failed to get volume placement strategy [%s] of volume [%s]. Err: %v",
+				strategyName, vol.Name, err)
+		}
+		strategies[placed] = strategy
+
+		volPlacement := &scheduler.VolumePlacement{
+			Volume:   vol,
+			Strategy: strategyName,
+		}
+		for _, replica := range placed.replicas {
+			volPlacement.Replicas = append(volPlacement.Replicas, replica.ReplicaPlacement)
+		}
+		report.Volumes = append(report.Volumes, volPlacement)
+	}
+
+	// Rules are only checked once all volumes are placed since volume (anti-)affinity looks at the other volumes
+	for _, placed := range placedVols {
+		strategy, ok := strategies[placed]
+		if !ok {
+			continue
+		}
+		for _, violation := range checkPlacement(&strategy.Spec, placed, placedVols) {
+			if violation.enforcement == talismanv1beta1.EnforcementPreferred {
+				log.Warnf("Volume [%s] does not satisfy preferred %s rule of placement strategy [%s]: %s",
+					placed.name, violation.rule, strategy.Name, violation.cause)
+				continue
+			}
+			return report, &scheduler.ErrVolumePlacementMismatch{
+				Volume:   placed.name,
+				Strategy: strategy.Name,
+				Cause:    fmt.Sprintf("%s rule: %s", violation.rule, violation.cause),
+			}
+		}
+		log.Infof("Validated placement strategy [%s] of volume [%s]: replicas %s",
+			strategy.Name, placed.name, describeReplicaPlacement(replicaPlacements(placed.replicas)))
+	}
+	return report, nil
+}
+
+// getVolumePlacementStrategyName returns the placement strategy set on the storage class of the volume's PVC
+func getVolumePlacementStrategyName(vol *volume.Volume) (string, error) {
+	pvc, err := k8sCore.GetPersistentVolumeClaim(vol.Name, vol.Namespace)
+	if err != nil {
+		return "", fmt.Errorf("failed to get PVC [%s/%s]. Err: %v", vol.Namespace, vol.Name, err)
+	}
+	sc, err := k8sCore.GetStorageClassForPVC(pvc)
+	if err != nil {
+		return "", fmt.Errorf("failed to get storage class for PVC [%s/%s]. Err: %v", pvc.Namespace, pvc.Name, err)
+	}
+	return sc.Parameters[PlacementStrategyParam], nil
+}
+
+// getPlacedVolume returns the volume labels and the replicas of the volume along with the labels of their nodes and pools
+func getPlacedVolume(vol *volume.Volume, volDriver volume.Driver, nodeLabels map[string]map[string]string,
+	nodeZones map[string]string) (*placedVolume, error) {
+	placed := &placedVolume{name: vol.Name, labels: vol.Labels}
+	if driverVol, err := volDriver.InspectVolume(vol.ID); err == nil {
+		placed.labels = driverVol.GetLocator().GetVolumeLabels()
+	} else {
+		log.Warnf("failed to inspect volume [%s], using the labels known to the scheduler. Err: %v", vol.Name, err)
+	}
+
+	replicaSets, err := volDriver.GetReplicaSets(vol)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotSupported); ok {
+			log.Warnf("volume driver [%s] does not report replica sets, skipping replica placement check", volDriver.String())
+			return placed, nil
+		}
+		return nil, fmt.Errorf("failed to get replica sets for volume [%s]. Err: %v", vol.Name, err)
+	}
+
+	nodesByID := node.GetNodesByVoDriverNodeID()
+	for i, rs := range replicaSets {
+		for j, nodeID := range rs.Nodes {
+			replica := placedReplica{
+				ReplicaPlacement: scheduler.ReplicaPlacement{
+					ReplicaSetIndex: i,
+					NodeID:          nodeID,
+				},
+				labels: make(map[string]string),
+			}
+			if j < len(rs.PoolUuids) {
+				replica.PoolUUID = rs.PoolUuids[j]
+			}
+			if n, ok := nodesByID[nodeID]; ok {
+				replica.NodeName = n.Name
+				replica.Zone = nodeZones[n.Name]
+				mergeLabels(replica.labels, nodeLabels[n.Name])
+				if n.StorageNode != nil {
+					mergeLabels(replica.labels, n.StorageNode.NodeLabels)
+				}
+				if pool := getNodePool(n, replica.PoolUUID); pool != nil {
+					mergeLabels(replica.labels, pool.Labels)
+				}
+			}
+			placed.replicas = append(placed.replicas, replica)
+		}
+	}
+	return placed, nil
+}
+
+// checkPlacement returns the rules of the placement strategy that the replicas of the volume do not satisfy.
+// peers are the volumes the volume (anti-)affinity rules are checked against, the volume itself is skipped.
+func checkPlacement(spec *talismanv1beta2.VolumePlacementSpec, vol *placedVolume, peers []*placedVolume) []placementViolation {
+	var violations []placementViolation
+	addViolation := func(rule string, common talismanv1beta2.CommonPlacementSpec, format string, args ...interface{}) {
+		violations = append(violations, placementViolation{
+			rule:        rule,
+			enforcement: common.Enforcement,
+			cause:       fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, rule := range spec.ReplicaAffinity {
+		matching := matchingReplicas(vol.replicas, rule.MatchExpressions)
+		if required := affectedReplicas(rule.AffectedReplicas, len(vol.replicas)); len(matching) < required {
+			addViolation("replicaAffinity", rule.CommonPlacementSpec, "only %d of %d replicas are on nodes or pools matching %s",
+				len(matching), required, describeExpressions(rule.MatchExpressions))
+		}
+		if len(rule.TopologyKey) > 0 {
+			if domains := topologyDomains(matching, rule.TopologyKey); len(domains) > 1 {
+				addViolation("replicaAffinity", rule.CommonPlacementSpec, "replicas are spread over %s values %v",
+					rule.TopologyKey, domains)
+			}
+		}
+	}
+
+	for _, rule := range spec.ReplicaAntiAffinity {
+		matching := matchingReplicas(vol.replicas, rule.MatchExpressions)
+		if len(rule.TopologyKey) > 0 {
+			if shared := sharedTopologyDomains(matching, rule.TopologyKey); len(shared) > 0 {
+				addViolation("replicaAntiAffinity", rule.CommonPlacementSpec, "more than one replica has %s values %v",
+					rule.TopologyKey, shared)
+			}
+			continue
+		}
+		if allowed := len(vol.replicas) - affectedReplicas(rule.AffectedReplicas, len(vol.replicas)); len(matching) > allowed {
+			addViolation("replicaAntiAffinity", rule.CommonPlacementSpec, "%d replicas are on nodes or pools matching %s, at most %d allowed",
+				len(matching), describeExpressions(rule.MatchExpressions), allowed)
+		}
+	}
+
+	for _, rule := range spec.VolumeAffinity {
+		peerDomains, ok := peerTopologyDomains(vol, peers, rule)
+		if !ok {
+			log.Infof("No volume matches %s, skipping volumeAffinity rule for volume [%s]",
+				describeExpressions(rule.MatchExpressions), vol.name)
+			continue
+		}
+		for _, replica := range vol.replicas {
+			if domain := replicaDomain(replica, rule.TopologyKey); !peerDomains[domain] {
+				addViolation("volumeAffinity", *rule, "replica on node [%s] is not placed with a volume matching %s",
+					replica.NodeName, describeExpressions(rule.MatchExpressions))
+			}
+		}
+	}
+
+	for _, rule := range spec.VolumeAntiAffinity {
+		peerDomains, _ := peerTopologyDomains(vol, peers, rule)
+		for _, replica := range vol.replicas {
+			if domain := replicaDomain(replica, rule.TopologyKey); peerDomains[domain] {
+				addViolation("volumeAntiAffinity", *rule, "replica on node [%s] is placed with a volume matching %s",
+					replica.NodeName, describeExpressions(rule.MatchExpressions))
+			}
+		}
+	}
+	return violations
+}
+
+// affectedReplicas returns the number of replicas a replica rule applies to. Zero means all replicas.
+func affectedReplicas(affected uint64, total int) int {
+	if affected == 0 || int(affected) > total {
+		return total
+	}
+	return int(affected)
+}
+
+// matchingReplicas returns the replicas whose labels match all the expressions
+func matchingReplicas(replicas []placedReplica, exprs []*talismanv1beta1.LabelSelectorRequirement) []placedReplica {
+	var matching []placedReplica
+	for _, replica := range replicas {
+		if matchesExpressions(replica.labels, exprs) {
+			matching = append(matching, replica)
+		}
+	}
+	return matching
+}
+
+// replicaDomain returns the value of the topology key for the replica. Without a topology key the domain is the node.
+func replicaDomain(replica placedReplica, topologyKey string) string {
+	if len(topologyKey) == 0 {
+		return replica.NodeID
+	}
+	return replica.labels[topologyKey]
+}
+
+// topologyDomains returns the sorted values of the topology key of the replicas
+func topologyDomains(replicas []placedReplica, topologyKey string) []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, replica := range replicas {
+		domain := replicaDomain(replica, topologyKey)
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+// sharedTopologyDomains returns the sorted values of the topology key held by more than one replica
+func sharedTopologyDomains(replicas []placedReplica, topologyKey string) []string {
+	counts := make(map[string]int)
+	var shared []string
+	for _, replica := range replicas {
+		domain := replicaDomain(replica, topologyKey)
+		counts[domain]++
+		if counts[domain] == 2 {
+			shared = append(shared, domain)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// peerTopologyDomains returns the topology domains of the replicas of the other volumes matching the rule, and
+// whether any volume matched at all
+func peerTopologyDomains(vol *placedVolume, peers []*placedVolume, rule *talismanv1beta2.CommonPlacementSpec) (map[string]bool, bool) {
+	domains := make(map[string]bool)
+	matched := false
+	for _, peer := range peers {
+		if peer == vol || !matchesExpressions(peer.labels, rule.MatchExpressions) {
+			continue
+		}
+		matched = true
+		for _, replica := range peer.replicas {
+			domains[replicaDomain(replica, rule.TopologyKey)] = true
+		}
+	}
+	return domains, matched
+}
+
+// matchesExpressions returns true if the labels satisfy all the expressions. No expressions match any labels.
+func matchesExpressions(labels map[string]string, exprs []*talismanv1beta1.LabelSelectorRequirement) bool {
+	for _, expr := range exprs {
+		value, exists := labels[expr.Key]
+		switch expr.Operator {
+		case talismanv1beta1.LabelSelectorOpIn:
+			if !exists || !containsValue(expr.Values, value) {
+				return false
+			}
+		case talismanv1beta1.LabelSelectorOpNotIn:
+			if exists && containsValue(expr.Values, value) {
+				return false
+			}
+		case talismanv1beta1.LabelSelectorOpExists:
+			if !exists {
+				return false
+			}
+		case talismanv1beta1.LabelSelectorOpDoesNotExist:
+			if exists {
+				return false
+			}
+		case talismanv1beta1.LabelSelectorOpGt, talismanv1beta1.LabelSelectorOpLt:
+			if !exists || !compareLabelValue(value, expr.Operator, expr.Values) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// compareLabelValue returns true if the integer label value is greater (Gt) or less (Lt) than all the values
+func compareLabelValue(value string, op talismanv1beta1.LabelSelectorOperator, values []string) bool {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	for _, bound := range values {
+		b, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil {
+			return false
+		}
+		if (op == talismanv1beta1.LabelSelectorOpGt && v <= b) || (op == talismanv1beta1.LabelSelectorOpLt && v >= b) {
+			return false
+		}
+	}
+	return true
+}
+
+// getNodeLabels returns a map of k8s node name to its labels
+func getNodeLabels() (map[string]map[string]string, error) {
+	nodes, err := k8sCore.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get k8s nodes. Err: %v", err)
+	}
+	nodeLabels := make(map[string]map[string]string)
+	for _, n := range nodes.Items {
+		nodeLabels[n.Name] = n.Labels
+	}
+	return nodeLabels, nil
+}
+
+// getNodePool returns the storage pool of the node with the given uuid, or nil if the node has no such pool
+func getNodePool(n node.Node, poolUUID string) *api.StoragePool {
+	if len(poolUUID) == 0 {
+		return nil
+	}
+	for _, pool := range n.StoragePools {
+		if pool.StoragePool != nil && pool.Uuid == poolUUID {
+			return pool.StoragePool
+		}
+	}
+	return nil
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeLabels(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+func replicaPlacements(replicas []placedReplica) []scheduler.ReplicaPlacement {
+	placements := make([]scheduler.ReplicaPlacement, 0, len(replicas))
+	for _, replica := range replicas {
+		placements = append(placements, replica.ReplicaPlacement)
+	}
+	return placements
+}
+
+func describeExpressions(exprs []*talismanv1beta1.LabelSelectorRequirement) string {
+	var descs []string
+	for _, expr := range exprs {
+		descs = append(descs, fmt.Sprintf("%s %s %v", expr.Key, expr.Operator, expr.Values))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(descs, ", "))
+}
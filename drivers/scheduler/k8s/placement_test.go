@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"testing"
+
+	talismanv1beta1 "github.com/portworx/talisman/pkg/apis/portworx/v1beta1"
+	talismanv1beta2 "github.com/portworx/talisman/pkg/apis/portworx/v1beta2"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/stretchr/testify/require"
+)
+
+func testReplica(nodeID string, labels map[string]string) placedReplica {
+	return placedReplica{
+		ReplicaPlacement: scheduler.ReplicaPlacement{NodeID: nodeID, NodeName: nodeID},
+		labels:           labels,
+	}
+}
+
+func testVolume(name string, labels map[string]string, replicas ...placedReplica) *placedVolume {
+	return &placedVolume{name: name, labels: labels, replicas: replicas}
+}
+
+func TestMatchesExpressions(t *testing.T) {
+	labels := map[string]string{"media": "ssd", "rack": "3"}
+	tests := []struct {
+		expr  talismanv1beta1.LabelSelectorRequirement
+		match bool
+	}{
+		{talismanv1beta1.LabelSelectorRequirement{Key: "media", Operator: talismanv1beta1.LabelSelectorOpIn, Values: []string{"ssd", "nvme"}}, true},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "media", Operator: talismanv1beta1.LabelSelectorOpNotIn, Values: []string{"ssd"}}, false},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "zone", Operator: talismanv1beta1.LabelSelectorOpNotIn, Values: []string{"a"}}, true},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "media", Operator: talismanv1beta1.LabelSelectorOpExists}, true},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "media", Operator: talismanv1beta1.LabelSelectorOpDoesNotExist}, false},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "rack", Operator: talismanv1beta1.LabelSelectorOpGt, Values: []string{"2"}}, true},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "rack", Operator: talismanv1beta1.LabelSelectorOpLt, Values: []string{"3"}}, false},
+		{talismanv1beta1.LabelSelectorRequirement{Key: "media", Operator: talismanv1beta1.LabelSelectorOpGt, Values: []string{"2"}}, false},
+	}
+	for _, tc := range tests {
+		expr := tc.expr
+		require.Equal(t, tc.match, matchesExpressions(labels, []*talismanv1beta1.LabelSelectorRequirement{&expr}),
+			"%s %s %v", expr.Key, expr.Operator, expr.Values)
+	}
+	require.True(t, matchesExpressions(labels, nil))
+}
+
+func TestCheckPlacementReplicaRules(t *testing.T) {
+	ssd := []*talismanv1beta1.LabelSelectorRequirement{
+		{Key: "media", Operator: talismanv1beta1.LabelSelectorOpIn, Values: []string{"ssd"}},
+	}
+	vol := testVolume("vol", nil,
+		testReplica("n1", map[string]string{"media": "ssd", "zone": "a"}),
+		testReplica("n2", map[string]string{"media": "hdd", "zone": "a"}),
+	)
+
+	spec := &talismanv1beta2.VolumePlacementSpec{
+		ReplicaAffinity: []*talismanv1beta2.ReplicaPlacementSpec{
+			{CommonPlacementSpec: talismanv1beta2.CommonPlacementSpec{MatchExpressions: ssd}},
+		},
+	}
+	violations := checkPlacement(spec, vol, nil)
+	require.Len(t, violations, 1)
+	require.Equal(t, "replicaAffinity", violations[0].rule)
+
+	spec.ReplicaAffinity[0].AffectedReplicas = 1
+	require.Empty(t, checkPlacement(spec, vol, nil))
+
+	spec = &talismanv1beta2.VolumePlacementSpec{
+		ReplicaAntiAffinity: []*talismanv1beta2.ReplicaPlacementSpec{
+			{CommonPlacementSpec: talismanv1beta2.CommonPlacementSpec{TopologyKey: "zone"}},
+		},
+	}
+	violations = checkPlacement(spec, vol, nil)
+	require.Len(t, violations, 1)
+	require.Equal(t, "replicaAntiAffinity", violations[0].rule)
+
+	spec.ReplicaAntiAffinity[0].Enforcement = talismanv1beta1.EnforcementPreferred
+	violations = checkPlacement(spec, vol, nil)
+	require.Len(t, violations, 1)
+	require.Equal(t, talismanv1beta1.EnforcementPreferred, violations[0].enforcement)
+}
+
+func TestCheckPlacementVolumeRules(t *testing.T) {
+	appDB := []*talismanv1beta1.LabelSelectorRequirement{
+		{Key: "app", Operator: talismanv1beta1.LabelSelectorOpIn, Values: []string{"db"}},
+	}
+	vol := testVolume("vol", map[string]string{"app": "web"},
+		testReplica("n1", map[string]string{"zone": "a"}),
+		testReplica("n2", map[string]string{"zone": "b"}),
+	)
+	db := testVolume("db", map[string]string{"app": "db"},
+		testReplica("n1", map[string]string{"zone": "a"}),
+		testReplica("n3", map[string]string{"zone": "b"}),
+	)
+	peers := []*placedVolume{vol, db}
+
+	spec := &talismanv1beta2.VolumePlacementSpec{
+		VolumeAffinity: []*talismanv1beta2.CommonPlacementSpec{{MatchExpressions: appDB, TopologyKey: "zone"}},
+	}
+	require.Empty(t, checkPlacement(spec, vol, peers))
+
+	// Without a topology key the replicas have to share the nodes of the matching volume
+	spec.VolumeAffinity[0].TopologyKey = ""
+	violations := checkPlacement(spec, vol, peers)
+	require.Len(t, violations, 1)
+	require.Equal(t, "volumeAffinity", violations[0].rule)
+
+	// No matching volume means there is nothing to be placed with
+	require.Empty(t, checkPlacement(spec, vol, []*placedVolume{vol}))
+
+	spec = &talismanv1beta2.VolumePlacementSpec{
+		VolumeAntiAffinity: []*talismanv1beta2.CommonPlacementSpec{{MatchExpressions: appDB}},
+	}
+	violations = checkPlacement(spec, vol, peers)
+	require.Len(t, violations, 1)
+	require.Equal(t, "volumeAntiAffinity", violations[0].rule)
+}
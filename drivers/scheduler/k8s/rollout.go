@@ -0,0 +1,400 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	appsapi "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// deploymentRevisionAnnotation is the annotation holding the revision of a deployment and its replica sets
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+	// podTemplateHashLabel is the label added to the pod template of the replica sets of a deployment
+	podTemplateHashLabel = "pod-template-hash"
+	// statefulSetPodNameLabel is the label holding the name of a statefulset pod
+	statefulSetPodNameLabel = "statefulset.kubernetes.io/pod-name"
+	// controllerRevisionHashLabel is the label holding the controller revision a statefulset pod was created from
+	controllerRevisionHashLabel = "controller-revision-hash"
+	// defaultRolloutTimeout is the default time to wait for a rollout to finish
+	defaultRolloutTimeout = 10 * time.Minute
+)
+
+// RolloutApplication does a rolling update of the Deployments and StatefulSets of the given context
+func (k *K8s) RolloutApplication(ctx *scheduler.Context, opts scheduler.RolloutOptions) error {
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = defaultRolloutTimeout
+	}
+	for _, specObj := range ctx.App.SpecList {
+		if obj, ok := specObj.(*appsapi.Deployment); ok {
+			t := func() (interface{}, bool, error) {
+				dep, err := k8sApps.GetDeployment(obj.Name, obj.Namespace)
+				if err != nil {
+					return "", true, err
+				}
+				updatePodTemplate(&dep.Spec.Template, opts)
+				dep, err = k8sApps.UpdateDeployment(dep)
+				if err != nil {
+					return "", true, err
+				}
+				return dep, false, nil
+			}
+			if _, err := task.DoRetryWithTimeout(t, 2*time.Minute, time.Second); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: fmt.Sprintf("Failed to roll out Deployment: %v. Err: %v", obj.Name, err),
+				}
+			}
+			log.Infof("Rolling out Deployment %s", obj.Name)
+			if err := k.waitForDeploymentRollout(obj, timeout); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: err.Error(),
+				}
+			}
+		} else if obj, ok := specObj.(*appsapi.StatefulSet); ok {
+			var partition int32
+			if opts.Partition != nil {
+				partition = *opts.Partition
+			}
+			t := func() (interface{}, bool, error) {
+				ss, err := k8sApps.GetStatefulSet(obj.Name, obj.Namespace)
+				if err != nil {
+					return "", true, err
+				}
+				updatePodTemplate(&ss.Spec.Template, opts)
+				setStatefulSetPartition(ss, partition)
+				ss, err = k8sApps.UpdateStatefulSet(ss)
+				if err != nil {
+					return "", true, err
+				}
+				return ss, false, nil
+			}
+			ss, err := task.DoRetryWithTimeout(t, 2*time.Minute, time.Second)
+			if err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: fmt.Sprintf("Failed to roll out StatefulSet: %v. Err: %v", obj.Name, err),
+				}
+			}
+			if ss.(*appsapi.StatefulSet).Spec.UpdateStrategy.Type == appsapi.OnDeleteStatefulSetStrategyType {
+				log.Warnf("StatefulSet %s uses the OnDelete update strategy, pods are only updated once they get deleted", obj.Name)
+				continue
+			}
+			log.Infof("Rolling out StatefulSet %s with partition %d", obj.Name, partition)
+			if err := k.waitForStatefulSetRollout(obj, partition, timeout); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: err.Error(),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// RollbackApplication rolls the Deployments and StatefulSets of the given context back to their previous revision
+func (k *K8s) RollbackApplication(ctx *scheduler.Context, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = defaultRolloutTimeout
+	}
+	for _, specObj := range ctx.App.SpecList {
+		if obj, ok := specObj.(*appsapi.Deployment); ok {
+			dep, err := k8sApps.GetDeployment(obj.Name, obj.Namespace)
+			if err != nil {
+				return err
+			}
+			template, err := k.getPreviousDeploymentTemplate(dep)
+			if err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: fmt.Sprintf("Failed to get previous revision of Deployment: %v. Err: %v", obj.Name, err),
+				}
+			}
+			dep.Spec.Template = *template
+			if _, err := k8sApps.UpdateDeployment(dep); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: fmt.Sprintf("Failed to roll back Deployment: %v. Err: %v", obj.Name, err),
+				}
+			}
+			log.Infof("Rolling back Deployment %s", obj.Name)
+			if err := k.waitForDeploymentRollout(obj, timeout); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: err.Error(),
+				}
+			}
+		} else if obj, ok := specObj.(*appsapi.StatefulSet); ok {
+			ss, err := k8sApps.GetStatefulSet(obj.Name, obj.Namespace)
+			if err != nil {
+				return err
+			}
+			template, err := k.getPreviousStatefulSetTemplate(ss)
+			if err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: fmt.Sprintf("Failed to get previous revision of StatefulSet: %v. Err: %v", obj.Name, err),
+				}
+			}
+			ss.Spec.Template = *template
+			setStatefulSetPartition(ss, 0)
+			if _, err := k8sApps.UpdateStatefulSet(ss); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: fmt.Sprintf("Failed to roll back StatefulSet: %v. Err: %v", obj.Name, err),
+				}
+			}
+			if ss.Spec.UpdateStrategy.Type == appsapi.OnDeleteStatefulSetStrategyType {
+				log.Warnf("StatefulSet %s uses the OnDelete update strategy, pods are only rolled back once they get deleted", obj.Name)
+				continue
+			}
+			log.Infof("Rolling back StatefulSet %s", obj.Name)
+			if err := k.waitForStatefulSetRollout(obj, 0, timeout); err != nil {
+				return &scheduler.ErrFailedToUpdateApp{
+					App:   ctx.App,
+					Cause: err.Error(),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// updatePodTemplate applies the image and environment changes of the rollout options to the pod template
+func updatePodTemplate(template *corev1.PodTemplateSpec, opts scheduler.RolloutOptions) {
+	var envNames []string
+	for name := range opts.Env {
+		envNames = append(envNames, name)
+	}
+	sort.Strings(envNames)
+
+	for i := range template.Spec.Containers {
+		container := &template.Spec.Containers[i]
+		if len(opts.Image) > 0 && imageRepository(container.Image) == imageRepository(opts.Image) {
+			container.Image = opts.Image
+		}
+		for _, name := range envNames {
+			found := false
+			for j := range container.Env {
+				if container.Env[j].Name == name {
+					container.Env[j].Value = opts.Env[name]
+					container.Env[j].ValueFrom = nil
+					found = true
+					break
+				}
+			}
+			if !found {
+				container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: opts.Env[name]})
+			}
+		}
+	}
+}
+
+// imageRepository returns the image without its tag or digest
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+func setStatefulSetPartition(ss *appsapi.StatefulSet, partition int32) {
+	if ss.Spec.UpdateStrategy.Type == appsapi.OnDeleteStatefulSetStrategyType {
+		return
+	}
+	if ss.Spec.UpdateStrategy.RollingUpdate == nil {
+		ss.Spec.UpdateStrategy.RollingUpdate = &appsapi.RollingUpdateStatefulSetStrategy{}
+	}
+	ss.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+}
+
+// waitForDeploymentRollout waits until all pods of the deployment run the latest revision
+func (k *K8s) waitForDeploymentRollout(obj *appsapi.Deployment, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		dep, err := k8sApps.GetDeployment(obj.Name, obj.Namespace)
+		if err != nil {
+			return "", true, err
+		}
+		if dep.Status.ObservedGeneration < dep.Generation {
+			return "", true, fmt.Errorf("deployment %s generation %d is not observed yet", dep.Name, dep.Generation)
+		}
+		replicas := *dep.Spec.Replicas
+		if dep.Status.UpdatedReplicas < replicas {
+			return "", true, fmt.Errorf("deployment %s has %d of %d replicas updated", dep.Name, dep.Status.UpdatedReplicas, replicas)
+		}
+		if dep.Status.Replicas > dep.Status.UpdatedReplicas {
+			return "", true, fmt.Errorf("deployment %s has %d old replicas pending termination", dep.Name, dep.Status.Replicas-dep.Status.UpdatedReplicas)
+		}
+		return "", false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, timeout, DefaultRetryInterval); err != nil {
+		return fmt.Errorf("rollout of Deployment %s did not finish. Err: %v", obj.Name, err)
+	}
+	if err := k8sApps.ValidateDeployment(obj, timeout, DefaultRetryInterval); err != nil {
+		return fmt.Errorf("Deployment %s is not ready after rollout. Err: %v", obj.Name, err)
+	}
+	log.Infof("Rollout of Deployment %s finished", obj.Name)
+	return nil
+}
+
+// waitForStatefulSetRollout waits until all pods of the statefulset with an ordinal greater or equal to the
+// partition run the update revision
+func (k *K8s) waitForStatefulSetRollout(obj *appsapi.StatefulSet, partition int32, timeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		ss, err := k8sApps.GetStatefulSet(obj.Name, obj.Namespace)
+		if err != nil {
+			return "", true, err
+		}
+		if ss.Status.ObservedGeneration < ss.Generation {
+			return "", true, fmt.Errorf("statefulset %s generation %d is not observed yet", ss.Name, ss.Generation)
+		}
+		pods, err := k8sApps.GetStatefulSetPods(ss)
+		if err != nil {
+			return "", true, err
+		}
+		for _, pod := range pods {
+			ordinal, err := getStatefulSetPodOrdinal(ss, pod)
+			if err != nil {
+				return "", false, err
+			}
+			if ordinal < partition {
+				continue
+			}
+			if pod.Labels[controllerRevisionHashLabel] != ss.Status.UpdateRevision {
+				return "", true, fmt.Errorf("pod %s of statefulset %s is at revision %s, expected %s",
+					pod.Name, ss.Name, pod.Labels[controllerRevisionHashLabel], ss.Status.UpdateRevision)
+			}
+		}
+		if expected := *ss.Spec.Replicas - partition; ss.Status.UpdatedReplicas < expected {
+			return "", true, fmt.Errorf("statefulset %s has %d of %d replicas updated", ss.Name, ss.Status.UpdatedReplicas, expected)
+		}
+		return "", false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, timeout, DefaultRetryInterval); err != nil {
+		return fmt.Errorf("rollout of StatefulSet %s did not finish. Err: %v", obj.Name, err)
+	}
+	if err := k8sApps.ValidateStatefulSet(obj, timeout); err != nil {
+		return fmt.Errorf("StatefulSet %s is not ready after rollout. Err: %v", obj.Name, err)
+	}
+	log.Infof("Rollout of StatefulSet %s finished", obj.Name)
+	return nil
+}
+
+func getStatefulSetPodOrdinal(ss *appsapi.StatefulSet, pod corev1.Pod) (int32, error) {
+	name := pod.Labels[statefulSetPodNameLabel]
+	if len(name) == 0 {
+		name = pod.Name
+	}
+	ordinal, err := strconv.ParseInt(strings.TrimPrefix(name, ss.Name+"-"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get ordinal of pod %s of statefulset %s. Err: %v", pod.Name, ss.Name, err)
+	}
+	return int32(ordinal), nil
+}
+
+// getPreviousDeploymentTemplate returns the pod template of the revision before the current revision of the deployment
+func (k *K8s) getPreviousDeploymentTemplate(dep *appsapi.Deployment) (*corev1.PodTemplateSpec, error) {
+	current, err := strconv.ParseInt(dep.Annotations[deploymentRevisionAnnotation], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse revision of deployment %s. Err: %v", dep.Name, err)
+	}
+	clientset, err := k.getKubeClient("")
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(dep.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	rsList, err := clientset.AppsV1().ReplicaSets(dep.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *appsapi.ReplicaSet
+	var previousRevision int64
+	for i, rs := range rsList.Items {
+		if !metav1.IsControlledBy(&rs, dep) {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[deploymentRevisionAnnotation], 10, 64)
+		if err != nil || revision >= current {
+			continue
+		}
+		if revision > previousRevision {
+			previous = &rsList.Items[i]
+			previousRevision = revision
+		}
+	}
+	if previous == nil {
+		return nil, fmt.Errorf("deployment %s has no revision before revision %d", dep.Name, current)
+	}
+
+	template := previous.Spec.Template.DeepCopy()
+	delete(template.Labels, podTemplateHashLabel)
+	return template, nil
+}
+
+// getPreviousStatefulSetTemplate returns the pod template of the revision before the update revision of the statefulset
+func (k *K8s) getPreviousStatefulSetTemplate(ss *appsapi.StatefulSet) (*corev1.PodTemplateSpec, error) {
+	clientset, err := k.getKubeClient("")
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ss.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := clientset.AppsV1().ControllerRevisions(ss.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []appsapi.ControllerRevision
+	var current int64 = -1
+	for _, revision := range revisions.Items {
+		if !metav1.IsControlledBy(&revision, ss) {
+			continue
+		}
+		owned = append(owned, revision)
+		if revision.Name == ss.Status.UpdateRevision {
+			current = revision.Revision
+		}
+	}
+	if current < 0 {
+		return nil, fmt.Errorf("update revision %s of statefulset %s not found", ss.Status.UpdateRevision, ss.Name)
+	}
+	sort.Slice(owned, func(i, j int) bool { return owned[i].Revision > owned[j].Revision })
+
+	for _, revision := range owned {
+		if revision.Revision >= current {
+			continue
+		}
+		// statefulset controller revisions store a patch replacing the pod template of the statefulset
+		patch := struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		}{}
+		if err := json.Unmarshal(revision.Data.Raw, &patch); err != nil {
+			return nil, fmt.Errorf("failed to parse controller revision %s of statefulset %s. Err: %v", revision.Name, ss.Name, err)
+		}
+		return &patch.Spec.Template, nil
+	}
+	return nil, fmt.Errorf("statefulset %s has no revision before revision %d", ss.Name, current)
+}
@@ -0,0 +1,366 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// K8sTopologyZoneLabel is the well-known label describing the zone of a k8s node
+	K8sTopologyZoneLabel = "topology.kubernetes.io/zone"
+	// K8sTopologyRegionLabel is the well-known label describing the region of a k8s node
+	K8sTopologyRegionLabel = "topology.kubernetes.io/region"
+)
+
+// zoneLabelKeys are the node label keys which are treated as zone labels, in order of preference
+var zoneLabelKeys = []string{K8sTopologyZoneLabel, ZoneK8SNodeLabel}
+
+// zoneTopologyKeys are the topology keys which describe a zone, the k8s zone labels and the zone keys
+// advertised by the CSI drivers torpedo runs with
+var zoneTopologyKeys = []string{
+	K8sTopologyZoneLabel,
+	ZoneK8SNodeLabel,
+	TopologyZoneK8sNodeLabel,
+	"topology.ebs.csi.aws.com/zone",
+	"topology.disk.csi.azure.com/zone",
+	"topology.gke.io/zone",
+	"topology.csi.vmware.com/k8s-zone",
+}
+
+// ValidateZoneTopology validates that the PV node affinity, storage class allowed topologies,
+// replica placement reported by the volume driver and the pod placement of the given app agree
+// on the zones of the cluster. Zones are taken from the well-known k8s zone node labels.
+func (k *K8s) ValidateZoneTopology(ctx *scheduler.Context) (*scheduler.TopologyReport, error) {
+	nodeZones, err := getNodeZones()
+	if err != nil {
+		return nil, err
+	}
+	if len(nodeZones) == 0 {
+		log.Warnf("No node in the cluster has a [%s] label, skipping zone topology validation", K8sTopologyZoneLabel)
+		return &scheduler.TopologyReport{}, nil
+	}
+
+	volDriver, err := volume.Get(k.VolDriverName)
+	if err != nil {
+		return nil, err
+	}
+
+	vols, err := k.GetVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	csiNodeKeys, err := k.getCSINodeTopologyKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterZones []string
+	for _, zone := range nodeZones {
+		if !containsZone(clusterZones, zone) {
+			clusterZones = append(clusterZones, zone)
+		}
+	}
+	sort.Strings(clusterZones)
+
+	report := &scheduler.TopologyReport{}
+	for _, vol := range vols {
+		volTopology, podNodes, err := k.getVolumeTopology(vol, volDriver, nodeZones)
+		if err != nil {
+			return report, err
+		}
+		report.Volumes = append(report.Volumes, volTopology)
+
+		if err := validateVolumeTopology(volTopology, podNodes, csiNodeKeys, clusterZones); err != nil {
+			return report, err
+		}
+		log.Infof("Validated zone topology of volume [%s]: replicas %s, pods %v",
+			vol.Name, describeReplicaPlacement(volTopology.Replicas), volTopology.PodZones)
+	}
+	return report, nil
+}
+
+// getVolumeTopology collects the allowed zones, PV affinity zones, replica zones and pod zones of a volume
+// along with a map of pod name to the node the pod runs on
+func (k *K8s) getVolumeTopology(vol *volume.Volume, volDriver volume.Driver, nodeZones map[string]string) (*scheduler.VolumeTopology, map[string]string, error) {
+	podNodes := make(map[string]string)
+	volTopology := &scheduler.VolumeTopology{
+		Volume:   vol,
+		PodZones: make(map[string]string),
+	}
+
+	pvc, err := k8sCore.GetPersistentVolumeClaim(vol.Name, vol.Namespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get PVC [%s/%s]. Err: %v", vol.Namespace, vol.Name, err)
+	}
+
+	if sc, err := k8sCore.GetStorageClassForPVC(pvc); err == nil {
+		volTopology.AllowedZones = getAllowedTopologyZones(sc)
+	} else {
+		log.Warnf("failed to get storage class for PVC [%s/%s]. Err: %v", pvc.Namespace, pvc.Name, err)
+	}
+
+	if len(pvc.Spec.VolumeName) > 0 {
+		pv, err := k8sCore.GetPersistentVolume(pvc.Spec.VolumeName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get PV [%s]. Err: %v", pvc.Spec.VolumeName, err)
+		}
+		volTopology.PVAffinityZones = getPVAffinityZones(pv)
+	}
+
+	replicaSets, err := volDriver.GetReplicaSets(vol)
+	if err != nil {
+		if _, ok := err.(*errors.ErrNotSupported); !ok {
+			return nil, nil, fmt.Errorf("failed to get replica sets for volume [%s]. Err: %v", vol.Name, err)
+		}
+		log.Warnf("volume driver [%s] does not report replica sets, skipping replica placement check", volDriver.String())
+	}
+	nodesByID := node.GetNodesByVoDriverNodeID()
+	for i, rs := range replicaSets {
+		for _, nodeID := range rs.Nodes {
+			placement := scheduler.ReplicaPlacement{
+				ReplicaSetIndex: i,
+				NodeID:          nodeID,
+			}
+			if n, ok := nodesByID[nodeID]; ok {
+				placement.NodeName = n.Name
+				placement.Zone = nodeZones[n.Name]
+			}
+			volTopology.Replicas = append(volTopology.Replicas, placement)
+		}
+	}
+
+	pods, err := k8sCore.GetPodsUsingPVC(pvc.Name, pvc.Namespace)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get pods using PVC [%s/%s]. Err: %v", pvc.Namespace, pvc.Name, err)
+	}
+	for _, pod := range pods {
+		if len(pod.Spec.NodeName) == 0 {
+			continue
+		}
+		volTopology.PodZones[pod.Name] = nodeZones[pod.Spec.NodeName]
+		podNodes[pod.Name] = pod.Spec.NodeName
+	}
+	return volTopology, podNodes, nil
+}
+
+// validateVolumeTopology checks that replicas and pods of a volume are only placed in zones allowed
+// by both the storage class and the PV node affinity, that pods run in a zone of a replica or of the PV
+// affinity, that the replicas of a replica set are spread over zones if more than one zone is allowed and
+// that the CSI driver reports a zone topology key
+func validateVolumeTopology(volTopology *scheduler.VolumeTopology, podNodes map[string]string, csiNodeKeys map[string][]string, clusterZones []string) error {
+	volName := volTopology.Volume.Name
+	allowed := intersectZones(volTopology.AllowedZones, volTopology.PVAffinityZones)
+	if len(volTopology.AllowedZones) > 0 && len(volTopology.PVAffinityZones) > 0 && len(allowed) == 0 {
+		return &scheduler.ErrZoneTopologyMismatch{
+			Volume: volName,
+			Cause: fmt.Sprintf("PV affinity zones %v are not part of storage class allowed zones %v",
+				volTopology.PVAffinityZones, volTopology.AllowedZones),
+		}
+	}
+
+	for _, replica := range volTopology.Replicas {
+		if len(replica.NodeName) == 0 {
+			return &scheduler.ErrZoneTopologyMismatch{
+				Volume: volName,
+				Cause:  fmt.Sprintf("replica node [%s] is not a known node of the cluster", replica.NodeID),
+			}
+		}
+		if len(allowed) > 0 && !containsZone(allowed, replica.Zone) {
+			return &scheduler.ErrZoneTopologyMismatch{
+				Volume: volName,
+				Cause: fmt.Sprintf("replica on node [%s] is in zone [%s] which is not one of the allowed zones %v",
+					replica.NodeName, replica.Zone, allowed),
+			}
+		}
+	}
+
+	// replicas of a replica set are expected in more than one zone if more than one zone is allowed
+	replicaSetZones := make(map[int][]string)
+	replicaSetSizes := make(map[int]int)
+	var replicaZones []string
+	for _, replica := range volTopology.Replicas {
+		replicaSetSizes[replica.ReplicaSetIndex]++
+		if !containsZone(replicaSetZones[replica.ReplicaSetIndex], replica.Zone) {
+			replicaSetZones[replica.ReplicaSetIndex] = append(replicaSetZones[replica.ReplicaSetIndex], replica.Zone)
+		}
+		if !containsZone(replicaZones, replica.Zone) {
+			replicaZones = append(replicaZones, replica.Zone)
+		}
+	}
+	availableZones := intersectZones(clusterZones, allowed)
+	if len(availableZones) > 1 {
+		for i, size := range replicaSetSizes {
+			if size > 1 && len(replicaSetZones[i]) == 1 {
+				return &scheduler.ErrZoneTopologyMismatch{
+					Volume: volName,
+					Cause: fmt.Sprintf("all %d replicas of replica set %d are in zone [%s] while zones %v are available",
+						size, i, replicaSetZones[i][0], availableZones),
+				}
+			}
+		}
+	}
+
+	// pods are expected to run in a zone which holds a replica of the volume or which the PV is restricted to
+	podZones := append(append([]string{}, replicaZones...), volTopology.PVAffinityZones...)
+	for podName, zone := range volTopology.PodZones {
+		if len(allowed) > 0 && !containsZone(allowed, zone) {
+			return &scheduler.ErrZoneTopologyMismatch{
+				Volume: volName,
+				Cause: fmt.Sprintf("pod [%s] is running in zone [%s] which is not one of the allowed zones %v",
+					podName, zone, allowed),
+			}
+		}
+		if len(podZones) > 0 && !containsZone(podZones, zone) {
+			return &scheduler.ErrZoneTopologyMismatch{
+				Volume: volName,
+				Cause: fmt.Sprintf("pod [%s] is running in zone [%s] which holds no replica and is not one of the PV affinity zones, "+
+					"replicas %s, PV affinity zones %v", podName, zone, describeReplicaPlacement(volTopology.Replicas), volTopology.PVAffinityZones),
+			}
+		}
+	}
+
+	// A zone restricted volume is only honored when the CSI node plugin advertises a zone topology key
+	if len(allowed) > 0 {
+		for podName, nodeName := range podNodes {
+			if keys := csiNodeKeys[nodeName]; len(keys) > 0 && !hasZoneTopologyKey(keys) {
+				return &scheduler.ErrZoneTopologyMismatch{
+					Volume: volName,
+					Cause: fmt.Sprintf("CSI node [%s] used by pod [%s] does not advertise a zone topology key, keys: %v",
+						nodeName, podName, keys),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// getNodeZones returns a map of k8s node name to its zone
+func getNodeZones() (map[string]string, error) {
+	nodes, err := k8sCore.GetNodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get k8s nodes. Err: %v", err)
+	}
+	nodeZones := make(map[string]string)
+	for _, n := range nodes.Items {
+		for _, key := range zoneLabelKeys {
+			if zone, ok := n.Labels[key]; ok && len(zone) > 0 {
+				nodeZones[n.Name] = zone
+				break
+			}
+		}
+	}
+	return nodeZones, nil
+}
+
+// getCSINodeTopologyKeys returns a map of node name to the topology keys advertised by its CSI drivers
+func (k *K8s) getCSINodeTopologyKeys() (map[string][]string, error) {
+	clientset, err := k.getKubeClient("")
+	if err != nil {
+		return nil, err
+	}
+	csiNodes, err := clientset.StorageV1().CSINodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CSI nodes. Err: %v", err)
+	}
+	keys := make(map[string][]string)
+	for _, csiNode := range csiNodes.Items {
+		for _, driver := range csiNode.Spec.Drivers {
+			keys[csiNode.Name] = append(keys[csiNode.Name], driver.TopologyKeys...)
+		}
+	}
+	return keys, nil
+}
+
+// getAllowedTopologyZones returns the zones listed in the storage class allowedTopologies
+func getAllowedTopologyZones(sc *storageapi.StorageClass) []string {
+	var zones []string
+	for _, term := range sc.AllowedTopologies {
+		for _, expr := range term.MatchLabelExpressions {
+			if isZoneKey(expr.Key) {
+				zones = append(zones, expr.Values...)
+			}
+		}
+	}
+	return zones
+}
+
+// getPVAffinityZones returns the zones the PV node affinity restricts the volume to
+func getPVAffinityZones(pv *corev1.PersistentVolume) []string {
+	var zones []string
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return zones
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if isZoneKey(expr.Key) && expr.Operator == corev1.NodeSelectorOpIn {
+				zones = append(zones, expr.Values...)
+			}
+		}
+	}
+	return zones
+}
+
+func isZoneKey(key string) bool {
+	for _, zoneKey := range zoneTopologyKeys {
+		if key == zoneKey {
+			return true
+		}
+	}
+	return false
+}
+
+func hasZoneTopologyKey(keys []string) bool {
+	for _, key := range keys {
+		if isZoneKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// intersectZones returns the zones present in both lists. An empty list means any zone.
+func intersectZones(a, b []string) []string {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	var zones []string
+	for _, zone := range a {
+		if containsZone(b, zone) {
+			zones = append(zones, zone)
+		}
+	}
+	return zones
+}
+
+func containsZone(zones []string, zone string) bool {
+	for _, z := range zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
+
+func describeReplicaPlacement(replicas []scheduler.ReplicaPlacement) string {
+	var placements []string
+	for _, replica := range replicas {
+		placements = append(placements, fmt.Sprintf("set %d: %s(%s)", replica.ReplicaSetIndex, replica.NodeName, replica.Zone))
+	}
+	sort.Strings(placements)
+	return fmt.Sprintf("[%s]", strings.Join(placements, ", "))
+}
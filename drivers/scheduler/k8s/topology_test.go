@@ -0,0 +1,138 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+)
+
+func TestIsZoneKey(t *testing.T) {
+	for _, key := range []string{
+		K8sTopologyZoneLabel,
+		ZoneK8SNodeLabel,
+		TopologyZoneK8sNodeLabel,
+		"topology.ebs.csi.aws.com/zone",
+	} {
+		require.True(t, isZoneKey(key), key)
+	}
+	for _, key := range []string{
+		K8sTopologyRegionLabel,
+		"example.com/zone",
+		"topology.kubernetes.io/zone-name",
+		"kubernetes.io/hostname",
+	} {
+		require.False(t, isZoneKey(key), key)
+	}
+	require.True(t, hasZoneTopologyKey([]string{"kubernetes.io/hostname", TopologyZoneK8sNodeLabel}))
+	require.False(t, hasZoneTopologyKey([]string{"kubernetes.io/hostname", "example.com/zone"}))
+}
+
+func TestGetAllowedTopologyZones(t *testing.T) {
+	sc := &storageapi.StorageClass{
+		AllowedTopologies: []corev1.TopologySelectorTerm{{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: K8sTopologyZoneLabel, Values: []string{"zone-a", "zone-b"}},
+				{Key: "example.com/zone", Values: []string{"zone-c"}},
+				{Key: K8sTopologyRegionLabel, Values: []string{"region-1"}},
+			},
+		}},
+	}
+	require.Equal(t, []string{"zone-a", "zone-b"}, getAllowedTopologyZones(sc))
+	require.Empty(t, getAllowedTopologyZones(&storageapi.StorageClass{}))
+}
+
+func TestGetPVAffinityZones(t *testing.T) {
+	require.Empty(t, getPVAffinityZones(&corev1.PersistentVolume{}))
+
+	pv := &corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: TopologyZoneK8sNodeLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-a"}},
+							{Key: K8sTopologyZoneLabel, Operator: corev1.NodeSelectorOpNotIn, Values: []string{"zone-b"}},
+							{Key: "example.com/zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"zone-c"}},
+						},
+					}},
+				},
+			},
+		},
+	}
+	require.Equal(t, []string{"zone-a"}, getPVAffinityZones(pv))
+}
+
+func TestIntersectZones(t *testing.T) {
+	require.Equal(t, []string{"zone-a"}, intersectZones(nil, []string{"zone-a"}))
+	require.Equal(t, []string{"zone-a"}, intersectZones([]string{"zone-a"}, nil))
+	require.Equal(t, []string{"zone-b"}, intersectZones([]string{"zone-a", "zone-b"}, []string{"zone-b", "zone-c"}))
+	require.Empty(t, intersectZones([]string{"zone-a"}, []string{"zone-b"}))
+}
+
+func TestValidateVolumeTopology(t *testing.T) {
+	newTopology := func() *scheduler.VolumeTopology {
+		return &scheduler.VolumeTopology{
+			Volume:          &volume.Volume{Name: "vol"},
+			AllowedZones:    []string{"zone-a", "zone-b"},
+			PVAffinityZones: []string{"zone-a"},
+			Replicas: []scheduler.ReplicaPlacement{
+				{NodeID: "id-1", NodeName: "node-1", Zone: "zone-a"},
+			},
+			PodZones: map[string]string{"pod-1": "zone-a"},
+		}
+	}
+	podNodes := map[string]string{"pod-1": "node-1"}
+	csiNodeKeys := map[string][]string{"node-1": {TopologyZoneK8sNodeLabel}}
+	clusterZones := []string{"zone-a", "zone-b", "zone-c"}
+	require.NoError(t, validateVolumeTopology(newTopology(), podNodes, csiNodeKeys, clusterZones))
+
+	topology := newTopology()
+	topology.PVAffinityZones = []string{"zone-c"}
+	require.Error(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+
+	topology = newTopology()
+	topology.Replicas[0].Zone = "zone-b"
+	require.Error(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+
+	topology = newTopology()
+	topology.Replicas[0].NodeName = ""
+	require.Error(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+
+	topology = newTopology()
+	topology.PodZones["pod-1"] = "zone-b"
+	require.Error(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+
+	err := validateVolumeTopology(newTopology(), podNodes, map[string][]string{"node-1": {"example.com/zone"}}, clusterZones)
+	_, mismatch := err.(*scheduler.ErrZoneTopologyMismatch)
+	require.True(t, mismatch)
+
+	// without zone restrictions, pods run in the zone of a replica and replicas are spread over the zones
+	unrestricted := func() *scheduler.VolumeTopology {
+		return &scheduler.VolumeTopology{
+			Volume: &volume.Volume{Name: "vol"},
+			Replicas: []scheduler.ReplicaPlacement{
+				{ReplicaSetIndex: 0, NodeID: "id-1", NodeName: "node-1", Zone: "zone-a"},
+				{ReplicaSetIndex: 0, NodeID: "id-2", NodeName: "node-2", Zone: "zone-b"},
+			},
+			PodZones: map[string]string{"pod-1": "zone-a"},
+		}
+	}
+	require.NoError(t, validateVolumeTopology(unrestricted(), podNodes, csiNodeKeys, clusterZones))
+
+	topology = unrestricted()
+	topology.PodZones["pod-1"] = "zone-c"
+	require.Error(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+
+	topology = unrestricted()
+	topology.Replicas[1].Zone = "zone-a"
+	require.Error(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+	require.NoError(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, []string{"zone-a"}))
+
+	// a single allowed zone holds all replicas
+	topology.AllowedZones = []string{"zone-a"}
+	require.NoError(t, validateVolumeTopology(topology, podNodes, csiNodeKeys, clusterZones))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/portworx/sched-ops/k8s/externalsnapshotter"
 	opnshift "github.com/portworx/sched-ops/k8s/openshift"
 	"github.com/portworx/sched-ops/task"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/node/vsphere"
 	"github.com/portworx/torpedo/drivers/scheduler"
@@ -899,6 +900,11 @@ func (k *openshift) String() string {
 	return SchedName
 }
 
+// Capabilities returns the k8s capabilities extended with scheduler upgrade and node recycle
+func (k *openshift) Capabilities() driver_api.Capabilities {
+	return k.K8s.Capabilities().With(scheduler.CapabilityUpgrade, scheduler.CapabilityRecycleNode)
+}
+
 func getParsedVersion(version string) (semver.Version, error) {
 	if versionReg.MatchString(version) {
 		cli := &http.Client{}
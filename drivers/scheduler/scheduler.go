@@ -28,6 +28,25 @@ const (
 	SecretK8S                         = "k8s"
 )
 
+const (
+	// CapabilityScale is the capability to scale applications
+	CapabilityScale api.Capability = "scale"
+	// CapabilityRollout is the capability to roll out and roll back applications
+	CapabilityRollout api.Capability = "rollout"
+	// CapabilityDrain is the capability to drain nodes
+	CapabilityDrain api.Capability = "drain"
+	// CapabilityTopology is the capability to validate the topology labels of applications
+	CapabilityTopology api.Capability = "topology"
+	// CapabilityCSISnapshot is the capability to create CSI snapshots
+	CapabilityCSISnapshot api.Capability = "csi-snapshot"
+	// CapabilityAutopilot is the capability to manage autopilot rules
+	CapabilityAutopilot api.Capability = "autopilot"
+	// CapabilityUpgrade is the capability to upgrade the scheduler
+	CapabilityUpgrade api.Capability = "upgrade"
+	// CapabilityRecycleNode is the capability to recycle nodes
+	CapabilityRecycleNode api.Capability = "recycle-node"
+)
+
 // Context holds the execution context of a test task.
 type Context struct {
 	// UID unique object identifier
@@ -153,6 +172,9 @@ type Driver interface {
 	// String returns the string name of this driver.
 	String() string
 
+	// Capabilities returns the set of optional operations supported by the driver
+	Capabilities() api.Capabilities
+
 	// IsNodeReady checks if node is in ready state. Returns nil if ready.
 	IsNodeReady(n node.Node) error
 
@@ -202,6 +224,14 @@ type Driver interface {
 	// ValidateTopologyLabel validate topology Labels for App
 	ValidateTopologyLabel(cc *Context) error
 
+	// ValidateZoneTopology validates that PV node affinity, storage class allowed topologies, volume
+	// replica placement and pod placement of the given app agree on the cluster zones
+	ValidateZoneTopology(cc *Context) (*TopologyReport, error)
+
+	// ValidateVolumePlacementStrategy validates that the replica placement of the volumes of the given app
+	// satisfies the volume placement strategy referenced by their storage class
+	ValidateVolumePlacementStrategy(cc *Context) (*PlacementReport, error)
+
 	// GetSnapShotData retruns volumesnapshotdata
 	GetSnapShotData(ctx *Context, snapshotName, snapshotNameSpace string) (*snapv1.VolumeSnapshotData, error)
 
@@ -241,6 +271,14 @@ type Driver interface {
 	// GetScaleFactorMap gets a map of current applications to their new scales, based on "factor"
 	GetScaleFactorMap(*Context) (map[string]int32, error)
 
+	// RolloutApplication does a rolling update of the Deployments and StatefulSets of the given context
+	// and waits for the rollout to finish
+	RolloutApplication(*Context, RolloutOptions) error
+
+	// RollbackApplication rolls the Deployments and StatefulSets of the given context back to their
+	// previous revision and waits for the rollback to finish
+	RollbackApplication(ctx *Context, timeout time.Duration) error
+
 	// StopSchedOnNode stops scheduler service on the given node
 	StopSchedOnNode(n node.Node) error
 
@@ -262,6 +300,9 @@ type Driver interface {
 	// PrepareNodeToDecommission prepares a given node for decommissioning
 	PrepareNodeToDecommission(n node.Node, provisioner string) error
 
+	// DrainNode cordons the given node and evicts its pods through the eviction API, honoring PodDisruptionBudgets
+	DrainNode(n node.Node, opts DrainOptions) (*DrainReport, error)
+
 	// IsScalable check if a given spec is scalable or not
 	IsScalable(spec interface{}) bool
 
@@ -422,6 +463,97 @@ type Event struct {
 	Type      string
 }
 
+// ReplicaPlacement describes where a single volume replica is placed
+type ReplicaPlacement struct {
+	// ReplicaSetIndex is the index of the replica set the replica belongs to
+	ReplicaSetIndex int
+	// NodeID is the volume driver ID of the node holding the replica
+	NodeID string
+	// NodeName is the scheduler name of the node holding the replica
+	NodeName string
+	// Zone is the zone of the node holding the replica
+	Zone string
+	// PoolUUID is the uuid of the storage pool holding the replica, if reported by the volume driver
+	PoolUUID string
+}
+
+// VolumeTopology describes the zones observed for a single volume of an app
+type VolumeTopology struct {
+	// Volume is the volume that was validated
+	Volume *volume.Volume
+	// AllowedZones are the zones allowed by the storage class allowedTopologies. Empty means any zone
+	AllowedZones []string
+	// PVAffinityZones are the zones the PV node affinity restricts the volume to. Empty means any zone
+	PVAffinityZones []string
+	// Replicas is the placement of every replica of the volume
+	Replicas []ReplicaPlacement
+	// PodZones maps the name of every pod using the volume to the zone it runs in
+	PodZones map[string]string
+}
+
+// DrainOptions are the options for draining a node
+type DrainOptions struct {
+	// Timeout is the time to wait for all pods to be evicted, including retries of evictions blocked by PodDisruptionBudgets
+	Timeout time.Duration
+	// GracePeriod is the termination grace period in seconds of evicted pods. Nil uses the grace period of the pod
+	GracePeriod *int64
+	// WaitForReattach waits until the volumes of the evicted pods are used by running pods again
+	WaitForReattach bool
+	// Force also evicts pods without a controller, which are not recreated elsewhere, like kubectl drain --force
+	Force bool
+}
+
+// DrainReport is the outcome of draining a node
+type DrainReport struct {
+	// Node is the name of the drained node
+	Node string
+	// EvictedPods are the namespace/name of the pods evicted from the node
+	EvictedPods []string
+	// UnmanagedPods are the namespace/name of the pods without a controller on the node, which are only evicted
+	// with Force
+	UnmanagedPods []string
+	// PDBBlockedEvictions is the number of times the eviction of a pod was rejected due to a PodDisruptionBudget, by pod
+	PDBBlockedEvictions map[string]int
+	// VolumeReattachTimes is the time it took from eviction until a volume was used by a running pod again, by PVC
+	VolumeReattachTimes map[string]time.Duration
+	// Duration is the time it took to drain the node
+	Duration time.Duration
+}
+
+// RolloutOptions are the options of a rolling update of an application
+type RolloutOptions struct {
+	// Image is the image rolled out to all containers running another tag of the same image. Empty keeps the current images
+	Image string
+	// Env are environment variables set on all containers. Changing them rolls out a config update
+	Env map[string]string
+	// Partition, if set, only rolls out the StatefulSet pods with an ordinal greater or equal to it
+	Partition *int32
+	// Timeout is the time to wait for the rollout to finish
+	Timeout time.Duration
+}
+
+// TopologyReport is the result of a zone topology validation for an app
+type TopologyReport struct {
+	// Volumes holds the observed topology of every volume of the app
+	Volumes []*VolumeTopology
+}
+
+// VolumePlacement describes the replica placement of a volume validated against its placement strategy
+type VolumePlacement struct {
+	// Volume is the volume that was validated
+	Volume *volume.Volume
+	// Strategy is the name of the volume placement strategy of the volume
+	Strategy string
+	// Replicas is the placement of every replica of the volume
+	Replicas []ReplicaPlacement
+}
+
+// PlacementReport is the result of a volume placement strategy validation for an app
+type PlacementReport struct {
+	// Volumes holds the observed placement of every volume of the app which has a placement strategy
+	Volumes []*VolumePlacement
+}
+
 // HelmRepo has the related info about the repo
 type HelmRepo struct {
 	RepoName    string `yaml:"reponame"`
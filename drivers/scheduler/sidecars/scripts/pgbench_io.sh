@@ -15,6 +15,20 @@ if [ -z "${DELAY}" ]; then
     DELAY=0
 fi
 
+# PROGRESS_INTERVAL is the number of seconds between the progress reports of pgbench, which torpedo measures
+# the IO of the app by. The pgbench workload driver sets it, without it pgbench only fills the databases and
+# idles afterwards
+
+# run_pgbench runs transactions on the latest database for a while, reporting progress
+run_pgbench() {
+    latest=$(psql -h ${PG_HOST} -U ${PG_USER} -Atc "select datname from pg_database where datname like 'pxdemo_%' order by datname desc limit 1")
+    if [ -n "${latest}" ]; then
+        pgbench -h ${PG_HOST} -U ${PG_USER} -P ${PROGRESS_INTERVAL} -T $1 ${latest}
+    else
+        sleep $1
+    fi
+}
+
 pgbench_folder=/pgbench
 pgbench_state_file=${pgbench_folder}/pgbench_state.file
 
@@ -27,7 +41,11 @@ do
         echo "all done"
         while :
         do
-            sleep 600
+            if [ -n "${PROGRESS_INTERVAL}" ]; then
+                run_pgbench 600 || sleep 600
+            else
+                sleep 600
+            fi
         done
     else
         actual_data_size=$(du -s /var/lib/postgresql/* | cut -f1)
@@ -44,6 +62,9 @@ do
             if [ $? -ne 0 ]; then exit 1; fi
             pgbench -h ${PG_HOST} -U ${PG_USER} -i -s 10 ${database}
             if [ $? -ne 0 ]; then exit 1; fi
+            if [ -n "${PROGRESS_INTERVAL}" ]; then
+                pgbench -h ${PG_HOST} -U ${PG_USER} -P ${PROGRESS_INTERVAL} -T 60 ${database}
+            fi
             echo "Sleeping for ${DELAY} seconds"
             sleep $DELAY
         fi
@@ -2,7 +2,10 @@ package aws
 
 import (
 	"fmt"
+
+	"github.com/libopenstorage/openstorage/api"
 	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/drivers/volume/clouddisk"
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
 	"github.com/portworx/torpedo/pkg/errors"
 	"github.com/portworx/torpedo/pkg/log"
@@ -22,6 +25,7 @@ var provisioners = map[torpedovolume.StorageProvisionerType]torpedovolume.Storag
 
 type aws struct {
 	schedOps schedops.Driver
+	disks    *clouddisk.Validator
 	torpedovolume.DefaultDriver
 }
 
@@ -50,9 +54,34 @@ func (d *aws) Init(sched, nodeDriver, token, storageProvisioner, csiGenericDrive
 	} else {
 		return fmt.Errorf("Provisioner is empty for volume driver: %s", DriverName)
 	}
+	cloud, err := newEC2Cloud()
+	if err != nil {
+		return err
+	}
+	d.disks = clouddisk.NewValidator(cloud, ebsParams)
 	return nil
 }
 
+// InspectVolume returns the volume with the given PV name from its EBS volume
+func (d *aws) InspectVolume(name string) (*api.Volume, error) {
+	return d.disks.InspectVolume(name)
+}
+
+// ValidateCreateVolume validates the EBS volume of the PV against its claim and storage class
+func (d *aws) ValidateCreateVolume(name string, params map[string]string) error {
+	return d.disks.ValidateCreateVolume(name, params)
+}
+
+// ValidateVolumeSetup validates that the EBS volume is attached to the instances running its pods
+func (d *aws) ValidateVolumeSetup(vol *torpedovolume.Volume) error {
+	return d.disks.ValidateVolumeSetup(vol)
+}
+
+// ValidateDeleteVolume validates that the EBS volume is deleted with its PV
+func (d *aws) ValidateDeleteVolume(vol *torpedovolume.Volume) error {
+	return d.disks.ValidateDeleteVolume(vol)
+}
+
 func (d *aws) ValidateStorageCluster(endpointURL, endpointVersion string) error {
 	// TODO: Add implementation
 	return &errors.ErrNotSupported{
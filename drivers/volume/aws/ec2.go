@@ -0,0 +1,111 @@
+package aws
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	aws_pkg "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/portworx/torpedo/drivers/volume/clouddisk"
+	"github.com/portworx/torpedo/pkg/units"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ec2EndpointEnv overrides the EC2 endpoint, e.g. with a LocalStack URL
+	ec2EndpointEnv = "AWS_EC2_ENDPOINT"
+	// ebsCSIDriver is the name of the EBS CSI driver
+	ebsCSIDriver = "ebs.csi.aws.com"
+	// errCodeVolumeNotFound is the EC2 error code for an unknown volume ID
+	errCodeVolumeNotFound = "InvalidVolume.NotFound"
+)
+
+// ebsParams are the storage class parameters of the in-tree and CSI EBS provisioners
+var ebsParams = clouddisk.ParamKeys{
+	Type:           []string{"type"},
+	IOPS:           []string{"iops"},
+	ThroughputMBps: []string{"throughput"},
+	Encrypted:      []string{"encrypted"},
+	KeyID:          []string{"kmsKeyId"},
+}
+
+// ec2Cloud looks up EBS volumes through the EC2 API
+type ec2Cloud struct {
+	svc *ec2.EC2
+}
+
+func newEC2Cloud() (*ec2Cloud, error) {
+	sess, err := session.NewSessionWithOptions(session.Options{
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session. Err: %v", err)
+	}
+	config := aws_pkg.NewConfig()
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		config = config.WithRegion(region)
+	}
+	if endpoint := os.Getenv(ec2EndpointEnv); endpoint != "" {
+		config = config.WithEndpoint(endpoint)
+	}
+	return &ec2Cloud{svc: ec2.New(sess, config)}, nil
+}
+
+// DiskID returns the EBS volume ID of an in-tree or CSI PV. In-tree volume IDs may be prefixed with the zone,
+// e.g. aws://us-east-1a/vol-0123.
+func (c *ec2Cloud) DiskID(pv *corev1.PersistentVolume) (string, error) {
+	if ebs := pv.Spec.AWSElasticBlockStore; ebs != nil {
+		return ebs.VolumeID[strings.LastIndex(ebs.VolumeID, "/")+1:], nil
+	}
+	if csi := pv.Spec.CSI; csi != nil && csi.Driver == ebsCSIDriver {
+		return csi.VolumeHandle, nil
+	}
+	return "", fmt.Errorf("PV %s is not an EBS volume", pv.Name)
+}
+
+// GetDisk describes the EBS volume with the given ID
+func (c *ec2Cloud) GetDisk(id string) (*clouddisk.Disk, error) {
+	out, err := c.svc.DescribeVolumes(&ec2.DescribeVolumesInput{
+		VolumeIds: []*string{aws_pkg.String(id)},
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == errCodeVolumeNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to describe EBS volume %s. Err: %v", id, err)
+	}
+	if len(out.Volumes) == 0 {
+		return nil, nil
+	}
+	vol := out.Volumes[0]
+	disk := &clouddisk.Disk{
+		ID:             aws_pkg.StringValue(vol.VolumeId),
+		SizeBytes:      uint64(aws_pkg.Int64Value(vol.Size)) * units.GiB,
+		Type:           aws_pkg.StringValue(vol.VolumeType),
+		IOPS:           aws_pkg.Int64Value(vol.Iops),
+		ThroughputMBps: aws_pkg.Int64Value(vol.Throughput),
+		Encrypted:      aws_pkg.BoolValue(vol.Encrypted),
+		KeyID:          aws_pkg.StringValue(vol.KmsKeyId),
+	}
+	for _, attachment := range vol.Attachments {
+		if aws_pkg.StringValue(attachment.State) == ec2.VolumeAttachmentStateAttached {
+			disk.AttachedTo = append(disk.AttachedTo, aws_pkg.StringValue(attachment.InstanceId))
+		}
+	}
+	return disk, nil
+}
+
+// InstanceID returns the EC2 instance ID from a provider ID of the form aws:///us-east-1a/i-0123
+func (c *ec2Cloud) InstanceID(providerID string) (string, error) {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return "", fmt.Errorf("provider ID %q is not an AWS provider ID", providerID)
+	}
+	instance := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(instance, "i-") {
+		return "", fmt.Errorf("provider ID %q has no EC2 instance ID", providerID)
+	}
+	return instance, nil
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/portworx/torpedo/pkg/units"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeEC2 answers DescribeVolumes like EC2 or LocalStack do for a single known volume
+func fakeEC2(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "DescribeVolumes", r.Form.Get("Action"))
+		if r.Form.Get("VolumeId.1") != "vol-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<Response><Errors><Error><Code>InvalidVolume.NotFound</Code><Message>not found</Message></Error></Errors><RequestID>1</RequestID></Response>`)
+			return
+		}
+		fmt.Fprint(w, `<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>1</requestId>
+  <volumeSet>
+    <item>
+      <volumeId>vol-1</volumeId>
+      <size>20</size>
+      <volumeType>gp3</volumeType>
+      <iops>3000</iops>
+      <throughput>125</throughput>
+      <encrypted>true</encrypted>
+      <kmsKeyId>arn:aws:kms:us-east-1:123:key/abc</kmsKeyId>
+      <attachmentSet>
+        <item><volumeId>vol-1</volumeId><instanceId>i-1</instanceId><status>attached</status></item>
+        <item><volumeId>vol-1</volumeId><instanceId>i-2</instanceId><status>detaching</status></item>
+      </attachmentSet>
+    </item>
+  </volumeSet>
+</DescribeVolumesResponse>`)
+	}))
+}
+
+func TestEC2GetDisk(t *testing.T) {
+	server := fakeEC2(t)
+	defer server.Close()
+	t.Setenv(ec2EndpointEnv, server.URL)
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	cloud, err := newEC2Cloud()
+	require.NoError(t, err)
+
+	disk, err := cloud.GetDisk("vol-1")
+	require.NoError(t, err)
+	require.NotNil(t, disk)
+	require.Equal(t, uint64(20*units.GiB), disk.SizeBytes)
+	require.Equal(t, "gp3", disk.Type)
+	require.Equal(t, int64(3000), disk.IOPS)
+	require.Equal(t, int64(125), disk.ThroughputMBps)
+	require.True(t, disk.Encrypted)
+	require.Equal(t, "arn:aws:kms:us-east-1:123:key/abc", disk.KeyID)
+	require.Equal(t, []string{"i-1"}, disk.AttachedTo)
+
+	disk, err = cloud.GetDisk("vol-2")
+	require.NoError(t, err)
+	require.Nil(t, disk)
+}
+
+func TestEC2DiskAndInstanceID(t *testing.T) {
+	cloud := &ec2Cloud{}
+
+	id, err := cloud.DiskID(&corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+			AWSElasticBlockStore: &corev1.AWSElasticBlockStoreVolumeSource{VolumeID: "aws://us-east-1a/vol-1"},
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "vol-1", id)
+
+	id, err = cloud.DiskID(&corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+			CSI: &corev1.CSIPersistentVolumeSource{Driver: ebsCSIDriver, VolumeHandle: "vol-2"},
+		}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "vol-2", id)
+
+	_, err = cloud.DiskID(&corev1.PersistentVolume{ObjectMeta: metav1.ObjectMeta{Name: "pv"}})
+	require.Error(t, err)
+
+	instance, err := cloud.InstanceID("aws:///us-east-1a/i-0123")
+	require.NoError(t, err)
+	require.Equal(t, "i-0123", instance)
+	_, err = cloud.InstanceID("gce://project/zone/name")
+	require.Error(t, err)
+}
@@ -2,7 +2,10 @@ package azure
 
 import (
 	"fmt"
+
+	"github.com/libopenstorage/openstorage/api"
 	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/drivers/volume/clouddisk"
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
 	"github.com/portworx/torpedo/pkg/errors"
 	"github.com/portworx/torpedo/pkg/log"
@@ -22,6 +25,7 @@ var provisioners = map[torpedovolume.StorageProvisionerType]torpedovolume.Storag
 
 type azure struct {
 	schedOps schedops.Driver
+	disks    *clouddisk.Validator
 	torpedovolume.DefaultDriver
 }
 
@@ -58,9 +62,34 @@ func (d *azure) Init(sched, nodeDriver, token, storageProvisioner, csiGenericDri
 	} else {
 		torpedovolume.StorageProvisioner = provisioners[torpedovolume.DefaultStorageProvisioner]
 	}
+	cloud, err := newDiskCloud()
+	if err != nil {
+		return err
+	}
+	d.disks = clouddisk.NewValidator(cloud, azureDiskParams)
 	return nil
 }
 
+// InspectVolume returns the volume with the given PV name from its managed disk
+func (d *azure) InspectVolume(name string) (*api.Volume, error) {
+	return d.disks.InspectVolume(name)
+}
+
+// ValidateCreateVolume validates the managed disk of the PV against its claim and storage class
+func (d *azure) ValidateCreateVolume(name string, params map[string]string) error {
+	return d.disks.ValidateCreateVolume(name, params)
+}
+
+// ValidateVolumeSetup validates that the managed disk is attached to the VMs running its pods
+func (d *azure) ValidateVolumeSetup(vol *torpedovolume.Volume) error {
+	return d.disks.ValidateVolumeSetup(vol)
+}
+
+// ValidateDeleteVolume validates that the managed disk is deleted with its PV
+func (d *azure) ValidateDeleteVolume(vol *torpedovolume.Volume) error {
+	return d.disks.ValidateDeleteVolume(vol)
+}
+
 func init() {
 	torpedovolume.Register(DriverName, provisioners, &azure{})
 }
@@ -0,0 +1,129 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2019-12-01/compute"
+	"github.com/Azure/go-autorest/autorest"
+	azure_pkg "github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/portworx/torpedo/drivers/volume/clouddisk"
+	"github.com/portworx/torpedo/pkg/units"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// resourceManagerEndpointEnv overrides the Azure resource manager endpoint, e.g. with the URL of a mock
+	resourceManagerEndpointEnv = "AZURE_RESOURCE_MANAGER_ENDPOINT"
+	// azureDiskCSIDriver is the name of the Azure Disk CSI driver
+	azureDiskCSIDriver = "disk.csi.azure.com"
+	// azureProviderIDPrefix is the prefix of the provider IDs of Azure nodes, followed by the VM resource ID
+	azureProviderIDPrefix = "azure://"
+)
+
+// azureDiskParams are the storage class parameters of the in-tree and CSI Azure Disk provisioners. Managed disks
+// are always encrypted at rest, a disk encryption set selects a customer managed key.
+var azureDiskParams = clouddisk.ParamKeys{
+	Type:           []string{"skuName", "storageaccounttype"},
+	IOPS:           []string{"diskIOPSReadWrite"},
+	ThroughputMBps: []string{"diskMBpsReadWrite"},
+	KeyID:          []string{"diskEncryptionSetID"},
+}
+
+// diskCloud looks up managed disks through the Azure resource manager
+type diskCloud struct {
+	baseURI    string
+	authorizer autorest.Authorizer
+}
+
+func newDiskCloud() (*diskCloud, error) {
+	baseURI := os.Getenv(resourceManagerEndpointEnv)
+	if baseURI != "" && os.Getenv(auth.ClientID) == "" {
+		// mocks of the resource manager do not authenticate
+		return &diskCloud{baseURI: baseURI, authorizer: autorest.NullAuthorizer{}}, nil
+	}
+	if baseURI == "" {
+		baseURI = compute.DefaultBaseURI
+	}
+	authorizer, err := auth.NewAuthorizerFromEnvironment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure authorizer. Err: %v", err)
+	}
+	return &diskCloud{baseURI: baseURI, authorizer: authorizer}, nil
+}
+
+// DiskID returns the resource ID of the managed disk of an in-tree or CSI PV
+func (c *diskCloud) DiskID(pv *corev1.PersistentVolume) (string, error) {
+	if disk := pv.Spec.AzureDisk; disk != nil {
+		return disk.DataDiskURI, nil
+	}
+	if csi := pv.Spec.CSI; csi != nil && csi.Driver == azureDiskCSIDriver {
+		return csi.VolumeHandle, nil
+	}
+	return "", fmt.Errorf("PV %s is not an Azure disk", pv.Name)
+}
+
+// GetDisk gets the managed disk with the given resource ID
+func (c *diskCloud) GetDisk(id string) (*clouddisk.Disk, error) {
+	resource, err := azure_pkg.ParseResourceID(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid disk resource ID %s. Err: %v", id, err)
+	}
+	client := compute.NewDisksClientWithBaseURI(c.baseURI, resource.SubscriptionID)
+	client.Authorizer = c.authorizer
+	result, err := client.Get(context.TODO(), resource.ResourceGroup, resource.ResourceName)
+	if err != nil {
+		if result.Response.Response != nil && result.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get Azure disk %s. Err: %v", id, err)
+	}
+
+	disk := &clouddisk.Disk{
+		ID: id,
+		// managed disks are always encrypted at rest, with a platform or a customer managed key
+		Encrypted: true,
+	}
+	if result.Sku != nil {
+		disk.Type = string(result.Sku.Name)
+	}
+	if props := result.DiskProperties; props != nil {
+		if props.DiskSizeBytes != nil {
+			disk.SizeBytes = uint64(*props.DiskSizeBytes)
+		} else if props.DiskSizeGB != nil {
+			disk.SizeBytes = uint64(*props.DiskSizeGB) * units.GiB
+		}
+		if props.DiskIOPSReadWrite != nil {
+			disk.IOPS = *props.DiskIOPSReadWrite
+		}
+		if props.DiskMBpsReadWrite != nil {
+			disk.ThroughputMBps = *props.DiskMBpsReadWrite
+		}
+		if props.Encryption != nil && props.Encryption.DiskEncryptionSetID != nil {
+			disk.KeyID = *props.Encryption.DiskEncryptionSetID
+		}
+	}
+	// shared disks list all their VMs in managedByExtended, others only have managedBy
+	if result.ManagedByExtended != nil && len(*result.ManagedByExtended) > 0 {
+		for _, vm := range *result.ManagedByExtended {
+			disk.AttachedTo = append(disk.AttachedTo, strings.ToLower(vm))
+		}
+	} else if result.ManagedBy != nil && *result.ManagedBy != "" {
+		disk.AttachedTo = append(disk.AttachedTo, strings.ToLower(*result.ManagedBy))
+	}
+	return disk, nil
+}
+
+// InstanceID returns the lower cased VM resource ID from a provider ID of the form
+// azure:///subscriptions/<id>/resourceGroups/<rg>/providers/Microsoft.Compute/virtualMachines/<vm>, as
+// resource IDs are case insensitive
+func (c *diskCloud) InstanceID(providerID string) (string, error) {
+	if !strings.HasPrefix(providerID, azureProviderIDPrefix) {
+		return "", fmt.Errorf("provider ID %q is not an Azure provider ID", providerID)
+	}
+	return strings.ToLower(strings.TrimPrefix(providerID, azureProviderIDPrefix)), nil
+}
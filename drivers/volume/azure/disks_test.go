@@ -0,0 +1,69 @@
+package azure
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/portworx/torpedo/pkg/units"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDiskID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/pvc-1"
+	testVMID   = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/Node-1"
+)
+
+// fakeResourceManager answers disk gets like the resource manager does for a single known disk
+func fakeResourceManager() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method != http.MethodGet || r.URL.Path != testDiskID {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": "ResourceNotFound", "message": "not found"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{
+  "id": %q,
+  "name": "pvc-1",
+  "managedBy": %q,
+  "sku": {"name": "UltraSSD_LRS"},
+  "properties": {
+    "diskSizeGB": 32,
+    "diskIOPSReadWrite": 2000,
+    "diskMBpsReadWrite": 100,
+    "encryption": {"type": "EncryptionAtRestWithCustomerKey", "diskEncryptionSetId": "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"}
+  }
+}`, testDiskID, testVMID)
+	}))
+}
+
+func TestGetDisk(t *testing.T) {
+	server := fakeResourceManager()
+	defer server.Close()
+	t.Setenv(resourceManagerEndpointEnv, server.URL)
+	t.Setenv(auth.ClientID, "")
+
+	cloud, err := newDiskCloud()
+	require.NoError(t, err)
+
+	disk, err := cloud.GetDisk(testDiskID)
+	require.NoError(t, err)
+	require.NotNil(t, disk)
+	require.Equal(t, uint64(32*units.GiB), disk.SizeBytes)
+	require.Equal(t, "UltraSSD_LRS", disk.Type)
+	require.Equal(t, int64(2000), disk.IOPS)
+	require.Equal(t, int64(100), disk.ThroughputMBps)
+	require.True(t, disk.Encrypted)
+	require.Contains(t, disk.KeyID, "diskEncryptionSets/des")
+
+	instance, err := cloud.InstanceID("azure://" + testVMID)
+	require.NoError(t, err)
+	require.Equal(t, []string{instance}, disk.AttachedTo)
+
+	disk, err = cloud.GetDisk("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/pvc-2")
+	require.NoError(t, err)
+	require.Nil(t, disk)
+}
@@ -0,0 +1,143 @@
+// Package clouddisk validates volumes provisioned as cloud block disks (EBS, Azure Disk, GCE PD) against the
+// control plane of the cloud, independent of which cloud provides the disk.
+package clouddisk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Disk is a cloud block disk as reported by the cloud control plane
+type Disk struct {
+	// ID is the cloud ID of the disk
+	ID string
+	// SizeBytes is the provisioned size of the disk
+	SizeBytes uint64
+	// Type is the disk type or SKU, e.g. gp3, Premium_LRS or pd-ssd
+	Type string
+	// IOPS is the provisioned IOPS of the disk, 0 if the disk type does not provision IOPS
+	IOPS int64
+	// ThroughputMBps is the provisioned throughput of the disk, 0 if the disk type does not provision throughput
+	ThroughputMBps int64
+	// Encrypted is true if the disk is encrypted at rest
+	Encrypted bool
+	// KeyID is the ID of the customer managed key the disk is encrypted with, empty for cloud managed keys
+	KeyID string
+	// AttachedTo are the IDs of the instances the disk is attached to, in the format of Cloud.InstanceID
+	AttachedTo []string
+}
+
+// Cloud is the control plane of a cloud which provides block disks
+type Cloud interface {
+	// DiskID returns the cloud ID of the disk backing the persistent volume
+	DiskID(pv *corev1.PersistentVolume) (string, error)
+	// GetDisk returns the disk with the given ID, or nil if it does not exist
+	GetDisk(id string) (*Disk, error)
+	// InstanceID returns the instance ID of a kubernetes node from its provider ID
+	InstanceID(providerID string) (string, error)
+}
+
+// ParamKeys are the storage class parameters with which a provisioner configures a disk. A property is not
+// validated if none of its keys is set in the storage class.
+type ParamKeys struct {
+	Type           []string
+	IOPS           []string
+	ThroughputMBps []string
+	Encrypted      []string
+	KeyID          []string
+}
+
+// Expectations are the properties a disk is expected to have
+type Expectations struct {
+	// MinSizeBytes is the size requested by the claim, disks may be rounded up
+	MinSizeBytes   uint64
+	Type           string
+	IOPS           int64
+	ThroughputMBps int64
+	// Encrypted is nil if the storage class does not set encryption
+	Encrypted *bool
+	KeyID     string
+}
+
+// Expectations returns the expectations for a disk of the given size from the storage class params
+func (k ParamKeys) Expectations(params map[string]string, sizeBytes uint64) (Expectations, error) {
+	exp := Expectations{
+		MinSizeBytes: sizeBytes,
+		Type:         lookup(params, k.Type),
+		KeyID:        lookup(params, k.KeyID),
+	}
+	var err error
+	if exp.IOPS, err = lookupInt(params, k.IOPS); err != nil {
+		return exp, err
+	}
+	if exp.ThroughputMBps, err = lookupInt(params, k.ThroughputMBps); err != nil {
+		return exp, err
+	}
+	if value := lookup(params, k.Encrypted); value != "" {
+		encrypted, err := strconv.ParseBool(value)
+		if err != nil {
+			return exp, fmt.Errorf("invalid encryption parameter %q. Err: %v", value, err)
+		}
+		exp.Encrypted = &encrypted
+	}
+	// a customer managed key implies encryption
+	if exp.KeyID != "" && exp.Encrypted == nil {
+		encrypted := true
+		exp.Encrypted = &encrypted
+	}
+	return exp, nil
+}
+
+// Validate returns an error listing every property of the disk which does not match the expectations
+func (e Expectations) Validate(disk *Disk) error {
+	var mismatches []string
+	if disk.SizeBytes < e.MinSizeBytes {
+		mismatches = append(mismatches, fmt.Sprintf("size %d bytes is less than requested %d bytes", disk.SizeBytes, e.MinSizeBytes))
+	}
+	if e.Type != "" && !strings.EqualFold(disk.Type, e.Type) {
+		mismatches = append(mismatches, fmt.Sprintf("type %s, expected %s", disk.Type, e.Type))
+	}
+	if e.IOPS != 0 && disk.IOPS != e.IOPS {
+		mismatches = append(mismatches, fmt.Sprintf("%d IOPS, expected %d", disk.IOPS, e.IOPS))
+	}
+	if e.ThroughputMBps != 0 && disk.ThroughputMBps != e.ThroughputMBps {
+		mismatches = append(mismatches, fmt.Sprintf("throughput %d MBps, expected %d", disk.ThroughputMBps, e.ThroughputMBps))
+	}
+	if e.Encrypted != nil && disk.Encrypted != *e.Encrypted {
+		mismatches = append(mismatches, fmt.Sprintf("encrypted is %t, expected %t", disk.Encrypted, *e.Encrypted))
+	}
+	if e.KeyID != "" && !strings.EqualFold(disk.KeyID, e.KeyID) {
+		mismatches = append(mismatches, fmt.Sprintf("encryption key %s, expected %s", disk.KeyID, e.KeyID))
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("disk %s does not match its storage class: %s", disk.ID, strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+func lookup(params map[string]string, keys []string) string {
+	for _, key := range keys {
+		for param, value := range params {
+			// in-tree provisioners accept parameter keys in any case
+			if strings.EqualFold(param, key) && value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+func lookupInt(params map[string]string, keys []string) (int64, error) {
+	value := lookup(params, keys)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid parameter %v value %q. Err: %v", keys, value, err)
+	}
+	return i, nil
+}
@@ -0,0 +1,78 @@
+package clouddisk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var testParams = ParamKeys{
+	Type:           []string{"type"},
+	IOPS:           []string{"iops"},
+	ThroughputMBps: []string{"throughput"},
+	Encrypted:      []string{"encrypted"},
+	KeyID:          []string{"kmsKeyId"},
+}
+
+func TestExpectationsValidate(t *testing.T) {
+	disk := &Disk{
+		ID:             "vol-1",
+		SizeBytes:      10 << 30,
+		Type:           "gp3",
+		IOPS:           4000,
+		ThroughputMBps: 250,
+		Encrypted:      true,
+		KeyID:          "arn:aws:kms:us-east-1:123:key/abc",
+	}
+
+	type testCase struct {
+		params        map[string]string
+		size          uint64
+		expectedToErr bool
+	}
+	testCases := []testCase{
+		{params: map[string]string{}, size: 10 << 30},
+		{params: map[string]string{"type": "GP3", "iops": "4000", "throughput": "250", "encrypted": "true"}, size: 9 << 30},
+		{params: map[string]string{"kmskeyid": "arn:aws:kms:us-east-1:123:key/abc"}, size: 10 << 30},
+		{params: map[string]string{}, size: 11 << 30, expectedToErr: true},
+		{params: map[string]string{"type": "io2"}, size: 10 << 30, expectedToErr: true},
+		{params: map[string]string{"iops": "3000"}, size: 10 << 30, expectedToErr: true},
+		{params: map[string]string{"encrypted": "false"}, size: 10 << 30, expectedToErr: true},
+		{params: map[string]string{"kmsKeyId": "arn:aws:kms:us-east-1:123:key/other"}, size: 10 << 30, expectedToErr: true},
+	}
+	for _, tc := range testCases {
+		exp, err := testParams.Expectations(tc.params, tc.size)
+		require.NoError(t, err)
+		err = exp.Validate(disk)
+		if tc.expectedToErr {
+			require.Error(t, err, "params %v", tc.params)
+		} else {
+			require.NoError(t, err, "params %v", tc.params)
+		}
+	}
+
+	_, err := testParams.Expectations(map[string]string{"iops": "many"}, 0)
+	require.Error(t, err)
+}
+
+func TestZone(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{corev1.LabelZoneFailureDomain: "us-central1-b"}},
+	}
+	require.Equal(t, "us-central1-b", Zone(pv))
+
+	pv.Spec.NodeAffinity = &corev1.VolumeNodeAffinity{
+		Required: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:      corev1.LabelTopologyZone,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{"us-central1-a"},
+				}},
+			}},
+		},
+	}
+	require.Equal(t, "us-central1-a", Zone(pv))
+}
@@ -0,0 +1,39 @@
+package clouddisk
+
+import "fmt"
+
+// ErrFailedToInspectVolume error type for failing to inspect the disk of a volume
+type ErrFailedToInspectVolume struct {
+	// ID is the ID/name of the volume that failed to inspect
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToInspectVolume) Error() string {
+	return fmt.Sprintf("Failed to inspect volume: %v due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToValidateVolume error type for a disk which does not match its volume
+type ErrFailedToValidateVolume struct {
+	// ID is the ID/name of the volume that failed to validate
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToValidateVolume) Error() string {
+	return fmt.Sprintf("Failed to validate volume: %v due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToDeleteVolume error type for a disk which is not deleted with its volume
+type ErrFailedToDeleteVolume struct {
+	// ID is the ID/name of the volume that failed to delete
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToDeleteVolume) Error() string {
+	return fmt.Sprintf("Failed to delete volume: %v due to err: %v", e.ID, e.Cause)
+}
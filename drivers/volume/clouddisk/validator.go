@@ -0,0 +1,305 @@
+package clouddisk
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/sched-ops/task"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// pvcNameParam is the volume param holding the name of the PVC of a volume
+	pvcNameParam = "pvc_name"
+	// pvcNamespaceParam is the volume param holding the namespace of the PVC of a volume
+	pvcNamespaceParam = "pvc_namespace"
+	validateTimeout   = 5 * time.Minute
+	// deleteTimeout is the time to wait for a disk to be deleted after its PV. Disks are deleted only once
+	// they are detached, which takes a while on some clouds.
+	deleteTimeout = 10 * time.Minute
+	validateRetry = 10 * time.Second
+)
+
+var k8sCore = core.Instance()
+
+// Validator validates the volumes of a cloud disk provisioner against the disks in the cloud
+type Validator struct {
+	cloud  Cloud
+	params ParamKeys
+	sync.Mutex
+	// disks are the disks of the volumes seen so far by PV name, as the PV is gone when its deletion is validated
+	disks map[string]volumeDisk
+}
+
+type volumeDisk struct {
+	id     string
+	retain bool
+}
+
+// NewValidator returns a validator for the disks of the given cloud configured by the given storage class params
+func NewValidator(cloud Cloud, params ParamKeys) *Validator {
+	return &Validator{
+		cloud:  cloud,
+		params: params,
+		disks:  make(map[string]volumeDisk),
+	}
+}
+
+// InspectVolume returns the volume with the given PV name as seen by the cloud
+func (v *Validator) InspectVolume(name string) (*api.Volume, error) {
+	pv, err := k8sCore.GetPersistentVolume(name)
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+	}
+	disk, err := v.getDisk(pv)
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+	}
+	vol := &api.Volume{
+		Id: disk.ID,
+		Locator: &api.VolumeLocator{
+			Name:         pv.Name,
+			VolumeLabels: pv.Labels,
+		},
+		Spec: &api.VolumeSpec{
+			Size:      disk.SizeBytes,
+			Encrypted: disk.Encrypted,
+		},
+		Status: api.VolumeStatus_VOLUME_STATUS_UP,
+		State:  api.VolumeState_VOLUME_STATE_DETACHED,
+	}
+	if len(disk.AttachedTo) > 0 {
+		vol.State = api.VolumeState_VOLUME_STATE_ATTACHED
+		if vol.AttachedOn, err = v.nodeForInstance(disk.AttachedTo[0]); err != nil {
+			return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+		}
+	}
+	return vol, nil
+}
+
+// ValidateCreateVolume validates that the disk of the PV with the given name exists with the size requested
+// by its claim and with the type, IOPS, throughput and encryption of its storage class
+func (v *Validator) ValidateCreateVolume(name string, params map[string]string) error {
+	t := func() (interface{}, bool, error) {
+		pv, err := k8sCore.GetPersistentVolume(name)
+		if err != nil {
+			return nil, true, err
+		}
+		if pv.Status.Phase != corev1.VolumeBound {
+			return nil, true, fmt.Errorf("PV %s is in phase %s, expected %s", pv.Name, pv.Status.Phase, corev1.VolumeBound)
+		}
+		disk, err := v.getDisk(pv)
+		if err != nil {
+			return nil, true, err
+		}
+		capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+		size := uint64(capacity.Value())
+		if pvcName, ok := params[pvcNameParam]; ok {
+			pvc, err := k8sCore.GetPersistentVolumeClaim(pvcName, params[pvcNamespaceParam])
+			if err != nil {
+				return nil, true, err
+			}
+			request := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			size = uint64(request.Value())
+		}
+		return &validateRequest{disk: disk, size: size}, false, nil
+	}
+	out, err := task.DoRetryWithTimeout(t, validateTimeout, validateRetry)
+	if err != nil {
+		return &ErrFailedToValidateVolume{ID: name, Cause: err.Error()}
+	}
+	req := out.(*validateRequest)
+
+	exp, err := v.params.Expectations(params, req.size)
+	if err != nil {
+		return &ErrFailedToValidateVolume{ID: name, Cause: err.Error()}
+	}
+	if err := exp.Validate(req.disk); err != nil {
+		return &ErrFailedToValidateVolume{ID: name, Cause: err.Error()}
+	}
+	log.Infof("Validated disk %s of volume %s: size %d bytes, type %s, encrypted %t", req.disk.ID, name, req.disk.SizeBytes, req.disk.Type, req.disk.Encrypted)
+	return nil
+}
+
+type validateRequest struct {
+	disk *Disk
+	size uint64
+}
+
+// ValidateVolumeSetup validates that the disk of the volume is attached to the instances of the nodes which
+// run the pods using its claim
+func (v *Validator) ValidateVolumeSetup(vol *torpedovolume.Volume) error {
+	t := func() (interface{}, bool, error) {
+		pv, err := k8sCore.GetPersistentVolume(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		disk, err := v.getDisk(pv)
+		if err != nil {
+			return nil, true, err
+		}
+		expected, err := v.podInstances(vol)
+		if err != nil {
+			return nil, true, err
+		}
+		attached := make(map[string]bool)
+		for _, instance := range disk.AttachedTo {
+			attached[instance] = true
+		}
+		for nodeName, instance := range expected {
+			if !attached[instance] {
+				return nil, true, fmt.Errorf("disk %s is attached to %v, expected it on instance %s of node %s", disk.ID, disk.AttachedTo, instance, nodeName)
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, validateTimeout, validateRetry); err != nil {
+		return &ErrFailedToValidateVolume{ID: vol.ID, Cause: err.Error()}
+	}
+	return nil
+}
+
+// podInstances returns the instances of the nodes running pods which use the claim of the volume by node name
+func (v *Validator) podInstances(vol *torpedovolume.Volume) (map[string]string, error) {
+	pods, err := k8sCore.GetPodsUsingPVC(vol.Name, vol.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	instances := make(map[string]string)
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.Spec.NodeName == "" {
+			continue
+		}
+		if _, ok := instances[pod.Spec.NodeName]; ok {
+			continue
+		}
+		n, err := k8sCore.GetNodeByName(pod.Spec.NodeName)
+		if err != nil {
+			return nil, err
+		}
+		instance, err := v.cloud.InstanceID(n.Spec.ProviderID)
+		if err != nil {
+			return nil, err
+		}
+		instances[n.Name] = instance
+	}
+	return instances, nil
+}
+
+// ValidateDeleteVolume validates that the disk of the volume is deleted from the cloud, unless the reclaim
+// policy of its PV retains it
+func (v *Validator) ValidateDeleteVolume(vol *torpedovolume.Volume) error {
+	v.Lock()
+	vd, ok := v.disks[vol.ID]
+	v.Unlock()
+	if !ok {
+		pv, err := k8sCore.GetPersistentVolume(vol.ID)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				return &ErrFailedToDeleteVolume{ID: vol.ID, Cause: "PV is deleted and its disk was never inspected"}
+			}
+			return &ErrFailedToDeleteVolume{ID: vol.ID, Cause: err.Error()}
+		}
+		if vd, err = v.record(pv); err != nil {
+			return &ErrFailedToDeleteVolume{ID: vol.ID, Cause: err.Error()}
+		}
+	}
+	if vd.retain {
+		log.Infof("Disk %s of volume %s is retained by its reclaim policy", vd.id, vol.ID)
+		return nil
+	}
+
+	t := func() (interface{}, bool, error) {
+		disk, err := v.cloud.GetDisk(vd.id)
+		if err != nil {
+			return nil, true, err
+		}
+		if disk != nil {
+			return nil, true, fmt.Errorf("disk %s still exists, attached to %v", disk.ID, disk.AttachedTo)
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, deleteTimeout, validateRetry); err != nil {
+		return &ErrFailedToDeleteVolume{ID: vol.ID, Cause: err.Error()}
+	}
+	v.Lock()
+	delete(v.disks, vol.ID)
+	v.Unlock()
+	log.Infof("Validated that disk %s of volume %s is deleted", vd.id, vol.ID)
+	return nil
+}
+
+// record remembers the disk of the PV for validating its deletion
+func (v *Validator) record(pv *corev1.PersistentVolume) (volumeDisk, error) {
+	id, err := v.cloud.DiskID(pv)
+	if err != nil {
+		return volumeDisk{}, err
+	}
+	vd := volumeDisk{id: id, retain: pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain}
+	v.Lock()
+	v.disks[pv.Name] = vd
+	v.Unlock()
+	return vd, nil
+}
+
+// getDisk returns the disk of the PV and errors if it does not exist
+func (v *Validator) getDisk(pv *corev1.PersistentVolume) (*Disk, error) {
+	vd, err := v.record(pv)
+	if err != nil {
+		return nil, err
+	}
+	disk, err := v.cloud.GetDisk(vd.id)
+	if err != nil {
+		return nil, err
+	}
+	if disk == nil {
+		return nil, fmt.Errorf("disk %s of PV %s not found", vd.id, pv.Name)
+	}
+	return disk, nil
+}
+
+// nodeForInstance returns the name of the kubernetes node of the instance, or the instance if it is no node
+func (v *Validator) nodeForInstance(instance string) (string, error) {
+	nodes, err := k8sCore.GetNodes()
+	if err != nil {
+		return "", err
+	}
+	for _, n := range nodes.Items {
+		if n.Spec.ProviderID == "" {
+			continue
+		}
+		id, err := v.cloud.InstanceID(n.Spec.ProviderID)
+		if err != nil {
+			log.Warnf("Failed to get instance of node %s. Err: %v", n.Name, err)
+			continue
+		}
+		if strings.EqualFold(id, instance) {
+			return n.Name, nil
+		}
+	}
+	return instance, nil
+}
+
+// Zone returns the zone of a PV from its node affinity, as in-tree PVs do not carry it in their source
+func Zone(pv *corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity != nil && pv.Spec.NodeAffinity.Required != nil {
+		for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+			for _, expr := range term.MatchExpressions {
+				if (expr.Key == corev1.LabelTopologyZone || expr.Key == corev1.LabelZoneFailureDomain) && len(expr.Values) > 0 {
+					return expr.Values[0]
+				}
+			}
+		}
+	}
+	if zone, ok := pv.Labels[corev1.LabelTopologyZone]; ok {
+		return zone
+	}
+	return pv.Labels[corev1.LabelZoneFailureDomain]
+}
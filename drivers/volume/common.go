@@ -14,6 +14,7 @@ import (
 	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/pxctl"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -83,6 +84,23 @@ func (d *DefaultDriver) String() string {
 	return ""
 }
 
+// Capabilities returns an empty set, none of the optional operations are supported by default
+func (d *DefaultDriver) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities()
+}
+
+func (d *DefaultDriver) SetQueryMode(mode QueryMode) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "SetQueryMode()",
+	}
+}
+
+// GetQueryMode returns the SDK query mode, drivers without query modes answer all queries through their API
+func (d *DefaultDriver) GetQueryMode() QueryMode {
+	return QueryModeSDK
+}
+
 func (d *DefaultDriver) GetVolumeDriverNamespace() (string, error) {
 	return "", &errors.ErrNotSupported{
 		Type:      "Function",
@@ -247,15 +265,6 @@ func (d *DefaultDriver) ValidateCreateSnapshot(name string, params map[string]st
 	}
 }
 
-// ValidateCreateSnapshotUsingPxctl validates whether a volume snapshot has been created properly.
-// params are the custom volume options passed when creating the volume.
-func (d *DefaultDriver) ValidateCreateSnapshotUsingPxctl(name string) error {
-	return &errors.ErrNotSupported{
-		Type:      "Function",
-		Operation: "ValidateCreateSnapshotUsingPxctl()",
-	}
-}
-
 // ValidateCreateCloudsnap validates whether a volume has been created properly.
 // params are the custom volume options passed when creating the volume.
 func (d *DefaultDriver) ValidateCreateCloudsnap(name string, params map[string]string) error {
@@ -265,15 +274,6 @@ func (d *DefaultDriver) ValidateCreateCloudsnap(name string, params map[string]s
 	}
 }
 
-// ValidateCreateCloudsnapUsingPxctl validates whether a cloudsnap has been created properly.
-// params are the custom volume options passed when creating the volume.
-func (d *DefaultDriver) ValidateCreateCloudsnapUsingPxctl(name string) error {
-	return &errors.ErrNotSupported{
-		Type:      "Function",
-		Operation: "ValidateCreateCloudsnapUsingPxctl()",
-	}
-}
-
 // ValidateCreateGroupSnapshotUsingPxctl validates whether a group volumesnapshot has been created properly.
 // params are the custom volume options passed when creating the volume.
 func (d *DefaultDriver) ValidateCreateGroupSnapshotUsingPxctl() error {
@@ -835,6 +835,14 @@ func (d *DefaultDriver) GetPxctlCmdOutput(n node.Node, command string) (string,
 	}
 }
 
+// GetPxctlClient returns a client which runs pxctl commands on the given node and decodes their output
+func (d *DefaultDriver) GetPxctlClient(n node.Node) (*pxctl.Client, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GetPxctlClient()",
+	}
+}
+
 // IsPureVolume returns true if volume is FA/FB DA volumes else false
 func (d *DefaultDriver) IsPureVolume(volume *Volume) (bool, error) {
 	return false, &errors.ErrNotSupported{
@@ -915,6 +923,70 @@ func (d *DefaultDriver) GetPoolsUsedSize(n *node.Node) (map[string]string, error
 	}
 }
 
+// CreatePool creates a storage pool on the node from the given drive path or cloud drive spec and returns its UUID
+func (d *DefaultDriver) CreatePool(n *node.Node, driveSpec string) (string, error) {
+	return "", &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "CreatePool()",
+	}
+}
+
+// DeletePool deletes the storage pool with the given UUID from the node
+func (d *DefaultDriver) DeletePool(n *node.Node, poolUUID string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "DeletePool()",
+	}
+}
+
+// ValidateDeletePool deletes the storage pool and validates that the volumes on it were moved or the deletion blocked
+func (d *DefaultDriver) ValidateDeletePool(n *node.Node, poolUUID string) (bool, error) {
+	return false, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "ValidateDeletePool()",
+	}
+}
+
+// UpdatePoolLabels sets the given labels on the storage pool
+func (d *DefaultDriver) UpdatePoolLabels(n *node.Node, poolUUID string, labels map[string]string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "UpdatePoolLabels()",
+	}
+}
+
+// AddJournalDevice adds the drive as the journal device of the node
+func (d *DefaultDriver) AddJournalDevice(n *node.Node, drivePath string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "AddJournalDevice()",
+	}
+}
+
+// AddMetadataDevice adds the drive as the metadata device of the node
+func (d *DefaultDriver) AddMetadataDevice(n *node.Node, drivePath string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "AddMetadataDevice()",
+	}
+}
+
+// GetVolumeEncryption returns how the given volume is encrypted
+func (d *DefaultDriver) GetVolumeEncryption(vol *Volume) (*Encryption, error) {
+	return nil, &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "GetVolumeEncryption()",
+	}
+}
+
+// SetClusterEncryptionKey sets the key used to encrypt volumes which do not have a key of their own
+func (d *DefaultDriver) SetClusterEncryptionKey(n node.Node, keyName string) error {
+	return &errors.ErrNotSupported{
+		Type:      "Function",
+		Operation: "SetClusterEncryptionKey()",
+	}
+}
+
 // GetRebalanceJobs returns the list of rebalance jobs
 func (d *DefaultDriver) GetRebalanceJobs() ([]*api.StorageRebalanceJob, error) {
 	return nil, &errors.ErrNotSupported{
@@ -0,0 +1,339 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+// Names of the conformance checks
+const (
+	CheckCreate        = "create"
+	CheckInspect       = "inspect"
+	CheckAttach        = "attach"
+	CheckSnapshot      = "snapshot"
+	CheckClone         = "clone"
+	CheckResize        = "resize"
+	CheckDriverRestart = "driver-restart"
+	CheckNodeReboot    = "node-reboot"
+	CheckDetach        = "detach"
+	CheckDelete        = "delete"
+)
+
+// Checks returns the conformance matrix in the order it runs
+func Checks() []Check {
+	return []Check{
+		{
+			Name:        CheckCreate,
+			Description: "deploy the apps and validate that their volumes get provisioned",
+			run:         (*run).create,
+		},
+		{
+			Name:        CheckInspect,
+			Description: "inspect the app volumes and validate them against their claims",
+			Requires:    []string{CheckCreate},
+			run:         (*run).inspect,
+		},
+		{
+			Name:        CheckAttach,
+			Description: "validate that the app volumes are attached where the app runs",
+			Requires:    []string{CheckCreate},
+			run:         (*run).attach,
+		},
+		{
+			Name:             CheckSnapshot,
+			Description:      "take and validate a snapshot of every app volume",
+			Requires:         []string{CheckCreate},
+			VolumeCapability: volume.CapabilitySnapshot,
+			run:              (*run).snapshot,
+		},
+		{
+			Name:        CheckClone,
+			Description: "clone every app volume, inspect and delete the clone",
+			Requires:    []string{CheckCreate},
+			run:         (*run).clone,
+		},
+		{
+			Name:        CheckResize,
+			Description: "grow the app volumes and validate their new size",
+			Requires:    []string{CheckCreate},
+			run:         (*run).resize,
+		},
+		{
+			Name:             CheckDriverRestart,
+			Description:      "restart the volume driver on the app nodes and validate the apps keep their volumes",
+			Requires:         []string{CheckCreate},
+			VolumeCapability: volume.CapabilityDriverRestart,
+			run:              (*run).driverRestart,
+		},
+		{
+			Name:           CheckNodeReboot,
+			Description:    "reboot an app node and validate the apps come back with their volumes",
+			Requires:       []string{CheckCreate},
+			NodeCapability: node.CapabilityReboot,
+			run:            (*run).nodeReboot,
+		},
+		{
+			Name:        CheckDetach,
+			Description: "destroy the apps and validate that their volumes get detached",
+			Cleanup:     true,
+			run:         (*run).detach,
+		},
+		{
+			Name:        CheckDelete,
+			Description: "delete the app claims and validate that their volumes get deleted",
+			Cleanup:     true,
+			run:         (*run).delete,
+		},
+	}
+}
+
+// run is the state of a conformance run shared by its checks
+type run struct {
+	cfg      Config
+	contexts []*scheduler.Context
+}
+
+// appVolume is a volume of a deployed app with its params
+type appVolume struct {
+	ctx    *scheduler.Context
+	vol    *volume.Volume
+	params map[string]string
+}
+
+// forEachVolume calls f for every volume of the deployed apps
+func (r *run) forEachVolume(f func(av appVolume) error) error {
+	for _, ctx := range r.contexts {
+		vols, err := r.cfg.Scheduler.GetVolumes(ctx)
+		if err != nil {
+			return err
+		}
+		params, err := r.cfg.Scheduler.GetVolumeParameters(ctx)
+		if err != nil {
+			return err
+		}
+		for _, vol := range vols {
+			if err := f(appVolume{ctx: ctx, vol: vol, params: params[vol.ID]}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *run) create() error {
+	var err error
+	r.contexts, err = r.cfg.Scheduler.Schedule(fmt.Sprintf("%s-conformance", r.cfg.InstanceID), scheduler.ScheduleOptions{
+		AppKeys:            r.cfg.AppKeys,
+		StorageProvisioner: r.cfg.StorageProvisioner,
+		ConfigMap:          r.cfg.ConfigMap,
+	})
+	if err != nil {
+		return err
+	}
+	if len(r.contexts) == 0 {
+		return fmt.Errorf("no apps scheduled for %v", r.cfg.AppKeys)
+	}
+	numVols := 0
+	for _, ctx := range r.contexts {
+		if err := r.cfg.Scheduler.WaitForRunning(ctx, r.cfg.Timeout, r.cfg.RetryInterval); err != nil {
+			return err
+		}
+		if err := r.cfg.Scheduler.ValidateVolumes(ctx, r.cfg.Timeout, r.cfg.RetryInterval, nil); err != nil {
+			return err
+		}
+		vols, err := r.cfg.Scheduler.GetVolumes(ctx)
+		if err != nil {
+			return err
+		}
+		numVols += len(vols)
+	}
+	if numVols == 0 {
+		return fmt.Errorf("apps %v have no volumes", r.cfg.AppKeys)
+	}
+	return nil
+}
+
+func (r *run) inspect() error {
+	return r.forEachVolume(func(av appVolume) error {
+		if err := r.cfg.Volume.ValidateCreateVolume(av.vol.ID, av.params); err != nil {
+			return err
+		}
+		inspected, err := r.cfg.Volume.InspectVolume(av.vol.ID)
+		if err != nil {
+			return err
+		}
+		if inspected.Spec == nil || inspected.Spec.Size == 0 {
+			return fmt.Errorf("inspected volume %s has no size", av.vol.ID)
+		}
+		return nil
+	})
+}
+
+func (r *run) attach() error {
+	return r.forEachVolume(func(av appVolume) error {
+		return r.cfg.Volume.ValidateVolumeSetup(av.vol)
+	})
+}
+
+func (r *run) snapshot() error {
+	return r.forEachVolume(func(av appVolume) error {
+		return r.cfg.Volume.ValidateCreateSnapshot(av.vol.ID, av.params)
+	})
+}
+
+func (r *run) clone() error {
+	return r.forEachVolume(func(av appVolume) error {
+		cloneID, err := r.cfg.Volume.CloneVolume(av.vol.ID)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err := r.cfg.Volume.DeleteVolume(cloneID); err != nil {
+				log.Warnf("Failed to delete clone %s of volume %s. Err: %v", cloneID, av.vol.ID, err)
+			}
+		}()
+		if _, err := r.cfg.Volume.InspectVolume(cloneID); err != nil {
+			return fmt.Errorf("failed to inspect clone %s of volume %s. Err: %v", cloneID, av.vol.ID, err)
+		}
+		return nil
+	})
+}
+
+func (r *run) resize() error {
+	for _, ctx := range r.contexts {
+		requested, err := r.cfg.Scheduler.ResizeVolume(ctx, r.cfg.ConfigMap)
+		if err != nil {
+			return err
+		}
+		for _, vol := range requested {
+			if err := r.cfg.Volume.ValidateUpdateVolume(vol, make(map[string]string)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// appNodes returns the nodes running the apps
+func (r *run) appNodes() ([]node.Node, error) {
+	seen := make(map[string]bool)
+	var nodes []node.Node
+	for _, ctx := range r.contexts {
+		appNodes, err := r.cfg.Scheduler.GetNodesForApp(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range appNodes {
+			if !seen[n.Name] {
+				seen[n.Name] = true
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// validateApps waits for the apps to run again and validates their volumes
+func (r *run) validateApps() error {
+	for _, ctx := range r.contexts {
+		if err := r.cfg.Scheduler.WaitForRunning(ctx, r.cfg.Timeout, r.cfg.RetryInterval); err != nil {
+			return err
+		}
+	}
+	return r.forEachVolume(func(av appVolume) error {
+		return r.cfg.Volume.ValidateVolumeSetup(av.vol)
+	})
+}
+
+func (r *run) driverRestart() error {
+	nodes, err := r.appNodes()
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err := r.cfg.Volume.RestartDriver(n, nil); err != nil {
+			return err
+		}
+		if err := r.cfg.Volume.WaitDriverUpOnNode(n, r.cfg.Timeout); err != nil {
+			return err
+		}
+	}
+	return r.validateApps()
+}
+
+func (r *run) nodeReboot() error {
+	nodes, err := r.appNodes()
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("apps %v run on no node", r.cfg.AppKeys)
+	}
+	n := nodes[0]
+	connOpts := node.ConnectionOpts{Timeout: r.cfg.Timeout, TimeBeforeRetry: r.cfg.RetryInterval}
+	if err := r.cfg.Node.RebootNode(n, node.RebootNodeOpts{Force: true, ConnectionOpts: connOpts}); err != nil {
+		return err
+	}
+	if err := r.cfg.Node.TestConnection(n, connOpts); err != nil {
+		return err
+	}
+	if err := r.cfg.Volume.WaitDriverUpOnNode(n, r.cfg.Timeout); err != nil {
+		return err
+	}
+	return r.validateApps()
+}
+
+func (r *run) detach() error {
+	var vols []appVolume
+	if err := r.forEachVolume(func(av appVolume) error {
+		vols = append(vols, av)
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, ctx := range r.contexts {
+		if err := r.cfg.Scheduler.Destroy(ctx, map[string]bool{scheduler.OptionsWaitForDestroy: true}); err != nil {
+			return err
+		}
+		if err := r.cfg.Scheduler.WaitForDestroy(ctx, r.cfg.Timeout); err != nil {
+			return err
+		}
+	}
+	for _, av := range vols {
+		t := func() (interface{}, bool, error) {
+			inspected, err := r.cfg.Volume.InspectVolume(av.vol.ID)
+			if err != nil {
+				return nil, false, err
+			}
+			if inspected.State == api.VolumeState_VOLUME_STATE_ATTACHED {
+				return nil, true, fmt.Errorf("volume %s is still attached on %s", av.vol.ID, inspected.AttachedOn)
+			}
+			return nil, false, nil
+		}
+		if _, err := task.DoRetryWithTimeout(t, r.cfg.Timeout, r.cfg.RetryInterval); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *run) delete() error {
+	for _, ctx := range r.contexts {
+		vols, err := r.cfg.Scheduler.DeleteVolumes(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, vol := range vols {
+			if err := r.cfg.Volume.ValidateDeleteVolume(vol); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
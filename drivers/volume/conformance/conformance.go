@@ -0,0 +1,184 @@
+// Package conformance runs a standard matrix of volume operations against any registered volume driver and
+// reports which of them the driver supports, so that new drivers can be qualified the same way.
+package conformance
+
+import (
+	goerrors "errors"
+	"fmt"
+	"time"
+
+	driver_api "github.com/portworx/torpedo/drivers/api"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	defaultTimeout       = 10 * time.Minute
+	defaultRetryInterval = 10 * time.Second
+)
+
+// Config is the setup of a conformance run
+type Config struct {
+	// Volume is the volume driver under test
+	Volume volume.Driver
+	// Scheduler deploys the apps whose volumes the checks exercise
+	Scheduler scheduler.Driver
+	// Node reboots nodes, the node reboot check is not supported without it
+	Node node.Driver
+	// InstanceID is the prefix of the namespaces of the deployed apps
+	InstanceID string
+	// AppKeys are the apps to deploy, each needs at least one PVC
+	AppKeys []string
+	// StorageProvisioner is the provisioner of the app volumes
+	StorageProvisioner string
+	// ConfigMap holds the auth token of secured clusters
+	ConfigMap string
+	// Checks limits the run to the named checks and the checks they require, all checks run by default
+	Checks []string
+	// Timeout bounds every wait for apps, volumes and drivers
+	Timeout time.Duration
+	// RetryInterval is the interval between retries while waiting
+	RetryInterval time.Duration
+}
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	// StatusPassed is a check which succeeded
+	StatusPassed Status = "Passed"
+	// StatusFailed is a check which returned an error
+	StatusFailed Status = "Failed"
+	// StatusNotSupported is a check the driver returned ErrNotSupported for
+	StatusNotSupported Status = "NotSupported"
+	// StatusSkipped is a check which did not run as a check it requires did not pass or it was not selected
+	StatusSkipped Status = "Skipped"
+)
+
+// Check is one step of the conformance matrix
+type Check struct {
+	// Name identifies the check in Config.Checks and in the report
+	Name string
+	// Description says what the check validates
+	Description string
+	// Requires are the checks which must pass before this check runs
+	Requires []string
+	// VolumeCapability is the volume driver capability the check exercises, if any
+	VolumeCapability driver_api.Capability
+	// NodeCapability is the node driver capability the check needs, if any
+	NodeCapability driver_api.Capability
+	// Cleanup checks tear down the apps. They run whenever apps got scheduled, even if not selected.
+	Cleanup bool
+	run     func(*run) error
+}
+
+// Run deploys the configured apps and runs the conformance checks against their volumes. Apps are torn
+// down by the detach and delete checks, which run whenever the apps got scheduled.
+func Run(cfg Config) *Report {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = defaultRetryInterval
+	}
+	return runChecks(&run{cfg: cfg}, Checks())
+}
+
+func runChecks(r *run, checks []Check) *Report {
+	report := &Report{
+		VolumeDriver: r.cfg.Volume.String(),
+		Provisioner:  r.cfg.StorageProvisioner,
+	}
+	if r.cfg.Scheduler != nil {
+		report.Scheduler = r.cfg.Scheduler.String()
+	}
+	selected := selectChecks(checks, r.cfg.Checks)
+	statuses := make(map[string]Status)
+	for _, check := range checks {
+		result := Result{
+			Check:            check.Name,
+			Description:      check.Description,
+			VolumeCapability: check.VolumeCapability,
+			NodeCapability:   check.NodeCapability,
+		}
+		if check.VolumeCapability != "" {
+			result.Declared = r.cfg.Volume.Capabilities().Has(check.VolumeCapability)
+		}
+
+		if reason := r.skipReason(check, selected, statuses); reason != "" {
+			result.Status = StatusSkipped
+			result.Error = reason
+		} else if check.NodeCapability != "" && (r.cfg.Node == nil || !r.cfg.Node.Capabilities().Has(check.NodeCapability)) {
+			result.Status = StatusNotSupported
+			result.Error = fmt.Sprintf("node driver does not support %s", check.NodeCapability)
+		} else {
+			log.Infof("Running conformance check %s: %s", check.Name, check.Description)
+			start := time.Now()
+			err := check.run(r)
+			result.Duration = time.Since(start)
+			result.Status, result.Error = status(err)
+			log.Infof("Conformance check %s: %s %s", check.Name, result.Status, result.Error)
+		}
+		statuses[check.Name] = result.Status
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// selectChecks returns the selected checks with the checks they require, or nil to select all
+func selectChecks(checks []Check, names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+	requires := make(map[string][]string)
+	for _, check := range checks {
+		requires[check.Name] = check.Requires
+	}
+	selected := make(map[string]bool)
+	var add func(name string)
+	add = func(name string) {
+		if selected[name] {
+			return
+		}
+		selected[name] = true
+		for _, required := range requires[name] {
+			add(required)
+		}
+	}
+	for _, name := range names {
+		add(name)
+	}
+	return selected
+}
+
+func (r *run) skipReason(check Check, selected map[string]bool, statuses map[string]Status) string {
+	if check.Cleanup {
+		if len(r.contexts) == 0 {
+			return "no apps scheduled"
+		}
+		return ""
+	}
+	if selected != nil && !selected[check.Name] {
+		return "not selected"
+	}
+	for _, required := range check.Requires {
+		if statuses[required] != StatusPassed {
+			return fmt.Sprintf("required check %s did not pass", required)
+		}
+	}
+	return ""
+}
+
+func status(err error) (Status, string) {
+	if err == nil {
+		return StatusPassed, ""
+	}
+	var notSupported *errors.ErrNotSupported
+	if goerrors.As(err, &notSupported) {
+		return StatusNotSupported, err.Error()
+	}
+	return StatusFailed, err.Error()
+}
@@ -0,0 +1,111 @@
+package conformance
+
+import (
+	"fmt"
+	"testing"
+
+	driver_api "github.com/portworx/torpedo/drivers/api"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDriver struct {
+	volume.DefaultDriver
+	capabilities driver_api.Capabilities
+}
+
+func (d *fakeDriver) String() string {
+	return "fake"
+}
+
+func (d *fakeDriver) Capabilities() driver_api.Capabilities {
+	return d.capabilities
+}
+
+func (d *fakeDriver) ValidateStorageCluster(endpointURL, endpointVersion string) error {
+	return nil
+}
+
+func testChecks(ran *[]string, errs map[string]error) []Check {
+	check := func(name string, capability driver_api.Capability, cleanup bool, requires ...string) Check {
+		return Check{
+			Name:             name,
+			Requires:         requires,
+			VolumeCapability: capability,
+			Cleanup:          cleanup,
+			run: func(r *run) error {
+				*ran = append(*ran, name)
+				if name == CheckCreate {
+					r.contexts = []*scheduler.Context{{UID: "app"}}
+				}
+				return errs[name]
+			},
+		}
+	}
+	return []Check{
+		check(CheckCreate, "", false),
+		check(CheckInspect, "", false, CheckCreate),
+		check(CheckSnapshot, volume.CapabilitySnapshot, false, CheckCreate),
+		check(CheckDriverRestart, volume.CapabilityDriverRestart, false, CheckInspect),
+		check(CheckDelete, "", true),
+	}
+}
+
+func statuses(report *Report) map[string]Status {
+	result := make(map[string]Status)
+	for _, r := range report.Results {
+		result[r.Check] = r.Status
+	}
+	return result
+}
+
+func TestRunChecks(t *testing.T) {
+	driver := &fakeDriver{capabilities: driver_api.NewCapabilities(volume.CapabilitySnapshot)}
+	var ran []string
+	errs := map[string]error{
+		CheckInspect:  fmt.Errorf("inspect failed"),
+		CheckSnapshot: &errors.ErrNotSupported{Type: "Function", Operation: "ValidateCreateSnapshot()"},
+	}
+	report := runChecks(&run{cfg: Config{Volume: driver}}, testChecks(&ran, errs))
+
+	require.Equal(t, []string{CheckCreate, CheckInspect, CheckSnapshot, CheckDelete}, ran)
+	require.Equal(t, map[string]Status{
+		CheckCreate:        StatusPassed,
+		CheckInspect:       StatusFailed,
+		CheckSnapshot:      StatusNotSupported,
+		CheckDriverRestart: StatusSkipped,
+		CheckDelete:        StatusPassed,
+	}, statuses(report))
+	require.Equal(t, "capability snapshot is declared but not supported", report.Results[2].Mismatch())
+	require.False(t, report.Passed())
+	require.Equal(t, []string{CheckCreate, CheckDelete}, report.Supported())
+	require.Contains(t, report.String(), "driver-restart")
+}
+
+func TestRunSelectedChecks(t *testing.T) {
+	driver := &fakeDriver{capabilities: driver_api.NewCapabilities(volume.CapabilityDriverRestart)}
+	var ran []string
+	report := runChecks(&run{cfg: Config{Volume: driver, Checks: []string{CheckDriverRestart}}}, testChecks(&ran, nil))
+
+	// required checks run with the selected ones, cleanup always runs
+	require.Equal(t, []string{CheckCreate, CheckInspect, CheckDriverRestart, CheckDelete}, ran)
+	require.Equal(t, StatusSkipped, statuses(report)[CheckSnapshot])
+	require.True(t, report.Passed())
+}
+
+func TestRunWithoutApps(t *testing.T) {
+	var ran []string
+	errs := map[string]error{CheckCreate: fmt.Errorf("no apps")}
+	checks := testChecks(&ran, errs)
+	checks[0].run = func(r *run) error {
+		ran = append(ran, CheckCreate)
+		return errs[CheckCreate]
+	}
+	report := runChecks(&run{cfg: Config{Volume: &fakeDriver{}}}, checks)
+
+	require.Equal(t, []string{CheckCreate}, ran)
+	require.Equal(t, StatusSkipped, statuses(report)[CheckDelete])
+	require.False(t, report.Passed())
+}
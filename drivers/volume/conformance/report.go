@@ -0,0 +1,110 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"text/tabwriter"
+	"time"
+
+	driver_api "github.com/portworx/torpedo/drivers/api"
+)
+
+// Result is the outcome of one check
+type Result struct {
+	Check       string `json:"check"`
+	Description string `json:"description"`
+	Status      Status `json:"status"`
+	// Error is the error of a failed or unsupported check, or why it was skipped
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+	// VolumeCapability is the volume driver capability the check exercises, if any
+	VolumeCapability driver_api.Capability `json:"volumeCapability,omitempty"`
+	// Declared is true if the volume driver declares the capability of the check
+	Declared bool `json:"declared"`
+	// NodeCapability is the node driver capability the check needs, if any
+	NodeCapability driver_api.Capability `json:"nodeCapability,omitempty"`
+}
+
+// Mismatch returns why the outcome of the check contradicts the capabilities the volume driver declares,
+// or an empty string if it does not
+func (r Result) Mismatch() string {
+	if r.VolumeCapability == "" {
+		return ""
+	}
+	switch {
+	case r.Declared && r.Status == StatusNotSupported:
+		return fmt.Sprintf("capability %s is declared but not supported", r.VolumeCapability)
+	case !r.Declared && r.Status == StatusPassed:
+		return fmt.Sprintf("capability %s works but is not declared", r.VolumeCapability)
+	}
+	return ""
+}
+
+// Report is the outcome of a conformance run, which doubles as the capability report of the volume driver
+type Report struct {
+	VolumeDriver string   `json:"volumeDriver"`
+	Provisioner  string   `json:"provisioner"`
+	Scheduler    string   `json:"scheduler"`
+	Results      []Result `json:"results"`
+}
+
+// Passed returns true if no check failed and all checks agree with the declared capabilities
+func (r *Report) Passed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFailed || result.Mismatch() != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Supported returns the checks which passed, i.e. what the volume driver was qualified for
+func (r *Report) Supported() []string {
+	var supported []string
+	for _, result := range r.Results {
+		if result.Status == StatusPassed {
+			supported = append(supported, result.Check)
+		}
+	}
+	return supported
+}
+
+// String formats the report as a table
+func (r *Report) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Conformance of volume driver %s (provisioner %s, scheduler %s)\n", r.VolumeDriver, r.Provisioner, r.Scheduler)
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDURATION\tCAPABILITY\tDECLARED\tDETAILS")
+	for _, result := range r.Results {
+		declared := "-"
+		if result.VolumeCapability != "" {
+			declared = fmt.Sprintf("%t", result.Declared)
+		}
+		capability := string(result.VolumeCapability)
+		if capability == "" {
+			capability = string(result.NodeCapability)
+		}
+		if capability == "" {
+			capability = "-"
+		}
+		details := result.Error
+		if mismatch := result.Mismatch(); mismatch != "" {
+			details = mismatch
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.Check, result.Status,
+			result.Duration.Round(time.Second), capability, declared, details)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+// WriteJSON writes the report as JSON to the given file
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
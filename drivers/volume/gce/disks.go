@@ -0,0 +1,161 @@
+package gce
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers/volume/clouddisk"
+	"github.com/portworx/torpedo/pkg/units"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// computeEndpointEnv overrides the compute API endpoint including the projects/ path, e.g. with
+	// http://localhost:8080/compute/v1/projects/ for an emulator, which is used without authentication
+	computeEndpointEnv = "GCE_COMPUTE_ENDPOINT"
+	// projectEnv is the project of the disks of in-tree PVs. Defaults to the project of the cluster nodes.
+	projectEnv = "GOOGLE_CLOUD_PROJECT"
+	// pdCSIDriver is the name of the GCE PD CSI driver
+	pdCSIDriver = "pd.csi.storage.gke.io"
+	// gceProviderIDPrefix is the prefix of the provider IDs of GCE nodes, followed by project/zone/instance
+	gceProviderIDPrefix = "gce://"
+	// regionalZoneSeparator separates the zones of a regional disk in the zone label of in-tree PVs
+	regionalZoneSeparator = "__"
+)
+
+// pdParams are the storage class parameters of the in-tree and CSI GCE PD provisioners
+var pdParams = clouddisk.ParamKeys{
+	Type:  []string{"type"},
+	KeyID: []string{"disk-encryption-kms-key"},
+}
+
+// computeCloud looks up persistent disks through the compute API
+type computeCloud struct {
+	svc     *compute.Service
+	project string
+}
+
+func newComputeCloud() (*computeCloud, error) {
+	var opts []option.ClientOption
+	if endpoint := os.Getenv(computeEndpointEnv); endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint), option.WithoutAuthentication())
+	}
+	svc, err := compute.NewService(context.TODO(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCE compute client. Err: %v", err)
+	}
+	return &computeCloud{svc: svc, project: os.Getenv(projectEnv)}, nil
+}
+
+// DiskID returns the disk of an in-tree or CSI PV as projects/<project>/zones/<zone>/disks/<name>, or with
+// regions/<region> for regional disks
+func (c *computeCloud) DiskID(pv *corev1.PersistentVolume) (string, error) {
+	if csi := pv.Spec.CSI; csi != nil && csi.Driver == pdCSIDriver {
+		return csi.VolumeHandle, nil
+	}
+	pd := pv.Spec.GCEPersistentDisk
+	if pd == nil {
+		return "", fmt.Errorf("PV %s is not a GCE persistent disk", pv.Name)
+	}
+	project, err := c.getProject()
+	if err != nil {
+		return "", err
+	}
+	zone := clouddisk.Zone(pv)
+	if zone == "" {
+		return "", fmt.Errorf("PV %s has no zone", pv.Name)
+	}
+	if strings.Contains(zone, regionalZoneSeparator) {
+		zone = strings.Split(zone, regionalZoneSeparator)[0]
+		region := zone[:strings.LastIndex(zone, "-")]
+		return fmt.Sprintf("projects/%s/regions/%s/disks/%s", project, region, pd.PDName), nil
+	}
+	return fmt.Sprintf("projects/%s/zones/%s/disks/%s", project, zone, pd.PDName), nil
+}
+
+// getProject returns the configured project or the project of the first GCE node of the cluster
+func (c *computeCloud) getProject() (string, error) {
+	if c.project != "" {
+		return c.project, nil
+	}
+	nodes, err := core.Instance().GetNodes()
+	if err != nil {
+		return "", err
+	}
+	for _, n := range nodes.Items {
+		if strings.HasPrefix(n.Spec.ProviderID, gceProviderIDPrefix) {
+			c.project = strings.Split(strings.TrimPrefix(n.Spec.ProviderID, gceProviderIDPrefix), "/")[0]
+			return c.project, nil
+		}
+	}
+	return "", fmt.Errorf("no GCE node found to get the project from, set %s", projectEnv)
+}
+
+// GetDisk gets the zonal or regional disk with the given ID
+func (c *computeCloud) GetDisk(id string) (*clouddisk.Disk, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[4] != "disks" {
+		return nil, fmt.Errorf("invalid GCE disk ID %s", id)
+	}
+	var pd *compute.Disk
+	var err error
+	switch parts[2] {
+	case "zones":
+		pd, err = c.svc.Disks.Get(parts[1], parts[3], parts[5]).Do()
+	case "regions":
+		pd, err = c.svc.RegionDisks.Get(parts[1], parts[3], parts[5]).Do()
+	default:
+		return nil, fmt.Errorf("invalid GCE disk ID %s", id)
+	}
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get GCE disk %s. Err: %v", id, err)
+	}
+
+	disk := &clouddisk.Disk{
+		ID:        id,
+		SizeBytes: uint64(pd.SizeGb) * units.GiB,
+		Type:      lastSegment(pd.Type),
+		// persistent disks are always encrypted at rest, with a Google or a customer managed key
+		Encrypted: true,
+	}
+	if pd.DiskEncryptionKey != nil {
+		// the key name of a disk includes the key version it was created with
+		disk.KeyID = strings.Split(pd.DiskEncryptionKey.KmsKeyName, "/cryptoKeyVersions/")[0]
+	}
+	for _, user := range pd.Users {
+		disk.AttachedTo = append(disk.AttachedTo, resourcePath(user))
+	}
+	return disk, nil
+}
+
+// InstanceID returns the instance as projects/<project>/zones/<zone>/instances/<name> from a provider ID of the
+// form gce://<project>/<zone>/<name>, as disk users are referred to
+func (c *computeCloud) InstanceID(providerID string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(providerID, gceProviderIDPrefix), "/")
+	if !strings.HasPrefix(providerID, gceProviderIDPrefix) || len(parts) != 3 {
+		return "", fmt.Errorf("provider ID %q is not a GCE provider ID", providerID)
+	}
+	return fmt.Sprintf("projects/%s/zones/%s/instances/%s", parts[0], parts[1], parts[2]), nil
+}
+
+// resourcePath strips the API URL from a resource URL, e.g. a disk user
+func resourcePath(url string) string {
+	if i := strings.Index(url, "projects/"); i >= 0 {
+		return url[i:]
+	}
+	return url
+}
+
+func lastSegment(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
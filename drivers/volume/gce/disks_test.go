@@ -0,0 +1,72 @@
+package gce
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/portworx/torpedo/pkg/units"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// fakeCompute answers disk gets like the compute API does for a single known zonal disk
+func fakeCompute() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/compute/v1/projects/p/zones/us-central1-a/disks/pvc-1" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error": {"code": 404, "message": "not found"}}`)
+			return
+		}
+		fmt.Fprint(w, `{
+  "name": "pvc-1",
+  "sizeGb": "50",
+  "type": "https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a/diskTypes/pd-ssd",
+  "diskEncryptionKey": {"kmsKeyName": "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"},
+  "users": ["https://www.googleapis.com/compute/v1/projects/p/zones/us-central1-a/instances/node-1"]
+}`)
+	}))
+}
+
+func TestGetDisk(t *testing.T) {
+	server := fakeCompute()
+	defer server.Close()
+	t.Setenv(computeEndpointEnv, server.URL+"/compute/v1/projects/")
+	t.Setenv(projectEnv, "p")
+
+	cloud, err := newComputeCloud()
+	require.NoError(t, err)
+
+	id, err := cloud.DiskID(&corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeSource: corev1.PersistentVolumeSource{
+				GCEPersistentDisk: &corev1.GCEPersistentDiskVolumeSource{PDName: "pvc-1"},
+			},
+			NodeAffinity: &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key:    corev1.LabelZoneFailureDomain,
+					Values: []string{"us-central1-a"},
+				}}}},
+			}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "projects/p/zones/us-central1-a/disks/pvc-1", id)
+
+	disk, err := cloud.GetDisk(id)
+	require.NoError(t, err)
+	require.NotNil(t, disk)
+	require.Equal(t, uint64(50*units.GiB), disk.SizeBytes)
+	require.Equal(t, "pd-ssd", disk.Type)
+	require.Equal(t, "projects/p/locations/global/keyRings/r/cryptoKeys/k", disk.KeyID)
+
+	instance, err := cloud.InstanceID("gce://p/us-central1-a/node-1")
+	require.NoError(t, err)
+	require.Equal(t, []string{instance}, disk.AttachedTo)
+
+	disk, err = cloud.GetDisk("projects/p/zones/us-central1-a/disks/pvc-2")
+	require.NoError(t, err)
+	require.Nil(t, disk)
+}
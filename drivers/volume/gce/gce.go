@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"github.com/portworx/torpedo/pkg/log"
 
+	"github.com/libopenstorage/openstorage/api"
 	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/drivers/volume/clouddisk"
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
 	"github.com/portworx/torpedo/pkg/errors"
 )
@@ -23,6 +25,7 @@ var provisioners = map[torpedovolume.StorageProvisionerType]torpedovolume.Storag
 
 type gce struct {
 	schedOps schedops.Driver
+	disks    *clouddisk.Validator
 	torpedovolume.DefaultDriver
 }
 
@@ -43,9 +46,34 @@ func (d *gce) Init(sched, nodeDriver, token, storageProvisioner, csiGenericDrive
 	} else {
 		return fmt.Errorf("Provisioner is empty for volume driver: %s", DriverName)
 	}
+	cloud, err := newComputeCloud()
+	if err != nil {
+		return err
+	}
+	d.disks = clouddisk.NewValidator(cloud, pdParams)
 	return nil
 }
 
+// InspectVolume returns the volume with the given PV name from its persistent disk
+func (d *gce) InspectVolume(name string) (*api.Volume, error) {
+	return d.disks.InspectVolume(name)
+}
+
+// ValidateCreateVolume validates the persistent disk of the PV against its claim and storage class
+func (d *gce) ValidateCreateVolume(name string, params map[string]string) error {
+	return d.disks.ValidateCreateVolume(name, params)
+}
+
+// ValidateVolumeSetup validates that the persistent disk is attached to the instances running its pods
+func (d *gce) ValidateVolumeSetup(vol *torpedovolume.Volume) error {
+	return d.disks.ValidateVolumeSetup(vol)
+}
+
+// ValidateDeleteVolume validates that the persistent disk is deleted with its PV
+func (d *gce) ValidateDeleteVolume(vol *torpedovolume.Volume) error {
+	return d.disks.ValidateDeleteVolume(vol)
+}
+
 func (d *gce) ValidateStorageCluster(endpointURL, endpointVersion string) error {
 	// TODO: Add implementation
 	return &errors.ErrNotSupported{
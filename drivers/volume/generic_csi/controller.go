@@ -0,0 +1,174 @@
+package csi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	csispec "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/portworx/torpedo/pkg/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// csiDialTimeout is the timeout for connecting to the CSI controller endpoint
+	csiDialTimeout = 30 * time.Second
+	// csiCallTimeout is the timeout of a single CSI call
+	csiCallTimeout = 1 * time.Minute
+)
+
+// csiController is a client of the controller service of a CSI driver
+type csiController struct {
+	conn         *grpc.ClientConn
+	identity     csispec.IdentityClient
+	controller   csispec.ControllerClient
+	driverName   string
+	capabilities map[csispec.ControllerServiceCapability_RPC_Type]bool
+}
+
+// csiVolume is the state of a volume as reported by the CSI controller
+type csiVolume struct {
+	// CapacityBytes is the capacity of the volume, 0 if unknown
+	CapacityBytes int64
+	// PublishedNodeIDs are the CSI node IDs the volume is published to, if reported
+	PublishedNodeIDs []string
+}
+
+// newCSIController connects to the CSI controller at the given endpoint. The endpoint is either a
+// unix socket (unix:///csi/csi.sock) or a host:port address
+func newCSIController(endpoint string) (*csiController, error) {
+	if strings.HasPrefix(endpoint, "/") {
+		endpoint = "unix://" + endpoint
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), csiDialTimeout)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to CSI controller at %s. Err: %v", endpoint, err)
+	}
+
+	c := &csiController{
+		conn:         conn,
+		identity:     csispec.NewIdentityClient(conn),
+		controller:   csispec.NewControllerClient(conn),
+		capabilities: make(map[csispec.ControllerServiceCapability_RPC_Type]bool),
+	}
+
+	callCtx, callCancel := context.WithTimeout(context.Background(), csiCallTimeout)
+	defer callCancel()
+	info, err := c.identity.GetPluginInfo(callCtx, &csispec.GetPluginInfoRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get CSI plugin info from %s. Err: %v", endpoint, err)
+	}
+	c.driverName = info.GetName()
+
+	caps, err := c.controller.ControllerGetCapabilities(callCtx, &csispec.ControllerGetCapabilitiesRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to get CSI controller capabilities from %s. Err: %v", endpoint, err)
+	}
+	for _, capability := range caps.GetCapabilities() {
+		if rpc := capability.GetRpc(); rpc != nil {
+			c.capabilities[rpc.GetType()] = true
+		}
+	}
+	log.Infof("Connected to CSI controller %s %s at %s with capabilities %v", c.driverName, info.GetVendorVersion(), endpoint, c.capabilityNames())
+	return c, nil
+}
+
+func (c *csiController) capabilityNames() []string {
+	var names []string
+	for capability := range c.capabilities {
+		names = append(names, capability.String())
+	}
+	return names
+}
+
+// hasCapability returns true if the controller supports the given RPC
+func (c *csiController) hasCapability(capability csispec.ControllerServiceCapability_RPC_Type) bool {
+	return c.capabilities[capability]
+}
+
+// canGetVolume returns true if the controller can report the state of a single volume
+func (c *csiController) canGetVolume() bool {
+	return c.hasCapability(csispec.ControllerServiceCapability_RPC_GET_VOLUME) ||
+		c.hasCapability(csispec.ControllerServiceCapability_RPC_LIST_VOLUMES)
+}
+
+// reportsPublishedNodes returns true if the controller publishes volumes to nodes and reports those nodes
+func (c *csiController) reportsPublishedNodes() bool {
+	return c.canGetVolume() &&
+		c.hasCapability(csispec.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME) &&
+		c.hasCapability(csispec.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES)
+}
+
+// getVolume returns the volume with the given handle, or nil if the controller does not know it
+func (c *csiController) getVolume(volumeHandle string) (*csiVolume, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), csiCallTimeout)
+	defer cancel()
+
+	if c.hasCapability(csispec.ControllerServiceCapability_RPC_GET_VOLUME) {
+		resp, err := c.controller.ControllerGetVolume(ctx, &csispec.ControllerGetVolumeRequest{VolumeId: volumeHandle})
+		if status.Code(err) == codes.NotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get volume %s from CSI controller. Err: %v", volumeHandle, err)
+		}
+		return &csiVolume{
+			CapacityBytes:    resp.GetVolume().GetCapacityBytes(),
+			PublishedNodeIDs: resp.GetStatus().GetPublishedNodeIds(),
+		}, nil
+	}
+
+	if !c.hasCapability(csispec.ControllerServiceCapability_RPC_LIST_VOLUMES) {
+		return nil, fmt.Errorf("CSI controller %s supports neither ControllerGetVolume nor ListVolumes", c.driverName)
+	}
+	token := ""
+	for {
+		resp, err := c.controller.ListVolumes(ctx, &csispec.ListVolumesRequest{StartingToken: token})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list volumes of CSI controller. Err: %v", err)
+		}
+		for _, entry := range resp.GetEntries() {
+			if entry.GetVolume().GetVolumeId() == volumeHandle {
+				return &csiVolume{
+					CapacityBytes:    entry.GetVolume().GetCapacityBytes(),
+					PublishedNodeIDs: entry.GetStatus().GetPublishedNodeIds(),
+				}, nil
+			}
+		}
+		token = resp.GetNextToken()
+		if len(token) == 0 {
+			return nil, nil
+		}
+	}
+}
+
+// canListSnapshots returns true if the controller can list snapshots
+func (c *csiController) canListSnapshots() bool {
+	return c.hasCapability(csispec.ControllerServiceCapability_RPC_LIST_SNAPSHOTS)
+}
+
+// hasSnapshot returns true if the controller reports a snapshot with the given handle
+func (c *csiController) hasSnapshot(snapshotHandle string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), csiCallTimeout)
+	defer cancel()
+	resp, err := c.controller.ListSnapshots(ctx, &csispec.ListSnapshotsRequest{SnapshotId: snapshotHandle})
+	if err != nil {
+		return false, fmt.Errorf("failed to list snapshot %s from CSI controller. Err: %v", snapshotHandle, err)
+	}
+	for _, entry := range resp.GetEntries() {
+		if entry.GetSnapshot().GetSnapshotId() == snapshotHandle {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,132 @@
+package csi
+
+import (
+	"context"
+	"testing"
+
+	csispec "github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeController is a CSI controller client serving the given volumes and snapshots, listing one volume per page
+type fakeController struct {
+	csispec.ControllerClient
+	volumes   []*csispec.Volume
+	snapshots []*csispec.Snapshot
+}
+
+func (c *fakeController) ControllerGetVolume(ctx context.Context, req *csispec.ControllerGetVolumeRequest, opts ...grpc.CallOption) (*csispec.ControllerGetVolumeResponse, error) {
+	for _, vol := range c.volumes {
+		if vol.GetVolumeId() == req.GetVolumeId() {
+			return &csispec.ControllerGetVolumeResponse{
+				Volume: vol,
+				Status: &csispec.ControllerGetVolumeResponse_VolumeStatus{PublishedNodeIds: []string{"node-1"}},
+			}, nil
+		}
+	}
+	return nil, status.Error(codes.NotFound, "volume not found")
+}
+
+func (c *fakeController) ListVolumes(ctx context.Context, req *csispec.ListVolumesRequest, opts ...grpc.CallOption) (*csispec.ListVolumesResponse, error) {
+	start := 0
+	for i, vol := range c.volumes {
+		if vol.GetVolumeId() == req.GetStartingToken() {
+			start = i
+		}
+	}
+	resp := &csispec.ListVolumesResponse{}
+	if start < len(c.volumes) {
+		resp.Entries = []*csispec.ListVolumesResponse_Entry{{
+			Volume: c.volumes[start],
+			Status: &csispec.ListVolumesResponse_VolumeStatus{PublishedNodeIds: []string{"node-2"}},
+		}}
+	}
+	if start+1 < len(c.volumes) {
+		resp.NextToken = c.volumes[start+1].GetVolumeId()
+	}
+	return resp, nil
+}
+
+func (c *fakeController) ListSnapshots(ctx context.Context, req *csispec.ListSnapshotsRequest, opts ...grpc.CallOption) (*csispec.ListSnapshotsResponse, error) {
+	resp := &csispec.ListSnapshotsResponse{}
+	for _, snap := range c.snapshots {
+		if snap.GetSnapshotId() == req.GetSnapshotId() {
+			resp.Entries = append(resp.Entries, &csispec.ListSnapshotsResponse_Entry{Snapshot: snap})
+		}
+	}
+	return resp, nil
+}
+
+func newTestController(capabilities ...csispec.ControllerServiceCapability_RPC_Type) *csiController {
+	c := &csiController{
+		controller: &fakeController{
+			volumes: []*csispec.Volume{
+				{VolumeId: "vol-1", CapacityBytes: 1024},
+				{VolumeId: "vol-2", CapacityBytes: 2048},
+				{VolumeId: "vol-3", CapacityBytes: 4096},
+			},
+			snapshots: []*csispec.Snapshot{{SnapshotId: "snap-1", SourceVolumeId: "vol-1"}},
+		},
+		driverName:   testDriverName,
+		capabilities: make(map[csispec.ControllerServiceCapability_RPC_Type]bool),
+	}
+	for _, capability := range capabilities {
+		c.capabilities[capability] = true
+	}
+	return c
+}
+
+func TestControllerCapabilities(t *testing.T) {
+	c := newTestController()
+	require.False(t, c.canGetVolume())
+	require.False(t, c.reportsPublishedNodes())
+	require.False(t, c.canListSnapshots())
+
+	c = newTestController(csispec.ControllerServiceCapability_RPC_LIST_VOLUMES,
+		csispec.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME)
+	require.True(t, c.canGetVolume())
+	require.False(t, c.reportsPublishedNodes())
+
+	c = newTestController(csispec.ControllerServiceCapability_RPC_GET_VOLUME,
+		csispec.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
+		csispec.ControllerServiceCapability_RPC_LIST_VOLUMES_PUBLISHED_NODES,
+		csispec.ControllerServiceCapability_RPC_LIST_SNAPSHOTS)
+	require.True(t, c.canGetVolume())
+	require.True(t, c.reportsPublishedNodes())
+	require.True(t, c.canListSnapshots())
+}
+
+func TestControllerGetVolume(t *testing.T) {
+	_, err := newTestController().getVolume("vol-1")
+	require.Error(t, err)
+
+	c := newTestController(csispec.ControllerServiceCapability_RPC_GET_VOLUME)
+	vol, err := c.getVolume("vol-2")
+	require.NoError(t, err)
+	require.Equal(t, &csiVolume{CapacityBytes: 2048, PublishedNodeIDs: []string{"node-1"}}, vol)
+	vol, err = c.getVolume("vol-4")
+	require.NoError(t, err)
+	require.Nil(t, vol)
+
+	// listing goes through all the pages
+	c = newTestController(csispec.ControllerServiceCapability_RPC_LIST_VOLUMES)
+	vol, err = c.getVolume("vol-3")
+	require.NoError(t, err)
+	require.Equal(t, &csiVolume{CapacityBytes: 4096, PublishedNodeIDs: []string{"node-2"}}, vol)
+	vol, err = c.getVolume("vol-4")
+	require.NoError(t, err)
+	require.Nil(t, vol)
+}
+
+func TestControllerHasSnapshot(t *testing.T) {
+	c := newTestController(csispec.ControllerServiceCapability_RPC_LIST_SNAPSHOTS)
+	found, err := c.hasSnapshot("snap-1")
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = c.hasSnapshot("snap-2")
+	require.NoError(t, err)
+	require.False(t, found)
+}
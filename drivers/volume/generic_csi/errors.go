@@ -0,0 +1,51 @@
+package csi
+
+import "fmt"
+
+// ErrFailedToInspectVolume error type for failing to inspect a volume
+type ErrFailedToInspectVolume struct {
+	// ID is the ID/name of the volume that failed to inspect
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToInspectVolume) Error() string {
+	return fmt.Sprintf("Failed to inspect volume: %v due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToValidateAttachment error type for failing to validate attachment of a volume
+type ErrFailedToValidateAttachment struct {
+	// ID is the ID/name of the volume that failed to validate attachment
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToValidateAttachment) Error() string {
+	return fmt.Sprintf("Failed to validate volume: %v attachment due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToDeleteVolume error type for failing to delete a volume
+type ErrFailedToDeleteVolume struct {
+	// ID is the ID/name of the volume that failed to delete
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToDeleteVolume) Error() string {
+	return fmt.Sprintf("Failed to delete volume: %v due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToValidateSnapshot error type for failing to validate a snapshot of a volume
+type ErrFailedToValidateSnapshot struct {
+	// ID is the ID/name of the volume whose snapshot failed to validate
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToValidateSnapshot) Error() string {
+	return fmt.Sprintf("Failed to validate snapshot of volume: %v due to err: %v", e.ID, e.Cause)
+}
@@ -2,11 +2,16 @@ package csi
 
 import (
 	"fmt"
+	"sync"
+
 	"github.com/portworx/sched-ops/k8s/core"
+	driver_api "github.com/portworx/torpedo/drivers/api"
+	"github.com/portworx/torpedo/drivers/scheduler/k8s"
 	torpedovolume "github.com/portworx/torpedo/drivers/volume"
 	"github.com/portworx/torpedo/drivers/volume/portworx/schedops"
 	"github.com/portworx/torpedo/pkg/errors"
 	"github.com/portworx/torpedo/pkg/log"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -16,6 +21,9 @@ const (
 	CsiStorage torpedovolume.StorageProvisionerType = "generic_csi"
 	// CsiStorageClassKey CSI Generic driver config map key name
 	CsiStorageClassKey = "csi_storageclass_key"
+	// CsiControllerEndpointKey is the optional config map key with the endpoint of the CSI controller service,
+	// either a unix socket or a host:port address
+	CsiControllerEndpointKey = "csi_controller_endpoint"
 )
 
 // Provisioners types of supported provisioners
@@ -23,8 +31,17 @@ var provisioners = map[torpedovolume.StorageProvisionerType]torpedovolume.Storag
 	CsiStorage: "csi",
 }
 
+var k8sCore = core.Instance()
+
 type genericCsi struct {
-	schedOps schedops.Driver
+	schedOps   schedops.Driver
+	kubeClient kubernetes.Interface
+	// controller is the client of the CSI controller service, nil if no endpoint is configured
+	controller *csiController
+	// volumeHandles are the CSI volume handles of the validated volumes by PV name, so they can
+	// still be checked after the PV got deleted
+	volumeHandles map[string]string
+	sync.Mutex
 	torpedovolume.DefaultDriver
 }
 
@@ -32,6 +49,10 @@ func (d *genericCsi) String() string {
 	return string(CsiStorage)
 }
 
+func (d *genericCsi) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities(torpedovolume.CapabilitySnapshot)
+}
+
 func (d *genericCsi) ValidateVolumeCleanup() error {
 	return nil
 }
@@ -54,16 +75,31 @@ func (d *genericCsi) Init(sched, nodeDriver, token, storageProvisioner, csiGener
 	// Set provisioner for torpedo, from
 	if storageProvisioner == string(CsiStorage) {
 		// Get the provisioner from the config map
-		configMap, err := core.Instance().GetConfigMap(csiGenericDriverConfigMap, "default")
+		configMap, err := k8sCore.GetConfigMap(csiGenericDriverConfigMap, "default")
 		if err != nil {
 			return fmt.Errorf("Failed to get config map for volume driver: %s, provisioner: %s", DriverName, storageProvisioner)
 		}
 		if p, ok := configMap.Data[CsiStorageClassKey]; ok {
 			torpedovolume.StorageProvisioner = torpedovolume.StorageProvisionerType(p)
 		}
+		if endpoint, ok := configMap.Data[CsiControllerEndpointKey]; ok && len(endpoint) > 0 {
+			d.controller, err = newCSIController(endpoint)
+			if err != nil {
+				return err
+			}
+			if d.controller.driverName != string(torpedovolume.StorageProvisioner) {
+				return fmt.Errorf("CSI controller at %s is driver %s, expected %s", endpoint, d.controller.driverName, torpedovolume.StorageProvisioner)
+			}
+		}
 	} else {
 		return fmt.Errorf("Invalid provisioner %s for volume driver: %s", storageProvisioner, DriverName)
 	}
+
+	var err error
+	if d.kubeClient, err = k8s.GetKubeClient(""); err != nil {
+		return fmt.Errorf("Failed to create kube client for volume driver: %s. Err: %v", DriverName, err)
+	}
+	d.volumeHandles = make(map[string]string)
 	return nil
 }
 
@@ -0,0 +1,134 @@
+package csi
+
+import (
+	"fmt"
+	"time"
+
+	snapv1beta1 "github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
+	"github.com/portworx/sched-ops/k8s/externalsnapshotter"
+	"github.com/portworx/sched-ops/task"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	snapshotReadyTimeout = 10 * time.Minute
+	snapshotRetry        = 5 * time.Second
+)
+
+var k8sSnapshot = externalsnapshotter.Instance()
+
+// ValidateCreateSnapshot takes a CSI snapshot of the PVC of the given PV using a VolumeSnapshotClass of the
+// driver, validates that the snapshot becomes ready with a snapshot handle of the driver and deletes it again
+func (d *genericCsi) ValidateCreateSnapshot(name string, params map[string]string) error {
+	pv, err := d.getCSIPersistentVolume(name)
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+	pvcName, namespace := params[pvcNameParam], params[pvcNamespaceParam]
+	if len(pvcName) == 0 && pv.Spec.ClaimRef != nil {
+		pvcName, namespace = pv.Spec.ClaimRef.Name, pv.Spec.ClaimRef.Namespace
+	}
+	if len(pvcName) == 0 {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: "PV is not bound to a PVC"}
+	}
+
+	snapClass, err := d.getSnapshotClass()
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+
+	snap, err := k8sSnapshot.CreateSnapshot(&snapv1beta1.VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-torpedo-%d", pvcName, time.Now().Unix()),
+			Namespace: namespace,
+		},
+		Spec: snapv1beta1.VolumeSnapshotSpec{
+			Source: snapv1beta1.VolumeSnapshotSource{
+				PersistentVolumeClaimName: &pvcName,
+			},
+			VolumeSnapshotClassName: &snapClass,
+		},
+	})
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: fmt.Sprintf("failed to create snapshot of PVC %s/%s. Err: %v", namespace, pvcName, err)}
+	}
+	defer func() {
+		if err := k8sSnapshot.DeleteSnapshot(snap.Name, snap.Namespace); err != nil {
+			log.Warnf("failed to delete snapshot %s/%s. Err: %v", snap.Namespace, snap.Name, err)
+		}
+	}()
+
+	t := func() (interface{}, bool, error) {
+		snap, err := k8sSnapshot.GetSnapshot(snap.Name, snap.Namespace)
+		if err != nil {
+			return nil, true, err
+		}
+		if snap.Status == nil {
+			return nil, true, fmt.Errorf("snapshot %s/%s has no status yet", snap.Namespace, snap.Name)
+		}
+		if snap.Status.Error != nil && snap.Status.Error.Message != nil {
+			return nil, true, fmt.Errorf("snapshot %s/%s failed: %s", snap.Namespace, snap.Name, *snap.Status.Error.Message)
+		}
+		if snap.Status.ReadyToUse == nil || !*snap.Status.ReadyToUse || snap.Status.BoundVolumeSnapshotContentName == nil {
+			return nil, true, fmt.Errorf("snapshot %s/%s is not ready yet", snap.Namespace, snap.Name)
+		}
+		return *snap.Status.BoundVolumeSnapshotContentName, false, nil
+	}
+	contentName, err := task.DoRetryWithTimeout(t, snapshotReadyTimeout, snapshotRetry)
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+
+	content, err := k8sSnapshot.GetSnapshotContent(contentName.(string))
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+	if content.Spec.Driver != string(torpedovolume.StorageProvisioner) {
+		return &ErrFailedToValidateSnapshot{
+			ID:    name,
+			Cause: fmt.Sprintf("snapshot content %s is of driver %s, expected %s", content.Name, content.Spec.Driver, torpedovolume.StorageProvisioner),
+		}
+	}
+	if content.Status == nil || content.Status.SnapshotHandle == nil || len(*content.Status.SnapshotHandle) == 0 {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: fmt.Sprintf("snapshot content %s has no snapshot handle", content.Name)}
+	}
+	snapshotHandle := *content.Status.SnapshotHandle
+
+	if d.controller != nil && d.controller.canListSnapshots() {
+		found, err := d.controller.hasSnapshot(snapshotHandle)
+		if err != nil {
+			return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+		}
+		if !found {
+			return &ErrFailedToValidateSnapshot{ID: name, Cause: fmt.Sprintf("snapshot %s is not known to the CSI controller", snapshotHandle)}
+		}
+	}
+	log.Infof("Validated snapshot %s of CSI volume %s", snapshotHandle, name)
+	return nil
+}
+
+// getSnapshotClass returns the name of a VolumeSnapshotClass of the CSI driver, preferring the default class
+func (d *genericCsi) getSnapshotClass() (string, error) {
+	classes, err := k8sSnapshot.ListSnapshotClasses()
+	if err != nil {
+		return "", fmt.Errorf("failed to list volume snapshot classes. Err: %v", err)
+	}
+	var found string
+	for _, class := range classes.Items {
+		if class.Driver != string(torpedovolume.StorageProvisioner) {
+			continue
+		}
+		if class.Annotations["snapshot.storage.kubernetes.io/is-default-class"] == "true" {
+			return class.Name, nil
+		}
+		if len(found) == 0 {
+			found = class.Name
+		}
+	}
+	if len(found) == 0 {
+		return "", fmt.Errorf("no volume snapshot class found for CSI driver %s", torpedovolume.StorageProvisioner)
+	}
+	return found, nil
+}
@@ -0,0 +1,357 @@
+package csi
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/sched-ops/k8s/storage"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// pvcNameParam is the volume param holding the name of the PVC of a volume
+	pvcNameParam = "pvc_name"
+	// pvcNamespaceParam is the volume param holding the namespace of the PVC of a volume
+	pvcNamespaceParam = "pvc_namespace"
+	validateTimeout   = 5 * time.Minute
+	validateRetry     = 10 * time.Second
+)
+
+var k8sStorage = storage.Instance()
+
+// InspectVolume builds the volume with the given PV name from its PV and VolumeAttachment objects
+func (d *genericCsi) InspectVolume(name string) (*api.Volume, error) {
+	pv, err := d.getCSIPersistentVolume(name)
+	if err != nil {
+		return nil, err
+	}
+	capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+	vol := &api.Volume{
+		Id: pv.Spec.CSI.VolumeHandle,
+		Locator: &api.VolumeLocator{
+			Name:         pv.Name,
+			VolumeLabels: pv.Labels,
+		},
+		Spec: &api.VolumeSpec{
+			Size:   uint64(capacity.Value()),
+			Shared: hasAccessMode(pv, corev1.ReadWriteMany),
+		},
+		Readonly: pv.Spec.CSI.ReadOnly,
+		Status:   api.VolumeStatus_VOLUME_STATUS_UP,
+		State:    api.VolumeState_VOLUME_STATE_DETACHED,
+	}
+	attachments, err := d.getVolumeAttachments(pv.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, va := range attachments {
+		if va.Status.Attached {
+			vol.AttachedOn = va.Spec.NodeName
+			vol.State = api.VolumeState_VOLUME_STATE_ATTACHED
+			break
+		}
+	}
+	return vol, nil
+}
+
+// ValidateCreateVolume validates that the PV with the given name is bound, provisioned by the CSI driver
+// and, if a CSI controller endpoint is configured, known to the controller with the requested capacity
+func (d *genericCsi) ValidateCreateVolume(name string, params map[string]string) error {
+	t := func() (interface{}, bool, error) {
+		pv, err := d.getCSIPersistentVolume(name)
+		if err != nil {
+			return nil, true, err
+		}
+		if pv.Status.Phase != corev1.VolumeBound {
+			return nil, true, fmt.Errorf("PV %s is in phase %s, expected %s", pv.Name, pv.Status.Phase, corev1.VolumeBound)
+		}
+		capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+		if pvcName, ok := params[pvcNameParam]; ok {
+			pvc, err := k8sCore.GetPersistentVolumeClaim(pvcName, params[pvcNamespaceParam])
+			if err != nil {
+				return nil, true, err
+			}
+			requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+			if capacity.Cmp(requested) < 0 {
+				return nil, false, fmt.Errorf("PV %s has capacity %s which is less than the %s requested by PVC %s",
+					pv.Name, capacity.String(), requested.String(), pvcName)
+			}
+		}
+		if d.controller != nil && d.controller.canGetVolume() {
+			csiVol, err := d.controller.getVolume(pv.Spec.CSI.VolumeHandle)
+			if err != nil {
+				return nil, true, err
+			}
+			if csiVol == nil {
+				return nil, true, fmt.Errorf("volume %s of PV %s is not known to the CSI controller", pv.Spec.CSI.VolumeHandle, pv.Name)
+			}
+			if csiVol.CapacityBytes > 0 && csiVol.CapacityBytes < capacity.Value() {
+				return nil, false, fmt.Errorf("CSI controller reports capacity %d for volume %s, PV %s has %d",
+					csiVol.CapacityBytes, pv.Spec.CSI.VolumeHandle, pv.Name, capacity.Value())
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, validateTimeout, validateRetry); err != nil {
+		return &ErrFailedToInspectVolume{
+			ID:    name,
+			Cause: err.Error(),
+		}
+	}
+	log.Infof("Validated creation of CSI volume %s", name)
+	return nil
+}
+
+// ValidateDeleteVolume validates that the PV, its VolumeAttachments and, if a CSI controller endpoint is
+// configured, the CSI volume are gone
+func (d *genericCsi) ValidateDeleteVolume(vol *torpedovolume.Volume) error {
+	t := func() (interface{}, bool, error) {
+		pv, err := k8sCore.GetPersistentVolume(vol.ID)
+		if err == nil {
+			if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimDelete {
+				log.Infof("PV %s has reclaim policy %s, skipping delete validation", pv.Name, pv.Spec.PersistentVolumeReclaimPolicy)
+				return nil, false, nil
+			}
+			return nil, true, fmt.Errorf("PV %s still exists in phase %s", pv.Name, pv.Status.Phase)
+		}
+		if !k8serrors.IsNotFound(err) {
+			return nil, true, err
+		}
+		attachments, err := d.getVolumeAttachments(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		if len(attachments) > 0 {
+			return nil, true, fmt.Errorf("PV %s still has %d VolumeAttachment(s)", vol.ID, len(attachments))
+		}
+		d.Lock()
+		handle, ok := d.volumeHandles[vol.ID]
+		d.Unlock()
+		if ok && d.controller != nil && d.controller.canGetVolume() {
+			csiVol, err := d.controller.getVolume(handle)
+			if err != nil {
+				return nil, true, err
+			}
+			if csiVol != nil {
+				return nil, true, fmt.Errorf("volume %s of deleted PV %s still exists in the CSI controller", handle, vol.ID)
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, validateTimeout, validateRetry); err != nil {
+		return &ErrFailedToDeleteVolume{
+			ID:    vol.ID,
+			Cause: err.Error(),
+		}
+	}
+	d.Lock()
+	delete(d.volumeHandles, vol.ID)
+	d.Unlock()
+	return nil
+}
+
+// ValidateVolumeSetup validates that the volume is attached to the nodes of all running pods using it and
+// that the CSI driver is registered on those nodes
+func (d *genericCsi) ValidateVolumeSetup(vol *torpedovolume.Volume) error {
+	t := func() (interface{}, bool, error) {
+		pv, err := d.getCSIPersistentVolume(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		pods, err := k8sCore.GetPodsUsingPV(pv.Name)
+		if err != nil {
+			return nil, true, err
+		}
+		attachRequired, err := d.isAttachRequired()
+		if err != nil {
+			return nil, true, err
+		}
+		attachments, err := d.getVolumeAttachments(pv.Name)
+		if err != nil {
+			return nil, true, err
+		}
+		var csiVol *csiVolume
+		if d.controller != nil && d.controller.reportsPublishedNodes() {
+			if csiVol, err = d.controller.getVolume(pv.Spec.CSI.VolumeHandle); err != nil {
+				return nil, true, err
+			}
+		}
+
+		for _, pod := range pods {
+			if pod.Status.Phase != corev1.PodRunning || len(pod.Spec.NodeName) == 0 {
+				continue
+			}
+			nodeID, err := d.getCSINodeID(pod.Spec.NodeName)
+			if err != nil {
+				return nil, true, err
+			}
+			if attachRequired && !isAttachedTo(attachments, pod.Spec.NodeName) {
+				return nil, true, fmt.Errorf("PV %s is used by pod %s/%s but not attached to its node %s",
+					pv.Name, pod.Namespace, pod.Name, pod.Spec.NodeName)
+			}
+			if csiVol != nil && !containsString(csiVol.PublishedNodeIDs, nodeID) {
+				return nil, true, fmt.Errorf("CSI controller does not report volume %s as published to node %s (%s)",
+					pv.Spec.CSI.VolumeHandle, pod.Spec.NodeName, nodeID)
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, validateTimeout, validateRetry); err != nil {
+		return &ErrFailedToValidateAttachment{
+			ID:    vol.ID,
+			Cause: err.Error(),
+		}
+	}
+	return nil
+}
+
+// GetNodeForVolume returns the node the volume is attached to, or the node of a running pod using
+// the volume for CSI drivers which do not require attach
+func (d *genericCsi) GetNodeForVolume(vol *torpedovolume.Volume, timeout time.Duration, retryInterval time.Duration) (*node.Node, error) {
+	t := func() (interface{}, bool, error) {
+		pv, err := d.getCSIPersistentVolume(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		attachRequired, err := d.isAttachRequired()
+		if err != nil {
+			return nil, true, err
+		}
+		var nodeName string
+		if attachRequired {
+			attachments, err := d.getVolumeAttachments(pv.Name)
+			if err != nil {
+				return nil, true, err
+			}
+			for _, va := range attachments {
+				if va.Status.Attached {
+					nodeName = va.Spec.NodeName
+					break
+				}
+			}
+		} else {
+			pods, err := k8sCore.GetPodsUsingPV(pv.Name)
+			if err != nil {
+				return nil, true, err
+			}
+			for _, pod := range pods {
+				if pod.Status.Phase == corev1.PodRunning {
+					nodeName = pod.Spec.NodeName
+					break
+				}
+			}
+		}
+		if len(nodeName) == 0 {
+			return nil, true, fmt.Errorf("PV %s is not attached to any node", pv.Name)
+		}
+		n, err := node.GetNodeByName(nodeName)
+		if err != nil {
+			return nil, false, err
+		}
+		return &n, false, nil
+	}
+	n, err := task.DoRetryWithTimeout(t, timeout, retryInterval)
+	if err != nil {
+		return nil, &ErrFailedToValidateAttachment{
+			ID:    vol.ID,
+			Cause: err.Error(),
+		}
+	}
+	return n.(*node.Node), nil
+}
+
+// getCSIPersistentVolume returns the PV with the given name after checking it is provisioned by the CSI driver
+func (d *genericCsi) getCSIPersistentVolume(name string) (*corev1.PersistentVolume, error) {
+	pv, err := k8sCore.GetPersistentVolume(name)
+	if err != nil {
+		return nil, err
+	}
+	if pv.Spec.CSI == nil {
+		return nil, fmt.Errorf("PV %s is not a CSI volume", pv.Name)
+	}
+	if pv.Spec.CSI.Driver != string(torpedovolume.StorageProvisioner) {
+		return nil, fmt.Errorf("PV %s is provisioned by CSI driver %s, expected %s", pv.Name, pv.Spec.CSI.Driver, torpedovolume.StorageProvisioner)
+	}
+	d.Lock()
+	d.volumeHandles[pv.Name] = pv.Spec.CSI.VolumeHandle
+	d.Unlock()
+	return pv, nil
+}
+
+// getVolumeAttachments returns the VolumeAttachments of the given PV
+func (d *genericCsi) getVolumeAttachments(pvName string) ([]storageapi.VolumeAttachment, error) {
+	vaList, err := k8sStorage.ListVolumeAttachments()
+	if err != nil {
+		return nil, err
+	}
+	var attachments []storageapi.VolumeAttachment
+	for _, va := range vaList.Items {
+		if va.Spec.Source.PersistentVolumeName != nil && *va.Spec.Source.PersistentVolumeName == pvName {
+			attachments = append(attachments, va)
+		}
+	}
+	return attachments, nil
+}
+
+// isAttachRequired returns true if the CSIDriver object of the driver requires the attach operation
+func (d *genericCsi) isAttachRequired() (bool, error) {
+	csiDriver, err := d.kubeClient.StorageV1().CSIDrivers().Get(context.TODO(), string(torpedovolume.StorageProvisioner), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		// drivers without a CSIDriver object are attached
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return csiDriver.Spec.AttachRequired == nil || *csiDriver.Spec.AttachRequired, nil
+}
+
+// getCSINodeID returns the ID the CSI driver uses for the given node, failing if the driver is not registered on it
+func (d *genericCsi) getCSINodeID(nodeName string) (string, error) {
+	csiNode, err := d.kubeClient.StorageV1().CSINodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get CSINode %s. Err: %v", nodeName, err)
+	}
+	for _, driver := range csiNode.Spec.Drivers {
+		if driver.Name == string(torpedovolume.StorageProvisioner) {
+			return driver.NodeID, nil
+		}
+	}
+	return "", fmt.Errorf("CSI driver %s is not registered on node %s", torpedovolume.StorageProvisioner, nodeName)
+}
+
+func isAttachedTo(attachments []storageapi.VolumeAttachment, nodeName string) bool {
+	for _, va := range attachments {
+		if va.Spec.NodeName == nodeName && va.Status.Attached {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAccessMode(pv *corev1.PersistentVolume, mode corev1.PersistentVolumeAccessMode) bool {
+	for _, m := range pv.Spec.AccessModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
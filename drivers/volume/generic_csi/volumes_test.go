@@ -0,0 +1,84 @@
+package csi
+
+import (
+	"testing"
+
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const testDriverName = "csi.example.com"
+
+func TestIsAttachRequired(t *testing.T) {
+	torpedovolume.StorageProvisioner = testDriverName
+
+	// drivers without a CSIDriver object are attached
+	d := &genericCsi{kubeClient: fake.NewSimpleClientset()}
+	required, err := d.isAttachRequired()
+	require.NoError(t, err)
+	require.True(t, required)
+
+	attachRequired := false
+	d = &genericCsi{kubeClient: fake.NewSimpleClientset(&storageapi.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: testDriverName},
+		Spec:       storageapi.CSIDriverSpec{AttachRequired: &attachRequired},
+	})}
+	required, err = d.isAttachRequired()
+	require.NoError(t, err)
+	require.False(t, required)
+}
+
+func TestGetCSINodeID(t *testing.T) {
+	torpedovolume.StorageProvisioner = testDriverName
+	d := &genericCsi{kubeClient: fake.NewSimpleClientset(
+		&storageapi.CSINode{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Spec: storageapi.CSINodeSpec{Drivers: []storageapi.CSINodeDriver{
+				{Name: "other.example.com", NodeID: "other-id"},
+				{Name: testDriverName, NodeID: "node-1-id"},
+			}},
+		},
+		&storageapi.CSINode{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Spec: storageapi.CSINodeSpec{Drivers: []storageapi.CSINodeDriver{
+				{Name: "other.example.com", NodeID: "other-id"},
+			}},
+		},
+	)}
+
+	nodeID, err := d.getCSINodeID("node-1")
+	require.NoError(t, err)
+	require.Equal(t, "node-1-id", nodeID)
+
+	_, err = d.getCSINodeID("node-2")
+	require.Error(t, err)
+
+	_, err = d.getCSINodeID("node-3")
+	require.Error(t, err)
+}
+
+func TestIsAttachedTo(t *testing.T) {
+	attachments := []storageapi.VolumeAttachment{
+		{Spec: storageapi.VolumeAttachmentSpec{NodeName: "node-1"}, Status: storageapi.VolumeAttachmentStatus{Attached: true}},
+		{Spec: storageapi.VolumeAttachmentSpec{NodeName: "node-2"}, Status: storageapi.VolumeAttachmentStatus{Attached: false}},
+	}
+	require.True(t, isAttachedTo(attachments, "node-1"))
+	require.False(t, isAttachedTo(attachments, "node-2"))
+	require.False(t, isAttachedTo(attachments, "node-3"))
+	require.False(t, isAttachedTo(nil, "node-1"))
+}
+
+func TestHasAccessMode(t *testing.T) {
+	pv := &corev1.PersistentVolume{
+		Spec: corev1.PersistentVolumeSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadOnlyMany},
+		},
+	}
+	require.True(t, hasAccessMode(pv, corev1.ReadWriteOnce))
+	require.True(t, hasAccessMode(pv, corev1.ReadOnlyMany))
+	require.False(t, hasAccessMode(pv, corev1.ReadWriteMany))
+}
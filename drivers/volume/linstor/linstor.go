@@ -42,6 +42,14 @@ func (d *linstor) String() string {
 	return string(LinstorStorage)
 }
 
+func (d *linstor) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities(
+		torpedovolume.CapabilityDriverRestart,
+		torpedovolume.CapabilityReplication,
+		torpedovolume.CapabilitySnapshot,
+	)
+}
+
 func (d *linstor) Init(sched, nodeDriver, token, storageProvisioner, csiGenericDriverConfigMap string) error {
 	log.Infof("Using the LINSTOR volume driver with provisioner %s under scheduler: %v", storageProvisioner, sched)
 
@@ -59,6 +67,10 @@ func (d *linstor) Init(sched, nodeDriver, token, storageProvisioner, csiGenericD
 
 	d.cli = client
 
+	if err := d.updateNodes(); err != nil {
+		return err
+	}
+
 	// Set provisioner for torpedo
 	if storageProvisioner != "" {
 		if p, ok := provisioners[torpedovolume.StorageProvisionerType(storageProvisioner)]; ok {
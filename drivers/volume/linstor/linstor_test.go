@@ -0,0 +1,302 @@
+package linstor
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	lclient "github.com/LINBIT/golinstor/client"
+	"github.com/libopenstorage/openstorage/api"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeController is a stand-in for the REST API of a LINSTOR controller with the resources of one cluster
+type fakeController struct {
+	sync.Mutex
+	rds       []lclient.ResourceDefinition
+	vds       map[string][]lclient.VolumeDefinition
+	resources []lclient.ResourceWithVolumes
+	pools     []lclient.StoragePool
+	snapshots map[string]lclient.Snapshot
+}
+
+func newFakeController() *fakeController {
+	return &fakeController{
+		rds: []lclient.ResourceDefinition{
+			{Name: "pvc-1"},
+			{Name: "linstor-rd-2", ExternalName: "PVC_With_Invalid_Name"},
+		},
+		vds: map[string][]lclient.VolumeDefinition{
+			"pvc-1":        {{VolumeNumber: 0, SizeKib: 1024 * 1024}},
+			"linstor-rd-2": {{VolumeNumber: 0, SizeKib: 2048}},
+		},
+		resources: []lclient.ResourceWithVolumes{
+			replica("pvc-1", "node-1", true),
+			replica("pvc-1", "node-2", false),
+			{Resource: lclient.Resource{Name: "pvc-1", NodeName: "node-3", Flags: []string{flagDiskless}}},
+			replica("linstor-rd-2", "node-2", false),
+		},
+		pools: []lclient.StoragePool{
+			{StoragePoolName: "thin", NodeName: "node-1", ProviderKind: lclient.LVM_THIN, Uuid: "uuid-1", TotalCapacity: 100, FreeCapacity: 40,
+				SupportsSnapshots: true, Props: map[string]string{storPoolNameProp: "vg/thin", "Aux/tier": "ssd"}},
+			{StoragePoolName: "thin", NodeName: "node-2", ProviderKind: lclient.LVM_THIN, Uuid: "uuid-2", TotalCapacity: 100, FreeCapacity: 100,
+				SupportsSnapshots: true, Props: map[string]string{storPoolNameProp: "vg/thin"}},
+			{StoragePoolName: "thin", NodeName: "node-3", ProviderKind: lclient.LVM_THIN, Uuid: "uuid-3", TotalCapacity: 100, FreeCapacity: 100,
+				SupportsSnapshots: true},
+			{StoragePoolName: "DfltDisklessStorPool", NodeName: "node-3", ProviderKind: lclient.DISKLESS, Uuid: "uuid-4"},
+		},
+		snapshots: make(map[string]lclient.Snapshot),
+	}
+}
+
+func replica(rd, nodeName string, inUse bool) lclient.ResourceWithVolumes {
+	return lclient.ResourceWithVolumes{
+		Resource: lclient.Resource{Name: rd, NodeName: nodeName, State: lclient.ResourceState{InUse: inUse}},
+		Volumes:  []lclient.Volume{{StoragePool: "thin", State: lclient.VolumeState{DiskState: diskStateUpToDate}}},
+	}
+}
+
+func (f *fakeController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	reply := func(v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+
+	switch {
+	case r.URL.Path == "/v1/view/storage-pools":
+		reply(f.pools)
+	case r.URL.Path == "/v1/view/resources":
+		filter := r.URL.Query().Get("resources")
+		var result []lclient.ResourceWithVolumes
+		for _, res := range f.resources {
+			if len(filter) == 0 || res.Name == filter {
+				result = append(result, res)
+			}
+		}
+		reply(result)
+	case r.URL.Path == "/v1/resource-definitions":
+		reply(f.rds)
+	case len(parts) == 3 && parts[1] == "resource-definitions":
+		for _, rd := range f.rds {
+			if rd.Name == parts[2] {
+				reply(rd)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case len(parts) == 4 && parts[3] == "volume-definitions":
+		reply(f.vds[parts[2]])
+	case len(parts) == 4 && parts[3] == "autoplace":
+		var req lclient.AutoPlaceRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		f.autoplace(parts[2], int(req.SelectFilter.PlaceCount))
+		w.WriteHeader(http.StatusCreated)
+	case len(parts) == 5 && parts[3] == "resources" && r.Method == http.MethodPost:
+		var req lclient.ResourceCreate
+		json.NewDecoder(r.Body).Decode(&req)
+		res := replica(parts[2], parts[4], false)
+		res.Volumes[0].StoragePool = req.Resource.Props[resourceStorPoolProp]
+		f.resources = append(f.resources, res)
+		w.WriteHeader(http.StatusCreated)
+	case len(parts) == 5 && parts[3] == "resources" && r.Method == http.MethodDelete:
+		for i, res := range f.resources {
+			if res.Name == parts[2] && res.NodeName == parts[4] {
+				f.resources = append(f.resources[:i], f.resources[i+1:]...)
+				break
+			}
+		}
+	case len(parts) == 7 && parts[5] == "toggle-disk" && parts[6] == "diskless":
+		for i, res := range f.resources {
+			if res.Name == parts[2] && res.NodeName == parts[4] {
+				f.resources[i].Flags = []string{flagDiskless}
+				f.resources[i].Volumes = nil
+			}
+		}
+	case len(parts) == 4 && parts[3] == "snapshots" && r.Method == http.MethodPost:
+		var snap lclient.Snapshot
+		json.NewDecoder(r.Body).Decode(&snap)
+		for _, res := range f.resources {
+			if res.Name == snap.ResourceName && !isDiskless(res.Resource) {
+				snap.Nodes = append(snap.Nodes, res.NodeName)
+			}
+		}
+		for _, vd := range f.vds[snap.ResourceName] {
+			snap.VolumeDefinitions = append(snap.VolumeDefinitions, lclient.SnapshotVolumeDefinition{VolumeNumber: vd.VolumeNumber, SizeKib: vd.SizeKib})
+		}
+		snap.Flags = []string{snapshotFlagSuccessful}
+		f.snapshots[snap.ResourceName+"/"+snap.Name] = snap
+		w.WriteHeader(http.StatusCreated)
+	case len(parts) == 5 && parts[3] == "snapshots":
+		key := parts[2] + "/" + parts[4]
+		snap, ok := f.snapshots[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			delete(f.snapshots, key)
+			return
+		}
+		reply(snap)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// autoplace adds diskful replicas on nodes with storage until the resource has the given number of them
+func (f *fakeController) autoplace(rd string, count int) {
+	placed := make(map[string]bool)
+	for _, res := range f.resources {
+		if res.Name == rd && !isDiskless(res.Resource) {
+			placed[res.NodeName] = true
+		}
+	}
+nextPool:
+	for _, sp := range f.pools {
+		if len(placed) >= count {
+			return
+		}
+		if sp.ProviderKind == lclient.DISKLESS || placed[sp.NodeName] {
+			continue
+		}
+		placed[sp.NodeName] = true
+		// a diskless resource on the node is turned into a replica
+		for i, res := range f.resources {
+			if res.Name == rd && res.NodeName == sp.NodeName {
+				f.resources[i] = replica(rd, sp.NodeName, res.State.InUse)
+				continue nextPool
+			}
+		}
+		f.resources = append(f.resources, replica(rd, sp.NodeName, false))
+	}
+}
+
+func newTestDriver(t *testing.T) (*linstor, *fakeController) {
+	controller := newFakeController()
+	server := httptest.NewServer(controller)
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	cli, err := lclient.NewClient(lclient.BaseURL(u), lclient.Log(log.New(io.Discard, "", 0)))
+	require.NoError(t, err)
+	return &linstor{cli: cli}, controller
+}
+
+func TestInspectVolume(t *testing.T) {
+	d, _ := newTestDriver(t)
+
+	vol, err := d.InspectVolume("pvc-1")
+	require.NoError(t, err)
+	require.Equal(t, "pvc-1", vol.Id)
+	require.Equal(t, uint64(1024*1024*1024), vol.Spec.Size)
+	require.Equal(t, int64(2), vol.Spec.HaLevel)
+	require.Equal(t, "node-1", vol.AttachedOn)
+	require.Equal(t, api.VolumeState_VOLUME_STATE_ATTACHED, vol.State)
+	require.Equal(t, api.VolumeStatus_VOLUME_STATUS_UP, vol.Status)
+	require.Len(t, vol.ReplicaSets, 1)
+	require.Equal(t, []string{"node-1", "node-2"}, vol.ReplicaSets[0].Nodes)
+	require.Equal(t, []string{"uuid-1", "uuid-2"}, vol.ReplicaSets[0].PoolUuids)
+
+	vol, err = d.InspectVolume("PVC_With_Invalid_Name")
+	require.NoError(t, err)
+	require.Equal(t, "linstor-rd-2", vol.Id)
+	require.Equal(t, api.VolumeState_VOLUME_STATE_DETACHED, vol.State)
+
+	_, err = d.InspectVolume("pvc-missing")
+	require.Error(t, err)
+	require.IsType(t, &ErrFailedToInspectVolume{}, err)
+}
+
+func TestValidateCreateVolume(t *testing.T) {
+	d, _ := newTestDriver(t)
+
+	require.NoError(t, d.ValidateCreateVolume("pvc-1", map[string]string{
+		"linstor.csi.linbit.com/placementCount": "2",
+		"linstor.csi.linbit.com/storagePool":    "thin",
+	}))
+	err := d.ValidateCreateVolume("pvc-1", map[string]string{"storagePool": "thick"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "expected thick")
+}
+
+func TestSetReplicationFactor(t *testing.T) {
+	d, _ := newTestDriver(t)
+	vol := &torpedovolume.Volume{ID: "pvc-1"}
+	opts := torpedovolume.Options{ValidateReplicationUpdateTimeout: time.Minute}
+
+	rf, err := d.GetReplicationFactor(vol)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), rf)
+
+	require.NoError(t, d.SetReplicationFactor(vol, 3, nil, nil, true, opts))
+	rf, err = d.GetReplicationFactor(vol)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), rf)
+
+	// the replica in use on node-1 is kept, the others are removed
+	require.NoError(t, d.SetReplicationFactor(vol, 1, nil, nil, true, opts))
+	sets, err := d.GetReplicaSets(vol)
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-1"}, sets[0].Nodes)
+
+	err = d.SetReplicationFactor(vol, 0, []string{"node-2"}, nil, false)
+	require.Error(t, err)
+	require.IsType(t, &ErrFailedToSetReplicationFactor{}, err)
+}
+
+func TestSetReplicationFactorOnNodes(t *testing.T) {
+	d, controller := newTestDriver(t)
+	vol := &torpedovolume.Volume{ID: "PVC_With_Invalid_Name"}
+	controller.resources[3].State.InUse = true
+
+	require.NoError(t, d.SetReplicationFactor(vol, 2, []string{"node-3"}, []string{"thin"}, true))
+	sets, err := d.GetReplicaSets(vol)
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-2", "node-3"}, sets[0].Nodes)
+
+	// the replica in use is turned diskless instead of being deleted
+	require.NoError(t, d.SetReplicationFactor(vol, 1, []string{"node-2"}, nil, true))
+	inspected, err := d.InspectVolume(vol.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"node-3"}, inspected.ReplicaSets[0].Nodes)
+	require.Equal(t, "node-2", inspected.AttachedOn)
+}
+
+func TestListStoragePools(t *testing.T) {
+	d, _ := newTestDriver(t)
+
+	pools, err := d.ListStoragePools(metav1.LabelSelector{})
+	require.NoError(t, err)
+	require.Len(t, pools, 3)
+	require.Equal(t, uint64(100*1024), pools["uuid-1"].TotalSize)
+	require.Equal(t, uint64(60*1024), pools["uuid-1"].Used)
+
+	pools, err = d.ListStoragePools(metav1.LabelSelector{MatchLabels: map[string]string{"tier": "ssd"}})
+	require.NoError(t, err)
+	require.Len(t, pools, 1)
+	require.Contains(t, pools, "uuid-1")
+}
+
+func TestValidateCreateSnapshot(t *testing.T) {
+	d, controller := newTestDriver(t)
+
+	require.NoError(t, d.ValidateCreateSnapshot("pvc-1", nil))
+	require.Empty(t, controller.snapshots)
+
+	controller.pools[1].SupportsSnapshots = false
+	err := d.ValidateCreateSnapshot("pvc-1", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not supported")
+}
@@ -0,0 +1,202 @@
+package linstor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	lclient "github.com/LINBIT/golinstor/client"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// poolNodeLabel is the storage pool label with the name of the LINSTOR node of the pool
+	poolNodeLabel = "linstor.linbit.com/node"
+	// poolNameLabel is the storage pool label with the name of the LINSTOR storage pool
+	poolNameLabel = "linstor.linbit.com/storage-pool"
+	// poolProviderLabel is the storage pool label with the provider kind of the pool, e.g. LVM_THIN
+	poolProviderLabel = "linstor.linbit.com/provider"
+	// auxPropPrefix is the prefix of user defined LINSTOR properties, which are exposed as pool labels
+	auxPropPrefix = "Aux/"
+	// storPoolNameProp is the property with the backing LVM volume group, thin pool or zpool of a storage pool
+	storPoolNameProp = "StorDriver/StorPoolName"
+	// nodeOnline is the connection status of a LINSTOR node which is up
+	nodeOnline = "ONLINE"
+)
+
+func poolKey(nodeName, poolName string) string {
+	return nodeName + "/" + poolName
+}
+
+// getStoragePools returns the storage pools with storage, i.e. all but the diskless ones, sorted by node and name
+func (d *linstor) getStoragePools() ([]lclient.StoragePool, error) {
+	pools, err := d.cli.Nodes.GetStoragePoolView(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get LINSTOR storage pools. Err: %v", err)
+	}
+	var result []lclient.StoragePool
+	for _, sp := range pools {
+		if sp.ProviderKind != lclient.DISKLESS {
+			result = append(result, sp)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return poolKey(result[i].NodeName, result[i].StoragePoolName) < poolKey(result[j].NodeName, result[j].StoragePoolName)
+	})
+	return result, nil
+}
+
+// getPoolUUIDs returns the UUIDs of the storage pools by node and pool name
+func (d *linstor) getPoolUUIDs() (map[string]string, error) {
+	pools, err := d.getStoragePools()
+	if err != nil {
+		return nil, err
+	}
+	uuids := make(map[string]string)
+	for _, sp := range pools {
+		uuids[poolKey(sp.NodeName, sp.StoragePoolName)] = sp.Uuid
+	}
+	return uuids, nil
+}
+
+// toStoragePool converts a LINSTOR storage pool. LINSTOR reports capacities in KiB.
+func toStoragePool(sp lclient.StoragePool, id int32) *api.StoragePool {
+	poolLabels := map[string]string{
+		poolNodeLabel:     sp.NodeName,
+		poolNameLabel:     sp.StoragePoolName,
+		poolProviderLabel: string(sp.ProviderKind),
+	}
+	for key, value := range sp.Props {
+		if strings.HasPrefix(key, auxPropPrefix) {
+			poolLabels[strings.TrimPrefix(key, auxPropPrefix)] = value
+		}
+	}
+	var used uint64
+	if sp.TotalCapacity > sp.FreeCapacity {
+		used = uint64(sp.TotalCapacity-sp.FreeCapacity) * 1024
+	}
+	return &api.StoragePool{
+		ID:        id,
+		Uuid:      sp.Uuid,
+		TotalSize: uint64(sp.TotalCapacity) * 1024,
+		Used:      used,
+		Labels:    poolLabels,
+	}
+}
+
+// ListStoragePools returns the storage pools matching the label selector, keyed by UUID. Pools are labeled
+// with their node, name and provider kind and with their user defined Aux/ properties.
+func (d *linstor) ListStoragePools(labelSelector metav1.LabelSelector) (map[string]*api.StoragePool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %v. Err: %v", labelSelector, err)
+	}
+	pools, err := d.getStoragePools()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*api.StoragePool)
+	ids := make(map[string]int32)
+	for _, sp := range pools {
+		pool := toStoragePool(sp, ids[sp.NodeName])
+		ids[sp.NodeName]++
+		if selector.Matches(labels.Set(pool.Labels)) {
+			result[pool.Uuid] = pool
+		}
+	}
+	return result, nil
+}
+
+// GetStorageDevices returns the backing volume groups, thin pools or zpools of the storage pools on the node
+func (d *linstor) GetStorageDevices(n node.Node) ([]string, error) {
+	pools, err := d.getStoragePools()
+	if err != nil {
+		return nil, err
+	}
+	var devices []string
+	for _, sp := range pools {
+		if sp.NodeName != n.Name {
+			continue
+		}
+		if device, ok := sp.Props[storPoolNameProp]; ok {
+			devices = append(devices, device)
+		} else {
+			devices = append(devices, sp.StoragePoolName)
+		}
+	}
+	return devices, nil
+}
+
+// RefreshDriverEndpoints updates the storage information of the nodes from the LINSTOR controller
+func (d *linstor) RefreshDriverEndpoints() error {
+	return d.updateNodes()
+}
+
+// updateNodes sets the storage node and storage pools of every node in the registry which is a LINSTOR
+// satellite. The LINSTOR node name is used as volume driver node ID, as replica sets refer to it.
+func (d *linstor) updateNodes() error {
+	linstorNodes, err := d.cli.Nodes.GetAll(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to get LINSTOR nodes. Err: %v", err)
+	}
+	byName := make(map[string]lclient.Node)
+	for _, ln := range linstorNodes {
+		byName[ln.Name] = ln
+	}
+	pools, err := d.getStoragePools()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range node.GetNodes() {
+		ln, ok := byName[n.Name]
+		if !ok {
+			continue
+		}
+		status := api.Status_STATUS_OFFLINE
+		if ln.ConnectionStatus == nodeOnline {
+			status = api.Status_STATUS_OK
+		}
+		storageNode := &api.StorageNode{
+			Id:                ln.Name,
+			Status:            status,
+			Hostname:          ln.Name,
+			SchedulerNodeName: n.Name,
+		}
+		// keep the pools captured at init across refreshes
+		poolsAtInit := make(map[string]*api.StoragePool)
+		for _, pool := range n.StoragePools {
+			if pool.StoragePoolAtInit != nil {
+				poolsAtInit[pool.StoragePoolAtInit.Uuid] = pool.StoragePoolAtInit
+			}
+		}
+		var storagePools []node.StoragePool
+		for _, sp := range pools {
+			if sp.NodeName != ln.Name {
+				continue
+			}
+			id := int32(len(storagePools))
+			pool := toStoragePool(sp, id)
+			storageNode.Pools = append(storageNode.Pools, pool)
+			poolAtInit, ok := poolsAtInit[sp.Uuid]
+			if !ok {
+				poolAtInit = toStoragePool(sp, id)
+			}
+			storagePools = append(storagePools, node.StoragePool{StoragePool: pool, StoragePoolAtInit: poolAtInit})
+		}
+		n.StorageNode = storageNode
+		n.VolDriverNodeID = ln.Name
+		n.IsStorageDriverInstalled = true
+		n.StoragePools = storagePools
+		if err := node.UpdateNode(n); err != nil {
+			return err
+		}
+		log.Debugf("Updated node %s with %d LINSTOR storage pool(s)", n.Name, len(storagePools))
+	}
+	return nil
+}
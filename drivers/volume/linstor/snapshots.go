@@ -0,0 +1,124 @@
+package linstor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	lclient "github.com/LINBIT/golinstor/client"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// snapshotFlagSuccessful is the flag of a snapshot which was taken on all its nodes
+	snapshotFlagSuccessful = "SUCCESSFUL"
+	// snapshotFlagFailedDeployment is the flag of a snapshot which failed to be taken
+	snapshotFlagFailedDeployment = "FAILED_DEPLOYMENT"
+	// snapshotFlagFailedDisconnect is the flag of a snapshot which failed as a node disconnected
+	snapshotFlagFailedDisconnect = "FAILED_DISCONNECT"
+	// validateSnapshotTimeout is the time to wait for a snapshot to be taken
+	validateSnapshotTimeout = 5 * time.Minute
+)
+
+// ValidateCreateSnapshot takes a LINSTOR snapshot of the resource definition of the volume, validates that it
+// gets taken on all replicas with the size of the volume and deletes it again
+func (d *linstor) ValidateCreateSnapshot(name string, params map[string]string) error {
+	rd, err := d.getResourceDefinition(name)
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+	if rd == nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: "resource definition not found"}
+	}
+	diskful, _, err := d.getResources(rd.Name)
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+	if err := d.checkSnapshotSupport(diskful); err != nil {
+		return err
+	}
+	size, err := d.getVolumeSize(rd.Name)
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+
+	snapName := fmt.Sprintf("torpedo-snap-%d", time.Now().Unix())
+	if err := d.cli.Resources.CreateSnapshot(context.TODO(), lclient.Snapshot{Name: snapName, ResourceName: rd.Name}); err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: fmt.Sprintf("failed to create snapshot %s. Err: %v", snapName, err)}
+	}
+	defer func() {
+		if err := d.cli.Resources.DeleteSnapshot(context.TODO(), rd.Name, snapName); err != nil {
+			log.Warnf("Failed to delete snapshot %s of %s. Err: %v", snapName, rd.Name, err)
+		}
+	}()
+
+	t := func() (interface{}, bool, error) {
+		snap, err := d.cli.Resources.GetSnapshot(context.TODO(), rd.Name, snapName)
+		if err != nil {
+			return nil, true, err
+		}
+		for _, flag := range snap.Flags {
+			switch flag {
+			case snapshotFlagSuccessful:
+				return snap, false, nil
+			case snapshotFlagFailedDeployment, snapshotFlagFailedDisconnect:
+				return nil, false, fmt.Errorf("snapshot %s failed with flags %v", snapName, snap.Flags)
+			}
+		}
+		return nil, true, fmt.Errorf("snapshot %s is not taken yet, flags %v", snapName, snap.Flags)
+	}
+	out, err := task.DoRetryWithTimeout(t, validateSnapshotTimeout, defaultRetryInterval)
+	if err != nil {
+		return &ErrFailedToValidateSnapshot{ID: name, Cause: err.Error()}
+	}
+	snap := out.(lclient.Snapshot)
+
+	snapNodes := make(map[string]bool)
+	for _, nodeName := range snap.Nodes {
+		snapNodes[nodeName] = true
+	}
+	for _, res := range diskful {
+		if !snapNodes[res.NodeName] {
+			return &ErrFailedToValidateSnapshot{
+				ID:    name,
+				Cause: fmt.Sprintf("snapshot %s was not taken on replica node %s, snapshot nodes: %v", snapName, res.NodeName, snap.Nodes),
+			}
+		}
+	}
+	for _, vd := range snap.VolumeDefinitions {
+		if vd.VolumeNumber == 0 && vd.SizeKib*1024 != size {
+			return &ErrFailedToValidateSnapshot{
+				ID:    name,
+				Cause: fmt.Sprintf("snapshot %s has size %d KiB, volume has %d bytes", snapName, vd.SizeKib, size),
+			}
+		}
+	}
+	log.Infof("Validated snapshot %s of LINSTOR volume %s on nodes %v", snapName, name, snap.Nodes)
+	return nil
+}
+
+// checkSnapshotSupport returns ErrNotSupported if any replica is in a storage pool without snapshot support,
+// e.g. thick LVM
+func (d *linstor) checkSnapshotSupport(diskful []lclient.ResourceWithVolumes) error {
+	pools, err := d.getStoragePools()
+	if err != nil {
+		return err
+	}
+	supported := make(map[string]bool)
+	for _, sp := range pools {
+		supported[poolKey(sp.NodeName, sp.StoragePoolName)] = sp.SupportsSnapshots
+	}
+	for _, res := range diskful {
+		for _, vol := range res.Volumes {
+			if !supported[poolKey(res.NodeName, vol.StoragePool)] {
+				return &errors.ErrNotSupported{
+					Type:      "Function",
+					Operation: fmt.Sprintf("ValidateCreateSnapshot() on storage pool %s of node %s", vol.StoragePool, res.NodeName),
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,469 @@
+package linstor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	lclient "github.com/LINBIT/golinstor/client"
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	// flagDiskless is the flag of resources without local storage, e.g. DRBD clients and tie breakers
+	flagDiskless = "DISKLESS"
+	// diskStateUpToDate is the disk state of a replica which is fully in sync
+	diskStateUpToDate = "UpToDate"
+	// resourceStorPoolProp is the resource property selecting the storage pool of a new replica
+	resourceStorPoolProp = "StorPoolName"
+	// minReplicationFactor is the lowest number of diskful replicas of a volume
+	minReplicationFactor = 1
+	// maxReplicationFactor is the highest number of diskful replicas the tests place for a volume
+	maxReplicationFactor = 3
+	// validateReplicationUpdateTimeout is the default time to wait for replicas to be in sync
+	validateReplicationUpdateTimeout = 30 * time.Minute
+	// validateVolumeTimeout is the time to wait for a volume to be created or deleted
+	validateVolumeTimeout = 5 * time.Minute
+)
+
+var (
+	// placementCountParams are the storage class parameters holding the number of replicas of a volume
+	placementCountParams = []string{
+		"linstor.csi.linbit.com/placementCount",
+		"placementCount",
+		"linstor.csi.linbit.com/autoPlace",
+		"autoPlace",
+	}
+	// storagePoolParams are the storage class parameters holding the storage pool of a volume
+	storagePoolParams = []string{
+		"linstor.csi.linbit.com/storagePool",
+		"storagePool",
+	}
+)
+
+// getResourceDefinition returns the resource definition of the volume with the given name, or nil if it does
+// not exist. LINSTOR CSI names resource definitions after the PV, or keeps the PV name as external name.
+func (d *linstor) getResourceDefinition(name string) (*lclient.ResourceDefinition, error) {
+	rd, err := d.cli.ResourceDefinitions.Get(context.TODO(), name)
+	if err == nil {
+		return &rd, nil
+	}
+	if err != lclient.NotFoundError {
+		return nil, fmt.Errorf("failed to get resource definition %s. Err: %v", name, err)
+	}
+	rds, err := d.cli.ResourceDefinitions.GetAll(context.TODO())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resource definitions. Err: %v", err)
+	}
+	for i := range rds {
+		if rds[i].ExternalName == name {
+			return &rds[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// getResources returns the resources of the given resource definition with their volumes, split in diskful
+// replicas and diskless resources. Both are sorted by node name.
+func (d *linstor) getResources(rdName string) ([]lclient.ResourceWithVolumes, []lclient.ResourceWithVolumes, error) {
+	resources, err := d.cli.Resources.GetResourceView(context.TODO(), &lclient.ListOpts{Resource: []string{rdName}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get resources of %s. Err: %v", rdName, err)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].NodeName < resources[j].NodeName })
+
+	var diskful, diskless []lclient.ResourceWithVolumes
+	for _, res := range resources {
+		// the resource view is not filtered by all controller versions
+		if res.Name != rdName {
+			continue
+		}
+		if isDiskless(res.Resource) {
+			diskless = append(diskless, res)
+		} else {
+			diskful = append(diskful, res)
+		}
+	}
+	return diskful, diskless, nil
+}
+
+func isDiskless(res lclient.Resource) bool {
+	for _, flag := range res.Flags {
+		if flag == flagDiskless {
+			return true
+		}
+	}
+	return false
+}
+
+// isUpToDate returns true if all volumes of the resource are in sync
+func isUpToDate(res lclient.ResourceWithVolumes) bool {
+	for _, vol := range res.Volumes {
+		if vol.State.DiskState != diskStateUpToDate {
+			return false
+		}
+	}
+	return true
+}
+
+// getVolumeSize returns the size in bytes of the first volume of the resource definition
+func (d *linstor) getVolumeSize(rdName string) (uint64, error) {
+	vds, err := d.cli.ResourceDefinitions.GetVolumeDefinitions(context.TODO(), rdName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get volume definitions of %s. Err: %v", rdName, err)
+	}
+	if len(vds) == 0 {
+		return 0, nil
+	}
+	return vds[0].SizeKib * 1024, nil
+}
+
+// getParam returns the value of the first of the given keys present in the params
+func getParam(params map[string]string, keys []string) (string, bool) {
+	for _, key := range keys {
+		if value, ok := params[key]; ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (d *linstor) InspectVolume(name string) (*api.Volume, error) {
+	rd, err := d.getResourceDefinition(name)
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+	}
+	if rd == nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: "resource definition not found"}
+	}
+	size, err := d.getVolumeSize(rd.Name)
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+	}
+	diskful, diskless, err := d.getResources(rd.Name)
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+	}
+	poolUUIDs, err := d.getPoolUUIDs()
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: name, Cause: err.Error()}
+	}
+
+	vol := &api.Volume{
+		Id: rd.Name,
+		Locator: &api.VolumeLocator{
+			Name: name,
+		},
+		Spec: &api.VolumeSpec{
+			Size:    size,
+			HaLevel: int64(len(diskful)),
+		},
+		Status: api.VolumeStatus_VOLUME_STATUS_UP,
+		State:  api.VolumeState_VOLUME_STATE_DETACHED,
+	}
+	replicaSet := &api.ReplicaSet{}
+	for _, res := range diskful {
+		replicaSet.Nodes = append(replicaSet.Nodes, res.NodeName)
+		if len(res.Volumes) > 0 {
+			replicaSet.PoolUuids = append(replicaSet.PoolUuids, poolUUIDs[poolKey(res.NodeName, res.Volumes[0].StoragePool)])
+		}
+		if !isUpToDate(res) {
+			vol.Status = api.VolumeStatus_VOLUME_STATUS_DEGRADED
+		}
+	}
+	vol.ReplicaSets = []*api.ReplicaSet{replicaSet}
+	if len(diskful) == 0 {
+		vol.Status = api.VolumeStatus_VOLUME_STATUS_DOWN
+	}
+	for _, res := range append(diskful, diskless...) {
+		if res.State.InUse {
+			vol.AttachedOn = res.NodeName
+			vol.State = api.VolumeState_VOLUME_STATE_ATTACHED
+			break
+		}
+	}
+	return vol, nil
+}
+
+// ValidateCreateVolume validates that the resource definition of the volume exists with the replica count and
+// storage pool requested by the storage class parameters, and that all its replicas are in sync
+func (d *linstor) ValidateCreateVolume(name string, params map[string]string) error {
+	expectedReplicas := 0
+	if value, ok := getParam(params, placementCountParams); ok {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid placement count %q for volume %s. Err: %v", value, name, err)
+		}
+		expectedReplicas = count
+	}
+	expectedPool, hasPool := getParam(params, storagePoolParams)
+
+	t := func() (interface{}, bool, error) {
+		rd, err := d.getResourceDefinition(name)
+		if err != nil {
+			return nil, true, err
+		}
+		if rd == nil {
+			return nil, true, fmt.Errorf("resource definition of volume %s not found", name)
+		}
+		diskful, _, err := d.getResources(rd.Name)
+		if err != nil {
+			return nil, true, err
+		}
+		if len(diskful) == 0 || len(diskful) < expectedReplicas {
+			return nil, true, fmt.Errorf("volume %s has %d replicas, expected %d", name, len(diskful), expectedReplicas)
+		}
+		for _, res := range diskful {
+			if !isUpToDate(res) {
+				return nil, true, fmt.Errorf("replica of volume %s on node %s is not up to date", name, res.NodeName)
+			}
+			for _, vol := range res.Volumes {
+				if hasPool && vol.StoragePool != expectedPool {
+					return nil, false, fmt.Errorf("replica of volume %s on node %s is in storage pool %s, expected %s",
+						name, res.NodeName, vol.StoragePool, expectedPool)
+				}
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, validateVolumeTimeout, defaultRetryInterval); err != nil {
+		return err
+	}
+	log.Infof("Validated LINSTOR volume %s", name)
+	return nil
+}
+
+// ValidateDeleteVolume validates that the resource definition of the volume got removed
+func (d *linstor) ValidateDeleteVolume(vol *torpedovolume.Volume) error {
+	t := func() (interface{}, bool, error) {
+		rd, err := d.getResourceDefinition(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		if rd != nil {
+			return nil, true, fmt.Errorf("resource definition %s still exists", rd.Name)
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, validateVolumeTimeout, defaultRetryInterval); err != nil {
+		return &ErrFailedToDeleteVolume{ID: vol.ID, Cause: err.Error()}
+	}
+	return nil
+}
+
+func (d *linstor) ValidateVolumeCleanup() error {
+	return nil
+}
+
+func (d *linstor) GetReplicationFactor(vol *torpedovolume.Volume) (int64, error) {
+	rd, err := d.getResourceDefinition(vol.ID)
+	if err != nil {
+		return 0, err
+	}
+	if rd == nil {
+		return 0, fmt.Errorf("resource definition of volume %s not found", vol.ID)
+	}
+	diskful, _, err := d.getResources(rd.Name)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(diskful)), nil
+}
+
+func (d *linstor) GetMaxReplicationFactor() int64 {
+	return maxReplicationFactor
+}
+
+func (d *linstor) GetMinReplicationFactor() int64 {
+	return minReplicationFactor
+}
+
+// SetReplicationFactor adds or removes diskful replicas until the volume has the given number of them.
+// Replicas are added on the given nodes and pools, or placed by LINSTOR if no nodes are given. Replicas are
+// removed from the given nodes, or else from nodes which do not use the volume. A replica which is in use is
+// turned diskless instead of being deleted.
+func (d *linstor) SetReplicationFactor(vol *torpedovolume.Volume, replFactor int64, nodesToBeUpdated []string, poolsToBeUpdated []string, waitForUpdateToFinish bool, opts ...torpedovolume.Options) error {
+	replicationUpdateTimeout := validateReplicationUpdateTimeout
+	if len(opts) > 0 && opts[0].ValidateReplicationUpdateTimeout > 0 {
+		replicationUpdateTimeout = opts[0].ValidateReplicationUpdateTimeout
+	}
+	rd, err := d.getResourceDefinition(vol.ID)
+	if err != nil {
+		return &ErrFailedToSetReplicationFactor{ID: vol.ID, Cause: err.Error()}
+	}
+	if rd == nil {
+		return &ErrFailedToSetReplicationFactor{ID: vol.ID, Cause: "resource definition not found"}
+	}
+	diskful, diskless, err := d.getResources(rd.Name)
+	if err != nil {
+		return &ErrFailedToSetReplicationFactor{ID: vol.ID, Cause: err.Error()}
+	}
+	log.Infof("Setting replication factor of LINSTOR volume %s from %d to %d", vol.ID, len(diskful), replFactor)
+
+	if replFactor > int64(len(diskful)) {
+		err = d.addReplicas(rd.Name, diskful, diskless, replFactor, nodesToBeUpdated, poolsToBeUpdated)
+	} else if replFactor < int64(len(diskful)) {
+		err = d.removeReplicas(rd.Name, diskful, replFactor, nodesToBeUpdated)
+	}
+	if err != nil {
+		return &ErrFailedToSetReplicationFactor{ID: vol.ID, Cause: err.Error()}
+	}
+
+	if waitForUpdateToFinish {
+		return d.WaitForReplicationToComplete(vol, replFactor, replicationUpdateTimeout)
+	}
+	return nil
+}
+
+func (d *linstor) addReplicas(rdName string, diskful, diskless []lclient.ResourceWithVolumes, replFactor int64, nodes, pools []string) error {
+	if len(nodes) == 0 {
+		return d.cli.Resources.Autoplace(context.TODO(), rdName, lclient.AutoPlaceRequest{
+			SelectFilter: lclient.AutoSelectFilter{PlaceCount: int32(replFactor)},
+		})
+	}
+
+	existing := make(map[string]bool)
+	for _, res := range diskful {
+		existing[res.NodeName] = true
+	}
+	clients := make(map[string]bool)
+	for _, res := range diskless {
+		clients[res.NodeName] = true
+	}
+	count := int64(len(diskful))
+	for i, nodeName := range nodes {
+		if count >= replFactor {
+			break
+		}
+		if existing[nodeName] {
+			continue
+		}
+		pool := ""
+		if i < len(pools) {
+			pool = pools[i]
+		}
+		if clients[nodeName] {
+			if err := d.cli.Resources.Diskful(context.TODO(), rdName, nodeName, pool); err != nil {
+				return fmt.Errorf("failed to make resource %s on node %s diskful. Err: %v", rdName, nodeName, err)
+			}
+		} else {
+			res := lclient.Resource{Name: rdName, NodeName: nodeName}
+			if len(pool) > 0 {
+				res.Props = map[string]string{resourceStorPoolProp: pool}
+			}
+			if err := d.cli.Resources.Create(context.TODO(), lclient.ResourceCreate{Resource: res}); err != nil {
+				return fmt.Errorf("failed to create resource %s on node %s. Err: %v", rdName, nodeName, err)
+			}
+		}
+		count++
+	}
+	if count < replFactor {
+		return fmt.Errorf("not enough nodes in %v to place %d replicas", nodes, replFactor)
+	}
+	return nil
+}
+
+func (d *linstor) removeReplicas(rdName string, diskful []lclient.ResourceWithVolumes, replFactor int64, nodes []string) error {
+	var candidates []lclient.ResourceWithVolumes
+	if len(nodes) > 0 {
+		byNode := make(map[string]lclient.ResourceWithVolumes)
+		for _, res := range diskful {
+			byNode[res.NodeName] = res
+		}
+		for _, nodeName := range nodes {
+			if res, ok := byNode[nodeName]; ok {
+				candidates = append(candidates, res)
+			}
+		}
+	} else {
+		// prefer replicas which are not in use
+		candidates = append(candidates, diskful...)
+		sort.SliceStable(candidates, func(i, j int) bool { return !candidates[i].State.InUse && candidates[j].State.InUse })
+	}
+
+	toRemove := int64(len(diskful)) - replFactor
+	if int64(len(candidates)) < toRemove {
+		return fmt.Errorf("not enough replicas on nodes %v to remove %d of them", nodes, toRemove)
+	}
+	for _, res := range candidates[:toRemove] {
+		var err error
+		if res.State.InUse {
+			err = d.cli.Resources.Diskless(context.TODO(), rdName, res.NodeName, "")
+		} else {
+			err = d.cli.Resources.Delete(context.TODO(), rdName, res.NodeName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove replica of %s from node %s. Err: %v", rdName, res.NodeName, err)
+		}
+	}
+	return nil
+}
+
+// WaitForReplicationToComplete waits until the volume has the given number of diskful replicas, all in sync
+func (d *linstor) WaitForReplicationToComplete(vol *torpedovolume.Volume, replFactor int64, replicationUpdateTimeout time.Duration) error {
+	t := func() (interface{}, bool, error) {
+		rd, err := d.getResourceDefinition(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		if rd == nil {
+			return nil, false, fmt.Errorf("resource definition of volume %s not found", vol.ID)
+		}
+		diskful, _, err := d.getResources(rd.Name)
+		if err != nil {
+			return nil, true, err
+		}
+		if int64(len(diskful)) != replFactor {
+			return nil, true, fmt.Errorf("volume %s has %d replicas, expected %d", vol.ID, len(diskful), replFactor)
+		}
+		for _, res := range diskful {
+			if !isUpToDate(res) {
+				return nil, true, fmt.Errorf("replica of volume %s on node %s is not up to date", vol.ID, res.NodeName)
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, replicationUpdateTimeout, defaultRetryInterval); err != nil {
+		return err
+	}
+	log.Infof("Replication of LINSTOR volume %s completed with %d replicas", vol.ID, replFactor)
+	return nil
+}
+
+// GetReplicaSets returns a single replica set with the nodes and storage pool UUIDs of the diskful replicas
+func (d *linstor) GetReplicaSets(vol *torpedovolume.Volume) ([]*api.ReplicaSet, error) {
+	inspected, err := d.InspectVolume(vol.ID)
+	if err != nil {
+		return nil, err
+	}
+	return inspected.ReplicaSets, nil
+}
+
+// GetNodeForVolume returns the node on which the volume is in use
+func (d *linstor) GetNodeForVolume(vol *torpedovolume.Volume, timeout time.Duration, retryInterval time.Duration) (*node.Node, error) {
+	t := func() (interface{}, bool, error) {
+		inspected, err := d.InspectVolume(vol.ID)
+		if err != nil {
+			return nil, true, err
+		}
+		if len(inspected.AttachedOn) == 0 {
+			return nil, true, fmt.Errorf("volume %s is not in use on any node", vol.ID)
+		}
+		n, err := node.GetNodeByName(inspected.AttachedOn)
+		if err != nil {
+			return nil, false, err
+		}
+		return &n, false, nil
+	}
+	n, err := task.DoRetryWithTimeout(t, timeout, retryInterval)
+	if err != nil {
+		return nil, err
+	}
+	return n.(*node.Node), nil
+}
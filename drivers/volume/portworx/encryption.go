@@ -0,0 +1,54 @@
+package portworx
+
+import (
+	"fmt"
+
+	"github.com/portworx/torpedo/drivers/node"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	pxctlSetClusterKey = "secrets set-cluster-key --secret %s --overwrite"
+
+	// locator labels referencing the secret holding the key of a volume
+	secretNameLabel         = "secret_name"
+	secretNamespaceLabel    = "secret_namespace"
+	pvcSecretNameLabel      = "px/secret-name"
+	pvcSecretNamespaceLabel = "px/secret-namespace"
+)
+
+// GetVolumeEncryption returns whether the volume is encrypted and the key it is encrypted with
+func (d *portworx) GetVolumeEncryption(vol *torpedovolume.Volume) (*torpedovolume.Encryption, error) {
+	pxVol, err := d.InspectVolume(vol.ID)
+	if err != nil {
+		return nil, &ErrFailedToInspectVolume{ID: vol.ID, Cause: err.Error()}
+	}
+	spec := pxVol.GetSpec()
+	encryption := &torpedovolume.Encryption{
+		Encrypted: spec.GetEncrypted(),
+		// the passphrase of the spec holds the name of the key, the key itself stays in the secrets store
+		KeyName: spec.GetPassphrase(),
+	}
+	labels := pxVol.GetLocator().GetVolumeLabels()
+	for _, l := range []string{secretNameLabel, pvcSecretNameLabel} {
+		if v, ok := labels[l]; ok {
+			encryption.SecretName = v
+		}
+	}
+	for _, l := range []string{secretNamespaceLabel, pvcSecretNamespaceLabel} {
+		if v, ok := labels[l]; ok {
+			encryption.SecretNamespace = v
+		}
+	}
+	return encryption, nil
+}
+
+// SetClusterEncryptionKey sets the key the volumes without a key of their own are encrypted with
+func (d *portworx) SetClusterEncryptionKey(n node.Node, keyName string) error {
+	log.Infof("Setting cluster key %s using node %s", keyName, n.Name)
+	if _, err := d.GetPxctlCmdOutput(n, fmt.Sprintf(pxctlSetClusterKey, keyName)); err != nil {
+		return &ErrFailedToSetClusterKey{Key: keyName, Cause: err.Error()}
+	}
+	return nil
+}
@@ -193,3 +193,91 @@ type ErrCsiTopologyMismatch struct {
 func (e *ErrCsiTopologyMismatch) Error() string {
 	return fmt.Sprintf("CSI Topology not matching for volume: %v. Err: %v", e.VolName, e.Cause)
 }
+
+// ErrQueryDivergence error type for a query which the SDK and pxctl answer differently
+type ErrQueryDivergence struct {
+	// Query is the name of the query
+	Query string
+	// SDK is the answer or error of the SDK
+	SDK interface{}
+	// Pxctl is the answer or error of pxctl
+	Pxctl interface{}
+}
+
+func (e *ErrQueryDivergence) Error() string {
+	return fmt.Sprintf("SDK and pxctl diverge on %v. SDK: %+v, pxctl: %+v", e.Query, e.SDK, e.Pxctl)
+}
+
+// ErrFailedToCreatePool error type for failing to create a storage pool
+type ErrFailedToCreatePool struct {
+	// Node is the name of the node on which the pool was created
+	Node string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToCreatePool) Error() string {
+	return fmt.Sprintf("Failed to create pool on node: %v due to err: %v", e.Node, e.Cause)
+}
+
+// ErrFailedToDeletePool error type for failing to delete a storage pool or to validate its deletion
+type ErrFailedToDeletePool struct {
+	// ID is the UUID of the pool that failed to delete
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToDeletePool) Error() string {
+	return fmt.Sprintf("Failed to delete pool: %v due to err: %v", e.ID, e.Cause)
+}
+
+// ErrPoolDeleteBlocked error type for a storage pool deletion refused because volumes have replicas on the pool
+type ErrPoolDeleteBlocked struct {
+	// ID is the UUID of the pool whose deletion was refused
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrPoolDeleteBlocked) Error() string {
+	return fmt.Sprintf("Deletion of pool: %v was blocked due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToUpdatePool error type for failing to update a storage pool
+type ErrFailedToUpdatePool struct {
+	// ID is the UUID of the pool that failed to update
+	ID string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToUpdatePool) Error() string {
+	return fmt.Sprintf("Failed to update pool: %v due to err: %v", e.ID, e.Cause)
+}
+
+// ErrFailedToAddDevice error type for failing to add a journal or metadata device
+type ErrFailedToAddDevice struct {
+	// Node is the name of the node the device was added to
+	Node string
+	// Path is the path of the device
+	Path string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToAddDevice) Error() string {
+	return fmt.Sprintf("Failed to add device: %v to node: %v due to err: %v", e.Path, e.Node, e.Cause)
+}
+
+// ErrFailedToSetClusterKey error type for failing to set the cluster wide encryption key
+type ErrFailedToSetClusterKey struct {
+	// Key is the name of the key
+	Key string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrFailedToSetClusterKey) Error() string {
+	return fmt.Sprintf("Failed to set cluster key: %v due to err: %v", e.Key, e.Cause)
+}
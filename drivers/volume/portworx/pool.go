@@ -0,0 +1,330 @@
+package portworx
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/portworx/sched-ops/task"
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const (
+	pxctlPoolMaintenanceEnter = "service pool maintenance --enter -y"
+	pxctlPoolMaintenanceExit  = "service pool maintenance --exit -y"
+	pxctlPoolDelete           = "service pool delete %d"
+	pxctlPoolUpdateLabels     = "service pool update %d --labels %s"
+	pxctlDriveAddJournal      = "service drive add -d %s --journal"
+	pxctlDriveAddMetadata     = "service drive add -d %s --metadata"
+
+	poolOpTimeout       = 30 * time.Minute
+	poolOpRetryInterval = 30 * time.Second
+)
+
+// poolDeleteBlockedRegex matches the pxctl errors for a pool deletion refused because volumes have replicas on it
+var poolDeleteBlockedRegex = regexp.MustCompile(`(?i)(has|contains|with) (\d+ )?(volumes|replicas)|pool is not empty`)
+
+// CreatePool creates a storage pool on the node from the given drive path or cloud drive spec and returns its UUID
+func (d *portworx) CreatePool(n *node.Node, driveSpec string) (string, error) {
+	pools, err := d.getNodePools(*n)
+	if err != nil {
+		return "", &ErrFailedToCreatePool{Node: n.Name, Cause: err.Error()}
+	}
+	existing := make(map[string]bool)
+	for _, pool := range pools {
+		existing[pool.GetUuid()] = true
+	}
+
+	// a cloud drive without a pool ID and a block drive with --newpool are added to a new pool
+	driveAddFlag := fmt.Sprintf("-d %s --newpool", driveSpec)
+	if strings.Contains(driveSpec, "size") {
+		driveAddFlag = fmt.Sprintf("-s %s", driveSpec)
+	}
+	log.Infof("Creating pool on %s with drive %s", n.Name, driveSpec)
+	if err := startDriveAdd(*n, driveSpec, driveAddFlag, d); err != nil {
+		return "", &ErrFailedToCreatePool{Node: n.Name, Cause: err.Error()}
+	}
+	if err := waitForAddDriveToComplete(*n, driveSpec, d); err != nil {
+		return "", &ErrFailedToCreatePool{Node: n.Name, Cause: err.Error()}
+	}
+
+	t := func() (interface{}, bool, error) {
+		pools, err := d.getNodePools(*n)
+		if err != nil {
+			return "", true, err
+		}
+		for _, pool := range pools {
+			if !existing[pool.GetUuid()] {
+				return pool.GetUuid(), false, nil
+			}
+		}
+		return "", true, fmt.Errorf("no new pool on node %s yet", n.Name)
+	}
+	poolUUID, err := task.DoRetryWithTimeout(t, poolOpTimeout, defaultRetryInterval)
+	if err != nil {
+		return "", &ErrFailedToCreatePool{Node: n.Name, Cause: err.Error()}
+	}
+	log.Infof("Created pool %s on %s", poolUUID, n.Name)
+	return poolUUID.(string), nil
+}
+
+// DeletePool deletes the storage pool with the given UUID from the node. The pool is put in maintenance mode
+// for the deletion.
+func (d *portworx) DeletePool(n *node.Node, poolUUID string) error {
+	pool, err := d.getNodePool(*n, poolUUID)
+	if err != nil {
+		return &ErrFailedToDeletePool{ID: poolUUID, Cause: err.Error()}
+	}
+
+	if err := d.poolMaintenanceOp(*n, pxctlPoolMaintenanceEnter, api.Status_STATUS_POOLMAINTENANCE); err != nil {
+		return &ErrFailedToDeletePool{ID: poolUUID, Cause: err.Error()}
+	}
+	log.Infof("Deleting pool %s (ID %d) on %s", poolUUID, pool.GetID(), n.Name)
+	_, deleteErr := d.GetPxctlCmdOutputConnectionOpts(*n, fmt.Sprintf(pxctlPoolDelete, pool.GetID()), node.ConnectionOpts{
+		Timeout:         crashDriverTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}, false)
+	// exit pool maintenance whether the deletion succeeded or not
+	if err := d.poolMaintenanceOp(*n, pxctlPoolMaintenanceExit, api.Status_STATUS_OK); err != nil {
+		return &ErrFailedToDeletePool{ID: poolUUID, Cause: err.Error()}
+	}
+	if deleteErr != nil {
+		if poolDeleteBlockedRegex.MatchString(deleteErr.Error()) {
+			return &ErrPoolDeleteBlocked{ID: poolUUID, Cause: deleteErr.Error()}
+		}
+		return &ErrFailedToDeletePool{ID: poolUUID, Cause: deleteErr.Error()}
+	}
+
+	t := func() (interface{}, bool, error) {
+		pool, err := d.getNodePool(*n, poolUUID)
+		if err == nil && pool != nil {
+			return nil, true, fmt.Errorf("pool %s still exists on node %s", poolUUID, n.Name)
+		}
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, true, err
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, poolOpTimeout, defaultRetryInterval); err != nil {
+		return &ErrFailedToDeletePool{ID: poolUUID, Cause: err.Error()}
+	}
+	log.Infof("Deleted pool %s on %s", poolUUID, n.Name)
+	return nil
+}
+
+// ValidateDeletePool deletes the storage pool and validates that the volumes on it were moved to other pools,
+// or that the deletion was blocked while volumes have replicas on it. It returns true if the pool was deleted.
+func (d *portworx) ValidateDeletePool(n *node.Node, poolUUID string) (bool, error) {
+	vols, err := d.getPoolVolumes(poolUUID)
+	if err != nil {
+		return false, &ErrFailedToDeletePool{ID: poolUUID, Cause: err.Error()}
+	}
+	log.Infof("Pool %s has replicas of volumes %v", poolUUID, volumeIDs(vols))
+
+	if err := d.DeletePool(n, poolUUID); err != nil {
+		// only a deletion refused because of the volumes on the pool is expected to fail
+		if _, blocked := err.(*ErrPoolDeleteBlocked); !blocked || len(vols) == 0 {
+			return false, err
+		}
+		// deletion is blocked, the volumes must be untouched
+		pool, inspectErr := d.getNodePool(*n, poolUUID)
+		if inspectErr != nil {
+			return false, &ErrFailedToDeletePool{ID: poolUUID, Cause: fmt.Sprintf("pool is missing after blocked deletion: %v", inspectErr)}
+		}
+		remaining, inspectErr := d.getPoolVolumes(pool.GetUuid())
+		if inspectErr != nil {
+			return false, &ErrFailedToDeletePool{ID: poolUUID, Cause: inspectErr.Error()}
+		}
+		if len(remaining) != len(vols) {
+			return false, &ErrFailedToDeletePool{
+				ID:    poolUUID,
+				Cause: fmt.Sprintf("deletion was blocked but volumes moved. Before: %v, after: %v", volumeIDs(vols), volumeIDs(remaining)),
+			}
+		}
+		log.Infof("Deletion of pool %s with volumes was blocked: %v", poolUUID, err)
+		return false, nil
+	}
+
+	// the pool is gone, its volumes must have been moved with all their replicas
+	for _, vol := range vols {
+		resp, err := d.getVolDriver().Inspect(d.getContext(), &api.SdkVolumeInspectRequest{VolumeId: vol.GetId()})
+		if err != nil {
+			return true, &ErrFailedToDeletePool{ID: poolUUID, Cause: fmt.Sprintf("failed to inspect volume %s: %v", vol.GetId(), err)}
+		}
+		replicas := 0
+		for _, rs := range resp.GetVolume().GetReplicaSets() {
+			for _, poolID := range rs.GetPoolUuids() {
+				if poolID == poolUUID {
+					return true, &ErrFailedToDeletePool{ID: poolUUID, Cause: fmt.Sprintf("volume %s still has a replica on the pool", vol.GetId())}
+				}
+			}
+			replicas += len(rs.GetNodes())
+		}
+		if int64(replicas) < resp.GetVolume().GetSpec().GetHaLevel() {
+			return true, &ErrFailedToDeletePool{
+				ID:    poolUUID,
+				Cause: fmt.Sprintf("volume %s has %d replicas, expected %d", vol.GetId(), replicas, resp.GetVolume().GetSpec().GetHaLevel()),
+			}
+		}
+	}
+	return true, nil
+}
+
+// UpdatePoolLabels sets the given labels on the storage pool
+func (d *portworx) UpdatePoolLabels(n *node.Node, poolUUID string, labels map[string]string) error {
+	pool, err := d.getNodePool(*n, poolUUID)
+	if err != nil {
+		return &ErrFailedToUpdatePool{ID: poolUUID, Cause: err.Error()}
+	}
+	var kvs []string
+	for k, v := range labels {
+		kvs = append(kvs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(kvs)
+	log.Infof("Setting labels %v on pool %s", kvs, poolUUID)
+	if _, err := d.GetPxctlCmdOutput(*n, fmt.Sprintf(pxctlPoolUpdateLabels, pool.GetID(), strings.Join(kvs, ","))); err != nil {
+		return &ErrFailedToUpdatePool{ID: poolUUID, Cause: err.Error()}
+	}
+
+	t := func() (interface{}, bool, error) {
+		pool, err := d.getNodePool(*n, poolUUID)
+		if err != nil {
+			return nil, true, err
+		}
+		for k, v := range labels {
+			if pool.GetLabels()[k] != v {
+				return nil, true, fmt.Errorf("pool %s has label %s=%s, expected %s", poolUUID, k, pool.GetLabels()[k], v)
+			}
+		}
+		return nil, false, nil
+	}
+	if _, err := task.DoRetryWithTimeout(t, maintenanceWaitTimeout, defaultRetryInterval); err != nil {
+		return &ErrFailedToUpdatePool{ID: poolUUID, Cause: err.Error()}
+	}
+	return nil
+}
+
+// AddJournalDevice adds the drive as the journal device of the node
+func (d *portworx) AddJournalDevice(n *node.Node, drivePath string) error {
+	return d.addDevice(n, drivePath, pxctlDriveAddJournal)
+}
+
+// AddMetadataDevice adds the drive as the metadata device of the node
+func (d *portworx) AddMetadataDevice(n *node.Node, drivePath string) error {
+	if err := d.addDevice(n, drivePath, pxctlDriveAddMetadata); err != nil {
+		return err
+	}
+	pxNode, err := d.GetPxNode(n)
+	if err != nil {
+		return &ErrFailedToAddDevice{Node: n.Name, Path: drivePath, Cause: err.Error()}
+	}
+	if !isMetadataDisk(pxNode, drivePath) {
+		return &ErrFailedToAddDevice{Node: n.Name, Path: drivePath, Cause: "drive is not a metadata device of the node"}
+	}
+	return nil
+}
+
+// isMetadataDisk returns whether the drive is a metadata device of the node
+func isMetadataDisk(pxNode *api.StorageNode, drivePath string) bool {
+	for _, disk := range pxNode.GetDisks() {
+		if disk.GetPath() == drivePath && disk.GetMetadata() {
+			return true
+		}
+	}
+	return false
+}
+
+// addDevice adds a journal or metadata device, which requires the node to be in maintenance mode
+func (d *portworx) addDevice(n *node.Node, drivePath, command string) error {
+	log.Infof("Adding device %s to %s: %s", drivePath, n.Name, fmt.Sprintf(command, drivePath))
+	if err := d.EnterMaintenance(*n); err != nil {
+		return &ErrFailedToAddDevice{Node: n.Name, Path: drivePath, Cause: err.Error()}
+	}
+	_, addErr := d.GetPxctlCmdOutputConnectionOpts(*n, fmt.Sprintf(command, drivePath), node.ConnectionOpts{
+		Timeout:         crashDriverTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}, false)
+	if err := d.ExitMaintenance(*n); err != nil {
+		return &ErrFailedToAddDevice{Node: n.Name, Path: drivePath, Cause: err.Error()}
+	}
+	if addErr != nil {
+		return &ErrFailedToAddDevice{Node: n.Name, Path: drivePath, Cause: addErr.Error()}
+	}
+	return nil
+}
+
+// poolMaintenanceOp runs the pool maintenance command and waits for the node to reach the expected status
+func (d *portworx) poolMaintenanceOp(n node.Node, command string, expected api.Status) error {
+	if _, err := d.GetPxctlCmdOutputConnectionOpts(n, command, node.ConnectionOpts{
+		Timeout:         maintenanceOpTimeout,
+		TimeBeforeRetry: defaultRetryInterval,
+	}, false); err != nil {
+		return err
+	}
+	t := func() (interface{}, bool, error) {
+		pxNode, err := d.GetPxNode(&n)
+		if err != nil {
+			return nil, true, err
+		}
+		if pxNode.GetStatus() != expected {
+			return nil, true, fmt.Errorf("node %s, current status: %v, expected: %v", n.Name, pxNode.GetStatus(), expected)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, poolOpTimeout, poolOpRetryInterval)
+	return err
+}
+
+func (d *portworx) getNodePools(n node.Node) ([]*api.StoragePool, error) {
+	pxNode, err := d.GetPxNode(&n)
+	if err != nil {
+		return nil, err
+	}
+	return pxNode.GetPools(), nil
+}
+
+func (d *portworx) getNodePool(n node.Node, poolUUID string) (*api.StoragePool, error) {
+	pools, err := d.getNodePools(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, pool := range pools {
+		if pool.GetUuid() == poolUUID {
+			return pool, nil
+		}
+	}
+	return nil, fmt.Errorf("pool %s not found on node %s", poolUUID, n.Name)
+}
+
+// getPoolVolumes returns the volumes with a replica on the pool
+func (d *portworx) getPoolVolumes(poolUUID string) ([]*api.Volume, error) {
+	resp, err := d.getVolDriver().InspectWithFilters(d.getContext(), &api.SdkVolumeInspectWithFiltersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var vols []*api.Volume
+	for _, v := range resp.GetVolumes() {
+	replicaSets:
+		for _, rs := range v.GetVolume().GetReplicaSets() {
+			for _, poolID := range rs.GetPoolUuids() {
+				if poolID == poolUUID {
+					vols = append(vols, v.GetVolume())
+					break replicaSets
+				}
+			}
+		}
+	}
+	return vols, nil
+}
+
+func volumeIDs(vols []*api.Volume) []string {
+	ids := make([]string, 0, len(vols))
+	for _, v := range vols {
+		ids = append(ids, v.GetId())
+	}
+	return ids
+}
@@ -0,0 +1,38 @@
+package portworx
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/openstorage/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolDeleteBlockedRegex(t *testing.T) {
+	for _, msg := range []string{
+		"failed to get pxctl status. cause: pool 1 has volumes, move them before deleting the pool",
+		"Pool contains 3 replicas",
+		"cannot delete pool with volumes",
+		"pool is not empty",
+	} {
+		require.True(t, poolDeleteBlockedRegex.MatchString(msg), msg)
+	}
+	for _, msg := range []string{
+		"failed to get pxctl status. cause: pxctl service pool delete 1: exit status 1",
+		"node is not in pool maintenance mode",
+		"timed out waiting for volumes",
+	} {
+		require.False(t, poolDeleteBlockedRegex.MatchString(msg), msg)
+	}
+}
+
+func TestIsMetadataDisk(t *testing.T) {
+	pxNode := &api.StorageNode{
+		Disks: map[string]*api.StorageResource{
+			"/dev/sdb": {Path: "/dev/sdb"},
+			"/dev/sdc": {Path: "/dev/sdc", Metadata: true},
+		},
+	}
+	require.True(t, isMetadataDisk(pxNode, "/dev/sdc"))
+	require.False(t, isMetadataDisk(pxNode, "/dev/sdb"))
+	require.False(t, isMetadataDisk(pxNode, "/dev/sdd"))
+}
@@ -47,6 +47,7 @@ import (
 	tp_errors "github.com/portworx/torpedo/pkg/errors"
 	"github.com/portworx/torpedo/pkg/netutil"
 	"github.com/portworx/torpedo/pkg/osutils"
+	"github.com/portworx/torpedo/pkg/pxctl"
 	"github.com/portworx/torpedo/pkg/units"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -198,6 +199,7 @@ type portworx struct {
 	token                 string
 	skipPXSvcEndpoint     bool
 	DiagsFile             string
+	queryMode             torpedovolume.QueryMode
 }
 
 type statusJSON struct {
@@ -287,6 +289,25 @@ func (d *portworx) String() string {
 	return DriverName
 }
 
+func (d *portworx) Capabilities() driver_api.Capabilities {
+	return driver_api.NewCapabilities(
+		torpedovolume.CapabilityReplication,
+		torpedovolume.CapabilityPoolExpand,
+		torpedovolume.CapabilityAddDrive,
+		torpedovolume.CapabilitySnapshot,
+		torpedovolume.CapabilityCloudsnap,
+		torpedovolume.CapabilityMaintenance,
+		torpedovolume.CapabilitySharedv4,
+		torpedovolume.CapabilityDriverRestart,
+		torpedovolume.CapabilityDecommission,
+		torpedovolume.CapabilityKvdb,
+		torpedovolume.CapabilityUpgrade,
+		torpedovolume.CapabilityIOPriority,
+		torpedovolume.CapabilityPoolLifecycle,
+		torpedovolume.CapabilityEncryption,
+	)
+}
+
 func (d *portworx) GetVolumeDriverNamespace() (string, error) {
 	return d.schedOps.GetPortworxNamespace()
 }
@@ -300,6 +321,13 @@ func (d *portworx) init(sched, nodeDriver, token, storageProvisioner, csiGeneric
 		d.skipPXSvcEndpoint, _ = strconv.ParseBool(skipStr)
 	}
 
+	d.queryMode = torpedovolume.QueryModeSDK
+	if mode := os.Getenv(envQueryMode); mode != "" {
+		if err := d.SetQueryMode(torpedovolume.QueryMode(mode)); err != nil {
+			return err
+		}
+	}
+
 	d.token = token
 
 	if d.nodeDriver, err = node.Get(nodeDriver); err != nil {
@@ -1284,8 +1312,17 @@ func (d *portworx) ValidateCreateSnapshot(volumeName string, params map[string]s
 		d.refreshEndpoint = refreshEndpoint
 	}
 
-	volDriver := d.getVolDriver()
-	_, err := volDriver.SnapshotCreate(d.getContextWithToken(context.Background(), token), &api.SdkVolumeSnapshotCreateRequest{VolumeId: volumeName, Name: volumeName + "_snapshot"})
+	_, err := d.runQuery(pxQuery{
+		name: fmt.Sprintf("create snapshot of volume %s", volumeName),
+		sdk: func() (interface{}, error) {
+			volDriver := d.getVolDriver()
+			return volDriver.SnapshotCreate(d.getContextWithToken(context.Background(), token), &api.SdkVolumeSnapshotCreateRequest{VolumeId: volumeName, Name: volumeName + "_snapshot"})
+		},
+		pxctl: func() (interface{}, error) {
+			return nil, d.createSnapshotPxctl(volumeName)
+		},
+		mutates: true,
+	})
 	if err != nil {
 		log.Errorf(fmt.Sprintf("error when creating local snapshot, Err: %v", err))
 		return err
@@ -1293,7 +1330,7 @@ func (d *portworx) ValidateCreateSnapshot(volumeName string, params map[string]s
 	return nil
 }
 
-func (d *portworx) ValidateCreateSnapshotUsingPxctl(volumeName string) error {
+func (d *portworx) createSnapshotPxctl(volumeName string) error {
 	// TODO: this should be refactored so we apply snapshot specs from the app specs instead
 	nodes := node.GetStorageDriverNodes()
 	_, err := d.nodeDriver.RunCommandWithNoRetry(nodes[0], fmt.Sprintf(formattingCommandPxctlLocalSnapshotCreate, volumeName, constructSnapshotName(volumeName)), node.ConnectionOpts{
@@ -1360,7 +1397,16 @@ func (d *portworx) ValidateCreateCloudsnap(volumeName string, params map[string]
 		refreshEndpoint, _ := strconv.ParseBool(val)
 		d.refreshEndpoint = refreshEndpoint
 	}
-	_, err := d.csbackupManager.Create(d.getContextWithToken(context.Background(), token), &api.SdkCloudBackupCreateRequest{VolumeId: volumeName})
+	_, err := d.runQuery(pxQuery{
+		name: fmt.Sprintf("create cloudsnap of volume %s", volumeName),
+		sdk: func() (interface{}, error) {
+			return d.csbackupManager.Create(d.getContextWithToken(context.Background(), token), &api.SdkCloudBackupCreateRequest{VolumeId: volumeName})
+		},
+		pxctl: func() (interface{}, error) {
+			return nil, d.createCloudsnapPxctl(volumeName)
+		},
+		mutates: true,
+	})
 	if err != nil {
 		log.Errorf(fmt.Sprintf("error when creating cloudsnap, Err: %v", err))
 		return err
@@ -1368,7 +1414,7 @@ func (d *portworx) ValidateCreateCloudsnap(volumeName string, params map[string]
 	return nil
 }
 
-func (d *portworx) ValidateCreateCloudsnapUsingPxctl(volumeName string) error {
+func (d *portworx) createCloudsnapPxctl(volumeName string) error {
 	nodes := node.GetStorageDriverNodes()
 	_, err := d.nodeDriver.RunCommandWithNoRetry(nodes[0], fmt.Sprintf(formattingCommandPxctlCloudSnapCreate, volumeName), node.ConnectionOpts{
 		Timeout:         crashDriverTimeout,
@@ -3767,21 +3813,10 @@ func (d *portworx) SetClusterOpts(n node.Node, clusterOpts map[string]string) er
 
 //GetClusterOpts get all cluster options
 func (d *portworx) GetClusterOpts(n node.Node, options []string) (map[string]string, error) {
-	opts := node.ConnectionOpts{
-		IgnoreError:     false,
-		TimeBeforeRetry: defaultRetryInterval,
-		Timeout:         defaultTimeout,
-	}
-	cmd := fmt.Sprintf("%s cluster options list -j  json", d.getPxctlPath(n))
-	out, err := d.nodeDriver.RunCommand(n, cmd, opts)
+	data, err := d.pxctlClient(n).ClusterOptions()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pxctl cluster options. cause: %v", err)
 	}
-	var data = map[string]interface{}{}
-	err = json.Unmarshal([]byte(out), &data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal pxctl cluster options. cause: %v", err)
-	}
 	sort.Strings(options)
 	var options_map = make(map[string]string)
 	//Values can be string, array or map
@@ -3987,46 +4022,14 @@ func (d *portworx) getPxctlPath(n node.Node) string {
 }
 
 func (d *portworx) getPxctlStatus(n node.Node) (string, error) {
-	opts := node.ConnectionOpts{
-		IgnoreError:     false,
-		TimeBeforeRetry: defaultRetryInterval,
-		Timeout:         defaultTimeout,
-	}
-
-	pxctlPath := d.getPxctlPath(n)
-
-	// create context
-	if len(d.token) > 0 {
-		_, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s context create admin --token=%s", pxctlPath, d.token), opts)
-		if err != nil {
-			return "", fmt.Errorf("failed to create pxctl context. cause: %v", err)
-		}
-	}
-
-	out, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s -j status", pxctlPath), opts)
+	status, err := d.pxctlClient(n).Status()
 	if err != nil {
 		return "", fmt.Errorf("failed to get pxctl status. cause: %v", err)
 	}
-
-	var data interface{}
-	err = json.Unmarshal([]byte(out), &data)
-	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal pxctl status. cause: %v", err)
+	if status.Status == "" {
+		return api.Status_STATUS_NONE.String(), nil
 	}
-
-	// delete context
-	if len(d.token) > 0 {
-		_, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s context delete admin", pxctlPath), opts)
-		if err != nil {
-			return "", fmt.Errorf("failed to delete pxctl context. cause: %v", err)
-		}
-	}
-
-	statusMap := data.(map[string]interface{})
-	if status, ok := statusMap["status"]; ok {
-		return status.(string), nil
-	}
-	return api.Status_STATUS_NONE.String(), nil
+	return string(status.Status), nil
 }
 
 // GetPxctlCmdOutputConnectionOpts returns the command output run on the given node with ConnectionOpts and any error
@@ -4077,6 +4080,17 @@ func (d *portworx) GetPxctlCmdOutput(n node.Node, command string) (string, error
 	return d.GetPxctlCmdOutputConnectionOpts(n, command, opts, true)
 }
 
+// GetPxctlClient returns a client which runs typed pxctl commands on the given node
+func (d *portworx) GetPxctlClient(n node.Node) (*pxctl.Client, error) {
+	return d.pxctlClient(n), nil
+}
+
+func (d *portworx) pxctlClient(n node.Node) *pxctl.Client {
+	return pxctl.NewClient(func(command string) (string, error) {
+		return d.GetPxctlCmdOutput(n, command)
+	})
+}
+
 func doesConditionMatch(expectedMetricValue float64, conditionExpression *apapi.LabelSelectorRequirement) bool {
 	condExprValue, _ := strconv.ParseFloat(conditionExpression.Values[0], 64)
 	return expectedMetricValue < condExprValue && conditionExpression.Operator == apapi.LabelSelectorOpLt ||
@@ -4189,118 +4203,45 @@ func getImageList(endpointURL, pxVersion, k8sVersion string, d *portworx) (map[s
 
 // GetNodeStats returns the node stats of the given node and an error if any
 func (d *portworx) GetNodeStats(n node.Node) (map[string]map[string]int, error) {
-	opts := node.ConnectionOpts{
-		IgnoreError:     false,
-		TimeBeforeRetry: defaultRetryInterval,
-		Timeout:         defaultTimeout,
-	}
-
-	pxctlPath := d.getPxctlPath(n)
-
-	// create context
-	if len(d.token) > 0 {
-		_, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s context create admin --token=%s", pxctlPath, d.token), opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create pxctl context. cause: %v", err)
-		}
-	}
-
-	out, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s sv dump --nodestats -j", pxctlPath), opts)
+	// relaxed reclaim stats are only exposed by pxctl
+	out, err := d.runQuery(pxQuery{
+		name: fmt.Sprintf("node stats of %s", n.Name),
+		pxctl: func() (interface{}, error) {
+			return d.getNodeStatsPxctl(n)
+		},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pxctl status. cause: %v", err)
+		return nil, err
 	}
+	return out.(map[string]map[string]int), nil
+}
 
-	var data interface{}
-	err = json.Unmarshal([]byte(out), &data)
+func (d *portworx) getNodeStatsPxctl(n node.Node) (map[string]map[string]int, error) {
+	stats, err := d.pxctlClient(n).NodeStats()
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal pxctl status. cause: %v", err)
-	}
-
-	// delete context
-	if len(d.token) > 0 {
-		_, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s context delete admin", pxctlPath), opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to delete pxctl context. cause: %v", err)
-		}
-	}
-
-	nodeStats := data.(map[string]interface{})
-	deleted := 0
-	pending := 0
-	skipped := 0
-
-	if value, ok := nodeStats["relaxed_reclaim_stats"].(map[string]interface{}); ok {
-
-		if p, ok := value["pending"]; ok {
-			pending = int(p.(float64))
-		}
-
-		if d, ok := value["deleted"]; ok {
-			deleted = int(d.(float64))
-		}
-
-		if s, ok := value["skipped"]; ok {
-			skipped = int(s.(float64))
-		}
-
+		return nil, fmt.Errorf("failed to get pxctl node stats. cause: %v", err)
 	}
 
 	var nodeStatsMap = map[string]map[string]int{}
 	nodeStatsMap[n.Name] = map[string]int{}
-	nodeStatsMap[n.Name]["deleted"] = deleted
-	nodeStatsMap[n.Name]["pending"] = pending
-	nodeStatsMap[n.Name]["skipped"] = skipped
+	nodeStatsMap[n.Name]["deleted"] = int(stats.RelaxedReclaim.Deleted)
+	nodeStatsMap[n.Name]["pending"] = int(stats.RelaxedReclaim.Pending)
+	nodeStatsMap[n.Name]["skipped"] = int(stats.RelaxedReclaim.Skipped)
 
 	return nodeStatsMap, nil
 }
 
-// GetTrashCanVolumeIds returns the volume ids in the trashcan and an error if any
+// GetTrashCanVolumeIds returns the volume ids in the trashcan, in the order pxctl lists them, and an error if any.
+// The SDK has no trashcan listing, so it is queried through pxctl in all query modes.
 func (d *portworx) GetTrashCanVolumeIds(n node.Node) ([]string, error) {
-	opts := node.ConnectionOpts{
-		IgnoreError:     false,
-		TimeBeforeRetry: defaultRetryInterval,
-		Timeout:         defaultTimeout,
-	}
-
-	pxctlPath := d.getPxctlPath(n)
-
-	// create context
-	if len(d.token) > 0 {
-		_, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s context create admin --token=%s", pxctlPath, d.token), opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create pxctl context. cause: %v", err)
-		}
-	}
-
-	out, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s v l --trashcan -j", pxctlPath), opts)
+	vols, err := d.pxctlClient(n).TrashcanVolumes()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get pxctl status. cause: %v", err)
+		return nil, fmt.Errorf("failed to get pxctl trashcan volumes. cause: %v", err)
 	}
-	log.Info(out)
-
-	var data interface{}
-	err = json.Unmarshal([]byte(out), &data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal pxctl status. cause: %v", err)
-	}
-
-	// delete context
-	if len(d.token) > 0 {
-		_, err := d.nodeDriver.RunCommand(n, fmt.Sprintf("%s context delete admin", pxctlPath), opts)
-		if err != nil {
-			return nil, fmt.Errorf("failed to delete pxctl context. cause: %v", err)
-		}
-	}
-
-	res := data.([]interface{})
-
-	trashcanVols := make([]string, 50)
-
-	for _, v := range res {
-		var tp map[string]interface{} = v.(map[string]interface{})
-		str := fmt.Sprintf("%v", tp["id"])
-		trashcanVols = append(trashcanVols, strings.Trim(str, " "))
 
+	trashcanVols := make([]string, 0)
+	for _, v := range vols {
+		trashcanVols = append(trashcanVols, v.ID)
 	}
 
 	log.Infof("trash vols: %v", trashcanVols)
@@ -4481,7 +4422,11 @@ func addDrive(n node.Node, drivePath string, poolID int32, d *portworx) error {
 			driveAddFlag = fmt.Sprintf("%s -p %d", driveAddFlag, poolID)
 		}
 	}
+	return startDriveAdd(n, drivePath, driveAddFlag, d)
+}
 
+// startDriveAdd starts adding the drive with the given pxctl service drive add flags
+func startDriveAdd(n node.Node, drivePath, driveAddFlag string, d *portworx) error {
 	out, err := d.nodeDriver.RunCommandWithNoRetry(n, fmt.Sprintf(pxctlDriveAddStart, d.getPxctlPath(n), driveAddFlag), node.ConnectionOpts{
 		Timeout:         crashDriverTimeout,
 		TimeBeforeRetry: defaultRetryInterval,
@@ -4555,43 +4500,44 @@ func waitForAddDriveToComplete(n node.Node, drivePath string, d *portworx) error
 
 //GetPoolsUsedSize returns map of pool id and current used size
 func (d *portworx) GetPoolsUsedSize(n *node.Node) (map[string]string, error) {
-
-	cmd := fmt.Sprintf("%s sv pool show -j | grep -e uuid -e '\"Used\"'", d.getPxctlPath(*n))
-
-	out, err := d.nodeDriver.RunCommandWithNoRetry(*n, cmd, node.ConnectionOpts{
-		Timeout:         2 * time.Minute,
-		TimeBeforeRetry: 10 * time.Second,
+	out, err := d.runQuery(pxQuery{
+		name: fmt.Sprintf("pools used size of %s", n.Name),
+		sdk: func() (interface{}, error) {
+			return d.getPoolsUsedSizeSDK(n)
+		},
+		pxctl: func() (interface{}, error) {
+			return d.getPoolsUsedSizePxctl(n)
+		},
 	})
-
 	if err != nil {
 		return nil, err
 	}
-	outLines := strings.Split(out, "\n")
+	return out.(map[string]string), nil
+}
 
+func (d *portworx) getPoolsUsedSizeSDK(n *node.Node) (map[string]string, error) {
+	resp, err := d.getNodeManager().Inspect(d.getContext(), &api.SdkNodeInspectRequest{NodeId: n.VolDriverNodeID})
+	if err != nil {
+		return nil, err
+	}
 	poolsData := make(map[string]string)
-	var poolId string
-	var usedSize string
-	for _, l := range outLines {
-		line := strings.Trim(l, " ")
-		line = strings.Trim(line, ",")
-		if strings.Contains(line, "uuid") {
-			poolId = strings.Split(line, ":")[1]
-			poolId = strings.Trim(poolId, " ")
-			poolId = strings.ReplaceAll(poolId, "\"", "")
-		}
-		if strings.Contains(line, "Used") {
-			usedSize = strings.Split(line, ":")[1]
-			usedSize = strings.Trim(usedSize, " ")
-			usedSize = strings.ReplaceAll(usedSize, "\"", "")
-		}
-		if poolId != "" && usedSize != "" {
-			poolsData[poolId] = usedSize
-			poolId = ""
-			usedSize = ""
-		}
+	for _, pool := range resp.GetNode().GetPools() {
+		poolsData[pool.GetUuid()] = strconv.FormatUint(pool.GetUsed(), 10)
 	}
 	return poolsData, nil
+}
+
+func (d *portworx) getPoolsUsedSizePxctl(n *node.Node) (map[string]string, error) {
+	pools, err := d.pxctlClient(*n).Pools()
+	if err != nil {
+		return nil, err
+	}
 
+	poolsData := make(map[string]string)
+	for _, pool := range pools {
+		poolsData[pool.UUID] = strconv.FormatUint(uint64(pool.Used), 10)
+	}
+	return poolsData, nil
 }
 
 // GetRebalanceJobs returns the list of rebalance jobs
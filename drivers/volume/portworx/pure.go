@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/libopenstorage/openstorage/api"
+	driver_api "github.com/portworx/torpedo/drivers/api"
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
 	"github.com/portworx/torpedo/pkg/log"
 	"strconv"
 )
@@ -27,6 +29,13 @@ func (p *pure) String() string {
 	return PureDriverName
 }
 
+// Capabilities returns the portworx capabilities less replication, which is handled by the FlashArray
+func (p *pure) Capabilities() driver_api.Capabilities {
+	caps := p.portworx.Capabilities()
+	delete(caps, torpedovolume.CapabilityReplication)
+	return caps
+}
+
 func (p *pure) ValidateCreateSnapshot(volumeName string, params map[string]string) error {
 	var token string
 	token = p.getTokenForVolume(volumeName, params)
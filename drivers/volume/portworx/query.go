@@ -0,0 +1,98 @@
+package portworx
+
+import (
+	"fmt"
+	"reflect"
+
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/pkg/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// envQueryMode selects the query mode of the driver, one of sdk, pxctl or crosscheck. Defaults to sdk.
+	envQueryMode = "PX_QUERY_MODE"
+)
+
+// pxQuery is a driver query with an SDK implementation and an optional pxctl implementation. Both return the
+// same normalized result, so that they can be compared in cross-check mode. Queries without an SDK equivalent
+// have only a pxctl implementation.
+type pxQuery struct {
+	name  string
+	sdk   func() (interface{}, error)
+	pxctl func() (interface{}, error)
+	// mutates is true for operations which change state, they are never run twice to cross-check them
+	mutates bool
+}
+
+// SetQueryMode selects whether queries are answered through the SDK, pxctl or both
+func (d *portworx) SetQueryMode(mode torpedovolume.QueryMode) error {
+	switch mode {
+	case torpedovolume.QueryModeSDK, torpedovolume.QueryModePxctl, torpedovolume.QueryModeCrossCheck:
+		d.queryMode = mode
+		log.Infof("Using %s query mode for %s", mode, d.String())
+		return nil
+	}
+	return fmt.Errorf("invalid query mode %q, expected one of %s, %s or %s", mode,
+		torpedovolume.QueryModeSDK, torpedovolume.QueryModePxctl, torpedovolume.QueryModeCrossCheck)
+}
+
+// GetQueryMode returns whether queries are answered through the SDK, pxctl or both
+func (d *portworx) GetQueryMode() torpedovolume.QueryMode {
+	return d.queryMode
+}
+
+// runQuery answers the query according to the query mode. In SDK mode queries fall back to pxctl if the SDK
+// is unavailable. In cross-check mode the SDK answer is returned, with ErrQueryDivergence if pxctl differs.
+func (d *portworx) runQuery(q pxQuery) (interface{}, error) {
+	if q.sdk == nil {
+		return q.pxctl()
+	}
+	if q.pxctl == nil {
+		return q.sdk()
+	}
+	switch d.queryMode {
+	case torpedovolume.QueryModePxctl:
+		return q.pxctl()
+	case torpedovolume.QueryModeCrossCheck:
+		if !q.mutates {
+			return d.crossCheck(q)
+		}
+	}
+	out, err := q.sdk()
+	if err != nil && sdkUnavailable(err) {
+		log.Warnf("SDK is unavailable for %s, falling back to pxctl. Err: %v", q.name, err)
+		return q.pxctl()
+	}
+	return out, err
+}
+
+func (d *portworx) crossCheck(q pxQuery) (interface{}, error) {
+	sdkOut, sdkErr := q.sdk()
+	pxctlOut, pxctlErr := q.pxctl()
+	divergence := &ErrQueryDivergence{Query: q.name, SDK: sdkOut, Pxctl: pxctlOut}
+	switch {
+	case sdkErr != nil && pxctlErr != nil:
+		return nil, sdkErr
+	case sdkErr != nil:
+		divergence.SDK = sdkErr
+		return nil, divergence
+	case pxctlErr != nil:
+		divergence.Pxctl = pxctlErr
+		return sdkOut, divergence
+	case !reflect.DeepEqual(sdkOut, pxctlOut):
+		return sdkOut, divergence
+	}
+	log.Debugf("SDK and pxctl agree on %s", q.name)
+	return sdkOut, nil
+}
+
+// sdkUnavailable returns true if the SDK server cannot be reached or does not implement the call
+func sdkUnavailable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Unimplemented:
+		return true
+	}
+	return false
+}
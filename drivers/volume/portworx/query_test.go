@@ -0,0 +1,80 @@
+package portworx
+
+import (
+	"fmt"
+	"testing"
+
+	torpedovolume "github.com/portworx/torpedo/drivers/volume"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testQuery(sdkOut, pxctlOut interface{}, sdkErr error, calls *[]string) pxQuery {
+	return pxQuery{
+		name: "test",
+		sdk: func() (interface{}, error) {
+			*calls = append(*calls, "sdk")
+			return sdkOut, sdkErr
+		},
+		pxctl: func() (interface{}, error) {
+			*calls = append(*calls, "pxctl")
+			return pxctlOut, nil
+		},
+	}
+}
+
+func TestRunQuery(t *testing.T) {
+	d := &portworx{}
+	require.Error(t, d.SetQueryMode("both"))
+
+	var calls []string
+	out, err := d.runQuery(testQuery([]string{"a"}, []string{"b"}, nil, &calls))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, out)
+	require.Equal(t, []string{"sdk"}, calls)
+
+	calls = nil
+	out, err = d.runQuery(testQuery(nil, []string{"b"}, status.Error(codes.Unavailable, "down"), &calls))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, out)
+	require.Equal(t, []string{"sdk", "pxctl"}, calls)
+
+	calls = nil
+	_, err = d.runQuery(testQuery(nil, []string{"b"}, fmt.Errorf("invalid"), &calls))
+	require.Error(t, err)
+	require.Equal(t, []string{"sdk"}, calls)
+
+	require.NoError(t, d.SetQueryMode(torpedovolume.QueryModePxctl))
+	calls = nil
+	out, err = d.runQuery(testQuery([]string{"a"}, []string{"b"}, nil, &calls))
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, out)
+	require.Equal(t, []string{"pxctl"}, calls)
+}
+
+func TestRunQueryCrossCheck(t *testing.T) {
+	d := &portworx{}
+	require.NoError(t, d.SetQueryMode(torpedovolume.QueryModeCrossCheck))
+
+	var calls []string
+	out, err := d.runQuery(testQuery(map[string]string{"pool": "10"}, map[string]string{"pool": "10"}, nil, &calls))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"pool": "10"}, out)
+	require.Equal(t, []string{"sdk", "pxctl"}, calls)
+
+	out, err = d.runQuery(testQuery(map[string]string{"pool": "10"}, map[string]string{"pool": "12"}, nil, &calls))
+	require.IsType(t, &ErrQueryDivergence{}, err)
+	require.Equal(t, map[string]string{"pool": "10"}, out)
+
+	_, err = d.runQuery(testQuery(nil, map[string]string{"pool": "12"}, fmt.Errorf("failed"), &calls))
+	require.IsType(t, &ErrQueryDivergence{}, err)
+
+	// operations which change state are not run twice
+	calls = nil
+	q := testQuery(nil, nil, nil, &calls)
+	q.mutates = true
+	_, err = d.runQuery(q)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sdk"}, calls)
+}
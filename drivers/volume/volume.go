@@ -11,6 +11,7 @@ import (
 	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/pxctl"
 	pxapi "github.com/portworx/torpedo/porx/px/api"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -45,6 +46,61 @@ type Options struct {
 	ValidateReplicationUpdateTimeout time.Duration
 }
 
+// Encryption describes how a volume is encrypted
+type Encryption struct {
+	// Encrypted is true if the volume is encrypted
+	Encrypted bool
+	// KeyName is the name of the volume's own key in the secrets store. Empty if the volume uses the cluster wide key.
+	KeyName string
+	// SecretName is the name of the secret holding the key, if it is not named after the key
+	SecretName string
+	// SecretNamespace is the namespace of the secret holding the key, if it is not the default one
+	SecretNamespace string
+}
+
+const (
+	// CapabilityReplication is the capability to change the replication factor of volumes
+	CapabilityReplication driver_api.Capability = "replication"
+	// CapabilityPoolExpand is the capability to expand storage pools
+	CapabilityPoolExpand driver_api.Capability = "pool-expand"
+	// CapabilityAddDrive is the capability to add drives to storage nodes
+	CapabilityAddDrive driver_api.Capability = "add-drive"
+	// CapabilitySnapshot is the capability to create local snapshots
+	CapabilitySnapshot driver_api.Capability = "snapshot"
+	// CapabilityCloudsnap is the capability to create cloud snapshots
+	CapabilityCloudsnap driver_api.Capability = "cloudsnap"
+	// CapabilityMaintenance is the capability to put nodes in and out of maintenance mode
+	CapabilityMaintenance driver_api.Capability = "maintenance"
+	// CapabilitySharedv4 is the capability to provision sharedv4 volumes
+	CapabilitySharedv4 driver_api.Capability = "sharedv4"
+	// CapabilityDriverRestart is the capability to stop, start and restart the driver on nodes
+	CapabilityDriverRestart driver_api.Capability = "driver-restart"
+	// CapabilityDecommission is the capability to decommission and rejoin nodes
+	CapabilityDecommission driver_api.Capability = "decommission"
+	// CapabilityKvdb is the capability to inspect and fail over the internal kvdb
+	CapabilityKvdb driver_api.Capability = "kvdb"
+	// CapabilityUpgrade is the capability to upgrade the driver
+	CapabilityUpgrade driver_api.Capability = "upgrade"
+	// CapabilityIOPriority is the capability to change the IO priority and bandwidth of volumes
+	CapabilityIOPriority driver_api.Capability = "io-priority"
+	// CapabilityPoolLifecycle is the capability to create, delete and label storage pools
+	CapabilityPoolLifecycle driver_api.Capability = "pool-lifecycle"
+	// CapabilityEncryption is the capability to encrypt volumes with keys from a secrets store
+	CapabilityEncryption driver_api.Capability = "encryption"
+)
+
+// QueryMode selects how a driver with both an API and a CLI answers queries
+type QueryMode string
+
+const (
+	// QueryModeSDK answers queries through the API and falls back to the CLI where the API is unavailable
+	QueryModeSDK QueryMode = "sdk"
+	// QueryModePxctl answers queries through the CLI where the driver has a CLI implementation
+	QueryModePxctl QueryMode = "pxctl"
+	// QueryModeCrossCheck answers queries through both and fails queries whose answers differ
+	QueryModeCrossCheck QueryMode = "crosscheck"
+)
+
 // Driver defines an external volume driver interface that must be implemented
 // by any external storage provider that wants to qualify their product with
 // Torpedo.  The functions defined here are meant to be destructive and illustrative
@@ -56,6 +112,15 @@ type Driver interface {
 	// String returns the string name of this driver.
 	String() string
 
+	// Capabilities returns the set of optional operations supported by the driver
+	Capabilities() driver_api.Capabilities
+
+	// SetQueryMode selects how the driver answers queries which it implements through both its API and CLI
+	SetQueryMode(mode QueryMode) error
+
+	// GetQueryMode returns how the driver answers queries which it implements through both its API and CLI
+	GetQueryMode() QueryMode
+
 	// GetVolumeDriverNamespace returns the namespace of this driver.
 	GetVolumeDriverNamespace() (string, error)
 
@@ -97,16 +162,10 @@ type Driver interface {
 	// params are the custom volume options passed
 	ValidateCreateSnapshot(name string, params map[string]string) error
 
-	// ValidateCreateSnapshotUsingPxctl validates whether a snapshot has been created properly using pxctl.
-	ValidateCreateSnapshotUsingPxctl(name string) error
-
 	// ValidateCreateCloudsnap validates whether a cloudsnap backup can be created properly(or errored expectely)
 	// params are the custom backup options passed
 	ValidateCreateCloudsnap(name string, params map[string]string) error
 
-	// ValidateCreateCloudsnapUsingPxctl validates whether a cloudsnap backup can be created properly(or errored expectely) using pxctl
-	ValidateCreateCloudsnapUsingPxctl(name string) error
-
 	// ValidateCreateGroupSnapshotUsingPxctl validates whether a groupsnap backup can be created properly (or errored expectedly) using pxctl
 	ValidateCreateGroupSnapshotUsingPxctl() error
 
@@ -349,6 +408,9 @@ type Driver interface {
 	// GetPxctlCmdOutput returns the command output run on the given node and any error
 	GetPxctlCmdOutput(n node.Node, command string) (string, error)
 
+	// GetPxctlClient returns a client which runs pxctl commands on the given node and decodes their output
+	GetPxctlClient(n node.Node) (*pxctl.Client, error)
+
 	// GetNodeStats returns the node stats of the given node and an error if any
 	GetNodeStats(n node.Node) (map[string]map[string]int, error)
 
@@ -370,6 +432,32 @@ type Driver interface {
 	// GetPoolsUsedSize returns map of pool id and current used size
 	GetPoolsUsedSize(n *node.Node) (map[string]string, error)
 
+	// CreatePool creates a storage pool on the node from the given drive path or cloud drive spec and returns its UUID
+	CreatePool(n *node.Node, driveSpec string) (string, error)
+
+	// DeletePool deletes the storage pool with the given UUID from the node
+	DeletePool(n *node.Node, poolUUID string) error
+
+	// ValidateDeletePool deletes the storage pool and validates that the volumes on it were moved to other pools,
+	// or that the deletion was blocked. It returns true if the pool was deleted.
+	ValidateDeletePool(n *node.Node, poolUUID string) (bool, error)
+
+	// UpdatePoolLabels sets the given labels on the storage pool
+	UpdatePoolLabels(n *node.Node, poolUUID string, labels map[string]string) error
+
+	// AddJournalDevice adds the drive as the journal device of the node
+	AddJournalDevice(n *node.Node, drivePath string) error
+
+	// AddMetadataDevice adds the drive as the metadata device of the node
+	AddMetadataDevice(n *node.Node, drivePath string) error
+
+	// GetVolumeEncryption returns how the given volume is encrypted
+	GetVolumeEncryption(vol *Volume) (*Encryption, error)
+
+	// SetClusterEncryptionKey sets the key used to encrypt volumes which do not have a key of their own.
+	// Volumes created afterwards use the new key.
+	SetClusterEncryptionKey(n node.Node, keyName string) error
+
 	// GetRebalanceJobs returns the list of rebalance jobs
 	GetRebalanceJobs() ([]*api.StorageRebalanceJob, error)
 
@@ -0,0 +1,124 @@
+package workload
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// FioWorkload is the name of the fio workload driver
+	FioWorkload = "fio"
+	// fioOutputFile is where the fio app specs write the fio status output
+	fioOutputFile = "/logs/fio.log"
+	// fioDateLayout is the layout of the date fio prints in the header of a status block
+	fioDateLayout = "Mon Jan _2 15:04:05 2006"
+)
+
+var (
+	fioHeaderRegex  = regexp.MustCompile(`^(\S+): \(groupid=\d+, jobs=\d+\): err=\s*\d+: pid=\d+: (.+)$`)
+	fioIORegex      = regexp.MustCompile(`^\s*(read|write|trim)\s*: IOPS=[^,]+, BW=[^(]+\([^)]+\)\(([\d.]+)([KMGTP]?i?B)/(\d+)msec\)`)
+	fioLatencyRegex = regexp.MustCompile(`^\s+lat \((nsec|usec|msec)\):.*avg=\s*([\d.]+)`)
+)
+
+// fioBlock is a single status block of a fio job. Values are cumulative since the job started
+type fioBlock struct {
+	job       string
+	time      time.Time
+	bytes     float64
+	runtimeMs int64
+	latencyMs float64
+}
+
+// ParseFioOutput parses the periodic status blocks fio prints with --status-interval. fio
+// reports cumulative IO per block, so the throughput of a sample is the IO done since the
+// previous block of the same job in MB/s. The time of a block is printed in the local time
+// of the container, so it is parsed in loc
+func ParseFioOutput(lines []TimedLine, started time.Time, loc *time.Location) []Sample {
+	var blocks []*fioBlock
+	var current *fioBlock
+	for _, line := range lines {
+		if match := fioHeaderRegex.FindStringSubmatch(line.Text); match != nil {
+			current = &fioBlock{job: match[1], time: line.Time}
+			if ts, err := time.ParseInLocation(fioDateLayout, strings.TrimSpace(match[2]), loc); err == nil {
+				current.time = ts
+			}
+			blocks = append(blocks, current)
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if match := fioIORegex.FindStringSubmatch(line.Text); match != nil {
+			size, err := strconv.ParseFloat(match[2], 64)
+			if err != nil {
+				continue
+			}
+			current.bytes += size * float64(fioUnitMultiplier(match[3]))
+			if runtime, err := strconv.ParseInt(match[4], 10, 64); err == nil && runtime > current.runtimeMs {
+				current.runtimeMs = runtime
+			}
+			continue
+		}
+		if match := fioLatencyRegex.FindStringSubmatch(line.Text); match != nil && current.latencyMs == 0 {
+			if avg, err := strconv.ParseFloat(match[2], 64); err == nil {
+				current.latencyMs = avg * fioLatencyMultiplier(match[1])
+			}
+		}
+	}
+
+	var samples []Sample
+	previous := make(map[string]*fioBlock)
+	for _, block := range blocks {
+		if block.time.IsZero() && !started.IsZero() {
+			block.time = started.Add(time.Duration(block.runtimeMs) * time.Millisecond)
+		}
+		prev, ok := previous[block.job]
+		previous[block.job] = block
+		if !ok || block.runtimeMs <= prev.runtimeMs || block.bytes < prev.bytes {
+			// First block of a job run has no previous reference to compute the interval from
+			continue
+		}
+		intervalSec := float64(block.runtimeMs-prev.runtimeMs) / 1000
+		samples = append(samples, Sample{
+			Time:       block.time,
+			Throughput: (block.bytes - prev.bytes) / 1e6 / intervalSec,
+			LatencyMs:  block.latencyMs,
+		})
+	}
+	return samples
+}
+
+func fioUnitMultiplier(unit string) int64 {
+	multipliers := map[string]int64{
+		"B":   1,
+		"kB":  1000,
+		"KB":  1000,
+		"KiB": 1 << 10,
+		"MB":  1000 * 1000,
+		"MiB": 1 << 20,
+		"GB":  1000 * 1000 * 1000,
+		"GiB": 1 << 30,
+		"TB":  1000 * 1000 * 1000 * 1000,
+		"TiB": 1 << 40,
+	}
+	if multiplier, ok := multipliers[unit]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+func fioLatencyMultiplier(unit string) float64 {
+	switch unit {
+	case "nsec":
+		return 1.0 / 1e6
+	case "usec":
+		return 1.0 / 1e3
+	}
+	return 1
+}
+
+func init() {
+	Register(FioWorkload, newPodWorkload(FioWorkload, []string{"fio"}, fioOutputFile, ParseFioOutput))
+}
@@ -0,0 +1,51 @@
+package workload
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// PgbenchWorkload is the name of the pgbench workload driver
+	PgbenchWorkload = "pgbench"
+	// pgbenchProgressIntervalEnv is the env of the pgbench sidecar scripts which turns on progress reports
+	// every given number of seconds
+	pgbenchProgressIntervalEnv = "PROGRESS_INTERVAL"
+)
+
+// pgbenchProgressRegex matches the progress reports printed with -P, e.g.
+// progress: 5.0 s, 532.0 tps, lat 1.876 ms stddev 0.564
+var pgbenchProgressRegex = regexp.MustCompile(`^progress: ([\d.]+) s, ([\d.]+) tps, lat ([\d.]+) ms`)
+
+// ParsePgbenchOutput parses the progress reports of pgbench into samples of transactions per second
+func ParsePgbenchOutput(lines []TimedLine, started time.Time, loc *time.Location) []Sample {
+	var samples []Sample
+	for _, line := range lines {
+		match := pgbenchProgressRegex.FindStringSubmatch(line.Text)
+		if match == nil {
+			continue
+		}
+		tps, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		latency, _ := strconv.ParseFloat(match[3], 64)
+		sample := Sample{
+			Time:       line.Time,
+			Throughput: tps,
+			LatencyMs:  latency,
+		}
+		if sample.Time.IsZero() && !started.IsZero() {
+			elapsed, _ := strconv.ParseFloat(match[1], 64)
+			sample.Time = started.Add(time.Duration(elapsed * float64(time.Second)))
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func init() {
+	Register(PgbenchWorkload, newPodWorkload(PgbenchWorkload, []string{"pgbench"}, "", ParsePgbenchOutput).
+		withEnv(map[string]string{pgbenchProgressIntervalEnv: "10"}))
+}
@@ -0,0 +1,376 @@
+package workload
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/log"
+	appsapi "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// workloadRolloutTimeout is how long the app may take to roll out after the env of its workload containers changed
+	workloadRolloutTimeout = 10 * time.Minute
+	// workloadRolloutRetryInterval is the interval at which the rollout of the app is checked
+	workloadRolloutRetryInterval = 10 * time.Second
+)
+
+// TimedLine is a single line of workload output with the time it was emitted, if known
+type TimedLine struct {
+	Time time.Time
+	Text string
+}
+
+// OutputParser parses the output of a workload tool into samples. started is the
+// time the container running the tool started and loc the time zone of its clock,
+// which the times the tool prints without a zone are in
+type OutputParser func(lines []TimedLine, started time.Time, loc *time.Location) []Sample
+
+var (
+	k8sCore = core.Instance()
+	k8sApps = apps.Instance()
+)
+
+// window is the measurement window of a workload in an app context
+type window struct {
+	start time.Time
+	end   time.Time
+}
+
+// podWorkload is a workload which runs as a container in the pods of an app and reports
+// its progress either on stdout or in a file inside the container
+type podWorkload struct {
+	name string
+	// images are substrings of the container images which run the workload
+	images []string
+	// outputFile is the file inside the container the workload writes to. Container logs are used if empty
+	outputFile string
+	parser     OutputParser
+	// env is set on the workload containers before measuring, e.g. to turn on the progress reports of the tool
+	env map[string]string
+
+	sync.Mutex
+	windows map[string]*window
+}
+
+func newPodWorkload(name string, images []string, outputFile string, parser OutputParser) *podWorkload {
+	return &podWorkload{
+		name:       name,
+		images:     images,
+		outputFile: outputFile,
+		parser:     parser,
+		windows:    make(map[string]*window),
+	}
+}
+
+// withEnv sets the env which is set on the workload containers before measuring
+func (w *podWorkload) withEnv(env map[string]string) *podWorkload {
+	w.env = env
+	return w
+}
+
+func (w *podWorkload) String() string {
+	return w.name
+}
+
+func (w *podWorkload) IsRunningIn(ctx *scheduler.Context) bool {
+	for _, specObj := range ctx.App.SpecList {
+		var podSpec *corev1.PodSpec
+		if obj, ok := specObj.(*appsapi.Deployment); ok {
+			podSpec = &obj.Spec.Template.Spec
+		} else if obj, ok := specObj.(*appsapi.StatefulSet); ok {
+			podSpec = &obj.Spec.Template.Spec
+		} else {
+			continue
+		}
+		for _, container := range podSpec.Containers {
+			if w.isWorkloadContainer(container) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w *podWorkload) Start(ctx *scheduler.Context) error {
+	if err := w.setEnv(ctx); err != nil {
+		return err
+	}
+	pods, err := w.getWorkloadPods(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("no running [%s] workload pods found for app [%s]", w.name, ctx.App.Key)
+	}
+
+	w.Lock()
+	defer w.Unlock()
+	w.windows[ctx.GetID()] = &window{start: time.Now()}
+	log.Infof("Started measuring [%s] workload of app [%s] in %d pod(s)", w.name, ctx.App.Key, len(pods))
+	return nil
+}
+
+func (w *podWorkload) Stop(ctx *scheduler.Context) error {
+	w.Lock()
+	defer w.Unlock()
+	win, ok := w.windows[ctx.GetID()]
+	if !ok {
+		return fmt.Errorf("[%s] workload of app [%s] was not started", w.name, ctx.App.Key)
+	}
+	win.end = time.Now()
+	log.Infof("Stopped measuring [%s] workload of app [%s]", w.name, ctx.App.Key)
+	return nil
+}
+
+func (w *podWorkload) Progress(ctx *scheduler.Context) (*Progress, error) {
+	w.Lock()
+	win, ok := w.windows[ctx.GetID()]
+	w.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("[%s] workload of app [%s] was not started", w.name, ctx.App.Key)
+	}
+	end := win.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	pods, err := w.getWorkloadPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []Sample
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			if !w.isWorkloadContainer(container) {
+				continue
+			}
+			lines, err := w.getOutput(pod, container.Name, win.start)
+			if err != nil {
+				return nil, err
+			}
+			loc := getContainerLocation(pod, container.Name)
+			for _, sample := range w.parser(lines, getContainerStartTime(pod, container.Name), loc) {
+				if sample.Time.Before(win.start) || sample.Time.After(end) {
+					continue
+				}
+				sample.Pod = pod.Name
+				samples = append(samples, sample)
+			}
+		}
+	}
+	return NewProgress(win.start, end, samples), nil
+}
+
+func (w *podWorkload) Verify(ctx *scheduler.Context, opts VerifyOptions) error {
+	progress, err := w.Progress(ctx)
+	if err != nil {
+		return err
+	}
+	log.Infof("[%s] workload of app [%s]: %d samples, avg throughput %.2f, avg latency %.2fms, max stall %v of pod [%s]",
+		w.name, ctx.App.Key, len(progress.Samples), progress.AvgThroughput, progress.AvgLatencyMs, progress.MaxStall, progress.MaxStallPod)
+	return VerifyProgress(ctx.App.Key, w.name, progress, opts)
+}
+
+func (w *podWorkload) isWorkloadContainer(container corev1.Container) bool {
+	for _, image := range w.images {
+		if strings.Contains(container.Image, image) {
+			return true
+		}
+	}
+	return false
+}
+
+// setEnv sets the env of the workload on its containers in the app and waits for the app to roll out if
+// the env changed
+func (w *podWorkload) setEnv(ctx *scheduler.Context) error {
+	if len(w.env) == 0 {
+		return nil
+	}
+	for _, specObj := range ctx.App.SpecList {
+		if obj, ok := specObj.(*appsapi.Deployment); ok {
+			dep, err := k8sApps.GetDeployment(obj.Name, obj.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get deployment [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+			if !w.setContainerEnv(&dep.Spec.Template.Spec) {
+				continue
+			}
+			log.Infof("Setting env %v of [%s] workload on deployment [%s/%s]", w.env, w.name, obj.Namespace, obj.Name)
+			if dep, err = k8sApps.UpdateDeployment(dep); err != nil {
+				return fmt.Errorf("failed to update deployment [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+			if err := k8sApps.ValidateDeployment(dep, workloadRolloutTimeout, workloadRolloutRetryInterval); err != nil {
+				return fmt.Errorf("deployment [%s/%s] did not roll out. Err: %v", obj.Namespace, obj.Name, err)
+			}
+		} else if obj, ok := specObj.(*appsapi.StatefulSet); ok {
+			ss, err := k8sApps.GetStatefulSet(obj.Name, obj.Namespace)
+			if err != nil {
+				return fmt.Errorf("failed to get statefulset [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+			if !w.setContainerEnv(&ss.Spec.Template.Spec) {
+				continue
+			}
+			log.Infof("Setting env %v of [%s] workload on statefulset [%s/%s]", w.env, w.name, obj.Namespace, obj.Name)
+			if ss, err = k8sApps.UpdateStatefulSet(ss); err != nil {
+				return fmt.Errorf("failed to update statefulset [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+			if err := k8sApps.ValidateStatefulSet(ss, workloadRolloutTimeout); err != nil {
+				return fmt.Errorf("statefulset [%s/%s] did not roll out. Err: %v", obj.Namespace, obj.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// setContainerEnv sets the env of the workload on its containers in the pod spec and returns whether it changed
+func (w *podWorkload) setContainerEnv(podSpec *corev1.PodSpec) bool {
+	changed := false
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if !w.isWorkloadContainer(*container) {
+			continue
+		}
+		for name, value := range w.env {
+			found := false
+			for j := range container.Env {
+				if container.Env[j].Name != name {
+					continue
+				}
+				found = true
+				if container.Env[j].Value != value || container.Env[j].ValueFrom != nil {
+					container.Env[j] = corev1.EnvVar{Name: name, Value: value}
+					changed = true
+				}
+			}
+			if !found {
+				container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: value})
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// getWorkloadPods returns the running pods of the app which have a workload container
+func (w *podWorkload) getWorkloadPods(ctx *scheduler.Context) ([]corev1.Pod, error) {
+	var pods []corev1.Pod
+	for _, specObj := range ctx.App.SpecList {
+		var appPods []corev1.Pod
+		if obj, ok := specObj.(*appsapi.Deployment); ok {
+			dep, err := k8sApps.GetDeployment(obj.Name, obj.Namespace)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get deployment [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+			if appPods, err = k8sApps.GetDeploymentPods(dep); err != nil {
+				return nil, fmt.Errorf("failed to get pods of deployment [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+		} else if obj, ok := specObj.(*appsapi.StatefulSet); ok {
+			ss, err := k8sApps.GetStatefulSet(obj.Name, obj.Namespace)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get statefulset [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+			if appPods, err = k8sApps.GetStatefulSetPods(ss); err != nil {
+				return nil, fmt.Errorf("failed to get pods of statefulset [%s/%s]. Err: %v", obj.Namespace, obj.Name, err)
+			}
+		}
+		for _, pod := range appPods {
+			if pod.Status.Phase != corev1.PodRunning {
+				continue
+			}
+			for _, container := range pod.Spec.Containers {
+				if w.isWorkloadContainer(container) {
+					pods = append(pods, pod)
+					break
+				}
+			}
+		}
+	}
+	return pods, nil
+}
+
+// getOutput returns the output of the workload container emitted since the given time
+func (w *podWorkload) getOutput(pod corev1.Pod, container string, since time.Time) ([]TimedLine, error) {
+	var lines []TimedLine
+	if len(w.outputFile) > 0 {
+		output, err := k8sCore.RunCommandInPod([]string{"cat", w.outputFile}, pod.Name, container, pod.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read [%s] in pod [%s/%s]. Err: %v", w.outputFile, pod.Namespace, pod.Name, err)
+		}
+		for _, line := range strings.Split(output, "\n") {
+			lines = append(lines, TimedLine{Text: line})
+		}
+		return lines, nil
+	}
+
+	sinceTime := metav1.NewTime(since)
+	output, err := k8sCore.GetPodLog(pod.Name, pod.Namespace, &corev1.PodLogOptions{
+		Container:  container,
+		Timestamps: true,
+		SinceTime:  &sinceTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logs of pod [%s/%s]. Err: %v", pod.Namespace, pod.Name, err)
+	}
+	return splitTimestampedLog(output), nil
+}
+
+// splitTimestampedLog splits container logs fetched with timestamps into timed lines
+func splitTimestampedLog(output string) []TimedLine {
+	var lines []TimedLine
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.SplitN(line, " ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, parts[0])
+		if err != nil {
+			lines = append(lines, TimedLine{Text: line})
+			continue
+		}
+		lines = append(lines, TimedLine{Time: ts, Text: parts[1]})
+	}
+	return lines
+}
+
+// getContainerLocation returns the time zone of the clock of the container, which is that of the node
+// unless the container sets its own. UTC is assumed if the container cannot tell
+func getContainerLocation(pod corev1.Pod, container string) *time.Location {
+	output, err := k8sCore.RunCommandInPod([]string{"date", "+%z"}, pod.Name, container, pod.Namespace)
+	if err == nil {
+		var loc *time.Location
+		if loc, err = parseZoneOffset(output); err == nil {
+			return loc
+		}
+	}
+	log.Warnf("Failed to get time zone of pod [%s/%s], assuming UTC. Err: %v", pod.Namespace, pod.Name, err)
+	return time.UTC
+}
+
+// parseZoneOffset returns the time zone of the output of date +%z, e.g. -0700
+func parseZoneOffset(output string) (*time.Location, error) {
+	offset := strings.TrimSpace(output)
+	zone, err := time.Parse("-0700", offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse time zone offset [%s]. Err: %v", offset, err)
+	}
+	_, seconds := zone.Zone()
+	return time.FixedZone(offset, seconds), nil
+}
+
+func getContainerStartTime(pod corev1.Pod, container string) time.Time {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container && status.State.Running != nil {
+			return status.State.Running.StartedAt.Time
+		}
+	}
+	return time.Time{}
+}
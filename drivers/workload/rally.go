@@ -0,0 +1,49 @@
+package workload
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// RallyWorkload is the name of the elasticsearch rally workload driver
+	RallyWorkload = "esrally"
+)
+
+var (
+	// rallyThroughputRegex matches the mean throughput row of the rally summary report, e.g.
+	// |   All |   Mean Throughput | index-append |   12345.6 | docs/s |
+	rallyThroughputRegex = regexp.MustCompile(`\|\s*Mean Throughput\s*\|\s*[^|]+\|\s*([\d.]+)\s*\|\s*(docs|ops)/s`)
+	// rallyLatencyRegex matches the median latency row of the rally summary report
+	rallyLatencyRegex = regexp.MustCompile(`\|\s*50th percentile latency\s*\|\s*[^|]+\|\s*([\d.]+)\s*\|\s*ms`)
+)
+
+// ParseRallyOutput parses the summary report of elasticsearch rally. Rally only reports once
+// a race is done, so every race results in a single sample
+func ParseRallyOutput(lines []TimedLine, started time.Time, loc *time.Location) []Sample {
+	var samples []Sample
+	var current *Sample
+	for _, line := range lines {
+		if match := rallyThroughputRegex.FindStringSubmatch(line.Text); match != nil {
+			throughput, err := strconv.ParseFloat(match[1], 64)
+			if err != nil {
+				continue
+			}
+			samples = append(samples, Sample{
+				Time:       line.Time,
+				Throughput: throughput,
+			})
+			current = &samples[len(samples)-1]
+			continue
+		}
+		if match := rallyLatencyRegex.FindStringSubmatch(line.Text); match != nil && current != nil && current.LatencyMs == 0 {
+			current.LatencyMs, _ = strconv.ParseFloat(match[1], 64)
+		}
+	}
+	return samples
+}
+
+func init() {
+	Register(RallyWorkload, newPodWorkload(RallyWorkload, []string{"es-rally", "esrally"}, "", ParseRallyOutput))
+}
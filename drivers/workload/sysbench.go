@@ -0,0 +1,47 @@
+package workload
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// SysbenchWorkload is the name of the sysbench workload driver
+	SysbenchWorkload = "sysbench"
+)
+
+// sysbenchReportRegex matches the interval reports printed with --report-interval, e.g.
+// [ 10s ] thds: 1 tps: 23.99 qps: 479.74 (r/w/o: 335.82/95.94/47.97) lat (ms,95%): 57.87 err/s: 0.00 reconn/s: 0.00
+var sysbenchReportRegex = regexp.MustCompile(`^\[\s*(\d+)s\s*\] thds: \d+ tps: ([\d.]+) .*lat \(ms,\d+%\): ([\d.]+)`)
+
+// ParseSysbenchOutput parses the interval reports of sysbench into samples of transactions per second
+func ParseSysbenchOutput(lines []TimedLine, started time.Time, loc *time.Location) []Sample {
+	var samples []Sample
+	for _, line := range lines {
+		match := sysbenchReportRegex.FindStringSubmatch(line.Text)
+		if match == nil {
+			continue
+		}
+		tps, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		latency, _ := strconv.ParseFloat(match[3], 64)
+		sample := Sample{
+			Time:       line.Time,
+			Throughput: tps,
+			LatencyMs:  latency,
+		}
+		if sample.Time.IsZero() && !started.IsZero() {
+			elapsed, _ := strconv.Atoi(match[1])
+			sample.Time = started.Add(time.Duration(elapsed) * time.Second)
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func init() {
+	Register(SysbenchWorkload, newPodWorkload(SysbenchWorkload, []string{"sysbench"}, "", ParseSysbenchOutput))
+}
@@ -0,0 +1,43 @@
+package workload
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// VdbenchWorkload is the name of the vdbench workload driver
+	VdbenchWorkload = "vdbench"
+)
+
+// vdbenchIntervalRegex matches the interval lines of a vdbench raw IO run, e.g.
+// 10:00:01.052         1     2345.00     9.16    4096  50.00    0.412 ...
+// The columns are time, interval, i/o rate, MB/sec, bytes/io, read% and response time in ms
+var vdbenchIntervalRegex = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2}\.\d{3})\s+(\d+)\s+([\d.]+)\s+([\d.]+)\s+(\d+)\s+([\d.]+)\s+([\d.]+)`)
+
+// ParseVdbenchOutput parses the interval lines of vdbench into samples of MB/s
+func ParseVdbenchOutput(lines []TimedLine, started time.Time, loc *time.Location) []Sample {
+	var samples []Sample
+	for _, line := range lines {
+		match := vdbenchIntervalRegex.FindStringSubmatch(line.Text)
+		if match == nil {
+			continue
+		}
+		mbps, err := strconv.ParseFloat(match[4], 64)
+		if err != nil {
+			continue
+		}
+		latency, _ := strconv.ParseFloat(match[7], 64)
+		samples = append(samples, Sample{
+			Time:       line.Time,
+			Throughput: mbps,
+			LatencyMs:  latency,
+		})
+	}
+	return samples
+}
+
+func init() {
+	Register(VdbenchWorkload, newPodWorkload(VdbenchWorkload, []string{"vdbench"}, "", ParseVdbenchOutput))
+}
@@ -0,0 +1,219 @@
+package workload
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/scheduler"
+	"github.com/portworx/torpedo/pkg/errors"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+// Sample is a single throughput/latency measurement reported by a workload tool
+type Sample struct {
+	// Pod is the name of the pod that reported the sample
+	Pod string
+	// Time is the time at which the sample was reported
+	Time time.Time
+	// Throughput is the throughput of the interval in the unit reported by the tool (MB/s, tps, ops/s)
+	Throughput float64
+	// LatencyMs is the average latency of the interval in milliseconds, 0 if not reported
+	LatencyMs float64
+}
+
+// Progress is the progress made by a workload between Start and the time it was queried
+type Progress struct {
+	// Start is the time at which the workload measurement window started
+	Start time.Time
+	// End is the time at which progress was collected
+	End time.Time
+	// Samples are the measurements reported by the workload in the window, sorted by time
+	Samples []Sample
+	// MaxStall is the longest duration in the window in which a pod of the workload made no progress
+	MaxStall time.Duration
+	// MaxStallPod is the pod which stalled for MaxStall
+	MaxStallPod string
+	// AvgThroughput is the average throughput of all samples in the window
+	AvgThroughput float64
+	// AvgLatencyMs is the average latency in milliseconds of all samples that reported a latency
+	AvgLatencyMs float64
+}
+
+// VerifyOptions are the assertions checked by Verify
+type VerifyOptions struct {
+	// MaxStall is the longest allowed duration without IO progress. 0 disables the check
+	MaxStall time.Duration
+	// MinThroughput is the minimum allowed average throughput. 0 only checks that IO happened
+	MinThroughput float64
+	// MaxLatencyMs is the maximum allowed average latency in milliseconds. 0 disables the check
+	MaxLatencyMs float64
+}
+
+// Driver is the interface implemented by application level workloads (fio, sysbench, pgbench ...)
+// which run as containers of the apps scheduled by torpedo
+type Driver interface {
+	// String returns the string name of this driver
+	String() string
+
+	// Start starts measuring the workload running in the given app context
+	Start(ctx *scheduler.Context) error
+
+	// Stop stops measuring the workload running in the given app context
+	Stop(ctx *scheduler.Context) error
+
+	// Progress returns the progress made by the workload since Start
+	Progress(ctx *scheduler.Context) (*Progress, error)
+
+	// Verify returns an error if the progress made by the workload since Start violates the given options
+	Verify(ctx *scheduler.Context, opts VerifyOptions) error
+
+	// IsRunningIn returns true if the workload is part of the given app context
+	IsRunningIn(ctx *scheduler.Context) bool
+}
+
+// ErrWorkloadStalled is returned when a workload did not make progress for longer than allowed
+type ErrWorkloadStalled struct {
+	// App is the key of the app whose workload stalled
+	App string
+	// Workload is the name of the workload
+	Workload string
+	// Cause is the underlying cause of the error
+	Cause string
+}
+
+func (e *ErrWorkloadStalled) Error() string {
+	return fmt.Sprintf("workload [%s] of app [%s] failed verification: %s", e.Workload, e.App, e.Cause)
+}
+
+var (
+	workloadDrivers = make(map[string]Driver)
+)
+
+// Register registers the given workload driver
+func Register(name string, d Driver) error {
+	if _, ok := workloadDrivers[name]; !ok {
+		workloadDrivers[name] = d
+	} else {
+		return fmt.Errorf("workload driver: %s is already registered", name)
+	}
+	return nil
+}
+
+// Get returns a registered workload driver
+func Get(name string) (Driver, error) {
+	if d, ok := workloadDrivers[name]; ok {
+		return d, nil
+	}
+	return nil, &errors.ErrNotFound{
+		ID:   name,
+		Type: "Workload Driver",
+	}
+}
+
+// GetForContext returns all registered workload drivers which run as part of the given app context
+func GetForContext(ctx *scheduler.Context) []Driver {
+	var names []string
+	for name := range workloadDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var drivers []Driver
+	for _, name := range names {
+		if workloadDrivers[name].IsRunningIn(ctx) {
+			drivers = append(drivers, workloadDrivers[name])
+		}
+	}
+	return drivers
+}
+
+// NewProgress builds the progress of a workload from the given samples, computing the averages
+// and the longest stall of any pod in the window between start and end. Stalls are computed per pod,
+// so a stalled pod is not hidden by other pods which keep making progress
+func NewProgress(start, end time.Time, samples []Sample) *Progress {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Time.Before(samples[j].Time)
+	})
+
+	progress := &Progress{
+		Start:   start,
+		End:     end,
+		Samples: samples,
+	}
+
+	var throughputSum, latencySum float64
+	var latencyCount int
+	lastProgress := make(map[string]time.Time)
+	for _, sample := range samples {
+		throughputSum += sample.Throughput
+		if sample.LatencyMs > 0 {
+			latencySum += sample.LatencyMs
+			latencyCount++
+		}
+		last, ok := lastProgress[sample.Pod]
+		if !ok {
+			last = start
+			lastProgress[sample.Pod] = start
+		}
+		if sample.Throughput > 0 {
+			progress.recordStall(sample.Pod, sample.Time.Sub(last))
+			lastProgress[sample.Pod] = sample.Time
+		}
+	}
+	for pod, last := range lastProgress {
+		progress.recordStall(pod, end.Sub(last))
+	}
+	if len(samples) == 0 {
+		progress.MaxStall = end.Sub(start)
+	}
+	if len(samples) > 0 {
+		progress.AvgThroughput = throughputSum / float64(len(samples))
+	}
+	if latencyCount > 0 {
+		progress.AvgLatencyMs = latencySum / float64(latencyCount)
+	}
+	return progress
+}
+
+// recordStall records the stall of the pod if it is the longest one so far. Ties go to the pod which sorts
+// first, so the reported pod does not depend on the map order
+func (p *Progress) recordStall(pod string, stall time.Duration) {
+	if stall > p.MaxStall || (stall == p.MaxStall && stall > 0 && pod < p.MaxStallPod) {
+		p.MaxStall = stall
+		p.MaxStallPod = pod
+	}
+}
+
+// VerifyProgress checks the given progress against the verify options. Workloads which reported no
+// samples in the window, e.g. tools idle between runs or run without progress reports, are not verified
+func VerifyProgress(app, workload string, progress *Progress, opts VerifyOptions) error {
+	if len(progress.Samples) == 0 {
+		log.Warnf("[%s] workload of app [%s] reported no samples between %v and %v, skipping verification",
+			workload, app, progress.Start, progress.End)
+		return nil
+	}
+	if progress.AvgThroughput <= 0 || progress.AvgThroughput < opts.MinThroughput {
+		return &ErrWorkloadStalled{
+			App:      app,
+			Workload: workload,
+			Cause:    fmt.Sprintf("average throughput %.2f is below expected %.2f", progress.AvgThroughput, opts.MinThroughput),
+		}
+	}
+	if opts.MaxStall > 0 && progress.MaxStall > opts.MaxStall {
+		return &ErrWorkloadStalled{
+			App:      app,
+			Workload: workload,
+			Cause: fmt.Sprintf("IO of pod [%s] stalled for %v which is longer than allowed %v",
+				progress.MaxStallPod, progress.MaxStall, opts.MaxStall),
+		}
+	}
+	if opts.MaxLatencyMs > 0 && progress.AvgLatencyMs > opts.MaxLatencyMs {
+		return &ErrWorkloadStalled{
+			App:      app,
+			Workload: workload,
+			Cause:    fmt.Sprintf("average latency %.2fms is above allowed %.2fms", progress.AvgLatencyMs, opts.MaxLatencyMs),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package workload
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const sampleFioOutput = `fio_test: (groupid=0, jobs=1): err= 0: pid=15: Thu Jul 28 10:22:33 2022
+  write: IOPS=2560, BW=10.0MiB/s (10.5MB/s)(10.0MiB/1000msec); 0 zone resets
+    clat (usec): min=12, max=40000, avg=389.12, stdev=99.10
+     lat (usec): min=12, max=40012, avg=390.55, stdev=100.23
+fio_test: (groupid=0, jobs=1): err= 0: pid=15: Thu Jul 28 10:22:34 2022
+  write: IOPS=2560, BW=10.0MiB/s (10.5MB/s)(20.0MiB/2000msec); 0 zone resets
+     lat (usec): min=12, max=40012, avg=500.00, stdev=100.23
+fio_test: (groupid=0, jobs=1): err= 0: pid=15: Thu Jul 28 10:22:35 2022
+  write: IOPS=1280, BW=5120KiB/s (5243kB/s)(20.0MiB/3000msec); 0 zone resets
+     lat (usec): min=12, max=40012, avg=500.00, stdev=100.23
+`
+
+const sampleSysbenchOutput = `2022-07-28T10:22:33.000000000Z [ 1s ] thds: 1 tps: 23.99 qps: 479.74 (r/w/o: 335.82/95.94/47.97) lat (ms,95%): 57.87 err/s: 0.00 reconn/s: 0.00
+2022-07-28T10:22:34.000000000Z [ 2s ] thds: 1 tps: 0.00 qps: 0.00 (r/w/o: 0.00/0.00/0.00) lat (ms,95%): 0.00 err/s: 0.00 reconn/s: 0.00
+2022-07-28T10:22:35.000000000Z some unrelated line`
+
+const samplePgbenchOutput = `progress: 5.0 s, 532.0 tps, lat 1.876 ms stddev 0.564
+progress: 10.0 s, 498.2 tps, lat 2.004 ms stddev 0.611`
+
+const sampleVdbenchOutput = `Jul 28, 2022  interval        i/o   MB/sec   bytes   read     resp     read    write     resp     resp queue  cpu%  cpu%
+10:22:33.052         1     2345.00     9.16    4096  50.00    0.412    0.301    0.523    1.234    0.100   1.0   2.1   1.0`
+
+const sampleRallyOutput = `|   All |                  Mean Throughput | index-append |   12345.6 | docs/s |
+|   All |          50th percentile latency | index-append |   250.123 |     ms |`
+
+func toLines(output string) []TimedLine {
+	var lines []TimedLine
+	for _, line := range strings.Split(output, "\n") {
+		lines = append(lines, TimedLine{Text: line})
+	}
+	return lines
+}
+
+func TestParseFioOutput(t *testing.T) {
+	samples := ParseFioOutput(toLines(sampleFioOutput), time.Time{}, time.UTC)
+	require.Len(t, samples, 2)
+	require.InDelta(t, 10.48576, samples[0].Throughput, 0.0001)
+	require.InDelta(t, 0.5, samples[0].LatencyMs, 0.0001)
+	require.Equal(t, 34, samples[0].Time.Second())
+	require.Equal(t, float64(0), samples[1].Throughput)
+}
+
+func TestParseFioOutputLocalTime(t *testing.T) {
+	loc, err := parseZoneOffset("-0700\n")
+	require.NoError(t, err)
+	samples := ParseFioOutput(toLines(sampleFioOutput), time.Time{}, loc)
+	require.Len(t, samples, 2)
+	require.Equal(t, time.Date(2022, 7, 28, 17, 22, 34, 0, time.UTC), samples[0].Time.UTC())
+}
+
+func TestParseSysbenchOutput(t *testing.T) {
+	samples := ParseSysbenchOutput(splitTimestampedLog(sampleSysbenchOutput), time.Time{}, time.UTC)
+	require.Len(t, samples, 2)
+	require.Equal(t, 23.99, samples[0].Throughput)
+	require.Equal(t, 57.87, samples[0].LatencyMs)
+	require.Equal(t, 33, samples[0].Time.Second())
+}
+
+func TestParsePgbenchOutput(t *testing.T) {
+	started := time.Date(2022, 7, 28, 10, 0, 0, 0, time.UTC)
+	samples := ParsePgbenchOutput(toLines(samplePgbenchOutput), started, time.UTC)
+	require.Len(t, samples, 2)
+	require.Equal(t, 532.0, samples[0].Throughput)
+	require.Equal(t, 1.876, samples[0].LatencyMs)
+	require.Equal(t, started.Add(10*time.Second), samples[1].Time)
+}
+
+func TestParseVdbenchOutput(t *testing.T) {
+	samples := ParseVdbenchOutput(toLines(sampleVdbenchOutput), time.Time{}, time.UTC)
+	require.Len(t, samples, 1)
+	require.Equal(t, 9.16, samples[0].Throughput)
+	require.Equal(t, 0.412, samples[0].LatencyMs)
+}
+
+func TestParseRallyOutput(t *testing.T) {
+	samples := ParseRallyOutput(toLines(sampleRallyOutput), time.Time{}, time.UTC)
+	require.Len(t, samples, 1)
+	require.Equal(t, 12345.6, samples[0].Throughput)
+	require.Equal(t, 250.123, samples[0].LatencyMs)
+}
+
+func TestVerifyProgressStall(t *testing.T) {
+	start := time.Date(2022, 7, 28, 10, 0, 0, 0, time.UTC)
+	samples := []Sample{
+		{Time: start.Add(1 * time.Second), Throughput: 10},
+		{Time: start.Add(2 * time.Second), Throughput: 0},
+		{Time: start.Add(30 * time.Second), Throughput: 10},
+	}
+	progress := NewProgress(start, start.Add(31*time.Second), samples)
+	require.Equal(t, 29*time.Second, progress.MaxStall)
+	require.NoError(t, VerifyProgress("app", "fio", progress, VerifyOptions{MaxStall: time.Minute}))
+	require.Error(t, VerifyProgress("app", "fio", progress, VerifyOptions{MaxStall: 10 * time.Second}))
+	require.NoError(t, VerifyProgress("app", "fio", NewProgress(start, start, nil), VerifyOptions{}))
+
+	idle := NewProgress(start, start.Add(31*time.Second), []Sample{{Time: start.Add(time.Second)}})
+	require.Error(t, VerifyProgress("app", "fio", idle, VerifyOptions{}))
+
+	// a pod which stalls is not hidden by another pod making progress
+	samples = []Sample{
+		{Pod: "fio-0", Time: start.Add(1 * time.Second), Throughput: 10},
+		{Pod: "fio-1", Time: start.Add(1 * time.Second), Throughput: 10},
+		{Pod: "fio-0", Time: start.Add(10 * time.Second), Throughput: 10},
+		{Pod: "fio-0", Time: start.Add(20 * time.Second), Throughput: 10},
+		{Pod: "fio-1", Time: start.Add(25 * time.Second), Throughput: 10},
+		{Pod: "fio-0", Time: start.Add(30 * time.Second), Throughput: 10},
+	}
+	progress = NewProgress(start, start.Add(31*time.Second), samples)
+	require.Equal(t, 24*time.Second, progress.MaxStall)
+	require.Equal(t, "fio-1", progress.MaxStallPod)
+	require.Error(t, VerifyProgress("app", "fio", progress, VerifyOptions{MaxStall: 20 * time.Second}))
+}
+
+func TestSetContainerEnv(t *testing.T) {
+	w := newPodWorkload(PgbenchWorkload, []string{"pgbench"}, "", ParsePgbenchOutput).
+		withEnv(map[string]string{pgbenchProgressIntervalEnv: "10"})
+	podSpec := &corev1.PodSpec{
+		Containers: []corev1.Container{
+			{Name: "postgres", Image: "postgres:9.5"},
+			{Name: "pgbench", Image: "portworx/torpedo-pgbench:latest", Env: []corev1.EnvVar{{Name: "SIZE", Value: "70"}}},
+		},
+	}
+	require.True(t, w.setContainerEnv(podSpec))
+	require.Empty(t, podSpec.Containers[0].Env)
+	require.Equal(t, []corev1.EnvVar{{Name: "SIZE", Value: "70"}, {Name: pgbenchProgressIntervalEnv, Value: "10"}},
+		podSpec.Containers[1].Env)
+	require.False(t, w.setContainerEnv(podSpec))
+
+	podSpec.Containers[1].Env[1].Value = "5"
+	require.True(t, w.setContainerEnv(podSpec))
+	require.Equal(t, "10", podSpec.Containers[1].Env[1].Value)
+}
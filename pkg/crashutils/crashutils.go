@@ -0,0 +1,208 @@
+package crashutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxSignatureFrames is the number of top stack frames the signature of a crash is made of
+const maxSignatureFrames = 5
+
+var (
+	// journaldPrefixRegex matches the time, host and unit journald prefixes the lines of a log with
+	journaldPrefixRegex = regexp.MustCompile(`^\w{3} [ \d]\d \d{2}:\d{2}:\d{2} \S+ [^:\s]+: ?`)
+	// goPanicRegex matches the first line of a go panic or fatal error
+	goPanicRegex = regexp.MustCompile(`^(panic: .*|fatal error: .*)$`)
+	// goroutineRegex matches the header of the stack of a goroutine
+	goroutineRegex = regexp.MustCompile(`goroutine \d+ \[[^\]]*\]:\s*$`)
+	// goFrameRegex matches the function line of a go stack frame, e.g. main.(*T).f(0xc000010000, 0x1)
+	goFrameRegex = regexp.MustCompile(`^\s*([\w./*()\[\]-]+\.[\w.*()\[\]-]+)\((?:[^()]*)\)\s*$`)
+	// gdbFrameRegex matches a frame of a gdb backtrace, e.g. #1  0x00007f in pxd::io::submit (this=0x0) at io.cc:12
+	gdbFrameRegex = regexp.MustCompile(`^#\d+\s+(?:0x[0-9a-f]+ in )?([^\s(]+)`)
+	// gdbSignalRegex matches the signal a core was generated by in a gdb backtrace
+	gdbSignalRegex = regexp.MustCompile(`Program terminated with signal (\w+)`)
+	// execFnRegex matches the executable of a core file in the output of the file command
+	execFnRegex = regexp.MustCompile(`execfn: '([^']+)'`)
+	// fromRegex matches the command line of a core file in the output of older file commands
+	fromRegex = regexp.MustCompile(`from '([^' ]+)`)
+	// volatileRegex matches the parts of a crash reason which differ between occurrences of the same crash
+	volatileRegex = regexp.MustCompile(`0x[0-9a-fA-F]+|\b\d+\b`)
+)
+
+// Crash is a crash of a binary, from a go panic in its logs or from its core file
+type Crash struct {
+	// Node is the name of the node the crash happened on
+	Node string
+	// Binary is the path of the binary which crashed
+	Binary string
+	// Version is the version of the binary which crashed
+	Version string
+	// Reason is the panic message or the signal of the crash
+	Reason string
+	// Frames are the functions of the crashing stack, innermost first
+	Frames []string
+	// Stack is the full stack trace of the crash as it was logged or extracted from the core
+	Stack string
+	// CorePath is the path of the core file on the node, if there is one
+	CorePath string
+	// Checksum is the checksum of the core file, used to tell cores without a backtrace apart
+	Checksum string
+	// LocalPath is the path of the copy of the core file or the stack on the torpedo side
+	LocalPath string
+	// Link is the link to the copy of the core file or the stack
+	Link string
+}
+
+// Signature returns the signature of the crash, which is the same for crashes of the same binary at the same place
+// for the same reason. Crashes without a stack, e.g. cores of nodes without gdb, cannot be told to be at the same
+// place, so their signature is that of the crash itself: its node, core file and checksum, or its logged stack.
+func (c *Crash) Signature() string {
+	var parts []string
+	if len(c.Frames) == 0 {
+		parts = []string{c.Node, c.CorePath, c.Checksum, c.Stack}
+	} else {
+		frames := c.Frames
+		if len(frames) > maxSignatureFrames {
+			frames = frames[:maxSignatureFrames]
+		}
+		binary := c.Binary[strings.LastIndex(c.Binary, "/")+1:]
+		reason := volatileRegex.ReplaceAllString(c.Reason, "N")
+		parts = append([]string{binary, reason}, frames...)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+func (c *Crash) String() string {
+	frame := "unknown"
+	if len(c.Frames) > 0 {
+		frame = c.Frames[0]
+	}
+	return fmt.Sprintf("%s %s crashed in %s on node %s: %s", c.Binary, c.Version, frame, c.Node, c.Reason)
+}
+
+// ParseGoPanics returns the go panics and fatal errors in the logs
+func ParseGoPanics(logs string) []*Crash {
+	var crashes []*Crash
+	var current *Crash
+	var stack []string
+	inStack := false
+	finish := func() {
+		if current != nil {
+			current.Stack = strings.Join(stack, "\n")
+			crashes = append(crashes, current)
+		}
+		current, stack, inStack = nil, nil, false
+	}
+
+	for _, line := range strings.Split(logs, "\n") {
+		line = journaldPrefixRegex.ReplaceAllString(line, "")
+		if m := goPanicRegex.FindStringSubmatch(line); m != nil {
+			finish()
+			current = &Crash{Reason: strings.TrimSpace(m[1])}
+			stack = []string{line}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if goroutineRegex.MatchString(line) {
+			if inStack {
+				// only the stack of the panicking goroutine is of interest
+				finish()
+				continue
+			}
+			inStack = true
+		} else if inStack && len(strings.TrimSpace(line)) == 0 {
+			// the stack of a goroutine ends with an empty line
+			finish()
+			continue
+		}
+		stack = append(stack, line)
+		if m := goFrameRegex.FindStringSubmatch(line); inStack && m != nil {
+			current.Frames = append(current.Frames, m[1])
+		}
+	}
+	finish()
+	return crashes
+}
+
+// ParseBacktrace returns the signal the core was generated by and the functions of the frames of a gdb backtrace of
+// it, innermost first
+func ParseBacktrace(backtrace string) (string, []string) {
+	var reason string
+	if m := gdbSignalRegex.FindStringSubmatch(backtrace); m != nil {
+		reason = "signal " + m[1]
+	}
+	var frames []string
+	for _, line := range strings.Split(backtrace, "\n") {
+		if m := gdbFrameRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			frames = append(frames, m[1])
+		}
+	}
+	return reason, frames
+}
+
+// ParseCoreBinary returns the path of the binary of a core file from the output of the file command on it
+func ParseCoreBinary(fileOutput string) string {
+	if m := execFnRegex.FindStringSubmatch(fileOutput); m != nil {
+		return m[1]
+	}
+	if m := fromRegex.FindStringSubmatch(fileOutput); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Registry keeps the crashes seen so far by signature, so each crash is reported once however many times it
+// happens. It is safe for concurrent use.
+type Registry struct {
+	sync.Mutex
+	crashes map[string]*Crash
+	counts  map[string]int
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		crashes: make(map[string]*Crash),
+		counts:  make(map[string]int),
+	}
+}
+
+// Add adds the crash to the registry and returns whether it is the first crash with its signature
+func (r *Registry) Add(c *Crash) bool {
+	r.Lock()
+	defer r.Unlock()
+	signature := c.Signature()
+	r.counts[signature]++
+	if _, ok := r.crashes[signature]; ok {
+		return false
+	}
+	r.crashes[signature] = c
+	return true
+}
+
+// Count returns how many times the crash with the signature was added
+func (r *Registry) Count(signature string) int {
+	r.Lock()
+	defer r.Unlock()
+	return r.counts[signature]
+}
+
+// Signatures returns the sorted signatures of the crashes in the registry
+func (r *Registry) Signatures() []string {
+	r.Lock()
+	defer r.Unlock()
+	var signatures []string
+	for signature := range r.crashes {
+		signatures = append(signatures, signature)
+	}
+	sort.Strings(signatures)
+	return signatures
+}
@@ -0,0 +1,94 @@
+package crashutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const pxLogs = `Mar 03 10:00:01 node-1 portworx[1234]: time="2026-03-03T10:00:01Z" level=info msg="PX is ready"
+Mar 03 10:00:02 node-1 portworx[1234]: panic: runtime error: invalid memory address or nil pointer dereference
+Mar 03 10:00:02 node-1 portworx[1234]: [signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x1a2b3c]
+Mar 03 10:00:02 node-1 portworx[1234]:
+Mar 03 10:00:02 node-1 portworx[1234]: goroutine 4711 [running]:
+Mar 03 10:00:02 node-1 portworx[1234]: github.com/portworx/px/volume.(*Manager).Attach(0x0, 0xc000010000, 0x24)
+Mar 03 10:00:02 node-1 portworx[1234]:         /go/src/github.com/portworx/px/volume/manager.go:42 +0x1d
+Mar 03 10:00:02 node-1 portworx[1234]: github.com/portworx/px/server.handle(...)
+Mar 03 10:00:02 node-1 portworx[1234]:         /go/src/github.com/portworx/px/server/server.go:10 +0x25
+Mar 03 10:00:02 node-1 portworx[1234]: created by github.com/portworx/px/server.Serve
+Mar 03 10:00:02 node-1 portworx[1234]:         /go/src/github.com/portworx/px/server/server.go:5 +0x30
+Mar 03 10:00:02 node-1 portworx[1234]:
+Mar 03 10:00:02 node-1 portworx[1234]: goroutine 1 [chan receive]:
+Mar 03 10:00:02 node-1 portworx[1234]: main.main()
+Mar 03 10:00:09 node-1 portworx[1250]: time="2026-03-03T10:00:09Z" level=info msg="Starting PX"
+`
+
+func TestParseGoPanics(t *testing.T) {
+	crashes := ParseGoPanics(pxLogs)
+	require.Len(t, crashes, 1)
+	require.Equal(t, "panic: runtime error: invalid memory address or nil pointer dereference", crashes[0].Reason)
+	require.Equal(t, []string{
+		"github.com/portworx/px/volume.(*Manager).Attach",
+		"github.com/portworx/px/server.handle",
+	}, crashes[0].Frames)
+	require.Contains(t, crashes[0].Stack, "goroutine 4711 [running]:")
+	require.NotContains(t, crashes[0].Stack, "main.main()")
+}
+
+func TestSignature(t *testing.T) {
+	first := ParseGoPanics(pxLogs)[0]
+	first.Binary = "/usr/local/bin/px"
+	again := ParseGoPanics(pxLogs)[0]
+	again.Binary = "/opt/pwx/bin/px"
+	again.Reason = "panic: runtime error: index out of range [5] with length 3"
+	require.NotEqual(t, first.Signature(), again.Signature())
+
+	again.Reason = "panic: runtime error: index out of range [7] with length 2"
+	other := *again
+	other.Reason = "panic: runtime error: index out of range [5] with length 3"
+	require.Equal(t, other.Signature(), again.Signature())
+
+	registry := NewRegistry()
+	require.True(t, registry.Add(first))
+	require.True(t, registry.Add(again))
+	require.False(t, registry.Add(&other))
+	require.Equal(t, 2, registry.Count(again.Signature()))
+	require.Len(t, registry.Signatures(), 2)
+}
+
+func TestSignatureWithoutFrames(t *testing.T) {
+	first := &Crash{Node: "node-1", Binary: "/usr/local/bin/px-storage", Reason: "core dumped",
+		CorePath: "/var/cores/core-px-storage.1234", Checksum: "ab12"}
+	again := *first
+	again.CorePath, again.Checksum = "/var/cores/core-px-storage.5678", "cd34"
+	require.NotEqual(t, first.Signature(), again.Signature())
+
+	// cores whose binary is unknown are not duplicates of each other either
+	first.Binary, again.Binary = "", ""
+	require.NotEqual(t, first.Signature(), again.Signature())
+
+	registry := NewRegistry()
+	require.True(t, registry.Add(first))
+	require.True(t, registry.Add(&again))
+	require.False(t, registry.Add(first))
+}
+
+func TestParseBacktrace(t *testing.T) {
+	backtrace := `[New LWP 1234]
+Core was generated by '/usr/local/bin/px-storage'.
+Program terminated with signal SIGABRT, Aborted.
+#0  0x00007f3a1b2c3d4e in raise () from /lib64/libc.so.6
+#1  0x00007f3a1b2c5f6a in abort () from /lib64/libc.so.6
+#2  pxd::io::submit (this=0x0, req=...) at io.cc:12
+`
+	reason, frames := ParseBacktrace(backtrace)
+	require.Equal(t, "signal SIGABRT", reason)
+	require.Equal(t, []string{"raise", "abort", "pxd::io::submit"}, frames)
+}
+
+func TestParseCoreBinary(t *testing.T) {
+	require.Equal(t, "/usr/local/bin/px-storage", ParseCoreBinary("/var/cores/core-px-storage.1234: ELF 64-bit LSB core file, "+
+		"x86-64, version 1 (SYSV), SVR4-style, from '/usr/local/bin/px-storage -c', real uid: 0, execfn: '/usr/local/bin/px-storage', platform: 'x86_64'"))
+	require.Equal(t, "/usr/local/bin/px", ParseCoreBinary("core: ELF 64-bit LSB core file x86-64, version 1 (SYSV), SVR4-style, from '/usr/local/bin/px -daemon'"))
+	require.Empty(t, ParseCoreBinary("core: data"))
+}
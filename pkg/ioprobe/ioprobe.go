@@ -0,0 +1,345 @@
+package ioprobe
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/sched-ops/k8s/core"
+	"github.com/portworx/torpedo/pkg/log"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// probeFileName is the file the probe appends timestamps to inside the volume mount
+	probeFileName = ".torpedo-ioprobe"
+	// probePidFileName is the file holding the pid of the probe loop, kept outside the volume
+	// so it can always be read even while IO to the volume is blocked
+	probePidFileName = "/tmp/.torpedo-ioprobe-%s.pid"
+	// DefaultInterval is the default time between two probe writes
+	DefaultInterval = time.Second
+	// reprobeInterval is how often a running probe checks that its pod and container were not restarted
+	reprobeInterval = 10 * time.Second
+)
+
+var k8sCore = core.Instance()
+
+// Probe periodically writes and fsyncs a timestamp to a volume from inside a pod using it.
+// A write that blocks delays all following timestamps, so the largest gap between two
+// consecutive timestamps is the longest time IO to the volume was stalled. If the pod or container
+// the probe runs in is restarted, the probe is restarted in the pod which uses the volume next and
+// appends to the same file, so the restart shows up as a gap too.
+type Probe struct {
+	// Volume is the name of the volume being probed
+	Volume string
+	// PVC is the name of the PVC of the volume
+	PVC string
+	// Pod is the name of the pod the probe runs in
+	Pod string
+	// Namespace is the namespace of the pod the probe runs in
+	Namespace string
+	// PodUID is the UID of the pod the probe runs in, which changes when the pod is recreated
+	PodUID types.UID
+	// Container is the container the probe runs in
+	Container string
+	// ContainerID is the ID of the container the probe runs in, which changes when the container restarts
+	ContainerID string
+	// MountPath is the path the volume is mounted at in the container
+	MountPath string
+	// Interval is the time between two probe writes
+	Interval time.Duration
+
+	sync.Mutex
+	restarts int
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Result is the outcome of a probe run
+type Result struct {
+	// Volume is the name of the probed volume
+	Volume string
+	// Writes is the number of timestamps that were written and fsynced
+	Writes int
+	// MaxStall is the longest time between two consecutive writes, less the probe interval
+	MaxStall time.Duration
+	// Restarts is the number of times the probe was restarted in a new pod or container
+	Restarts int
+	// Lost is true if the pod or container the probe ran in was restarted and no pod was running with the
+	// volume again by the time the probe was stopped, so no stall could be measured
+	Lost bool
+}
+
+// NewProbeForPVC returns a probe for the first running pod which has the given PVC mounted read-write.
+// It returns nil if no such pod exists, e.g. for raw block volumes.
+func NewProbeForPVC(volumeName, pvcName, namespace string) (*Probe, error) {
+	pod, container, mountPath, err := findPVCMount(pvcName, namespace)
+	if err != nil || pod == nil {
+		return nil, err
+	}
+	return &Probe{
+		Volume:      volumeName,
+		PVC:         pvcName,
+		Pod:         pod.Name,
+		Namespace:   pod.Namespace,
+		PodUID:      pod.UID,
+		Container:   container,
+		ContainerID: getContainerID(pod, container),
+		MountPath:   mountPath,
+		Interval:    DefaultInterval,
+	}, nil
+}
+
+// getContainerID returns the ID of the running container of the pod, if it has one
+func getContainerID(pod *corev1.Pod, container string) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.ContainerID
+		}
+	}
+	return ""
+}
+
+// isLost returns true if the pod the probe ran in, as it is now, is not the same pod and container the probe was
+// started in. The pod is nil if it no longer exists.
+func (p *Probe) isLost(pod *corev1.Pod) bool {
+	return pod == nil || pod.UID != p.PodUID || getContainerID(pod, p.Container) != p.ContainerID
+}
+
+// findPVCMount returns the first running pod which has the given PVC mounted read-write, along with the
+// container and path it is mounted at. It returns a nil pod if no such pod exists.
+func findPVCMount(pvcName, namespace string) (*corev1.Pod, string, string, error) {
+	return findPVCMountWhere(pvcName, namespace, nil)
+}
+
+// findPVCMountWhere is findPVCMount only considering the pods accepted by the given filter, if any
+func findPVCMountWhere(pvcName, namespace string, accept func(pod *corev1.Pod) (bool, error)) (*corev1.Pod, string, string, error) {
+	pods, err := k8sCore.GetPodsUsingPVC(pvcName, namespace)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to get pods using PVC [%s/%s]. Err: %v", namespace, pvcName, err)
+	}
+	for i, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		container, mountPath := GetPVCMount(pod, pvcName)
+		if len(mountPath) == 0 {
+			continue
+		}
+		if accept != nil {
+			if ok, err := accept(&pods[i]); err != nil || !ok {
+				if err != nil {
+					return nil, "", "", err
+				}
+				continue
+			}
+		}
+		return &pods[i], container, mountPath, nil
+	}
+	return nil, "", "", nil
+}
+
+// GetPVCMount returns the container and path at which the given PVC is mounted read-write in the pod
+func GetPVCMount(pod corev1.Pod, pvcName string) (string, string) {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim == nil || vol.PersistentVolumeClaim.ClaimName != pvcName {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			for _, mount := range container.VolumeMounts {
+				if mount.Name == vol.Name && !mount.ReadOnly && len(mount.SubPath) == 0 {
+					return container.Name, mount.MountPath
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+func (p *Probe) probeFile() string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(p.MountPath, "/"), probeFileName)
+}
+
+func (p *Probe) pidFile() string {
+	return fmt.Sprintf(probePidFileName, p.Volume)
+}
+
+// Start starts the probe loop in the background inside the pod and restarts it whenever the pod or container
+// it runs in is restarted
+func (p *Probe) Start() error {
+	p.Lock()
+	defer p.Unlock()
+	if err := p.startLoop(false); err != nil {
+		return err
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.watch()
+	return nil
+}
+
+// startLoop starts the probe loop inside the pod, appending to the probe file if resume is set. The probe
+// must be locked.
+func (p *Probe) startLoop(resume bool) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	loop := fmt.Sprintf("while true; do date +%%s%%N | dd of=%s oflag=append conv=notrunc,fsync status=none; sleep %s; done",
+		p.probeFile(), strconv.FormatFloat(interval.Seconds(), 'f', -1, 64))
+	cmd := fmt.Sprintf("nohup sh -c '%s' >/dev/null 2>&1 & echo $! > %s", loop, p.pidFile())
+	if !resume {
+		cmd = fmt.Sprintf("rm -f %s; %s", p.probeFile(), cmd)
+	}
+	if _, err := k8sCore.RunCommandInPod([]string{"sh", "-c", cmd}, p.Pod, p.Container, p.Namespace); err != nil {
+		return fmt.Errorf("failed to start IO probe for volume [%s] in pod [%s/%s]. Err: %v", p.Volume, p.Namespace, p.Pod, err)
+	}
+	log.Infof("Started IO probe for volume [%s] at [%s] in pod [%s/%s]", p.Volume, p.MountPath, p.Namespace, p.Pod)
+	return nil
+}
+
+// watch restarts the probe in the pod using the volume once the pod or container the probe runs in was
+// restarted, until the probe is stopped
+func (p *Probe) watch() {
+	defer close(p.done)
+	ticker := time.NewTicker(reprobeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+		p.Lock()
+		if err := p.reprobe(); err != nil {
+			log.Warnf("Failed to restart IO probe for volume [%s]. Err: %v", p.Volume, err)
+		}
+		p.Unlock()
+	}
+}
+
+// reprobe restarts the probe in the running pod using the volume if the pod or container the probe ran in
+// was restarted. The probe must be locked.
+func (p *Probe) reprobe() error {
+	pod, err := p.getPod()
+	if err != nil || !p.isLost(pod) {
+		return err
+	}
+	newPod, container, mountPath, err := findPVCMount(p.PVC, p.Namespace)
+	if err != nil || newPod == nil {
+		return err
+	}
+	log.Infof("IO probe for volume [%s] was lost as pod [%s/%s] was restarted, restarting it in pod [%s/%s]",
+		p.Volume, p.Namespace, p.Pod, newPod.Namespace, newPod.Name)
+	p.Pod = newPod.Name
+	p.PodUID = newPod.UID
+	p.Container = container
+	p.ContainerID = getContainerID(newPod, container)
+	p.MountPath = mountPath
+	p.restarts++
+	return p.startLoop(true)
+}
+
+// getPod returns the pod the probe runs in, nil if it no longer exists
+func (p *Probe) getPod() (*corev1.Pod, error) {
+	pod, err := k8sCore.GetPodByName(p.Pod, p.Namespace)
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get pod [%s/%s] of IO probe for volume [%s]. Err: %v", p.Namespace, p.Pod, p.Volume, err)
+	}
+	return pod, nil
+}
+
+// Stop stops the probe loop, collects the written timestamps and removes the probe file. If the pod or
+// container the probe ran in was restarted, e.g. by a node reboot, and no pod uses the volume again, the
+// probe is reported as lost.
+func (p *Probe) Stop() (*Result, error) {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+		p.stop = nil
+	}
+	p.Lock()
+	defer p.Unlock()
+	if err := p.reprobe(); err != nil {
+		log.Warnf("Failed to restart IO probe for volume [%s]. Err: %v", p.Volume, err)
+	}
+	pod, err := p.getPod()
+	if err != nil {
+		return nil, err
+	}
+	if p.isLost(pod) {
+		log.Warnf("IO probe for volume [%s] was lost as pod [%s/%s] was restarted and no pod uses the volume again",
+			p.Volume, p.Namespace, p.Pod)
+		if pod != nil {
+			// the probe file is on the volume, so it outlives the probe
+			if _, err := k8sCore.RunCommandInPod([]string{"rm", "-f", p.probeFile()}, pod.Name, p.Container, pod.Namespace); err != nil {
+				log.Warnf("failed to remove IO probe file for volume [%s]. Err: %v", p.Volume, err)
+			}
+		}
+		return &Result{Volume: p.Volume, Restarts: p.restarts, Lost: true}, nil
+	}
+
+	stopCmd := fmt.Sprintf("kill $(cat %s); rm -f %s", p.pidFile(), p.pidFile())
+	if _, err := k8sCore.RunCommandInPod([]string{"sh", "-c", stopCmd}, p.Pod, p.Container, p.Namespace); err != nil {
+		log.Warnf("failed to stop IO probe for volume [%s] in pod [%s/%s]. Err: %v", p.Volume, p.Namespace, p.Pod, err)
+	}
+	output, err := k8sCore.RunCommandInPod([]string{"cat", p.probeFile()}, p.Pod, p.Container, p.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IO probe file for volume [%s] in pod [%s/%s]. Err: %v", p.Volume, p.Namespace, p.Pod, err)
+	}
+	if _, err := k8sCore.RunCommandInPod([]string{"rm", "-f", p.probeFile()}, p.Pod, p.Container, p.Namespace); err != nil {
+		log.Warnf("failed to remove IO probe file for volume [%s]. Err: %v", p.Volume, err)
+	}
+
+	result := &Result{Volume: p.Volume, Restarts: p.restarts}
+	result.Writes, result.MaxStall = ParseProbeOutput(output, p.Interval, time.Now())
+	log.Infof("IO probe for volume [%s]: %d writes, %d restarts, max stall %v", p.Volume, result.Writes, result.Restarts, result.MaxStall)
+	return result, nil
+}
+
+// ParseProbeOutput parses the timestamps written by a probe and returns the number of writes and the
+// longest stall between two consecutive writes, or between the last write and the given end time
+func ParseProbeOutput(output string, interval time.Duration, end time.Time) (int, time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	var stamps []time.Time
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		// busybox date does not support %N and prints it literally, fall back to seconds
+		line = strings.TrimSuffix(line, "%N")
+		if len(line) == 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(line) <= 10 {
+			stamps = append(stamps, time.Unix(value, 0))
+		} else {
+			stamps = append(stamps, time.Unix(0, value))
+		}
+	}
+	sort.Slice(stamps, func(i, j int) bool { return stamps[i].Before(stamps[j]) })
+
+	var maxStall time.Duration
+	for i := 1; i < len(stamps); i++ {
+		if stall := stamps[i].Sub(stamps[i-1]) - interval; stall > maxStall {
+			maxStall = stall
+		}
+	}
+	if len(stamps) > 0 && !end.IsZero() {
+		if stall := end.Sub(stamps[len(stamps)-1]) - interval; stall > maxStall {
+			maxStall = stall
+		}
+	}
+	return len(stamps), maxStall
+}
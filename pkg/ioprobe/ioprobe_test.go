@@ -0,0 +1,81 @@
+package ioprobe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	appsapi "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseProbeOutput(t *testing.T) {
+	start := time.Unix(1658999000, 0)
+	output := "1658999000000000000\n1658999001000000000\n1658999013500000000\ngarbage\n1658999014500000000\n"
+
+	writes, maxStall := ParseProbeOutput(output, time.Second, time.Time{})
+	require.Equal(t, 4, writes)
+	require.Equal(t, 11500*time.Millisecond, maxStall)
+
+	_, maxStall = ParseProbeOutput(output, time.Second, start.Add(40*time.Second))
+	require.Equal(t, 24500*time.Millisecond, maxStall)
+
+	writes, maxStall = ParseProbeOutput("1658999000\n1658999002\n", time.Second, time.Time{})
+	require.Equal(t, 2, writes)
+	require.Equal(t, time.Second, maxStall)
+
+	// busybox date prints %N literally
+	writes, maxStall = ParseProbeOutput("1658999000%N\n1658999001%N\n1658999005%N\n", time.Second, time.Time{})
+	require.Equal(t, 3, writes)
+	require.Equal(t, 3*time.Second, maxStall)
+
+	writes, maxStall = ParseProbeOutput("", time.Second, start)
+	require.Equal(t, 0, writes)
+	require.Equal(t, time.Duration(0), maxStall)
+}
+
+func TestIsLost(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql-0", UID: "uid-1"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "mysql", ContainerID: "containerd://1"},
+		}},
+	}
+	probe := &Probe{Pod: "mysql-0", PodUID: "uid-1", Container: "mysql", ContainerID: "containerd://1"}
+	require.False(t, probe.isLost(pod))
+	require.True(t, probe.isLost(nil))
+
+	restarted := pod.DeepCopy()
+	restarted.Status.ContainerStatuses[0].ContainerID = "containerd://2"
+	require.True(t, probe.isLost(restarted))
+
+	recreated := pod.DeepCopy()
+	recreated.UID = "uid-2"
+	require.True(t, probe.isLost(recreated))
+}
+
+func TestParseChecksum(t *testing.T) {
+	checksum, err := parseChecksum("9e107d9d372bb6826bd81d3542a419d6  /data/.torpedo-marker\n")
+	require.NoError(t, err)
+	require.Equal(t, "9e107d9d372bb6826bd81d3542a419d6", checksum)
+
+	_, err = parseChecksum("md5sum: can't open '/data/.torpedo-marker': No such file or directory")
+	require.Error(t, err)
+	_, err = parseChecksum("")
+	require.Error(t, err)
+}
+
+func TestRunsUpdateRevision(t *testing.T) {
+	ss := &appsapi.StatefulSet{Status: appsapi.StatefulSetStatus{CurrentRevision: "mysql-1", UpdateRevision: "mysql-2"}}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{controllerRevisionHashLabel: "mysql-1"}}}
+	require.False(t, runsUpdateRevision(pod, ss))
+
+	pod.Labels[controllerRevisionHashLabel] = "mysql-2"
+	require.True(t, runsUpdateRevision(pod, ss))
+
+	// a StatefulSet which is not updating only reports its current revision
+	ss.Status.UpdateRevision = ""
+	require.False(t, runsUpdateRevision(pod, ss))
+	require.False(t, runsUpdateRevision(&corev1.Pod{}, &appsapi.StatefulSet{}))
+}
@@ -0,0 +1,158 @@
+package ioprobe
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pborman/uuid"
+	"github.com/portworx/sched-ops/k8s/apps"
+	"github.com/portworx/torpedo/pkg/log"
+	appsapi "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// markerFilePrefix is the prefix of the files holding the random content of markers inside the volume mount.
+	// Each marker gets its own file, so markers written to the same volume by different triggers do not collide.
+	markerFilePrefix = ".torpedo-marker-"
+	// markerSizeKB is the size of the random content of a marker
+	markerSizeKB = 1024
+	// controllerRevisionHashLabel is the label holding the revision of the StatefulSet a pod runs
+	controllerRevisionHashLabel = "controller-revision-hash"
+)
+
+var k8sApps = apps.Instance()
+
+// Marker is a file with random content written to a volume from inside a pod using it. Verifying the marker
+// later, possibly from another pod, checks that data written to the volume is still readable and unchanged.
+type Marker struct {
+	// Volume is the name of the volume the marker was written to
+	Volume string
+	// PVC is the name of the PVC of the volume
+	PVC string
+	// Namespace is the namespace of the PVC
+	Namespace string
+	// NodeName is the node the pod which wrote the marker ran on
+	NodeName string
+	// File is the name of the marker file inside the volume mount
+	File string
+	// Checksum is the md5 checksum of the marker content
+	Checksum string
+}
+
+// WriteMarkerForPVC writes a marker to the volume of the PVC from the first running pod which has it mounted
+// read-write. It returns nil if no such pod exists, e.g. for raw block volumes.
+func WriteMarkerForPVC(volumeName, pvcName, namespace string) (*Marker, error) {
+	pod, container, mountPath, err := findPVCMount(pvcName, namespace)
+	if err != nil || pod == nil {
+		return nil, err
+	}
+	name := markerFilePrefix + uuid.New()
+	file := markerFile(mountPath, name)
+	cmd := fmt.Sprintf("dd if=/dev/urandom of=%s bs=1k count=%d conv=fsync status=none && md5sum %s", file, markerSizeKB, file)
+	output, err := k8sCore.RunCommandInPod([]string{"sh", "-c", cmd}, pod.Name, container, pod.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write marker to volume [%s] in pod [%s/%s]. Err: %v", volumeName, pod.Namespace, pod.Name, err)
+	}
+	checksum, err := parseChecksum(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write marker to volume [%s]. Err: %v", volumeName, err)
+	}
+	log.Infof("Wrote marker with checksum %s to volume [%s] in pod [%s/%s]", checksum, volumeName, pod.Namespace, pod.Name)
+	return &Marker{
+		Volume:    volumeName,
+		PVC:       pvcName,
+		Namespace: namespace,
+		NodeName:  pod.Spec.NodeName,
+		File:      name,
+		Checksum:  checksum,
+	}, nil
+}
+
+// Verify reads the marker back from a running pod using the PVC and checks it is unchanged
+func (m *Marker) Verify() error {
+	pod, container, mountPath, err := findPVCMount(m.PVC, m.Namespace)
+	if err != nil {
+		return err
+	}
+	if pod == nil {
+		return fmt.Errorf("no running pod has PVC [%s/%s] of volume [%s] mounted", m.Namespace, m.PVC, m.Volume)
+	}
+	return m.verifyIn(pod, container, mountPath)
+}
+
+// VerifyUpdated reads the marker back from a running pod using the PVC which runs the update revision of its
+// StatefulSet, e.g. after a partitioned rollout, and checks it is unchanged. Pods not run by a StatefulSet are
+// considered updated. It returns false if no updated pod uses the PVC.
+func (m *Marker) VerifyUpdated() (bool, error) {
+	pod, container, mountPath, err := findPVCMountWhere(m.PVC, m.Namespace, isUpdatedPod)
+	if err != nil || pod == nil {
+		return false, err
+	}
+	return true, m.verifyIn(pod, container, mountPath)
+}
+
+// isUpdatedPod returns true if the pod runs the update revision of its StatefulSet, or is not run by one
+func isUpdatedPod(pod *corev1.Pod) (bool, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" {
+		return true, nil
+	}
+	ss, err := k8sApps.GetStatefulSet(owner.Name, pod.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("failed to get statefulset [%s/%s] of pod [%s]. Err: %v", pod.Namespace, owner.Name, pod.Name, err)
+	}
+	return runsUpdateRevision(pod, ss), nil
+}
+
+// runsUpdateRevision returns true if the pod runs the revision the StatefulSet is updating its pods to
+func runsUpdateRevision(pod *corev1.Pod, ss *appsapi.StatefulSet) bool {
+	revision := ss.Status.UpdateRevision
+	if len(revision) == 0 {
+		revision = ss.Status.CurrentRevision
+	}
+	return len(revision) > 0 && pod.Labels[controllerRevisionHashLabel] == revision
+}
+
+// verifyIn reads the marker back from the given pod and checks it is unchanged
+func (m *Marker) verifyIn(pod *corev1.Pod, container, mountPath string) error {
+	output, err := k8sCore.RunCommandInPod([]string{"md5sum", markerFile(mountPath, m.File)}, pod.Name, container, pod.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to read marker of volume [%s] in pod [%s/%s]. Err: %v", m.Volume, pod.Namespace, pod.Name, err)
+	}
+	checksum, err := parseChecksum(output)
+	if err != nil {
+		return fmt.Errorf("failed to read marker of volume [%s]. Err: %v", m.Volume, err)
+	}
+	if checksum != m.Checksum {
+		return fmt.Errorf("marker of volume [%s] changed, checksum %s, expected %s", m.Volume, checksum, m.Checksum)
+	}
+	log.Infof("Verified marker of volume [%s] in pod [%s/%s]", m.Volume, pod.Namespace, pod.Name)
+	return nil
+}
+
+// Remove deletes the marker file from the volume
+func (m *Marker) Remove() error {
+	pod, container, mountPath, err := findPVCMount(m.PVC, m.Namespace)
+	if err != nil || pod == nil {
+		return err
+	}
+	if _, err := k8sCore.RunCommandInPod([]string{"rm", "-f", markerFile(mountPath, m.File)}, pod.Name, container, pod.Namespace); err != nil {
+		return fmt.Errorf("failed to remove marker of volume [%s]. Err: %v", m.Volume, err)
+	}
+	return nil
+}
+
+func markerFile(mountPath, name string) string {
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(mountPath, "/"), name)
+}
+
+// parseChecksum returns the checksum of the md5sum output '<checksum>  <file>'
+func parseChecksum(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 || len(fields[0]) != 32 {
+		return "", fmt.Errorf("unexpected md5sum output: %s", output)
+	}
+	return fields[0], nil
+}
@@ -2,13 +2,12 @@ package ipv6util
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
-	"net"
 	"regexp"
 	"strings"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/portworx/torpedo/pkg/pxctl"
 )
 
 const (
@@ -57,9 +56,8 @@ type parserOption struct {
 }
 
 var (
-	kvdbEndPtsRgx = regexp.MustCompile("https?://(.*)")
-	nodeDown      = "Node (.*) has an " + OperationalStatusDown
-	nodeDownRgx   = regexp.MustCompile(nodeDown)
+	nodeDown    = "Node (.*) has an " + OperationalStatusDown
+	nodeDownRgx = regexp.MustCompile(nodeDown)
 )
 
 // newIPv6Parser returns a parser instance
@@ -233,39 +231,13 @@ func AreAddressesIPv6(addrs []string) bool {
 
 // Process 'service kvdb enpoints output' consisting of lines 'http://<ip>:<port>'
 func parseIPAddressInPxctlServiceKvdbEndpoints(kvdbEndpointsOutput string) ([]string, error) {
-	// Parse out all <ip>:<port> strings from URLs "http(s)://<ip>:<port>" in the line
-	kvdbEndPts := kvdbEndPtsRgx.FindAllSubmatch([]byte(kvdbEndpointsOutput), -1)
-
-	kvdbEndPtsIPs := []string{}
-	for _, endPt := range kvdbEndPts {
-		ip, _, err := net.SplitHostPort(string(bytes.TrimSpace(endPt[1])))
-		if err != nil {
-			return kvdbEndPtsIPs, fmt.Errorf("Endpoint parse error %v", err)
-		}
-		kvdbEndPtsIPs = append(kvdbEndPtsIPs, ip)
-	}
-	return kvdbEndPtsIPs, nil
+	return pxctl.ParseKvdbEndpoints(kvdbEndpointsOutput)
 }
 
 // Process 'service kvdb members' output consisting of lines 'ID   PEER URLS   CLIENT URLS...'
 // which contain 'http://<ip>:<port>' URLS
 func parseIPAddressInPxctlServiceKvdbMembers(kvdbMembersOutput string) ([]string, error) {
-	kvdbMemberIPs := []string{}
-	for _, line := range strings.Split(kvdbMembersOutput, "\n") {
-		if strings.Contains(line, "http") {
-			cols := strings.Fields(strings.TrimSpace(line))
-			if len(cols) >= 2 {
-				// Parse out <ip>:<port> from URL "http(s)://<ip>:<port>" in the line
-				endPt := kvdbEndPtsRgx.FindSubmatch([]byte(strings.Trim(cols[2], "[]")))
-				ip, _, err := net.SplitHostPort(string(bytes.TrimSpace(endPt[1])))
-				if err != nil {
-					return kvdbMemberIPs, fmt.Errorf("Member parse error %v", err)
-				}
-				kvdbMemberIPs = append(kvdbMemberIPs, ip)
-			}
-		}
-	}
-	return kvdbMemberIPs, nil
+	return pxctl.ParseKvdbMembers(kvdbMembersOutput)
 }
 
 // ParseIPAddressInPxctlResourceDownAlert extract IP address from specific resource down alert description
@@ -279,3 +251,26 @@ func ParseIPAddressInPxctlResourceDownAlert(alertsOutput, resource string) (stri
 	}
 	return "", fmt.Errorf("failed to find resource down alerts")
 }
+
+// IPAddressInResourceDownAlert extracts the IP address from the node down alert of the given resource
+func IPAddressInResourceDownAlert(alerts []*pxctl.Alert, resource string) (string, error) {
+	for _, alert := range alerts {
+		if alert.ResourceID != resource {
+			continue
+		}
+		// Parse out <IP> from 'Node <IP> has an Operational Status: Down' description
+		if match := nodeDownRgx.FindStringSubmatch(alert.Message); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", fmt.Errorf("failed to find resource down alerts")
+}
+
+// IPAddressesOfNodes returns the data IP of each node in the cluster
+func IPAddressesOfNodes(cluster *pxctl.Cluster) []string {
+	ips := []string{}
+	for _, n := range cluster.Nodes {
+		ips = append(ips, n.DataIP)
+	}
+	return ips
+}
@@ -3,6 +3,7 @@ package ipv6util
 import (
 	"testing"
 
+	"github.com/portworx/torpedo/pkg/pxctl"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +62,19 @@ func TestValidIpv6Address(t *testing.T) {
 	assert.True(t, isIpv6, "running command %v. addresses are expected to be ipv6, got: %v", PxctlAlertsShow, ip)
 
 }
+
+func TestIpv6AddressInTypedOutput(t *testing.T) {
+	cluster := &pxctl.Cluster{Nodes: []*pxctl.Node{
+		{ID: "2ca8932b", DataIP: "0000:111:2222:3333:444:5555:6666:111"},
+		{ID: "6b9d12e0", DataIP: "0000:111:2222:3333:444:5555:6666:222"},
+	}}
+	addrs := IPAddressesOfNodes(cluster)
+	assert.Equal(t, []string{"0000:111:2222:3333:444:5555:6666:111", "0000:111:2222:3333:444:5555:6666:222"}, addrs)
+
+	alerts := []*pxctl.Alert{{ResourceID: sampleDownResource, Message: "Node 2620:125:9006:1330:250:56ff:fead:aaea has an Operational Status: Down"}}
+	ip, err := IPAddressInResourceDownAlert(alerts, sampleDownResource)
+	assert.NoError(t, err)
+	assert.True(t, IsAddressIPv6(ip), "alert address is expected to be ipv6, got: %v", ip)
+	_, err = IPAddressInResourceDownAlert(alerts, "other")
+	assert.Error(t, err)
+}
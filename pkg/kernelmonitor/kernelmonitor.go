@@ -0,0 +1,224 @@
+package kernelmonitor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+// Category is the kind of kernel problem a kernel message is about
+type Category string
+
+const (
+	// CategoryHungTask is a task blocked in the kernel for longer than the hung task timeout
+	CategoryHungTask Category = "hung-task"
+	// CategoryIOError is an IO error of a block device or file system
+	CategoryIOError Category = "io-error"
+	// CategoryNFSNotResponding is an NFS server, e.g. of a sharedv4 volume, not responding to the client
+	CategoryNFSNotResponding Category = "nfs-not-responding"
+	// CategoryOOMKill is a process killed by the OOM killer
+	CategoryOOMKill Category = "oom-kill"
+)
+
+// DefaultInterval is how often the kernel messages of the nodes are checked when no interval is given
+const DefaultInterval = 30 * time.Second
+
+// cursorPrefix is the prefix of the cursor line journalctl --show-cursor ends its output with
+const cursorPrefix = "-- cursor: "
+
+var classifiers = []struct {
+	category Category
+	regex    *regexp.Regexp
+}{
+	{CategoryHungTask, regexp.MustCompile(`blocked for more than \d+ seconds`)},
+	{CategoryIOError, regexp.MustCompile(`(?i)\bI/O error\b|blk_update_request: .*error|critical medium error`)},
+	{CategoryNFSNotResponding, regexp.MustCompile(`nfs: server .* not responding`)},
+	{CategoryOOMKill, regexp.MustCompile(`invoked oom-killer|Out of memory: Kill|Memory cgroup out of memory`)},
+}
+
+var cmdOpts = node.ConnectionOpts{
+	Timeout:         1 * time.Minute,
+	TimeBeforeRetry: 10 * time.Second,
+}
+
+// Finding is a kernel problem found on a node
+type Finding struct {
+	// Node is the name of the node the problem was found on
+	Node string
+	// Category is the kind of the problem
+	Category Category
+	// Message is the first kernel message about the problem
+	Message string
+	// Count is the number of kernel messages about the problem since the last check
+	Count int
+	// Time is when the problem was found
+	Time time.Time
+	// Trigger is the trigger which was running when the problem was found, if any
+	Trigger string
+}
+
+func (f Finding) Error() string {
+	return fmt.Sprintf("kernel %s on node %s (%d messages): %s", f.Category, f.Node, f.Count, f.Message)
+}
+
+// Classify returns the category of the kernel message, if it is about a known problem
+func Classify(message string) (Category, bool) {
+	for _, c := range classifiers {
+		if c.regex.MatchString(message) {
+			return c.category, true
+		}
+	}
+	return "", false
+}
+
+// classifyMessages returns a finding per category of problem the messages are about
+func classifyMessages(nodeName string, messages []string) []Finding {
+	var findings []Finding
+	index := make(map[Category]int)
+	for _, message := range messages {
+		category, ok := Classify(message)
+		if !ok {
+			continue
+		}
+		if i, ok := index[category]; ok {
+			findings[i].Count++
+			continue
+		}
+		index[category] = len(findings)
+		findings = append(findings, Finding{
+			Node:     nodeName,
+			Category: category,
+			Message:  strings.TrimSpace(message),
+			Count:    1,
+			Time:     time.Now(),
+		})
+	}
+	return findings
+}
+
+// parseJournal returns the messages and the cursor of the output of journalctl --show-cursor
+func parseJournal(out string) ([]string, string) {
+	var messages []string
+	var cursor string
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, cursorPrefix):
+			cursor = strings.TrimSpace(strings.TrimPrefix(line, cursorPrefix))
+		case strings.HasPrefix(line, "-- No entries --"), len(strings.TrimSpace(line)) == 0:
+		default:
+			messages = append(messages, line)
+		}
+	}
+	return messages, cursor
+}
+
+// newDmesgMessages returns the messages of the output of dmesg logged after the last message seen. The ring buffer
+// drops its oldest messages once full, so messages are tailed by the last one seen rather than by their number. If
+// the last message seen is gone, the ring buffer was cleared or wrapped past it and all of its messages are new.
+func newDmesgMessages(out, lastSeen string) ([]string, string) {
+	all := strings.Split(strings.TrimSpace(out), "\n")
+	if len(all) == 1 && len(all[0]) == 0 {
+		return nil, lastSeen
+	}
+	last := all[len(all)-1]
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i] == lastSeen {
+			return all[i+1:], last
+		}
+	}
+	return all, last
+}
+
+// Monitor tails the kernel messages of nodes through the node driver and reports the problems they are about
+type Monitor struct {
+	driver    node.Driver
+	interval  time.Duration
+	onFinding func(Finding)
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewMonitor returns a monitor which checks the kernel messages of the nodes every interval and calls onFinding
+// with each problem found. onFinding is called from the goroutine of each node, so it must be safe for concurrent
+// use.
+func NewMonitor(driver node.Driver, interval time.Duration, onFinding func(Finding)) *Monitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Monitor{
+		driver:    driver,
+		interval:  interval,
+		onFinding: onFinding,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start starts monitoring the kernel messages the nodes log from now on
+func (m *Monitor) Start(nodes []node.Node) {
+	for _, n := range nodes {
+		m.wg.Add(1)
+		go m.watch(n)
+	}
+}
+
+// Stop stops monitoring and waits for the checks in progress to finish
+func (m *Monitor) Stop() {
+	close(m.stopCh)
+	m.wg.Wait()
+}
+
+// watch checks the kernel messages of the node every interval until the monitor is stopped. journalctl is tailed by
+// cursor, and dmesg by the last message seen when journalctl is not available.
+func (m *Monitor) watch(n node.Node) {
+	defer m.wg.Done()
+	since := time.Now()
+	var cursor string
+	// dmesgLast is the last dmesg message seen, nil until dmesg was first checked
+	var dmesgLast *string
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		var messages []string
+		cmd := fmt.Sprintf("journalctl -k --no-pager -o short-iso --show-cursor --since @%d", since.Unix())
+		if len(cursor) > 0 {
+			cmd = fmt.Sprintf("journalctl -k --no-pager -o short-iso --show-cursor --after-cursor '%s'", cursor)
+		}
+		out, err := m.driver.RunCommandWithNoRetry(n, cmd, cmdOpts)
+		if err == nil {
+			var next string
+			if messages, next = parseJournal(out); len(next) > 0 {
+				cursor = next
+			}
+		} else {
+			log.Debugf("Failed to get kernel messages of node %s from journalctl, falling back to dmesg. Err: %v", n.Name, err)
+			// timestamps since boot keep each message unchanged between checks, unlike dmesg -T
+			if out, err = m.driver.RunCommandWithNoRetry(n, "dmesg", cmdOpts); err != nil {
+				log.Warnf("Failed to get kernel messages of node %s. Err: %v", n.Name, err)
+				continue
+			}
+			if dmesgLast == nil {
+				// messages logged before the monitor started are not reported
+				_, last := newDmesgMessages(out, "")
+				dmesgLast = &last
+				continue
+			}
+			messages, *dmesgLast = newDmesgMessages(out, *dmesgLast)
+		}
+
+		for _, finding := range classifyMessages(n.Name, messages) {
+			m.onFinding(finding)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package kernelmonitor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/portworx/torpedo/drivers/node"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassify(t *testing.T) {
+	for message, expected := range map[string]Category{
+		"INFO: task jbd2/pxd!pxd1:1234 blocked for more than 120 seconds.":            CategoryHungTask,
+		"blk_update_request: I/O error, dev pxd1, sector 2048 op 0x1:(WRITE)":         CategoryIOError,
+		"Buffer I/O error on dev pxd!pxd1, logical block 0, lost async page write":    CategoryIOError,
+		"nfs: server 10.13.1.2 not responding, still trying":                          CategoryNFSNotResponding,
+		"mysqld invoked oom-killer: gfp_mask=0x100cca(GFP_HIGHUSER_MOVABLE), order=0": CategoryOOMKill,
+		"Memory cgroup out of memory: Killed process 4242 (mysqld) total-vm:1024kB":   CategoryOOMKill,
+	} {
+		category, ok := Classify(message)
+		require.True(t, ok, message)
+		require.Equal(t, expected, category, message)
+	}
+	_, ok := Classify("EXT4-fs (pxd1): mounted filesystem with ordered data mode")
+	require.False(t, ok)
+}
+
+func TestParseJournal(t *testing.T) {
+	messages, cursor := parseJournal("2026-03-03T10:00:01+0000 node-1 kernel: nfs: server 10.13.1.2 not responding, still trying\n" +
+		"2026-03-03T10:00:02+0000 node-1 kernel: nfs: server 10.13.1.2 OK\n" +
+		"-- cursor: s=abc;i=1f2;b=def;m=12;t=34;x=56\n")
+	require.Len(t, messages, 2)
+	require.Equal(t, "s=abc;i=1f2;b=def;m=12;t=34;x=56", cursor)
+
+	messages, cursor = parseJournal("-- No entries --\n")
+	require.Empty(t, messages)
+	require.Empty(t, cursor)
+}
+
+// fakeDriver returns the next of its outputs for journalctl and fails dmesg
+type fakeDriver struct {
+	node.Driver
+	sync.Mutex
+	outputs []string
+	cmds    []string
+}
+
+func (d *fakeDriver) RunCommandWithNoRetry(n node.Node, command string, options node.ConnectionOpts) (string, error) {
+	d.Lock()
+	defer d.Unlock()
+	d.cmds = append(d.cmds, command)
+	if !strings.HasPrefix(command, "journalctl") || len(d.outputs) == 0 {
+		return "", fmt.Errorf("no output")
+	}
+	out := d.outputs[0]
+	d.outputs = d.outputs[1:]
+	return out, nil
+}
+
+func TestMonitor(t *testing.T) {
+	driver := &fakeDriver{
+		Driver: node.NotSupportedDriver,
+		outputs: []string{
+			"2026-03-03T10:00:01+0000 node-1 kernel: INFO: task mysqld:77 blocked for more than 120 seconds.\n" +
+				"2026-03-03T10:00:01+0000 node-1 kernel: INFO: task jbd2:78 blocked for more than 120 seconds.\n" +
+				"-- cursor: s=1\n",
+			"2026-03-03T10:00:02+0000 node-1 kernel: mysqld invoked oom-killer: gfp_mask=0x100cca\n-- cursor: s=2\n",
+		},
+	}
+	var lock sync.Mutex
+	var findings []Finding
+	monitor := NewMonitor(driver, 10*time.Millisecond, func(f Finding) {
+		lock.Lock()
+		defer lock.Unlock()
+		findings = append(findings, f)
+	})
+	monitor.Start([]node.Node{{Name: "node-1"}})
+	require.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(findings) == 2
+	}, time.Second, 10*time.Millisecond)
+	monitor.Stop()
+
+	require.Equal(t, CategoryHungTask, findings[0].Category)
+	require.Equal(t, 2, findings[0].Count)
+	require.Equal(t, "node-1", findings[0].Node)
+	require.Equal(t, CategoryOOMKill, findings[1].Category)
+	driver.Lock()
+	defer driver.Unlock()
+	require.Contains(t, driver.cmds[1], "--after-cursor 's=1'")
+}
+
+func TestNewDmesgMessages(t *testing.T) {
+	messages, last := newDmesgMessages("[  1.0] a\n[  2.0] b\n", "")
+	require.Equal(t, []string{"[  1.0] a", "[  2.0] b"}, messages)
+	require.Equal(t, "[  2.0] b", last)
+
+	// the ring buffer is full, so the oldest message is dropped as a new one is logged
+	messages, last = newDmesgMessages("[  2.0] b\n[  3.0] c\n", last)
+	require.Equal(t, []string{"[  3.0] c"}, messages)
+	require.Equal(t, "[  3.0] c", last)
+
+	messages, last = newDmesgMessages("[  2.0] b\n[  3.0] c\n", last)
+	require.Empty(t, messages)
+	require.Equal(t, "[  3.0] c", last)
+
+	// the ring buffer wrapped past the last message seen
+	messages, last = newDmesgMessages("[  4.0] d\n[  5.0] e\n", last)
+	require.Equal(t, []string{"[  4.0] d", "[  5.0] e"}, messages)
+	require.Equal(t, "[  5.0] e", last)
+
+	messages, last = newDmesgMessages("", last)
+	require.Empty(t, messages)
+	require.Equal(t, "[  5.0] e", last)
+}
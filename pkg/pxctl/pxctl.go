@@ -0,0 +1,182 @@
+// Package pxctl runs pxctl commands and decodes their output into typed structs. Commands are run with JSON
+// output where pxctl supports it; only the legacy text commands are parsed from their human readable output.
+package pxctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	jsonFlag                = "-j"
+	cmdStatus               = "status"
+	cmdClusterList          = "cluster list"
+	cmdClusterInspect       = "cluster inspect"
+	cmdClusterOptionsList   = "cluster options list"
+	cmdVolumeList           = "volume list"
+	cmdVolumeInspect        = "volume inspect"
+	cmdNodeStats            = "sv dump --nodestats"
+	cmdPoolShow             = "sv pool show"
+	cmdAlertsShow           = "alerts show"
+	cmdServiceKvdbEndpoints = "service kvdb endpoints"
+	cmdServiceKvdbMembers   = "service kvdb members"
+)
+
+// RunFunc runs the given pxctl arguments on a node and returns the output. It takes care of the pxctl path
+// and of the auth context, e.g. the portworx volume driver's GetPxctlCmdOutput.
+type RunFunc func(command string) (string, error)
+
+// Client runs pxctl commands on one node
+type Client struct {
+	run RunFunc
+}
+
+// NewClient returns a client which runs pxctl commands with the given function
+func NewClient(run RunFunc) *Client {
+	return &Client{run: run}
+}
+
+// Status returns the output of pxctl status
+func (c *Client) Status() (*StatusInfo, error) {
+	status := &StatusInfo{}
+	if err := c.runJSON(status, cmdStatus); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// ClusterList returns the cluster with its nodes
+func (c *Client) ClusterList() (*Cluster, error) {
+	cluster := &Cluster{}
+	if err := c.runJSON(cluster, cmdClusterList); err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// ClusterInspect returns the node with the given id
+func (c *Client) ClusterInspect(nodeID string) (*Node, error) {
+	n := &Node{}
+	if err := c.runJSON(n, cmdClusterInspect, nodeID); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// ClusterOptions returns the cluster options by name. Values are strings, numbers, lists or maps.
+func (c *Client) ClusterOptions() (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+	if err := c.runJSON(&options, cmdClusterOptionsList); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// VolumeList returns the volumes with all the given labels
+func (c *Client) VolumeList(labels map[string]string) ([]*Volume, error) {
+	args := []string{cmdVolumeList}
+	if len(labels) > 0 {
+		var selectors []string
+		for k, v := range labels {
+			selectors = append(selectors, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(selectors)
+		args = append(args, "-l", strings.Join(selectors, ","))
+	}
+	var vols []*Volume
+	if err := c.runJSON(&vols, args...); err != nil {
+		return nil, err
+	}
+	return vols, nil
+}
+
+// TrashcanVolumes returns the volumes in the trashcan
+func (c *Client) TrashcanVolumes() ([]*Volume, error) {
+	var vols []*Volume
+	if err := c.runJSON(&vols, cmdVolumeList, "--trashcan"); err != nil {
+		return nil, err
+	}
+	return vols, nil
+}
+
+// VolumeInspect returns the volume with the given name or id
+func (c *Client) VolumeInspect(volumeNameOrID string) (*Volume, error) {
+	var vols []*Volume
+	if err := c.runJSON(&vols, cmdVolumeInspect, volumeNameOrID); err != nil {
+		return nil, err
+	}
+	if len(vols) == 0 {
+		return nil, fmt.Errorf("volume %s not found", volumeNameOrID)
+	}
+	return vols[0], nil
+}
+
+// NodeStats returns the stats of the node
+func (c *Client) NodeStats() (*NodeStats, error) {
+	stats := &NodeStats{}
+	if err := c.runJSON(stats, cmdNodeStats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Pools returns the storage pools of the node
+func (c *Client) Pools() ([]*Pool, error) {
+	pools := &poolList{}
+	if err := c.runJSON(pools, cmdPoolShow); err != nil {
+		return nil, err
+	}
+	return pools.Pools, nil
+}
+
+// Alerts returns the alerts matching the given pxctl alerts show flags, e.g. "-t", "node"
+func (c *Client) Alerts(flags ...string) ([]*Alert, error) {
+	var alerts []*Alert
+	if err := c.runJSON(&alerts, append([]string{cmdAlertsShow}, flags...)...); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// KvdbEndpoints returns the hosts of the kvdb client endpoints. pxctl prints them as text only.
+func (c *Client) KvdbEndpoints() ([]string, error) {
+	out, err := c.run(cmdServiceKvdbEndpoints)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKvdbEndpoints(out)
+}
+
+// KvdbMembers returns the hosts of the kvdb members' client URLs. pxctl prints them as text only.
+func (c *Client) KvdbMembers() ([]string, error) {
+	out, err := c.run(cmdServiceKvdbMembers)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKvdbMembers(out)
+}
+
+// runJSON runs the command with JSON output and decodes it into v
+func (c *Client) runJSON(v interface{}, args ...string) error {
+	command := strings.Join(append([]string{jsonFlag}, args...), " ")
+	out, err := c.run(command)
+	if err != nil {
+		return err
+	}
+	return decode(command, out, v)
+}
+
+// decode decodes the JSON output of the command. Some PX versions print warnings before the JSON document,
+// those are skipped.
+func decode(command, out string, v interface{}) error {
+	start := strings.IndexAny(out, "{[")
+	if start < 0 {
+		return fmt.Errorf("failed to decode output of pxctl %s, no JSON found in: %s", command, out)
+	}
+	if err := json.Unmarshal([]byte(out[start:]), v); err != nil {
+		return fmt.Errorf("failed to decode output of pxctl %s. cause: %v", command, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package pxctl
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files from the current decoding: go test ./pkg/pxctl -update
+var update = flag.Bool("update", false, "update golden files")
+
+const (
+	testNodeID   = "2ca8932b-b17e-425c-bcbe-d33b0f64b623"
+	testVolumeID = "197020883293002044"
+)
+
+// goldenCases maps the pxctl output files in testdata/<px version> to the command the client runs for them. The
+// outputs are synthetic, see testdata/README.md.
+var goldenCases = []struct {
+	name    string
+	command string
+	call    func(c *Client) (interface{}, error)
+}{
+	{"status", "-j status", func(c *Client) (interface{}, error) { return c.Status() }},
+	{"cluster_list", "-j cluster list", func(c *Client) (interface{}, error) { return c.ClusterList() }},
+	{"cluster_inspect", "-j cluster inspect " + testNodeID, func(c *Client) (interface{}, error) { return c.ClusterInspect(testNodeID) }},
+	{"cluster_options", "-j cluster options list", func(c *Client) (interface{}, error) { return c.ClusterOptions() }},
+	{"volume_list", "-j volume list -l app=fio", func(c *Client) (interface{}, error) { return c.VolumeList(map[string]string{"app": "fio"}) }},
+	{"volume_inspect", "-j volume inspect " + testVolumeID, func(c *Client) (interface{}, error) { return c.VolumeInspect(testVolumeID) }},
+	{"trashcan", "-j volume list --trashcan", func(c *Client) (interface{}, error) { return c.TrashcanVolumes() }},
+	{"node_stats", "-j sv dump --nodestats", func(c *Client) (interface{}, error) { return c.NodeStats() }},
+	{"pool_show", "-j sv pool show", func(c *Client) (interface{}, error) { return c.Pools() }},
+	{"alerts", "-j alerts show -t node", func(c *Client) (interface{}, error) { return c.Alerts("-t", "node") }},
+	{"kvdb_endpoints", "service kvdb endpoints", func(c *Client) (interface{}, error) { return c.KvdbEndpoints() }},
+	{"kvdb_members", "service kvdb members", func(c *Client) (interface{}, error) { return c.KvdbMembers() }},
+}
+
+// TestGolden decodes the pxctl outputs of each PX version in testdata and compares them to the golden files
+func TestGolden(t *testing.T) {
+	versions, err := ioutil.ReadDir("testdata")
+	require.NoError(t, err)
+	for _, version := range versions {
+		if !version.IsDir() {
+			continue
+		}
+		for _, tc := range goldenCases {
+			outPath := filepath.Join("testdata", version.Name(), tc.name+".out")
+			out, err := ioutil.ReadFile(outPath)
+			if os.IsNotExist(err) {
+				continue
+			}
+			require.NoError(t, err)
+
+			t.Run(fmt.Sprintf("%s/%s", version.Name(), tc.name), func(t *testing.T) {
+				client := NewClient(func(command string) (string, error) {
+					require.Equal(t, tc.command, command)
+					return string(out), nil
+				})
+				result, err := tc.call(client)
+				require.NoError(t, err)
+				got, err := json.MarshalIndent(result, "", "  ")
+				require.NoError(t, err)
+
+				goldenPath := filepath.Join("testdata", version.Name(), tc.name+".golden")
+				if *update {
+					require.NoError(t, ioutil.WriteFile(goldenPath, append(got, '\n'), 0644))
+				}
+				want, err := ioutil.ReadFile(goldenPath)
+				require.NoError(t, err)
+				require.JSONEq(t, string(want), string(got))
+			})
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	stats := &NodeStats{}
+	require.NoError(t, decode("-j sv dump --nodestats", "Token expires in 2h\n{\"relaxed_reclaim_stats\": {\"pending\": 1}}", stats))
+	require.Equal(t, Uint64(1), stats.RelaxedReclaim.Pending)
+
+	require.Error(t, decode("-j status", "PX is not running on this host", &StatusInfo{}))
+	require.Error(t, decode("-j status", `{"status": {}}`, &StatusInfo{}))
+}
+
+func TestRunError(t *testing.T) {
+	client := NewClient(func(command string) (string, error) {
+		return "", fmt.Errorf("connection refused")
+	})
+	_, err := client.ClusterList()
+	require.EqualError(t, err, "connection refused")
+
+	client = NewClient(func(command string) (string, error) {
+		return "[]", nil
+	})
+	_, err = client.VolumeInspect("missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package pxctl
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Parsers for the commands pxctl prints as text only
+
+var kvdbEndpointRgx = regexp.MustCompile(`https?://(\S*)`)
+
+// ParseKvdbEndpoints returns the hosts of the output of pxctl service kvdb endpoints, which consists of
+// lines 'http://<host>:<port>'
+func ParseKvdbEndpoints(out string) ([]string, error) {
+	hosts := []string{}
+	for _, endpoint := range kvdbEndpointRgx.FindAllStringSubmatch(out, -1) {
+		host, _, err := net.SplitHostPort(strings.TrimSpace(endpoint[1]))
+		if err != nil {
+			return hosts, fmt.Errorf("failed to parse kvdb endpoint %s. cause: %v", endpoint[0], err)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
+
+// ParseKvdbMembers returns the hosts of the client URLs in the output of pxctl service kvdb members, which
+// consists of lines 'ID [PEER URLs] [CLIENT URLs] LEADER HEALTHY DBSIZE'
+func ParseKvdbMembers(out string) ([]string, error) {
+	hosts := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		cols := strings.Fields(line)
+		if len(cols) < 3 || !strings.Contains(line, "http") {
+			continue
+		}
+		endpoint := kvdbEndpointRgx.FindStringSubmatch(strings.Trim(cols[2], "[]"))
+		if endpoint == nil {
+			return hosts, fmt.Errorf("failed to find kvdb client URL in %s", line)
+		}
+		host, _, err := net.SplitHostPort(endpoint[1])
+		if err != nil {
+			return hosts, fmt.Errorf("failed to parse kvdb client URL %s. cause: %v", endpoint[0], err)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, nil
+}
@@ -0,0 +1,205 @@
+package pxctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/libopenstorage/openstorage/api"
+)
+
+// StatusInfo is the output of pxctl status
+type StatusInfo struct {
+	Status Status `json:"status"`
+}
+
+// Cluster is the output of pxctl cluster list
+type Cluster struct {
+	ID     string  `json:"Id"`
+	NodeID string  `json:"NodeId"`
+	Status Status  `json:"Status"`
+	Nodes  []*Node `json:"Nodes"`
+}
+
+// Node is a node of pxctl cluster list and the output of pxctl cluster inspect
+type Node struct {
+	ID                string            `json:"Id"`
+	SchedulerNodeName string            `json:"SchedulerNodeName"`
+	Hostname          string            `json:"Hostname"`
+	MgmtIP            string            `json:"MgmtIp"`
+	DataIP            string            `json:"DataIp"`
+	Status            Status            `json:"Status"`
+	NodeLabels        map[string]string `json:"NodeLabels,omitempty"`
+}
+
+// Node returns the node with the given id, or nil if the cluster has no such node
+func (c *Cluster) Node(id string) *Node {
+	for _, n := range c.Nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// Volume is a volume of pxctl volume list and volume inspect
+type Volume struct {
+	ID          string        `json:"id"`
+	Locator     VolumeLocator `json:"locator"`
+	Spec        VolumeSpec    `json:"spec"`
+	State       VolumeState   `json:"state"`
+	Status      VolumeStatus  `json:"status"`
+	AttachedOn  string        `json:"attached_on"`
+	DevicePath  string        `json:"device_path"`
+	InTrashcan  bool          `json:"in_trashcan"`
+	ReplicaSets []ReplicaSet  `json:"replica_sets"`
+}
+
+// VolumeLocator identifies a volume by name and labels
+type VolumeLocator struct {
+	Name         string            `json:"name"`
+	VolumeLabels map[string]string `json:"volume_labels,omitempty"`
+}
+
+// VolumeSpec is the part of the volume spec the tests look at
+type VolumeSpec struct {
+	Size      Uint64 `json:"size"`
+	HaLevel   Int64  `json:"ha_level"`
+	Shared    bool   `json:"shared"`
+	Sharedv4  bool   `json:"sharedv4"`
+	Encrypted bool   `json:"encrypted"`
+}
+
+// ReplicaSet is the set of nodes and pools holding one copy of a volume
+type ReplicaSet struct {
+	Nodes     []string `json:"nodes"`
+	PoolUuids []string `json:"pool_uuids,omitempty"`
+}
+
+// NodeStats is the output of pxctl sv dump --nodestats
+type NodeStats struct {
+	RelaxedReclaim RelaxedReclaimStats `json:"relaxed_reclaim_stats"`
+}
+
+// RelaxedReclaimStats counts the volumes handled by relaxed reclaim on a node
+type RelaxedReclaimStats struct {
+	Pending Uint64 `json:"pending"`
+	Deleted Uint64 `json:"deleted"`
+	Skipped Uint64 `json:"skipped"`
+}
+
+// poolList is the output of pxctl sv pool show
+type poolList struct {
+	Pools []*Pool `json:"datapools"`
+}
+
+// Pool is a storage pool of pxctl sv pool show
+type Pool struct {
+	ID        Int64             `json:"poolID"`
+	UUID      string            `json:"uuid"`
+	Used      Uint64            `json:"Used"`
+	TotalSize Uint64            `json:"TotalSize"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Alert is an alert of pxctl alerts show
+type Alert struct {
+	ID         Int64        `json:"id"`
+	Severity   Severity     `json:"severity"`
+	AlertType  Int64        `json:"alert_type"`
+	Message    string       `json:"message"`
+	ResourceID string       `json:"resource_id"`
+	Resource   ResourceType `json:"resource"`
+	Cleared    bool         `json:"cleared"`
+	Count      Int64        `json:"count"`
+}
+
+// Uint64 is an unsigned integer which PX prints either as a JSON number or as a string
+type Uint64 uint64
+
+// UnmarshalJSON decodes the integer from a number or a string
+func (u *Uint64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := strconv.ParseUint(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid unsigned integer %s", data)
+	}
+	*u = Uint64(value)
+	return nil
+}
+
+// Int64 is an integer which PX prints either as a JSON number or as a string
+type Int64 int64
+
+// UnmarshalJSON decodes the integer from a number or a string
+func (i *Int64) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*i = Int64(value)
+	return nil
+}
+
+// Status is a node or cluster status, e.g. STATUS_OK
+type Status string
+
+// UnmarshalJSON decodes the status from its name or number
+func (s *Status) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, api.Status_name, (*string)(s))
+}
+
+// VolumeState is the attach state of a volume, e.g. VOLUME_STATE_ATTACHED
+type VolumeState string
+
+// UnmarshalJSON decodes the state from its name or number
+func (s *VolumeState) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, api.VolumeState_name, (*string)(s))
+}
+
+// VolumeStatus is the health of a volume, e.g. VOLUME_STATUS_UP
+type VolumeStatus string
+
+// UnmarshalJSON decodes the status from its name or number
+func (s *VolumeStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, api.VolumeStatus_name, (*string)(s))
+}
+
+// Severity is the severity of an alert, e.g. SEVERITY_TYPE_ALARM
+type Severity string
+
+// UnmarshalJSON decodes the severity from its name or number
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, api.SeverityType_name, (*string)(s))
+}
+
+// ResourceType is the type of resource an alert is raised on, e.g. RESOURCE_TYPE_NODE
+type ResourceType string
+
+// UnmarshalJSON decodes the resource type from its name or number
+func (r *ResourceType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnum(data, api.ResourceType_name, (*string)(r))
+}
+
+// unmarshalEnum decodes a protobuf enum, which older PX versions print as a number and newer ones by name
+func unmarshalEnum(data []byte, names map[int32]string, name *string) error {
+	if err := json.Unmarshal(data, name); err == nil {
+		return nil
+	}
+	var value int32
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("invalid enum value %s", data)
+	}
+	if known, ok := names[value]; ok {
+		*name = known
+		return nil
+	}
+	*name = strconv.Itoa(int(value))
+	return nil
+}
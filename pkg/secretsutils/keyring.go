@@ -0,0 +1,95 @@
+package secretsutils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/portworx/torpedo/pkg/log"
+)
+
+const passphraseBytes = 32
+
+// Keyring creates versioned encryption keys in a store. Rotating a key stores a new version under a new name,
+// <name>-v<version>, and keeps the previous versions since volumes encrypted with them must stay readable.
+type Keyring struct {
+	store    *Store
+	lock     sync.Mutex
+	versions map[string][]Key
+}
+
+// NewKeyring returns a keyring keeping its keys in the given store
+func NewKeyring(store *Store) *Keyring {
+	return &Keyring{
+		store:    store,
+		versions: make(map[string][]Key),
+	}
+}
+
+// Store returns the store of the keyring
+func (k *Keyring) Store() *Store {
+	return k.store
+}
+
+// Rotate stores a new version of the key with a random passphrase and returns it. The first rotation creates the key.
+func (k *Keyring) Rotate(name string) (Key, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	passphrase, err := newPassphrase()
+	if err != nil {
+		return Key{}, err
+	}
+	key := Key{Name: fmt.Sprintf("%s-v%d", name, len(k.versions[name])+1)}
+	if err := k.store.PutKey(key, passphrase); err != nil {
+		return Key{}, err
+	}
+	k.versions[name] = append(k.versions[name], key)
+	log.Infof("Created key %s in %s secrets", key.Name, k.store.Type())
+	return key, nil
+}
+
+// Current returns the latest version of the key, or false if the key was never created
+func (k *Keyring) Current(name string) (Key, bool) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	versions := k.versions[name]
+	if len(versions) == 0 {
+		return Key{}, false
+	}
+	return versions[len(versions)-1], true
+}
+
+// Versions returns all versions of the key, oldest first
+func (k *Keyring) Versions(name string) []Key {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	return append([]Key(nil), k.versions[name]...)
+}
+
+// DeleteOldVersions deletes all but the latest version of the key. Only call it once no volume uses them anymore.
+func (k *Keyring) DeleteOldVersions(name string) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	versions := k.versions[name]
+	for len(versions) > 1 {
+		if err := k.store.DeleteKey(versions[0]); err != nil {
+			return err
+		}
+		versions = versions[1:]
+	}
+	k.versions[name] = versions
+	return nil
+}
+
+func newPassphrase() (string, error) {
+	b := make([]byte, passphraseBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate passphrase. Err: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
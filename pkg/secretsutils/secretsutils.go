@@ -0,0 +1,142 @@
+// Package secretsutils stores, rotates and looks up volume encryption keys in the secrets store used by the
+// volume driver. Keys are read and written with the same secrets library the driver uses, so a key put here is
+// found by the driver under the same name.
+package secretsutils
+
+import (
+	"fmt"
+
+	"github.com/libopenstorage/secrets"
+	"github.com/libopenstorage/secrets/k8s"
+	"github.com/libopenstorage/secrets/vault"
+	corev1 "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+)
+
+const (
+	// PVC annotations and storage class parameters referencing the key of a volume
+	pvcSecretKeyAnnotation       = "px/secret-key"
+	pvcSecretNameAnnotation      = "px/secret-name"
+	pvcSecretNamespaceAnnotation = "px/secret-namespace"
+	scSecretKeyParam             = "secret_key"
+	scSecretNameParam            = "secret_name"
+	scSecretNamespaceParam       = "secret_namespace"
+
+	vaultAddressKey = "VAULT_ADDR"
+	vaultTokenKey   = "VAULT_TOKEN"
+)
+
+// Key identifies an encryption key in the secrets store. The passphrase is the field named after the key in the
+// secret SecretName, which is the key name itself if empty.
+type Key struct {
+	// Name is the name of the key, as referenced by the volume
+	Name string
+	// SecretName is the name of the secret holding the key, if it is not named after the key
+	SecretName string
+	// SecretNamespace is the namespace of the k8s secret holding the key, if it is not the store's namespace
+	SecretNamespace string
+}
+
+func (k Key) secretID() string {
+	if len(k.SecretName) > 0 {
+		return k.SecretName
+	}
+	return k.Name
+}
+
+// Store reads and writes encryption keys in a secrets backend
+type Store struct {
+	backend   secrets.Secrets
+	namespace string
+}
+
+// NewStore returns a store for the given secret type, k8s or vault. k8s secrets are kept in the given namespace,
+// which should be the namespace the volume driver reads its secrets from.
+func NewStore(secretType, namespace, vaultAddress, vaultToken string) (*Store, error) {
+	var backend secrets.Secrets
+	var err error
+	switch secretType {
+	case secrets.TypeK8s:
+		backend, err = k8s.New(nil)
+	case secrets.TypeVault:
+		backend, err = vault.New(map[string]interface{}{
+			vaultAddressKey: vaultAddress,
+			vaultTokenKey:   vaultToken,
+		})
+	default:
+		return nil, fmt.Errorf("secret type %s is not supported", secretType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s secrets. Err: %v", secretType, err)
+	}
+	return newStore(backend, namespace), nil
+}
+
+func newStore(backend secrets.Secrets, namespace string) *Store {
+	return &Store{backend: backend, namespace: namespace}
+}
+
+// Type returns the secret type of the store
+func (s *Store) Type() string {
+	return s.backend.String()
+}
+
+// PutKey stores the passphrase of the key. Other keys in the same secret are kept.
+func (s *Store) PutKey(key Key, passphrase string) error {
+	data := map[string]interface{}{key.Name: passphrase}
+	if err := s.backend.PutSecret(key.secretID(), data, s.keyContext(key)); err != nil {
+		return fmt.Errorf("failed to put key %s in %s secrets. Err: %v", key.Name, s.Type(), err)
+	}
+	return nil
+}
+
+// GetKey returns the passphrase of the key
+func (s *Store) GetKey(key Key) (string, error) {
+	data, err := s.backend.GetSecret(key.secretID(), s.keyContext(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to get key %s from %s secrets. Err: %v", key.Name, s.Type(), err)
+	}
+	value, ok := data[key.Name]
+	if !ok {
+		return "", fmt.Errorf("secret %s in %s secrets has no key %s", key.secretID(), s.Type(), key.Name)
+	}
+	return fmt.Sprintf("%s", value), nil
+}
+
+// DeleteKey deletes the secret holding the key
+func (s *Store) DeleteKey(key Key) error {
+	if err := s.backend.DeleteSecret(key.secretID(), s.keyContext(key)); err != nil {
+		return fmt.Errorf("failed to delete key %s from %s secrets. Err: %v", key.Name, s.Type(), err)
+	}
+	return nil
+}
+
+func (s *Store) keyContext(key Key) map[string]string {
+	namespace := s.namespace
+	if len(key.SecretNamespace) > 0 {
+		namespace = key.SecretNamespace
+	}
+	return map[string]string{k8s.SecretNamespace: namespace}
+}
+
+// KeyForPVC returns the key the PVC annotations or the storage class parameters ask the volume to be encrypted
+// with. It returns false if neither references a key, i.e. the volume uses the cluster wide key if encrypted.
+func KeyForPVC(pvc *corev1.PersistentVolumeClaim, sc *storageapi.StorageClass) (Key, bool) {
+	if name := pvc.Annotations[pvcSecretKeyAnnotation]; len(name) > 0 {
+		return Key{
+			Name:            name,
+			SecretName:      pvc.Annotations[pvcSecretNameAnnotation],
+			SecretNamespace: pvc.Annotations[pvcSecretNamespaceAnnotation],
+		}, true
+	}
+	if sc != nil {
+		if name := sc.Parameters[scSecretKeyParam]; len(name) > 0 {
+			return Key{
+				Name:            name,
+				SecretName:      sc.Parameters[scSecretNameParam],
+				SecretNamespace: sc.Parameters[scSecretNamespaceParam],
+			}, true
+		}
+	}
+	return Key{}, false
+}
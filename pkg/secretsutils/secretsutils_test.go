@@ -0,0 +1,128 @@
+package secretsutils
+
+import (
+	"testing"
+
+	"github.com/libopenstorage/secrets"
+	"github.com/libopenstorage/secrets/k8s"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storageapi "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeSecrets keeps secrets in memory by namespace and secret id
+type fakeSecrets struct {
+	secrets.Secrets
+	data map[string]map[string]interface{}
+}
+
+func newFakeSecrets() *fakeSecrets {
+	return &fakeSecrets{data: make(map[string]map[string]interface{})}
+}
+
+func (f *fakeSecrets) path(id string, keyContext map[string]string) string {
+	return keyContext[k8s.SecretNamespace] + "/" + id
+}
+
+func (f *fakeSecrets) String() string {
+	return "fake"
+}
+
+func (f *fakeSecrets) GetSecret(id string, keyContext map[string]string) (map[string]interface{}, error) {
+	data, ok := f.data[f.path(id, keyContext)]
+	if !ok {
+		return nil, secrets.ErrInvalidSecretId
+	}
+	return data, nil
+}
+
+func (f *fakeSecrets) PutSecret(id string, data map[string]interface{}, keyContext map[string]string) error {
+	path := f.path(id, keyContext)
+	if f.data[path] == nil {
+		f.data[path] = make(map[string]interface{})
+	}
+	for k, v := range data {
+		f.data[path][k] = v
+	}
+	return nil
+}
+
+func (f *fakeSecrets) DeleteSecret(id string, keyContext map[string]string) error {
+	delete(f.data, f.path(id, keyContext))
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	backend := newFakeSecrets()
+	store := newStore(backend, "portworx")
+
+	require.NoError(t, store.PutKey(Key{Name: "key"}, "secret"))
+	require.Contains(t, backend.data, "portworx/key")
+	passphrase, err := store.GetKey(Key{Name: "key"})
+	require.NoError(t, err)
+	require.Equal(t, "secret", passphrase)
+
+	appKey := Key{Name: "nginx-secret", SecretName: "volume-secrets", SecretNamespace: "nginx"}
+	require.NoError(t, store.PutKey(appKey, "app"))
+	require.Contains(t, backend.data, "nginx/volume-secrets")
+	_, err = store.GetKey(Key{Name: "other", SecretName: "volume-secrets", SecretNamespace: "nginx"})
+	require.Error(t, err)
+
+	require.NoError(t, store.DeleteKey(Key{Name: "key"}))
+	_, err = store.GetKey(Key{Name: "key"})
+	require.Error(t, err)
+}
+
+func TestKeyringRotate(t *testing.T) {
+	store := newStore(newFakeSecrets(), "portworx")
+	keyring := NewKeyring(store)
+
+	_, ok := keyring.Current("cluster")
+	require.False(t, ok)
+
+	v1, err := keyring.Rotate("cluster")
+	require.NoError(t, err)
+	require.Equal(t, "cluster-v1", v1.Name)
+	v2, err := keyring.Rotate("cluster")
+	require.NoError(t, err)
+	require.Equal(t, "cluster-v2", v2.Name)
+
+	current, ok := keyring.Current("cluster")
+	require.True(t, ok)
+	require.Equal(t, v2, current)
+	require.Equal(t, []Key{v1, v2}, keyring.Versions("cluster"))
+
+	p1, err := store.GetKey(v1)
+	require.NoError(t, err)
+	p2, err := store.GetKey(v2)
+	require.NoError(t, err)
+	require.NotEqual(t, p1, p2)
+
+	require.NoError(t, keyring.DeleteOldVersions("cluster"))
+	require.Equal(t, []Key{v2}, keyring.Versions("cluster"))
+	_, err = store.GetKey(v1)
+	require.Error(t, err)
+}
+
+func TestKeyForPVC(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{
+			pvcSecretKeyAnnotation:       "mysql-secret",
+			pvcSecretNameAnnotation:      "volume-secrets",
+			pvcSecretNamespaceAnnotation: "mysql",
+		},
+	}}
+	sc := &storageapi.StorageClass{Parameters: map[string]string{scSecretKeyParam: "sc-secret"}}
+
+	key, ok := KeyForPVC(pvc, sc)
+	require.True(t, ok)
+	require.Equal(t, Key{Name: "mysql-secret", SecretName: "volume-secrets", SecretNamespace: "mysql"}, key)
+
+	key, ok = KeyForPVC(&corev1.PersistentVolumeClaim{}, sc)
+	require.True(t, ok)
+	require.Equal(t, Key{Name: "sc-secret"}, key)
+
+	_, ok = KeyForPVC(&corev1.PersistentVolumeClaim{}, &storageapi.StorageClass{})
+	require.False(t, ok)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/portworx/torpedo/pkg/log"
 	"math/rand"
+	"os"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -20,6 +21,9 @@ const (
 	scaleTimeout = 10 * time.Minute
 )
 
+// targetNodePool is the node pool the ASG tests scale and delete the nodes of instead of the whole cluster, if set
+var targetNodePool = os.Getenv("TARGET_NODE_POOL")
+
 // This test performs basic test of scaling up and down the asg cluster
 var _ = Describe("{ClusterScaleUpDown}", func() {
 	var testrailID = 58847
@@ -45,6 +49,21 @@ var _ = Describe("{ClusterScaleUpDown}", func() {
 		log.FailOnError(err, "Failed to Get ASG cluster size")
 
 		scaleupCount := intitialNodeCount + intitialNodeCount/2
+		scale := Scale
+		if len(targetNodePool) > 0 {
+			// Only the target pool is scaled up, by about half its nodes, and back. Nodes are added to every zone
+			// alike, as some clouds size pools per zone.
+			pool := getNodePool(targetNodePool)
+			zones := pool.ZoneCount()
+			scaleupStep := pool.Size / zones / 2
+			if scaleupStep == 0 {
+				scaleupStep = 1
+			}
+			scaleupCount = intitialNodeCount + scaleupStep*zones
+			scale = func(count int64) {
+				ScaleNodePool(pool.Name, pool.Size+count-intitialNodeCount)
+			}
+		}
 		stepLog := fmt.Sprintf("scale up cluster from %d to %d nodes and validate",
 			intitialNodeCount, (scaleupCount/3)*3)
 		Step(stepLog, func() {
@@ -57,7 +76,7 @@ var _ = Describe("{ClusterScaleUpDown}", func() {
 			err = Inst().V.RefreshDriverEndpoints()
 			log.FailOnError(err, "Verify driver end points refresh")
 
-			Scale(scaleupCount)
+			scale(scaleupCount)
 			stepLog = "validate number of storage nodes after scale up"
 			Step(fmt.Sprintf(stepLog), func() {
 				log.InfoD(stepLog)
@@ -70,7 +89,7 @@ var _ = Describe("{ClusterScaleUpDown}", func() {
 			intitialNodeCount)
 		Step(stepLog, func() {
 			log.InfoD(stepLog)
-			Scale(intitialNodeCount)
+			scale(intitialNodeCount)
 
 			stepLog = fmt.Sprintf("wait for %s minutes for auto recovery of storeage nodes",
 				Inst().AutoStorageNodeRecoveryTimeout.String())
@@ -123,6 +142,18 @@ var _ = Describe("{ASGKillRandomNodes}", func() {
 
 		// Get list of nodes where storage driver is installed
 		storageDriverNodes := node.GetStorageDriverNodes()
+		nodesToKill := storageDriverNodes
+		if len(targetNodePool) > 0 {
+			// Only nodes of the target pool are deleted
+			pool := getNodePool(targetNodePool)
+			nodesToKill = nil
+			for _, n := range storageDriverNodes {
+				if pool.HasNode(n.Name) {
+					nodesToKill = append(nodesToKill, n)
+				}
+			}
+			dash.VerifyFatal(len(nodesToKill) > 0, true, fmt.Sprintf("Verify node pool %s has storage nodes", targetNodePool))
+		}
 
 		Step("Ensure apps are deployed", func() {
 			log.InfoD("Deploy Apps")
@@ -156,7 +187,7 @@ var _ = Describe("{ASGKillRandomNodes}", func() {
 			}
 			if Inst().MinRunTimeMins == 0 {
 				// Run once
-				asgKillANodeAndValidate(storageDriverNodes)
+				asgKillANodeAndValidate(storageDriverNodes, nodesToKill)
 
 				// Validate applications and tear down
 				opts := make(map[string]bool)
@@ -164,7 +195,7 @@ var _ = Describe("{ASGKillRandomNodes}", func() {
 				ValidateAndDestroy(contexts, opts)
 			} else {
 				// Run once till timer gets triggered
-				asgKillANodeAndValidate(storageDriverNodes)
+				asgKillANodeAndValidate(storageDriverNodes, nodesToKill)
 
 				Step("validate applications", func() {
 					for _, ctx := range contexts {
@@ -179,7 +210,7 @@ var _ = Describe("{ASGKillRandomNodes}", func() {
 				for {
 					select {
 					case <-ticker.C:
-						asgKillANodeAndValidate(storageDriverNodes)
+						asgKillANodeAndValidate(storageDriverNodes, nodesToKill)
 
 						Step("validate applications", func() {
 							for _, ctx := range contexts {
@@ -233,9 +264,38 @@ func Scale(count int64) {
 
 }
 
-func asgKillANodeAndValidate(storageDriverNodes []node.Node) {
+// ScaleNodePool sets the node count of the node pool over all its zones and validates the size of the pool
+func ScaleNodePool(name string, size int64) {
+	t := func() (interface{}, bool, error) {
+		err := Inst().N.SetNodePoolSize(name, size, scaleTimeout)
+		if err != nil {
+			return "", true, err
+		}
+		return "", false, nil
+	}
+
+	_, err := task.DoRetryWithTimeout(t, 60*time.Minute, 2*time.Minute)
+	dash.VerifyFatal(err, nil, fmt.Sprintf("Verify set node pool %s size", name))
+
+	pool := getNodePool(name)
+	dash.VerifyFatal(pool.Size, size, fmt.Sprintf("Verify node pool %s size", name))
+}
+
+func getNodePool(name string) node.NodePool {
+	pools, err := Inst().N.ListNodePools()
+	log.FailOnError(err, "Failed to list node pools")
+	for _, pool := range pools {
+		if pool.Name == name {
+			return pool
+		}
+	}
+	log.FailOnError(fmt.Errorf("node pool %s not found", name), "Failed to get node pool")
+	return node.NodePool{}
+}
+
+func asgKillANodeAndValidate(storageDriverNodes, nodesToKill []node.Node) {
 	rand.Seed(time.Now().Unix())
-	nodeToKill := storageDriverNodes[rand.Intn(len(storageDriverNodes))]
+	nodeToKill := nodesToKill[rand.Intn(len(nodesToKill))]
 
 	stepLog := fmt.Sprintf("Deleting node [%v]", nodeToKill.Name)
 	Step(stepLog, func() {
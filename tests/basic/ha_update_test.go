@@ -6,6 +6,7 @@ import (
 	"time"
 
 	. "github.com/onsi/ginkgo"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/scheduler"
 	"github.com/portworx/torpedo/drivers/volume"
@@ -39,6 +40,10 @@ func performHaIncreaseRebootTest(testName string) {
 	}
 	JustBeforeEach(func() {
 		StartTorpedoTest(testDesc, fmt.Sprintf("Validate HA increase and reboot %s", nodeRebootType), nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{
+			Volume: []driver_api.Capability{volume.CapabilityReplication},
+			Node:   []driver_api.Capability{node.CapabilityReboot},
+		})
 
 	})
 	stepLog := fmt.Sprintf("has to perform repl increase and reboot %s node", nodeRebootType)
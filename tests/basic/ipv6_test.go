@@ -2,6 +2,7 @@ package tests
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -9,6 +10,7 @@ import (
 	"github.com/portworx/torpedo/drivers/node"
 	"github.com/portworx/torpedo/drivers/scheduler"
 	"github.com/portworx/torpedo/pkg/ipv6util"
+	"github.com/portworx/torpedo/pkg/pxctl"
 	"github.com/portworx/torpedo/pkg/testrailuttils"
 	. "github.com/portworx/torpedo/tests"
 )
@@ -18,8 +20,6 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 	var contexts []*scheduler.Context
 	var nodes []node.Node
 	var numNodes int
-	var output string
-	var err error
 	var ips []string
 
 	BeforeEach(func() {
@@ -31,23 +31,20 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 
 	Context("{IPv6PxctlCommands}", func() {
 		var pxctlCmd string
-		var pxctlCmdFull string
+		var getIPs func(client *pxctl.Client) ([]string, error)
 		var expectedIPCount int
 
 		// shared test function for pxctl commands
 		testPxctlCmdForIPv6 := func() {
 			It("has to run pxctl command and checks for valid ipv6 addresses", func() {
-				Step(fmt.Sprintln("run pxctl command"), func() {
-					output, err = Inst().V.GetPxctlCmdOutput(nodes[0], pxctlCmdFull)
-					Expect(err).NotTo(HaveOccurred(), "unexpected error getting pxctl command output for running: %v, got output %v", pxctlCmdFull, output)
-				})
-
-				Step(fmt.Sprintln("parse address from pxctl command output"), func() {
-					ips, err = ipv6util.ParseIPv6AddressInPxctlCommand(pxctlCmd, output, numNodes)
+				Step(fmt.Sprintln("run pxctl command and get the addresses in its output"), func() {
+					client, err := Inst().V.GetPxctlClient(nodes[0])
+					Expect(err).NotTo(HaveOccurred(), "unexpected error getting pxctl client")
+					ips, err = getIPs(client)
+					Expect(err).NotTo(HaveOccurred(), "unexpected error getting addresses from pxctl %v, got %v", pxctlCmd, ips)
 					if expectedIPCount >= 0 { // negative 'expectedIPCount' is NA, so skip check
 						Expect(len(ips)).To(Equal(expectedIPCount), "unexpected parsed ip count")
 					}
-					Expect(err).NotTo(HaveOccurred(), "IP parsing failed for %v", ips)
 				})
 
 				Step(fmt.Sprintln("validate the address are ipv6"), func() {
@@ -61,7 +58,14 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 		Context("{PxctlStatusTest}", func() {
 			JustBeforeEach(func() {
 				pxctlCmd = ipv6util.PxctlStatus
-				pxctlCmdFull = ipv6util.PxctlStatus
+				// the node table of pxctl status is only printed as text
+				getIPs = func(client *pxctl.Client) ([]string, error) {
+					output, err := Inst().V.GetPxctlCmdOutput(nodes[0], pxctlCmd)
+					if err != nil {
+						return nil, err
+					}
+					return ipv6util.ParseIPv6AddressInPxctlCommand(pxctlCmd, output, numNodes)
+				}
 				// number of ips are the number of nodes + 1 (the node IP where the status command is run on)
 				expectedIPCount = numNodes + 1
 				testrailID = 9695443
@@ -73,7 +77,13 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 		Context("{PxctlClusterList}", func() {
 			JustBeforeEach(func() {
 				pxctlCmd = ipv6util.PxctlClusterList
-				pxctlCmdFull = ipv6util.PxctlClusterList
+				getIPs = func(client *pxctl.Client) ([]string, error) {
+					cluster, err := client.ClusterList()
+					if err != nil {
+						return nil, err
+					}
+					return ipv6util.IPAddressesOfNodes(cluster), nil
+				}
 				expectedIPCount = numNodes
 				testrailID = 9695444
 			})
@@ -84,7 +94,13 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 		Context("{PxctlClusterInspect}", func() {
 			JustBeforeEach(func() {
 				pxctlCmd = ipv6util.PxctlClusterInspect
-				pxctlCmdFull = fmt.Sprintf("%s %s", ipv6util.PxctlClusterInspect, nodes[0].Id)
+				getIPs = func(client *pxctl.Client) ([]string, error) {
+					n, err := client.ClusterInspect(nodes[0].Id)
+					if err != nil {
+						return nil, err
+					}
+					return []string{n.MgmtIP, n.DataIP}, nil
+				}
 				expectedIPCount = 2
 				testrailID = 9695444
 			})
@@ -96,7 +112,9 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 
 			JustBeforeEach(func() {
 				pxctlCmd = ipv6util.PxctlServiceKvdbEndpoints
-				pxctlCmdFull = ipv6util.PxctlServiceKvdbEndpoints
+				getIPs = func(client *pxctl.Client) ([]string, error) {
+					return client.KvdbEndpoints()
+				}
 				expectedIPCount = -1
 				testrailID = 9695435
 			})
@@ -108,7 +126,9 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 
 			JustBeforeEach(func() {
 				pxctlCmd = ipv6util.PxctlServiceKvdbMembers
-				pxctlCmdFull = ipv6util.PxctlServiceKvdbMembers
+				getIPs = func(client *pxctl.Client) ([]string, error) {
+					return client.KvdbMembers()
+				}
 				expectedIPCount = -1
 				testrailID = 9695435
 			})
@@ -118,9 +138,10 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 		// test ip address from pxctl service kvdb Alerts
 		Context("{IPv6PxctlAlertsDescription}", func() {
 
+			var alerts []*pxctl.Alert
+
 			JustBeforeEach(func() {
 				pxctlCmd = ipv6util.PxctlAlertsShow
-				pxctlCmdFull = fmt.Sprintf("%s -t node -i NodeStateChange -r %s", ipv6util.PxctlAlertsShow, nodes[1].VolDriverNodeID)
 				expectedIPCount = -1
 				testrailID = 9695446
 			})
@@ -131,16 +152,22 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 					Expect(err).NotTo(HaveOccurred(), "failed to stop node %v", nodes[1].Name)
 				})
 				Step(fmt.Sprintf("run pxctl alerts command for down volume %v and parse output\n", nodes[1].VolDriverNodeID), func() {
+					client, err := Inst().V.GetPxctlClient(nodes[0])
+					Expect(err).NotTo(HaveOccurred(), "unexpected error getting pxctl client")
 					Eventually(func() (string, error) {
-						output, err = Inst().V.GetPxctlCmdOutput(nodes[0], pxctlCmdFull)
-						Expect(err).NotTo(HaveOccurred(), "Failed to get alerts for down volume %v, %v", nodes[1].VolDriverNodeID, output)
-						return output, err
+						alerts, err = client.Alerts("-t", "node", "-i", "NodeStateChange", "-r", nodes[1].VolDriverNodeID)
+						Expect(err).NotTo(HaveOccurred(), "Failed to get alerts for down volume %v", nodes[1].VolDriverNodeID)
+						var messages []string
+						for _, alert := range alerts {
+							messages = append(messages, alert.Message)
+						}
+						return strings.Join(messages, "\n"), err
 					}, 45*time.Second, 5*time.Second).Should(ContainSubstring(ipv6util.OperationalStatusDown),
 						"failed to get alerts down for resource %v", nodes[1].VolDriverNodeID)
 				})
 
 				Step(fmt.Sprintf("parse address from pxctl alerts command output for down volume %v\n", nodes[1].VolDriverNodeID), func() {
-					ip, err := ipv6util.ParseIPAddressInPxctlResourceDownAlert(output, nodes[1].VolDriverNodeID)
+					ip, err := ipv6util.IPAddressInResourceDownAlert(alerts, nodes[1].VolDriverNodeID)
 					Expect(err).NotTo(HaveOccurred(), "failed to parse command output for down volume %v\n", nodes[1].VolDriverNodeID)
 					ips = []string{ip}
 				})
@@ -183,7 +210,14 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 			Context("{PxctlVolumeList", func() {
 				JustBeforeEach(func() {
 					pxctlCmd = ipv6util.PxctlVolumeList
-					pxctlCmdFull = ipv6util.PxctlVolumeList
+					// the volume table is only printed as text, with the IP of the node the volume is attached on
+					getIPs = func(client *pxctl.Client) ([]string, error) {
+						output, err := Inst().V.GetPxctlCmdOutput(nodes[0], pxctlCmd)
+						if err != nil {
+							return nil, err
+						}
+						return ipv6util.ParseIPv6AddressInPxctlCommand(pxctlCmd, output, numNodes)
+					}
 					expectedIPCount = 1
 					testrailID = 9695445
 				})
@@ -193,7 +227,14 @@ var _ = Describe("{IPv6PxctlFunctional}", func() {
 			Context("{PxctlVolumeInspect", func() {
 				JustBeforeEach(func() {
 					pxctlCmd = ipv6util.PxctlVolumeInspect
-					pxctlCmdFull = fmt.Sprintf("%s %s", ipv6util.PxctlVolumeInspect, volumeID)
+					// the JSON output only has node IDs, the text output has the IPs of the attached and replica nodes
+					getIPs = func(client *pxctl.Client) ([]string, error) {
+						output, err := Inst().V.GetPxctlCmdOutput(nodes[0], fmt.Sprintf("%s %s", pxctlCmd, volumeID))
+						if err != nil {
+							return nil, err
+						}
+						return ipv6util.ParseIPv6AddressInPxctlCommand(pxctlCmd, output, numNodes)
+					}
 					expectedIPCount = 2
 					testrailID = 9695445
 				})
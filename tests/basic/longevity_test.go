@@ -98,6 +98,20 @@ var _ = Describe("{Longevity}", func() {
 		AddDiskAndReboot:       TriggerPoolAddDiskAndReboot,
 		ResizeDiskAndReboot:    TriggerPoolResizeDiskAndReboot,
 		AutopilotRebalance:     TriggerAutopilotPoolRebalance,
+		RollingUpdateApps:      TriggerRollingUpdateApps,
+		PartitionedRollout:     TriggerPartitionedRollout,
+		RollbackApps:           TriggerRollbackApps,
+		DrainNodes:             TriggerDrainNodes,
+		PoolCreate:             TriggerPoolCreate,
+		PoolDelete:             TriggerPoolDelete,
+		EncryptionKeyRotation:  TriggerEncryptionKeyRotation,
+		SplitBrainPartition:    TriggerSplitBrainPartition,
+		PoolDriveFault:         TriggerPoolDriveFault,
+		KubeletHang:            TriggerKubeletHang,
+		NodeMemoryPressure:     TriggerNodeMemoryPressure,
+		ClockSkew:              TriggerClockSkew,
+		VMHardReset:            TriggerVMHardReset,
+		VMDeviceHotplug:        TriggerVMDeviceHotplug,
 	}
 	//Creating a distinct trigger to make sure email triggers at regular intervals
 	emailTriggerFunction = map[string]func(){
@@ -143,6 +157,10 @@ var _ = Describe("{Longevity}", func() {
 		var wg sync.WaitGroup
 		Step("Register test triggers", func() {
 			for triggerType, triggerFunc := range triggerFunctions {
+				if reason := UnsupportedTriggerReason(triggerType); len(reason) > 0 {
+					log.Warnf("Skipping trigger [%v]: %s", triggerType, reason)
+					continue
+				}
 				log.InfoD("Registering trigger: [%v]", triggerType)
 				go testTrigger(&wg, &contexts, triggerType, triggerFunc, &triggerLock, &triggerEventsChan)
 				wg.Add(1)
@@ -162,6 +180,7 @@ var _ = Describe("{Longevity}", func() {
 		})
 		log.InfoD("Finished registering email trigger")
 
+		StartKernelMonitor(node.GetWorkerNodes())
 		CollectEventRecords(&triggerEventsChan)
 		wg.Wait()
 		close(triggerEventsChan)
@@ -222,7 +241,9 @@ func testTrigger(wg *sync.WaitGroup,
 				log.Infof("===Releasing lock for non-disruptive event [%s]\n", triggerType)
 			}*/
 
+			SetRunningTrigger(triggerType)
 			triggerFunc(contexts, triggerEventsChan)
+			SetRunningTrigger("")
 			log.Infof("Trigger Function completed for [%s]\n", triggerType)
 
 			//if isDisruptiveTrigger(triggerType) {
@@ -235,6 +256,8 @@ func testTrigger(wg *sync.WaitGroup,
 		}
 		time.Sleep(controlLoopSleepTime)
 	}
+	// the run ends here as CollectEventRecords never returns, so stop the checks of the nodes in progress first
+	StopKernelMonitor()
 	os.Exit(0)
 }
 
@@ -351,6 +374,20 @@ func populateDisruptiveTriggers() {
 		AddDiskAndReboot:                true,
 		ResizeDiskAndReboot:             true,
 		VolumeCreatePxRestart:           true,
+		RollingUpdateApps:               false,
+		PartitionedRollout:              false,
+		RollbackApps:                    false,
+		DrainNodes:                      true,
+		PoolCreate:                      false,
+		PoolDelete:                      true,
+		EncryptionKeyRotation:           false,
+		SplitBrainPartition:             true,
+		PoolDriveFault:                  true,
+		KubeletHang:                     true,
+		NodeMemoryPressure:              true,
+		ClockSkew:                       true,
+		VMHardReset:                     true,
+		VMDeviceHotplug:                 true,
 	}
 }
 
@@ -602,6 +639,20 @@ func populateIntervals() {
 	triggerInterval[ResizeDiskAndReboot] = make(map[int]time.Duration)
 	triggerInterval[AutopilotRebalance] = make(map[int]time.Duration)
 	triggerInterval[VolumeCreatePxRestart] = make(map[int]time.Duration)
+	triggerInterval[RollingUpdateApps] = make(map[int]time.Duration)
+	triggerInterval[PartitionedRollout] = make(map[int]time.Duration)
+	triggerInterval[RollbackApps] = make(map[int]time.Duration)
+	triggerInterval[DrainNodes] = make(map[int]time.Duration)
+	triggerInterval[PoolCreate] = make(map[int]time.Duration)
+	triggerInterval[PoolDelete] = make(map[int]time.Duration)
+	triggerInterval[EncryptionKeyRotation] = make(map[int]time.Duration)
+	triggerInterval[SplitBrainPartition] = make(map[int]time.Duration)
+	triggerInterval[PoolDriveFault] = make(map[int]time.Duration)
+	triggerInterval[KubeletHang] = make(map[int]time.Duration)
+	triggerInterval[NodeMemoryPressure] = make(map[int]time.Duration)
+	triggerInterval[ClockSkew] = make(map[int]time.Duration)
+	triggerInterval[VMHardReset] = make(map[int]time.Duration)
+	triggerInterval[VMDeviceHotplug] = make(map[int]time.Duration)
 
 	baseInterval := 10 * time.Minute
 	triggerInterval[BackupScaleMongo][10] = 1 * baseInterval
@@ -1117,6 +1168,160 @@ func populateIntervals() {
 	triggerInterval[VolumeCreatePxRestart][2] = 9 * baseInterval
 	triggerInterval[VolumeCreatePxRestart][1] = 10 * baseInterval
 
+	triggerInterval[RollingUpdateApps][10] = 1 * baseInterval
+	triggerInterval[RollingUpdateApps][9] = 3 * baseInterval
+	triggerInterval[RollingUpdateApps][8] = 6 * baseInterval
+	triggerInterval[RollingUpdateApps][7] = 9 * baseInterval
+	triggerInterval[RollingUpdateApps][6] = 12 * baseInterval
+	triggerInterval[RollingUpdateApps][5] = 15 * baseInterval
+	triggerInterval[RollingUpdateApps][4] = 18 * baseInterval
+	triggerInterval[RollingUpdateApps][3] = 21 * baseInterval
+	triggerInterval[RollingUpdateApps][2] = 24 * baseInterval
+	triggerInterval[RollingUpdateApps][1] = 27 * baseInterval
+
+	triggerInterval[PartitionedRollout][10] = 1 * baseInterval
+	triggerInterval[PartitionedRollout][9] = 3 * baseInterval
+	triggerInterval[PartitionedRollout][8] = 6 * baseInterval
+	triggerInterval[PartitionedRollout][7] = 9 * baseInterval
+	triggerInterval[PartitionedRollout][6] = 12 * baseInterval
+	triggerInterval[PartitionedRollout][5] = 15 * baseInterval
+	triggerInterval[PartitionedRollout][4] = 18 * baseInterval
+	triggerInterval[PartitionedRollout][3] = 21 * baseInterval
+	triggerInterval[PartitionedRollout][2] = 24 * baseInterval
+	triggerInterval[PartitionedRollout][1] = 27 * baseInterval
+
+	triggerInterval[RollbackApps][10] = 1 * baseInterval
+	triggerInterval[RollbackApps][9] = 3 * baseInterval
+	triggerInterval[RollbackApps][8] = 6 * baseInterval
+	triggerInterval[RollbackApps][7] = 9 * baseInterval
+	triggerInterval[RollbackApps][6] = 12 * baseInterval
+	triggerInterval[RollbackApps][5] = 15 * baseInterval
+	triggerInterval[RollbackApps][4] = 18 * baseInterval
+	triggerInterval[RollbackApps][3] = 21 * baseInterval
+	triggerInterval[RollbackApps][2] = 24 * baseInterval
+	triggerInterval[RollbackApps][1] = 27 * baseInterval
+
+	triggerInterval[DrainNodes][10] = 1 * baseInterval
+	triggerInterval[DrainNodes][9] = 3 * baseInterval
+	triggerInterval[DrainNodes][8] = 6 * baseInterval
+	triggerInterval[DrainNodes][7] = 9 * baseInterval
+	triggerInterval[DrainNodes][6] = 12 * baseInterval
+	triggerInterval[DrainNodes][5] = 15 * baseInterval
+	triggerInterval[DrainNodes][4] = 18 * baseInterval
+	triggerInterval[DrainNodes][3] = 21 * baseInterval
+	triggerInterval[DrainNodes][2] = 24 * baseInterval
+	triggerInterval[DrainNodes][1] = 27 * baseInterval
+
+	triggerInterval[PoolCreate][10] = 1 * baseInterval
+	triggerInterval[PoolCreate][9] = 3 * baseInterval
+	triggerInterval[PoolCreate][8] = 6 * baseInterval
+	triggerInterval[PoolCreate][7] = 9 * baseInterval
+	triggerInterval[PoolCreate][6] = 12 * baseInterval
+	triggerInterval[PoolCreate][5] = 15 * baseInterval
+	triggerInterval[PoolCreate][4] = 18 * baseInterval
+	triggerInterval[PoolCreate][3] = 21 * baseInterval
+	triggerInterval[PoolCreate][2] = 24 * baseInterval
+	triggerInterval[PoolCreate][1] = 27 * baseInterval
+
+	triggerInterval[PoolDelete][10] = 1 * baseInterval
+	triggerInterval[PoolDelete][9] = 3 * baseInterval
+	triggerInterval[PoolDelete][8] = 6 * baseInterval
+	triggerInterval[PoolDelete][7] = 9 * baseInterval
+	triggerInterval[PoolDelete][6] = 12 * baseInterval
+	triggerInterval[PoolDelete][5] = 15 * baseInterval
+	triggerInterval[PoolDelete][4] = 18 * baseInterval
+	triggerInterval[PoolDelete][3] = 21 * baseInterval
+	triggerInterval[PoolDelete][2] = 24 * baseInterval
+	triggerInterval[PoolDelete][1] = 27 * baseInterval
+
+	triggerInterval[EncryptionKeyRotation][10] = 1 * baseInterval
+	triggerInterval[EncryptionKeyRotation][9] = 3 * baseInterval
+	triggerInterval[EncryptionKeyRotation][8] = 6 * baseInterval
+	triggerInterval[EncryptionKeyRotation][7] = 9 * baseInterval
+	triggerInterval[EncryptionKeyRotation][6] = 12 * baseInterval
+	triggerInterval[EncryptionKeyRotation][5] = 15 * baseInterval
+	triggerInterval[EncryptionKeyRotation][4] = 18 * baseInterval
+	triggerInterval[EncryptionKeyRotation][3] = 21 * baseInterval
+	triggerInterval[EncryptionKeyRotation][2] = 24 * baseInterval
+	triggerInterval[EncryptionKeyRotation][1] = 27 * baseInterval
+
+	triggerInterval[SplitBrainPartition][10] = 1 * baseInterval
+	triggerInterval[SplitBrainPartition][9] = 3 * baseInterval
+	triggerInterval[SplitBrainPartition][8] = 6 * baseInterval
+	triggerInterval[SplitBrainPartition][7] = 9 * baseInterval
+	triggerInterval[SplitBrainPartition][6] = 12 * baseInterval
+	triggerInterval[SplitBrainPartition][5] = 15 * baseInterval
+	triggerInterval[SplitBrainPartition][4] = 18 * baseInterval
+	triggerInterval[SplitBrainPartition][3] = 21 * baseInterval
+	triggerInterval[SplitBrainPartition][2] = 24 * baseInterval
+	triggerInterval[SplitBrainPartition][1] = 27 * baseInterval
+
+	triggerInterval[PoolDriveFault][10] = 1 * baseInterval
+	triggerInterval[PoolDriveFault][9] = 3 * baseInterval
+	triggerInterval[PoolDriveFault][8] = 6 * baseInterval
+	triggerInterval[PoolDriveFault][7] = 9 * baseInterval
+	triggerInterval[PoolDriveFault][6] = 12 * baseInterval
+	triggerInterval[PoolDriveFault][5] = 15 * baseInterval
+	triggerInterval[PoolDriveFault][4] = 18 * baseInterval
+	triggerInterval[PoolDriveFault][3] = 21 * baseInterval
+	triggerInterval[PoolDriveFault][2] = 24 * baseInterval
+	triggerInterval[PoolDriveFault][1] = 27 * baseInterval
+
+	triggerInterval[KubeletHang][10] = 1 * baseInterval
+	triggerInterval[KubeletHang][9] = 3 * baseInterval
+	triggerInterval[KubeletHang][8] = 6 * baseInterval
+	triggerInterval[KubeletHang][7] = 9 * baseInterval
+	triggerInterval[KubeletHang][6] = 12 * baseInterval
+	triggerInterval[KubeletHang][5] = 15 * baseInterval
+	triggerInterval[KubeletHang][4] = 18 * baseInterval
+	triggerInterval[KubeletHang][3] = 21 * baseInterval
+	triggerInterval[KubeletHang][2] = 24 * baseInterval
+	triggerInterval[KubeletHang][1] = 27 * baseInterval
+
+	triggerInterval[NodeMemoryPressure][10] = 1 * baseInterval
+	triggerInterval[NodeMemoryPressure][9] = 3 * baseInterval
+	triggerInterval[NodeMemoryPressure][8] = 6 * baseInterval
+	triggerInterval[NodeMemoryPressure][7] = 9 * baseInterval
+	triggerInterval[NodeMemoryPressure][6] = 12 * baseInterval
+	triggerInterval[NodeMemoryPressure][5] = 15 * baseInterval
+	triggerInterval[NodeMemoryPressure][4] = 18 * baseInterval
+	triggerInterval[NodeMemoryPressure][3] = 21 * baseInterval
+	triggerInterval[NodeMemoryPressure][2] = 24 * baseInterval
+	triggerInterval[NodeMemoryPressure][1] = 27 * baseInterval
+
+	triggerInterval[ClockSkew][10] = 1 * baseInterval
+	triggerInterval[ClockSkew][9] = 3 * baseInterval
+	triggerInterval[ClockSkew][8] = 6 * baseInterval
+	triggerInterval[ClockSkew][7] = 9 * baseInterval
+	triggerInterval[ClockSkew][6] = 12 * baseInterval
+	triggerInterval[ClockSkew][5] = 15 * baseInterval
+	triggerInterval[ClockSkew][4] = 18 * baseInterval
+	triggerInterval[ClockSkew][3] = 21 * baseInterval
+	triggerInterval[ClockSkew][2] = 24 * baseInterval
+	triggerInterval[ClockSkew][1] = 27 * baseInterval
+
+	triggerInterval[VMHardReset][10] = 1 * baseInterval
+	triggerInterval[VMHardReset][9] = 3 * baseInterval
+	triggerInterval[VMHardReset][8] = 6 * baseInterval
+	triggerInterval[VMHardReset][7] = 9 * baseInterval
+	triggerInterval[VMHardReset][6] = 12 * baseInterval
+	triggerInterval[VMHardReset][5] = 15 * baseInterval
+	triggerInterval[VMHardReset][4] = 18 * baseInterval
+	triggerInterval[VMHardReset][3] = 21 * baseInterval
+	triggerInterval[VMHardReset][2] = 24 * baseInterval
+	triggerInterval[VMHardReset][1] = 27 * baseInterval
+
+	triggerInterval[VMDeviceHotplug][10] = 1 * baseInterval
+	triggerInterval[VMDeviceHotplug][9] = 3 * baseInterval
+	triggerInterval[VMDeviceHotplug][8] = 6 * baseInterval
+	triggerInterval[VMDeviceHotplug][7] = 9 * baseInterval
+	triggerInterval[VMDeviceHotplug][6] = 12 * baseInterval
+	triggerInterval[VMDeviceHotplug][5] = 15 * baseInterval
+	triggerInterval[VMDeviceHotplug][4] = 18 * baseInterval
+	triggerInterval[VMDeviceHotplug][3] = 21 * baseInterval
+	triggerInterval[VMDeviceHotplug][2] = 24 * baseInterval
+	triggerInterval[VMDeviceHotplug][1] = 27 * baseInterval
+
 	baseInterval = 300 * time.Minute
 
 	triggerInterval[UpgradeStork][10] = 1 * baseInterval
@@ -1255,6 +1460,20 @@ func populateIntervals() {
 	triggerInterval[ResizeDiskAndReboot][0] = 0
 	triggerInterval[AutopilotRebalance][0] = 0
 	triggerInterval[VolumeCreatePxRestart][0] = 0
+	triggerInterval[RollingUpdateApps][0] = 0
+	triggerInterval[PartitionedRollout][0] = 0
+	triggerInterval[RollbackApps][0] = 0
+	triggerInterval[DrainNodes][0] = 0
+	triggerInterval[PoolCreate][0] = 0
+	triggerInterval[PoolDelete][0] = 0
+	triggerInterval[EncryptionKeyRotation][0] = 0
+	triggerInterval[SplitBrainPartition][0] = 0
+	triggerInterval[PoolDriveFault][0] = 0
+	triggerInterval[KubeletHang][0] = 0
+	triggerInterval[NodeMemoryPressure][0] = 0
+	triggerInterval[ClockSkew][0] = 0
+	triggerInterval[VMHardReset][0] = 0
+	triggerInterval[VMDeviceHotplug][0] = 0
 }
 
 func isTriggerEnabled(triggerType string) (time.Duration, bool) {
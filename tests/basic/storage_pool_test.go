@@ -15,6 +15,7 @@ import (
 	"github.com/libopenstorage/openstorage/api"
 	. "github.com/onsi/ginkgo"
 	"github.com/portworx/sched-ops/task"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/scheduler"
 	"github.com/portworx/torpedo/pkg/units"
 	. "github.com/portworx/torpedo/tests"
@@ -27,6 +28,7 @@ const retryTimeout = time.Minute * 2
 var _ = Describe("{StoragePoolExpandDiskResize}", func() {
 	JustBeforeEach(func() {
 		StartTorpedoTest("StoragePoolExpandDiskResize", "Validate storage pool expansion using resize-disk option", nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{Volume: []driver_api.Capability{volume.CapabilityPoolExpand}})
 	})
 
 	var contexts []*scheduler.Context
@@ -126,6 +128,7 @@ var _ = Describe("{StoragePoolExpandDiskResize}", func() {
 var _ = Describe("{StoragePoolExpandDiskAdd}", func() {
 	JustBeforeEach(func() {
 		StartTorpedoTest("StoragePoolExpandDiskAdd", "Validate storage pool expansion using add-disk option", nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{Volume: []driver_api.Capability{volume.CapabilityPoolExpand}})
 	})
 	var contexts []*scheduler.Context
 
@@ -224,6 +227,7 @@ var _ = Describe("{StoragePoolExpandDiskAdd}", func() {
 var _ = Describe("{StoragePoolExpandDiskAuto}", func() {
 	JustBeforeEach(func() {
 		StartTorpedoTest("StoragePoolExpandDiskAuto", "Validate storage pool expansion using auto option", nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{Volume: []driver_api.Capability{volume.CapabilityPoolExpand}})
 	})
 
 	var contexts []*scheduler.Context
@@ -321,6 +325,10 @@ var _ = Describe("{StoragePoolExpandDiskAuto}", func() {
 var _ = Describe("{PoolResizeDiskReboot}", func() {
 	JustBeforeEach(func() {
 		StartTorpedoTest("PoolResizeDiskReboot", "Initiate pool expansion using resize-disk and reboot node", nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{
+			Volume: []driver_api.Capability{volume.CapabilityPoolExpand},
+			Node:   []driver_api.Capability{node.CapabilityReboot},
+		})
 	})
 
 	var contexts []*scheduler.Context
@@ -427,6 +435,10 @@ var _ = Describe("{PoolResizeDiskReboot}", func() {
 var _ = Describe("{PoolAddDiskReboot}", func() {
 	JustBeforeEach(func() {
 		StartTorpedoTest("PoolAddDiskReboot", "Initiate pool expansion using add-disk and reboot node", nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{
+			Volume: []driver_api.Capability{volume.CapabilityPoolExpand},
+			Node:   []driver_api.Capability{node.CapabilityReboot},
+		})
 	})
 	var contexts []*scheduler.Context
 
@@ -975,6 +987,7 @@ var _ = Describe("{PoolAddDrive}", func() {
 	JustBeforeEach(func() {
 		StartTorpedoTest("PoolAddDrive", "Initiate pool expansion using add-drive", nil, testrailID)
 		runID = testrailuttils.AddRunsToMilestone(testrailID)
+		SkipIfNotCapable(RequiredCapabilities{Volume: []driver_api.Capability{volume.CapabilityAddDrive}})
 	})
 	var contexts []*scheduler.Context
 
@@ -2053,3 +2066,96 @@ var _ = Describe("{VolUpdateAddDrive}", func() {
 		AfterEachTest(contexts, testrailID, runID)
 	})
 })
+
+var _ = Describe("{AddJournalAndMetadataDevice}", func() {
+	// 1) Pick a storage node with free block drives.
+	// 2) Add a free drive as the metadata device of the node, unless it has one.
+	// 3) Add a free drive as the journal device of the node, unless journal is enabled.
+	// 4) Validate the apps.
+	JustBeforeEach(func() {
+		StartTorpedoTest("AddJournalAndMetadataDevice", "Add journal and metadata devices to a storage node", nil, 0)
+		SkipIfNotCapable(RequiredCapabilities{Volume: []driver_api.Capability{volume.CapabilityPoolLifecycle}})
+	})
+	var contexts []*scheduler.Context
+
+	stepLog := "should add free drives of a storage node as its journal and metadata devices"
+	It(stepLog, func() {
+		log.InfoD(stepLog)
+		contexts = make([]*scheduler.Context, 0)
+		for i := 0; i < Inst().GlobalScaleFactor; i++ {
+			contexts = append(contexts, ScheduleApplications(fmt.Sprintf("addjrnlmetadev-%d", i))...)
+		}
+		ValidateApplications(contexts)
+
+		var (
+			stNode     node.Node
+			freeDrives []string
+		)
+		for _, n := range node.GetStorageNodes() {
+			drives, err := getFreeBlockDrives(n)
+			log.FailOnError(err, fmt.Sprintf("error getting block drives of node %s", n.Name))
+			if len(drives) > len(freeDrives) {
+				stNode, freeDrives = n, drives
+			}
+		}
+		if len(freeDrives) == 0 {
+			Skip("No storage node has free block drives")
+		}
+
+		pxNode, err := Inst().V.GetPxNode(&stNode)
+		log.FailOnError(err, fmt.Sprintf("error getting px node %s", stNode.Name))
+		hasMetadataDev := false
+		for _, disk := range pxNode.GetDisks() {
+			hasMetadataDev = hasMetadataDev || disk.GetMetadata()
+		}
+		if !hasMetadataDev {
+			stepLog = fmt.Sprintf("add drive %s as metadata device of node %s", freeDrives[0], stNode.Name)
+			Step(stepLog, func() {
+				log.InfoD(stepLog)
+				err := Inst().V.AddMetadataDevice(&stNode, freeDrives[0])
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verify metadata device %s added to node %s", freeDrives[0], stNode.Name))
+			})
+			freeDrives = freeDrives[1:]
+		}
+
+		isJournal, err := isJournalEnabled()
+		log.FailOnError(err, "Failed to check if Journal enabled")
+		if !isJournal && len(freeDrives) > 0 {
+			stepLog = fmt.Sprintf("add drive %s as journal device of node %s", freeDrives[0], stNode.Name)
+			Step(stepLog, func() {
+				log.InfoD(stepLog)
+				err := Inst().V.AddJournalDevice(&stNode, freeDrives[0])
+				dash.VerifyFatal(err, nil, fmt.Sprintf("Verify journal device %s added to node %s", freeDrives[0], stNode.Name))
+			})
+		}
+
+		opts := make(map[string]bool)
+		opts[scheduler.OptionsWaitForResourceLeakCleanup] = true
+		ValidateAndDestroy(contexts, opts)
+	})
+	JustAfterEach(func() {
+		defer EndTorpedoTest()
+		AfterEachTest(contexts)
+	})
+})
+
+// getFreeBlockDrives returns the paths of the block drives of the node without a filesystem or mount
+func getFreeBlockDrives(n node.Node) ([]string, error) {
+	blockDrives, err := Inst().N.GetBlockDrives(n, node.SystemctlOpts{
+		ConnectionOpts: node.ConnectionOpts{
+			Timeout:         defaultTimeout,
+			TimeBeforeRetry: defaultRetryInterval,
+		},
+		Action: "start",
+	})
+	if err != nil {
+		return nil, err
+	}
+	var drives []string
+	for _, drv := range blockDrives {
+		if !strings.Contains(drv.Path, "pxd") && drv.MountPoint == "" && drv.FSType == "" && drv.Type == "disk" {
+			drives = append(drives, drv.Path)
+		}
+	}
+	return drives, nil
+}
@@ -24,6 +24,7 @@ import (
 	"os/exec"
 	"path"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -52,7 +53,9 @@ import (
 	api "github.com/portworx/px-backup-api/pkg/apis/v1"
 	"github.com/portworx/sched-ops/k8s/core"
 	"github.com/portworx/sched-ops/task"
+	talismanv1beta2 "github.com/portworx/talisman/pkg/apis/portworx/v1beta2"
 	"github.com/portworx/torpedo/drivers"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/backup"
 	"github.com/portworx/torpedo/drivers/monitor"
 	"github.com/portworx/torpedo/drivers/node"
@@ -80,6 +83,8 @@ import (
 	_ "github.com/portworx/torpedo/drivers/node/ibm"
 	// import oracle driver to invoke it's init
 	_ "github.com/portworx/torpedo/drivers/node/oracle"
+	// import libvirt driver to invoke it's init
+	_ "github.com/portworx/torpedo/drivers/node/libvirt"
 
 	// import ssh driver to invoke it's init
 	_ "github.com/portworx/torpedo/drivers/node/ssh"
@@ -93,6 +98,9 @@ import (
 	_ "github.com/portworx/torpedo/drivers/scheduler/openshift"
 	_ "github.com/portworx/torpedo/drivers/scheduler/rke"
 	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/drivers/workload"
+	"github.com/portworx/torpedo/pkg/crashutils"
+	"github.com/portworx/torpedo/pkg/ioprobe"
 
 	// import portworx driver to invoke it's init
 	_ "github.com/portworx/torpedo/drivers/volume/portworx"
@@ -146,6 +154,9 @@ const (
 	enableStorkUpgradeFlag               = "enable-stork-upgrade"
 	autopilotUpgradeImageCliFlag         = "autopilot-upgrade-version"
 	csiGenericDriverConfigMapFlag        = "csi-generic-driver-config-map"
+	validateZoneTopologyFlag             = "validate-zone-topology"
+	workloadMaxStallFlag                 = "workload-max-stall"
+	ioStallSLAFlag                       = "io-stall-sla"
 	licenseExpiryTimeoutHoursFlag        = "license_expiry_timeout_hours"
 	meteringIntervalMinsFlag             = "metering_interval_mins"
 	sourceClusterName                    = "source-cluster"
@@ -184,6 +195,9 @@ const (
 	defaultClusterPairDir  = "cluster-pair"
 
 	envSkipDiagCollection = "SKIP_DIAG_COLLECTION"
+	// diagBundleTimeout is how long diags requested asynchronously may take to be written
+	diagBundleTimeout       = 15 * time.Minute
+	diagBundleRetryInterval = 30 * time.Second
 
 	torpedoJobNameFlag = "torpedo-job-name"
 	torpedoJobTypeFlag = "torpedo-job-type"
@@ -457,6 +471,249 @@ func ValidateCleanup() {
 	})
 }
 
+// ValidateZoneTopology validates that volumes, their replicas and the pods using them
+// are placed in the zones allowed by the storage class and PV node affinity
+func ValidateZoneTopology(ctx *scheduler.Context, errChan ...*chan error) {
+	report, err := Inst().S.ValidateZoneTopology(ctx)
+	if report != nil {
+		for _, volTopology := range report.Volumes {
+			for _, replica := range volTopology.Replicas {
+				log.Infof("Volume [%s] replica set [%d] is on node [%s] in zone [%s]",
+					volTopology.Volume.Name, replica.ReplicaSetIndex, replica.NodeName, replica.Zone)
+			}
+		}
+	}
+	if err != nil {
+		processError(err, errChan...)
+	}
+}
+
+// ValidateVolumePlacementStrategy validates that the replicas of the app's volumes are placed
+// as required by the volume placement strategy of their storage class
+func ValidateVolumePlacementStrategy(ctx *scheduler.Context, errChan ...*chan error) {
+	report, err := Inst().S.ValidateVolumePlacementStrategy(ctx)
+	if report != nil {
+		for _, volPlacement := range report.Volumes {
+			for _, replica := range volPlacement.Replicas {
+				log.Infof("Volume [%s] with placement strategy [%s] replica set [%d] is on node [%s] pool [%s]",
+					volPlacement.Volume.Name, volPlacement.Strategy, replica.ReplicaSetIndex, replica.NodeName, replica.PoolUUID)
+			}
+		}
+	}
+	if err != nil {
+		processError(err, errChan...)
+	}
+}
+
+// hasVolumePlacementStrategy returns true if the app specs of the context include a volume placement strategy
+func hasVolumePlacementStrategy(ctx *scheduler.Context) bool {
+	for _, spec := range ctx.App.SpecList {
+		if _, ok := spec.(*talismanv1beta2.VolumePlacementStrategy); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// StartWorkloads starts measuring the application workloads (fio, sysbench, pgbench ...) running
+// in the given contexts and returns the started workload drivers of every context. Workloads are
+// only measured when a maximum workload stall is set
+func StartWorkloads(contexts []*scheduler.Context) (map[*scheduler.Context][]workload.Driver, []error) {
+	var errs []error
+	started := make(map[*scheduler.Context][]workload.Driver)
+	if Inst().WorkloadMaxStall == 0 {
+		return started, nil
+	}
+	for _, ctx := range contexts {
+		for _, w := range workload.GetForContext(ctx) {
+			if err := w.Start(ctx); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			started[ctx] = append(started[ctx], w)
+		}
+	}
+	return started, errs
+}
+
+// VerifyWorkloads stops measuring the given workloads and verifies that they kept doing IO
+// without stalling longer than the configured maximum stall
+func VerifyWorkloads(started map[*scheduler.Context][]workload.Driver) []error {
+	var errs []error
+	for ctx, workloads := range started {
+		for _, w := range workloads {
+			if err := w.Stop(ctx); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := w.Verify(ctx, workload.VerifyOptions{MaxStall: Inst().WorkloadMaxStall}); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// StartIOProbes starts an IO probe for every volume of the given contexts which is mounted in a running pod.
+// The probes periodically write and fsync timestamps to the volumes so IO stalls can be measured
+func StartIOProbes(contexts []*scheduler.Context) ([]*ioprobe.Probe, []error) {
+	var errs []error
+	var probes []*ioprobe.Probe
+	for _, ctx := range contexts {
+		vols, err := Inst().S.GetVolumes(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, vol := range vols {
+			probe, err := ioprobe.NewProbeForPVC(fmt.Sprintf("%s-%s", vol.Namespace, vol.Name), vol.Name, vol.Namespace)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if probe == nil {
+				log.Infof("Skipping IO probe for volume [%s/%s] as it is not mounted in a running pod", vol.Namespace, vol.Name)
+				continue
+			}
+			if err := probe.Start(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			probes = append(probes, probe)
+		}
+	}
+	return probes, errs
+}
+
+// StopIOProbes stops the given IO probes, records the maximum IO stall of every volume on the event and
+// fails the event for volumes which stalled longer than the configured IO stall SLA or whose probe was lost
+func StopIOProbes(event *EventRecord, probes []*ioprobe.Probe) {
+	for _, probe := range probes {
+		result, err := probe.Stop()
+		if err != nil {
+			UpdateOutcome(event, err)
+			continue
+		}
+		if result.Lost {
+			UpdateOutcome(event, fmt.Errorf("IO probe of volume [%s] was lost during %s as its pod was restarted and "+
+				"no pod was running with the volume again, so no IO stall was measured", result.Volume, event.Event.Type))
+			continue
+		}
+		if event.IOStalls == nil {
+			event.IOStalls = make(IOStalls)
+		}
+		event.IOStalls[result.Volume] = result.MaxStall
+		if Inst().IOStallSLA > 0 && result.MaxStall > Inst().IOStallSLA {
+			UpdateOutcome(event, fmt.Errorf("IO to volume [%s] stalled for %v during %s which is longer than the SLA of %v",
+				result.Volume, result.MaxStall, event.Event.Type, Inst().IOStallSLA))
+		}
+	}
+}
+
+// RequiredCapabilities are the capabilities an operation needs from each of the drivers
+type RequiredCapabilities struct {
+	// Volume are the capabilities needed from the volume driver
+	Volume []driver_api.Capability
+	// Node are the capabilities needed from the node driver
+	Node []driver_api.Capability
+	// Scheduler are the capabilities needed from the scheduler driver
+	Scheduler []driver_api.Capability
+}
+
+// UnsupportedReason returns why the configured drivers cannot perform an operation needing the
+// required capabilities, or an empty string if all of them are supported
+func (r RequiredCapabilities) UnsupportedReason() string {
+	var reasons []string
+	if missing := Inst().V.Capabilities().Missing(r.Volume...); len(missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("volume driver [%s] does not support %v", Inst().V.String(), missing))
+	}
+	if missing := Inst().N.Capabilities().Missing(r.Node...); len(missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("node driver [%s] does not support %v", Inst().N.String(), missing))
+	}
+	if missing := Inst().S.Capabilities().Missing(r.Scheduler...); len(missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("scheduler [%s] does not support %v", Inst().S.String(), missing))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// SkipIfNotCapable skips the running test if the configured drivers lack any of the required capabilities
+func SkipIfNotCapable(required RequiredCapabilities) {
+	if reason := required.UnsupportedReason(); len(reason) > 0 {
+		log.Warnf("Skipping test: %s", reason)
+		ginkgo.Skip(reason)
+	}
+}
+
+// WriteVolumeMarkers writes a marker to every volume of the given context which is mounted in a running pod and
+// returns the written markers. VerifyVolumeMarkers checks them after disruptions and RemoveVolumeMarkers
+// deletes them once they are no longer needed
+func WriteVolumeMarkers(ctx *scheduler.Context) ([]*ioprobe.Marker, error) {
+	vols, err := Inst().S.GetVolumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var markers []*ioprobe.Marker
+	for _, vol := range vols {
+		marker, err := ioprobe.WriteMarkerForPVC(vol.ID, vol.Name, vol.Namespace)
+		if err != nil {
+			RemoveVolumeMarkers(markers)
+			return nil, err
+		}
+		if marker == nil {
+			log.Infof("Skipping marker for volume [%s/%s] as it is not mounted in a running pod", vol.Namespace, vol.Name)
+			continue
+		}
+		markers = append(markers, marker)
+	}
+	return markers, nil
+}
+
+// VerifyVolumeMarkers verifies that the markers written by WriteVolumeMarkers are still present on the volumes,
+// as seen by the pods currently using them
+func VerifyVolumeMarkers(markers []*ioprobe.Marker) []error {
+	var errs []error
+	for _, marker := range markers {
+		if err := marker.Verify(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// VerifyVolumeMarkersOnUpdatedPods verifies the markers written by WriteVolumeMarkers as seen by the pods
+// which run the update revision of their StatefulSet, e.g. after the first phase of a partitioned rollout.
+// Markers of volumes used by no updated pod are skipped, but at least one marker must be verified.
+func VerifyVolumeMarkersOnUpdatedPods(markers []*ioprobe.Marker) []error {
+	var errs []error
+	verified := 0
+	for _, marker := range markers {
+		updated, err := marker.VerifyUpdated()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if !updated {
+			log.Infof("Skipping marker of volume [%s] as no updated pod uses it", marker.Volume)
+			continue
+		}
+		verified++
+	}
+	if len(markers) > 0 && verified == 0 && len(errs) == 0 {
+		errs = append(errs, fmt.Errorf("no updated pod uses any of the %d volumes with a marker", len(markers)))
+	}
+	return errs
+}
+
+// RemoveVolumeMarkers removes the markers written by WriteVolumeMarkers from the volumes
+func RemoveVolumeMarkers(markers []*ioprobe.Marker) []error {
+	var errs []error
+	for _, marker := range markers {
+		if err := marker.Remove(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
 func processError(err error, errChan ...*chan error) {
 	// if errChan is provided then just push err to on channel
 	// Useful for frameworks like longevity that must continue
@@ -523,6 +780,15 @@ func ValidateContext(ctx *scheduler.Context, errChan ...*chan error) {
 				}
 			})
 		}
+
+		// Validating zone topology of volumes, replicas and pods if requested
+		if Inst().ValidateZoneTopology {
+			stepLog = fmt.Sprintf("validate zone topology for %s app", ctx.App.Key)
+			Step(stepLog, func() {
+				log.InfoD(stepLog)
+				ValidateZoneTopology(ctx, errChan...)
+			})
+		}
 		stepLog = fmt.Sprintf("validate if %s app's volumes are setup", ctx.App.Key)
 
 		Step(stepLog, func() {
@@ -549,6 +815,14 @@ func ValidateContext(ctx *scheduler.Context, errChan ...*chan error) {
 			}
 		})
 
+		if !ctx.SkipVolumeValidation && hasVolumePlacementStrategy(ctx) {
+			stepLog = fmt.Sprintf("validate volume placement strategy for %s app", ctx.App.Key)
+			Step(stepLog, func() {
+				log.InfoD(stepLog)
+				ValidateVolumePlacementStrategy(ctx, errChan...)
+			})
+		}
+
 		Step("Validate Px pod restart count", func() {
 			ValidatePxPodRestartCount(ctx, errChan...)
 		})
@@ -557,97 +831,30 @@ func ValidateContext(ctx *scheduler.Context, errChan ...*chan error) {
 
 // ValidateContextForPureVolumesSDK is the ginkgo spec for validating a scheduled context
 func ValidateContextForPureVolumesSDK(ctx *scheduler.Context, errChan ...*chan error) {
-	defer func() {
-		if len(errChan) > 0 {
-			close(*errChan[0])
-		}
-	}()
-	ginkgo.Describe(fmt.Sprintf("For validation of %s app", ctx.App.Key), func() {
-		var timeout time.Duration
-		appScaleFactor := time.Duration(Inst().GlobalScaleFactor)
-		if ctx.ReadinessTimeout == time.Duration(0) {
-			timeout = appScaleFactor * defaultTimeout
-		} else {
-			timeout = appScaleFactor * ctx.ReadinessTimeout
-		}
-		Step(fmt.Sprintf("validate %s app's volumes", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidatePureSnapshotsSDK(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s app's volumes resizing ", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidateResizePurePVC(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("wait for %s app to start running", ctx.App.Key), func() {
-			err := Inst().S.WaitForRunning(ctx, timeout, defaultRetryInterval)
-			processError(err, errChan...)
-		})
-
-		Step(fmt.Sprintf("validate %s app's volumes statistics ", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidatePureVolumeStatisticsDynamicUpdate(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s app's pure volumes has no replicaset", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidatePureVolumeNoReplicaSet(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s app's pure volumes cloning", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidateCSIVolumeClone(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s app's pure volumes snapshot and restore", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidateCSISnapshotAndRestore(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s app's pure volume snapshot and restoring to many volumes", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidatePureVolumeLargeNumOfClones(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s px pool expansion when pure volumes attached", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidatePoolExpansionWithPureVolumes(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate if %s app's volumes are setup", ctx.App.Key), func() {
-			if ctx.SkipVolumeValidation {
-				return
-			}
-
-			vols, err := Inst().S.GetVolumes(ctx)
-			processError(err, errChan...)
-
-			for _, vol := range vols {
-				Step(fmt.Sprintf("validate if %s app's volume: %v is setup", ctx.App.Key, vol), func() {
-					err := Inst().V.ValidateVolumeSetup(vol)
-					processError(err, errChan...)
-				})
-			}
-		})
-	})
+	validateContextForPureVolumes(ctx, volume.QueryModeSDK, errChan...)
 }
 
-// ValidateContextForPureVolumesPXCTL is the ginkgo spec for validating a scheduled context
+// ValidateContextForPureVolumesPXCTL is the ginkgo spec for validating a scheduled context using pxctl
 func ValidateContextForPureVolumesPXCTL(ctx *scheduler.Context, errChan ...*chan error) {
+	validateContextForPureVolumes(ctx, volume.QueryModePxctl, errChan...)
+}
+
+// validateContextForPureVolumes validates a scheduled context with pure volumes. Only the volume and snapshot
+// validation differs between query modes, the remaining steps are the same.
+func validateContextForPureVolumes(ctx *scheduler.Context, mode volume.QueryMode, errChan ...*chan error) {
 	defer func() {
 		if len(errChan) > 0 {
 			close(*errChan[0])
 		}
 	}()
+	if prevMode := Inst().V.GetQueryMode(); prevMode != mode {
+		err := Inst().V.SetQueryMode(mode)
+		processError(err, errChan...)
+		defer func() {
+			err := Inst().V.SetQueryMode(prevMode)
+			processError(err, errChan...)
+		}()
+	}
 	ginkgo.Describe(fmt.Sprintf("For validation of %s app", ctx.App.Key), func() {
 		var timeout time.Duration
 		appScaleFactor := time.Duration(Inst().GlobalScaleFactor)
@@ -656,16 +863,17 @@ func ValidateContextForPureVolumesPXCTL(ctx *scheduler.Context, errChan ...*chan
 		} else {
 			timeout = appScaleFactor * ctx.ReadinessTimeout
 		}
+		if mode == volume.QueryModePxctl {
+			Step(fmt.Sprintf("validate %s app's volumes for pxctl", ctx.App.Key), func() {
+				if !ctx.SkipVolumeValidation {
+					ValidatePureVolumesPXCTL(ctx, errChan...)
+				}
+			})
+		}
 
-		Step(fmt.Sprintf("validate %s app's volumes for pxctl", ctx.App.Key), func() {
-			if !ctx.SkipVolumeValidation {
-				ValidatePureVolumesPXCTL(ctx, errChan...)
-			}
-		})
-
-		Step(fmt.Sprintf("validate %s app's snapshots for pxctl", ctx.App.Key), func() {
+		Step(fmt.Sprintf("validate %s app's volumes", ctx.App.Key), func() {
 			if !ctx.SkipVolumeValidation {
-				ValidatePureSnapshotsPXCTL(ctx, errChan...)
+				ValidatePureSnapshots(ctx, errChan...)
 			}
 		})
 
@@ -788,8 +996,9 @@ func ValidateVolumes(ctx *scheduler.Context, errChan ...*chan error) {
 	})
 }
 
-// ValidatePureSnapshotsSDK is the ginkgo spec for validating Pure direct access volume snapshots using API for a context
-func ValidatePureSnapshotsSDK(ctx *scheduler.Context, errChan ...*chan error) {
+// ValidatePureSnapshots is the ginkgo spec for validating Pure direct access volume snapshots for a context. The
+// snapshots are created through the query mode of the volume driver.
+func ValidatePureSnapshots(ctx *scheduler.Context, errChan ...*chan error) {
 	context("For validation of an app's volumes", func() {
 		var err error
 		Step(fmt.Sprintf("inspect %s app's volumes", ctx.App.Key), func() {
@@ -835,6 +1044,15 @@ func ValidatePureSnapshotsSDK(ctx *scheduler.Context, errChan ...*chan error) {
 				}
 			})
 		}
+		if Inst().V.GetQueryMode() == volume.QueryModePxctl {
+			Step("validating groupsnap for using pxctl", func() {
+				err = Inst().V.ValidateCreateGroupSnapshotUsingPxctl()
+				expect(err).NotTo(beNil(), "error expected but no error received while creating Pure groupsnap")
+				if err != nil {
+					expect(err.Error()).To(contain(errPureGroupsnapNotSupported.Error()), "incorrect error received creating Pure groupsnap")
+				}
+			})
+		}
 	})
 }
 
@@ -863,48 +1081,6 @@ func ValidatePureVolumesPXCTL(ctx *scheduler.Context, errChan ...*chan error) {
 	})
 }
 
-// ValidatePureSnapshotsPXCTL is the ginkgo spec for validating FADA volume snapshots using PXCTL for a context
-func ValidatePureSnapshotsPXCTL(ctx *scheduler.Context, errChan ...*chan error) {
-	context("For validation of an app's volumes", func() {
-		var (
-			err  error
-			vols map[string]map[string]string
-		)
-		Step(fmt.Sprintf("get %s app's volume's custom parameters", ctx.App.Key), func() {
-			vols, err = Inst().S.GetVolumeParameters(ctx)
-			processError(err, errChan...)
-		})
-
-		for vol, params := range vols {
-			Step(fmt.Sprintf("get %s app's volume: %s then create snapshot using pxctl", ctx.App.Key, vol), func() {
-				err = Inst().V.ValidateCreateSnapshotUsingPxctl(vol)
-				if params["backend"] == k8s.PureBlock {
-					expect(err).To(beNil(), "unexpected error creating pure_block snapshot")
-				} else if params["backend"] == k8s.PureFile {
-					expect(err).NotTo(beNil(), "error expected but no error received while creating pure_file snapshot")
-					if err != nil {
-						expect(err.Error()).To(contain(errPureFileSnapshotNotSupported.Error()), "incorrect error received creating pure_file snapshot")
-					}
-				}
-			})
-			Step(fmt.Sprintf("get %s app's volume: %s then create cloudsnap using pxctl", ctx.App.Key, vol), func() {
-				err = Inst().V.ValidateCreateCloudsnapUsingPxctl(vol)
-				expect(err).NotTo(beNil(), "error expected but no error received while creating Pure cloudsnap")
-				if err != nil {
-					expect(err.Error()).To(contain(errPureCloudsnapNotSupported.Error()), "incorrect error received creating Pure cloudsnap")
-				}
-			})
-		}
-		Step("validating groupsnap for using pxctl", func() {
-			err = Inst().V.ValidateCreateGroupSnapshotUsingPxctl()
-			expect(err).NotTo(beNil(), "error expected but no error received while creating Pure groupsnap")
-			if err != nil {
-				expect(err.Error()).To(contain(errPureGroupsnapNotSupported.Error()), "incorrect error received creating Pure groupsnap")
-			}
-		})
-	})
-}
-
 // ValidateResizePurePVC is the ginkgo spec for validating resize of volumes
 func ValidateResizePurePVC(ctx *scheduler.Context, errChan ...*chan error) {
 	context("For validation of an resizing pvc", func() {
@@ -1752,10 +1928,13 @@ func CollectSupport() {
 		nodes := node.GetWorkerNodes()
 		dash.VerifyFatal(len(nodes) > 0, true, "Worker nodes found ?")
 
+		var storageNodes []node.Node
+		diagFiles := make(map[string]string)
 		for _, n := range nodes {
 			if !n.IsStorageDriverInstalled {
 				continue
 			}
+			storageNodes = append(storageNodes, n)
 			Step(fmt.Sprintf("save all useful logs on node %s", n.SchedulerNodeName), func() {
 				log.Infof("save all useful logs on node %s", n.SchedulerNodeName)
 
@@ -1774,25 +1953,41 @@ func CollectSupport() {
 				}
 
 				Inst().V.CollectDiags(n, r, volume.DiagOps{})
-
-				journalCmd := fmt.Sprintf("journalctl -l > %s/all_journal_%v.log", Inst().BundleLocation, time.Now().Format(time.RFC3339))
-				runCmd(journalCmd, n)
-
-				runCmd(fmt.Sprintf("journalctl -lu portworx* > %s/portworx.log", Inst().BundleLocation), n)
+				diagFiles[n.Name] = r.OutputFile
 
 				Inst().S.SaveSchedulerLogsToFile(n, Inst().BundleLocation)
+			})
+		}
 
-				runCmd(fmt.Sprintf("dmesg -T > %s/dmesg.log", Inst().BundleLocation), n)
-
-				runCmd(fmt.Sprintf("lsblk > %s/lsblk.log", Inst().BundleLocation), n)
-
-				runCmd(fmt.Sprintf("cat /proc/mounts > %s/mounts.log", Inst().BundleLocation), n)
+		journalFile := fmt.Sprintf("all_journal_%v.log", time.Now().Format(time.RFC3339))
+		logFiles := []string{journalFile, "portworx.log", "dmesg.log", "lsblk.log", "mounts.log"}
 
+		Step(fmt.Sprintf("save system logs on %d nodes", len(storageNodes)), func() {
+			log.Infof("save system logs on %d nodes", len(storageNodes))
+			cmds := []string{
+				fmt.Sprintf("journalctl -l > %s/%s", Inst().BundleLocation, journalFile),
+				fmt.Sprintf("journalctl -lu portworx* > %s/portworx.log", Inst().BundleLocation),
+				fmt.Sprintf("dmesg -T > %s/dmesg.log", Inst().BundleLocation),
+				fmt.Sprintf("lsblk > %s/lsblk.log", Inst().BundleLocation),
+				fmt.Sprintf("cat /proc/mounts > %s/mounts.log", Inst().BundleLocation),
 				// this is a small tweak especially for providers like openshift, aws where oci-mon saves this file
 				// with root read permissions only but collect support bundle is a non-root user
-				runCmd(fmt.Sprintf("chmod 755 %s/oci.log", Inst().BundleLocation), n)
-			})
-		}
+				fmt.Sprintf("chmod 755 %s/oci.log", Inst().BundleLocation),
+			}
+			for _, cmd := range cmds {
+				runCmdOnNodes(cmd, storageNodes)
+			}
+		})
+
+		Step(fmt.Sprintf("copy support bundle of %d nodes to %s", len(storageNodes), Inst().LogLoc), func() {
+			log.Infof("copy support bundle of %d nodes to %s", len(storageNodes), Inst().LogLoc)
+			for _, n := range storageNodes {
+				copyFromNode(n, diagFiles[n.Name], "support")
+				for _, logFile := range logFiles {
+					copyFromNode(n, path.Join(Inst().BundleLocation, logFile), "support")
+				}
+			}
+		})
 	})
 }
 
@@ -1810,6 +2005,39 @@ func runCmd(cmd string, n node.Node) error {
 
 }
 
+// copyFromNode copies the file of the node to the directory of the node under the dir of the log location
+func copyFromNode(n node.Node, file, dir string) (*node.CopiedFile, error) {
+	localPath := path.Join(Inst().LogLoc, dir, n.Name, path.Base(file))
+	copied, err := Inst().N.CopyFromNode(n, file, localPath, node.CopyOpts{
+		ConnectionOpts: node.ConnectionOpts{
+			Timeout:         defaultCmdTimeout,
+			TimeBeforeRetry: defaultCmdRetryInterval,
+			Sudo:            true,
+		},
+	})
+	if err != nil {
+		log.Warnf("failed to copy %s of node %s. err: %v", file, n.Name, err)
+	}
+
+	return copied, err
+}
+
+// runCmdOnNodes runs the command on the nodes in parallel and logs the nodes it failed on
+func runCmdOnNodes(cmd string, nodes []node.Node) error {
+	_, err := Inst().N.RunCommandOnNodes(nodes, cmd, node.ParallelCommandOpts{
+		ConnectionOpts: node.ConnectionOpts{
+			Timeout:         defaultCmdTimeout,
+			TimeBeforeRetry: defaultCmdRetryInterval,
+			Sudo:            true,
+		},
+	})
+	if err != nil {
+		log.Warnf("failed to run cmd: %s. err: %v", cmd, err)
+	}
+
+	return err
+}
+
 func runCmdWithNoSudo(cmd string, n node.Node) error {
 	_, err := Inst().N.RunCommand(n, cmd, node.ConnectionOpts{
 		Timeout:         defaultCmdTimeout,
@@ -1844,6 +2072,9 @@ func PerformSystemCheck() {
 				if len(file) != 0 || err != nil {
 					log.Errorf("an error occurred, collecting bundle")
 					CollectSupport()
+					for _, crash := range systemCheckCrashes.collect(n, file) {
+						log.Errorf("Crash %s: %v. Stack: %s", crash.Signature(), crash, crash.Link)
+					}
 					dash.VerifySafely(file == "", true, fmt.Sprintf("Core generated on node %s, Core Path: %s", n.Name, file))
 				}
 				log.FailOnError(err, "Error occurred while checking for core on node %s", n.Name)
@@ -1852,6 +2083,163 @@ func PerformSystemCheck() {
 	})
 }
 
+const (
+	// maxCoreCopySize is the size of the largest core file copied off a node
+	maxCoreCopySize = 8 << 30
+	// pxRootfs is where the binaries of the PX container are on the host
+	pxRootfs = "/opt/pwx/oci/rootfs"
+	// panicLogsLookback is how far back the PX logs of a node are checked for panics the first time
+	panicLogsLookback = time.Hour
+)
+
+// coreChecksumOpts are the options of the checksum of a core file, which reads all of it
+var coreChecksumOpts = node.ConnectionOpts{
+	Timeout:         5 * time.Minute,
+	TimeBeforeRetry: defaultCmdRetryInterval,
+}
+
+// crashCollector keeps the crashes, core files and PX logs of the nodes seen so far
+type crashCollector struct {
+	sync.Mutex
+	// registry keeps the crashes seen so far by signature
+	registry *crashutils.Registry
+	// collectedCores are the core files already collected, by node and path
+	collectedCores map[string]bool
+	// lastPanicCheck is when the PX logs of each node were last checked for panics
+	lastPanicCheck map[string]time.Time
+}
+
+func newCrashCollector() *crashCollector {
+	return &crashCollector{
+		registry:       crashutils.NewRegistry(),
+		collectedCores: make(map[string]bool),
+		lastPanicCheck: make(map[string]time.Time),
+	}
+}
+
+var (
+	// reportedCrashes are the crashes collected to be reported in event records and JIRA issues
+	reportedCrashes = newCrashCollector()
+	// systemCheckCrashes are the crashes collected by the system checks, which only log them. They are kept apart so
+	// that the crashes a system check saw are still reported when collected to be reported.
+	systemCheckCrashes = newCrashCollector()
+)
+
+// CollectCrashes collects the crashes of the new core files of the node and the go panics in its PX logs since the
+// last check. Crashes are deduped by signature. The first crash with a signature has its core file copied off the
+// node, or its stack saved, to the log location, and is returned.
+func CollectCrashes(n node.Node, coreFiles string) []*crashutils.Crash {
+	return reportedCrashes.collect(n, coreFiles)
+}
+
+// collect collects the new crashes of the node as described in CollectCrashes
+func (c *crashCollector) collect(n node.Node, coreFiles string) []*crashutils.Crash {
+	c.Lock()
+	defer c.Unlock()
+
+	opts := node.ConnectionOpts{
+		Timeout:         defaultCmdTimeout,
+		TimeBeforeRetry: defaultCmdRetryInterval,
+	}
+	pxVersion, err := Inst().V.GetPxVersionOnNode(n)
+	if err != nil {
+		log.Warnf("failed to get PX version on node %s. Err: %v", n.Name, err)
+	}
+
+	var crashes []*crashutils.Crash
+	for _, coreFile := range strings.Fields(coreFiles) {
+		key := fmt.Sprintf("%s:%s", n.Name, coreFile)
+		if c.collectedCores[key] {
+			continue
+		}
+		c.collectedCores[key] = true
+
+		crash := &crashutils.Crash{Node: n.Name, CorePath: coreFile, Reason: "core dumped"}
+		if out, err := Inst().N.RunCommand(n, fmt.Sprintf("file %s", coreFile), opts); err == nil {
+			crash.Binary = crashutils.ParseCoreBinary(out)
+		}
+		if strings.Contains(path.Base(crash.Binary), "px") {
+			crash.Version = pxVersion
+		}
+		if len(crash.Binary) > 0 {
+			// the binaries of PX are in the rootfs of its container, and gdb may not be installed at all
+			gdbCmd := fmt.Sprintf("bin=%s; [ -f $bin ] || bin=%s$bin; which gdb > /dev/null && gdb -batch -ex bt $bin %s 2>/dev/null",
+				crash.Binary, pxRootfs, coreFile)
+			if out, err := Inst().N.RunCommand(n, gdbCmd, opts); err == nil {
+				crash.Stack = out
+				if reason, frames := crashutils.ParseBacktrace(out); len(frames) > 0 {
+					crash.Reason, crash.Frames = reason, frames
+				}
+			}
+		}
+		if len(crash.Frames) == 0 {
+			// without a backtrace the core can only be told apart from other cores by its contents
+			if out, err := Inst().N.RunCommand(n, fmt.Sprintf("sudo sha256sum %s", coreFile), coreChecksumOpts); err == nil {
+				if fields := strings.Fields(out); len(fields) > 0 {
+					crash.Checksum = fields[0]
+				}
+			} else {
+				log.Warnf("failed to get checksum of core %s of node %s. Err: %v", coreFile, n.Name, err)
+			}
+		}
+		if !c.registry.Add(crash) {
+			log.Infof("Core %s on node %s is a duplicate of crash %s", coreFile, n.Name, crash.Signature())
+			continue
+		}
+		copied, err := Inst().N.CopyFromNode(n, coreFile, path.Join(Inst().LogLoc, "cores", n.Name, path.Base(coreFile)), node.CopyOpts{
+			ConnectionOpts: opts,
+			MaxSize:        maxCoreCopySize,
+		})
+		if err != nil {
+			log.Warnf("failed to copy core %s of node %s. Err: %v", coreFile, n.Name, err)
+		} else {
+			crash.LocalPath = copied.LocalPath
+			crash.Link = getArtifactLink(copied.LocalPath)
+		}
+		crashes = append(crashes, crash)
+	}
+
+	since, ok := c.lastPanicCheck[n.Name]
+	if !ok {
+		since = time.Now().Add(-panicLogsLookback)
+	}
+	logs, err := Inst().N.RunCommand(n, fmt.Sprintf("journalctl -lu portworx* --no-pager --since @%d", since.Unix()), opts)
+	if err != nil {
+		log.Warnf("failed to get PX logs of node %s. Err: %v", n.Name, err)
+		return crashes
+	}
+	c.lastPanicCheck[n.Name] = time.Now()
+	for _, crash := range crashutils.ParseGoPanics(logs) {
+		crash.Node, crash.Binary, crash.Version = n.Name, "px", pxVersion
+		if !c.registry.Add(crash) {
+			log.Infof("Panic on node %s is a duplicate of crash %s", n.Name, crash.Signature())
+			continue
+		}
+		localPath := path.Join(Inst().LogLoc, "cores", n.Name, fmt.Sprintf("panic-%s.log", crash.Signature()))
+		if err := os.MkdirAll(path.Dir(localPath), 0755); err == nil {
+			err = ioutil.WriteFile(localPath, []byte(crash.Stack), 0644)
+		}
+		if err != nil {
+			log.Warnf("failed to save stack of crash %s. Err: %v", crash.Signature(), err)
+		} else {
+			crash.LocalPath = localPath
+			crash.Link = getArtifactLink(localPath)
+		}
+		crashes = append(crashes, crash)
+	}
+	return crashes
+}
+
+// getArtifactLink returns the link to the file in the log location, which is archived with the artifacts of the
+// build when running in jenkins
+func getArtifactLink(localPath string) string {
+	if buildURL := os.Getenv("BUILD_URL"); len(buildURL) > 0 {
+		return fmt.Sprintf("%s/artifact/%s", strings.TrimSuffix(buildURL, "/"),
+			strings.TrimPrefix(strings.TrimPrefix(localPath, Inst().LogLoc), "/"))
+	}
+	return "file://" + localPath
+}
+
 // ChangeNamespaces updates the namespace in supplied in-memory contexts.
 // It does not apply changes on scheduler
 func ChangeNamespaces(contexts []*scheduler.Context,
@@ -3916,6 +4304,9 @@ type Torpedo struct {
 	JobName                             string
 	JobType                             string
 	PortworxPodRestartCheck             bool
+	ValidateZoneTopology                bool
+	WorkloadMaxStall                    time.Duration
+	IOStallSLA                          time.Duration
 }
 
 // ParseFlags parses command line flags
@@ -3944,6 +4335,9 @@ func ParseFlags() {
 	var hyperConverged bool
 	var enableDash bool
 	var pxPodRestartCheck bool
+	var validateZoneTopology bool
+	var workloadMaxStall time.Duration
+	var ioStallSLA time.Duration
 
 	// TODO: We rely on the customAppConfig map to be passed into k8s.go and stored there.
 	// We modify this map from the tests and expect that the next RescanSpecs will pick up the new custom configs.
@@ -3993,6 +4387,8 @@ func ParseFlags() {
 	flag.DurationVar(&driverStartTimeout, "driver-start-timeout", defaultDriverStartTimeout, "Maximum wait volume driver startup")
 	flag.DurationVar(&autoStorageNodeRecoveryTimeout, "storagenode-recovery-timeout", defaultAutoStorageNodeRecoveryTimeout, "Maximum wait time in minutes for storageless nodes to transition to storagenodes in case of ASG")
 	flag.DurationVar(&licenseExpiryTimeoutHours, licenseExpiryTimeoutHoursFlag, defaultLicenseExpiryTimeoutHours, "Maximum wait time in hours after which force expire license")
+	flag.DurationVar(&workloadMaxStall, workloadMaxStallFlag, 0, "Maximum time app workloads are allowed to stall IO during disruptive triggers, 0 disables the check")
+	flag.DurationVar(&ioStallSLA, ioStallSLAFlag, 0, "Maximum time app IO to a volume is allowed to stall during disruptive triggers, 0 only records the stall")
 	flag.DurationVar(&meteringIntervalMins, meteringIntervalMinsFlag, defaultMeteringIntervalMins, "Metering interval in minutes for metering agent")
 	flag.StringVar(&configMapName, configMapFlag, "", "Name of the config map to be used.")
 	flag.StringVar(&bundleLocation, "bundle-location", defaultBundleLocation, "Path to support bundle output files")
@@ -4027,6 +4423,7 @@ func ParseFlags() {
 	flag.StringVar(&testProduct, testProductFlag, "PxEnp", "Portworx product under test")
 	flag.StringVar(&pxRuntimeOpts, "px-runtime-opts", "", "comma separated list of run time options for cluster update")
 	flag.BoolVar(&pxPodRestartCheck, failOnPxPodRestartCount, false, "Set it true for px pods restart check during test")
+	flag.BoolVar(&validateZoneTopology, validateZoneTopologyFlag, false, "Set it true to validate volume, replica and pod placement against k8s zone topology")
 	flag.Parse()
 
 	log.SetLoglevel(logLevel)
@@ -4179,6 +4576,9 @@ func ParseFlags() {
 				JobName:                             torpedoJobName,
 				JobType:                             torpedoJobType,
 				PortworxPodRestartCheck:             pxPodRestartCheck,
+				ValidateZoneTopology:                validateZoneTopology,
+				WorkloadMaxStall:                    workloadMaxStall,
+				IOStallSLA:                          ioStallSLA,
 			}
 		})
 	}
@@ -4317,46 +4717,124 @@ func CreateJiraIssueWithLogs(issueDescription, issueSummary string) {
 
 func collectAndCopyDiagsOnWorkerNodes(issueKey string) {
 	isIssueDirCreated := false
-	for _, currNode := range node.GetWorkerNodes() {
-		err := runCmd("pwd", currNode)
+	var reachableNodes []node.Node
+	results, _ := Inst().N.RunCommandOnNodes(node.GetWorkerNodes(), "pwd", node.ParallelCommandOpts{
+		ConnectionOpts: node.ConnectionOpts{
+			Timeout:         defaultCmdTimeout,
+			TimeBeforeRetry: defaultCmdRetryInterval,
+			Sudo:            true,
+		},
+	})
+	for _, r := range results {
+		if r.Err == nil {
+			reachableNodes = append(reachableNodes, r.Node)
+		} else {
+			log.Warnf("Skipping diags of unreachable node %v. Err: %v", r.Node.Name, r.Err)
+		}
+	}
+	log.Infof("Creating directors logs in %d nodes", len(reachableNodes))
+	runCmdOnNodes(fmt.Sprintf("mkdir -p %v", rootLogDir), reachableNodes)
+	log.Info("Mounting nfs diags directory")
+	runCmdOnNodes(fmt.Sprintf("mount -t nfs %v %v", diagsDirPath, rootLogDir), reachableNodes)
+
+	for _, currNode := range reachableNodes {
+		if !isIssueDirCreated {
+			log.Infof("Creating PTX %v directory in the node %v", issueKey, currNode.Name)
+			runCmd(fmt.Sprintf("mkdir -p %v/%v", rootLogDir, issueKey), currNode)
+			isIssueDirCreated = true
+		}
+
+		log.Infof("collect diags on node: %s", currNode.Name)
+
+		filePath := fmt.Sprintf("/var/cores/%s-diags-*.tar.gz", currNode.Name)
+
+		config := &torpedovolume.DiagRequestConfig{
+			DockerHost:    "unix:///var/run/docker.sock",
+			OutputFile:    filePath,
+			ContainerName: "",
+			Profile:       false,
+			Live:          false,
+			Upload:        false,
+			All:           true,
+			Force:         true,
+			OnHost:        true,
+			Extra:         false,
+		}
+		// bundles of earlier diags are not copied again
+		existing, _ := listDiagBundles(currNode, filePath)
+		err := Inst().V.CollectDiags(currNode, config, torpedovolume.DiagOps{Validate: false, Async: true})
+
 		if err == nil {
-			log.Infof("Creating directors logs in the node %v", currNode.Name)
-			runCmd(fmt.Sprintf("mkdir -p %v", rootLogDir), currNode)
-			log.Info("Mounting nfs diags directory")
-			runCmd(fmt.Sprintf("mount -t nfs %v %v", diagsDirPath, rootLogDir), currNode)
-			if !isIssueDirCreated {
-				log.Infof("Creating PTX %v directory in the node %v", issueKey, currNode.Name)
-				runCmd(fmt.Sprintf("mkdir -p %v/%v", rootLogDir, issueKey), currNode)
-				isIssueDirCreated = true
+			diagFiles, err := waitForNewDiagBundles(currNode, filePath, existing)
+			if err != nil {
+				log.Warnf("Error waiting for diags on node: %v, Error: %v", currNode.Name, err)
+				continue
 			}
-
-			log.Infof("collect diags on node: %s", currNode.Name)
-
-			filePath := fmt.Sprintf("/var/cores/%s-diags-*.tar.gz", currNode.Name)
-
-			config := &torpedovolume.DiagRequestConfig{
-				DockerHost:    "unix:///var/run/docker.sock",
-				OutputFile:    filePath,
-				ContainerName: "",
-				Profile:       false,
-				Live:          false,
-				Upload:        false,
-				All:           true,
-				Force:         true,
-				OnHost:        true,
-				Extra:         false,
+			for _, diagFile := range diagFiles {
+				log.Infof("copying logs %v  on node: %s", diagFile, currNode.Name)
+				runCmd(fmt.Sprintf("cp %v %v/%v/", diagFile, rootLogDir, issueKey), currNode)
+				copyFromNode(currNode, diagFile, path.Join("diags", issueKey))
 			}
-			err = Inst().V.CollectDiags(currNode, config, torpedovolume.DiagOps{Validate: false, Async: true})
+		} else {
+			log.Warnf("Error collecting diags on node: %v, Error: %v", currNode.Name, err)
+		}
+	}
+}
 
-			if err == nil {
-				log.Infof("copying logs %v  on node: %s", filePath, currNode.Name)
-				runCmd(fmt.Sprintf("cp %v %v/%v/", filePath, rootLogDir, issueKey), currNode)
-			} else {
-				log.Warnf("Error collecting diags on node: %v, Error: %v", currNode.Name, err)
-			}
+// listDiagBundles returns the sizes of the diag bundles on the node matching the pattern by path
+func listDiagBundles(n node.Node, pattern string) (map[string]string, error) {
+	out, err := Inst().N.RunCommand(n, fmt.Sprintf("stat -c %%n:%%s %s", pattern), node.ConnectionOpts{
+		Timeout:         defaultCmdTimeout,
+		TimeBeforeRetry: defaultCmdRetryInterval,
+		Sudo:            true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bundles := make(map[string]string)
+	for _, line := range strings.Fields(out) {
+		if i := strings.LastIndex(line, ":"); i > 0 {
+			bundles[line[:i]] = line[i+1:]
+		}
+	}
+	return bundles, nil
+}
 
+// waitForNewDiagBundles waits for diag bundles matching the pattern which are not in existing to appear on the
+// node and to stop growing, as diags requested asynchronously are still being written, and returns them
+func waitForNewDiagBundles(n node.Node, pattern string, existing map[string]string) ([]string, error) {
+	var lastSizes map[string]string
+	t := func() (interface{}, bool, error) {
+		bundles, err := listDiagBundles(n, pattern)
+		if err != nil {
+			return nil, true, err
+		}
+		sizes := make(map[string]string)
+		for bundle, size := range bundles {
+			if _, ok := existing[bundle]; !ok {
+				sizes[bundle] = size
+			}
+		}
+		if len(sizes) == 0 {
+			return nil, true, fmt.Errorf("no new diags matching %s on node %s yet", pattern, n.Name)
 		}
+		growing := !reflect.DeepEqual(sizes, lastSizes)
+		lastSizes = sizes
+		if growing {
+			return nil, true, fmt.Errorf("diags %v on node %s are still being written", sizes, n.Name)
+		}
+		var newBundles []string
+		for bundle := range sizes {
+			newBundles = append(newBundles, bundle)
+		}
+		sort.Strings(newBundles)
+		return newBundles, false, nil
+	}
+	newBundles, err := task.DoRetryWithTimeout(t, diagBundleTimeout, diagBundleRetryInterval)
+	if err != nil {
+		return nil, err
 	}
+	return newBundles.([]string), nil
 }
 
 func collectAndCopyStorkLogs(issueKey string) {
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/reporters"
+	. "github.com/onsi/gomega"
+	"github.com/portworx/torpedo/drivers/volume/conformance"
+	"github.com/portworx/torpedo/pkg/aetosutil"
+	"github.com/portworx/torpedo/pkg/log"
+	. "github.com/portworx/torpedo/tests"
+)
+
+const (
+	// checksEnv limits the run to a comma separated list of checks, e.g. "snapshot,resize"
+	checksEnv = "CONFORMANCE_CHECKS"
+	// reportDir is where the JSON report of the run is written
+	reportDir            = "/testresults"
+	defaultTimeout       = 10 * time.Minute
+	defaultRetryInterval = 10 * time.Second
+)
+
+var dash *aetosutil.Dashboard
+
+// TestConformance qualifies the volume driver given by --storage-driver against the standard conformance matrix
+// using the apps given by --app-list, e.g. for LINSTOR:
+//
+//	ginkgo tests/conformance -- --storage-driver linstor --provisioner linstor.csi.linbit.com --app-list fio
+func TestConformance(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	var specReporters []Reporter
+	junitReporter := reporters.NewJUnitReporter("/testresults/junit_conformance.xml")
+	specReporters = append(specReporters, junitReporter)
+	RunSpecsWithDefaultAndCustomReporters(t, "Torpedo : Conformance", specReporters)
+}
+
+var _ = BeforeSuite(func() {
+	dash = Inst().Dash
+	log.Infof("Init instance")
+	InitInstance()
+	dash.TestSetBegin(dash.TestSet)
+})
+
+var _ = AfterSuite(func() {
+	defer dash.TestSetEnd()
+})
+
+var _ = Describe("{VolumeDriverConformance}", func() {
+	JustBeforeEach(func() {
+		StartTorpedoTest("VolumeDriverConformance", "Run the volume driver conformance matrix", nil, 0)
+	})
+
+	It("runs create, inspect, attach, snapshot, clone, resize, driver restart, node reboot, detach and delete", func() {
+		var checks []string
+		if value := os.Getenv(checksEnv); value != "" {
+			checks = strings.Split(value, ",")
+		}
+		scaleFactor := time.Duration(Inst().GlobalScaleFactor)
+		if scaleFactor == 0 {
+			scaleFactor = 1
+		}
+		report := conformance.Run(conformance.Config{
+			Volume:             Inst().V,
+			Scheduler:          Inst().S,
+			Node:               Inst().N,
+			InstanceID:         Inst().InstanceID,
+			AppKeys:            Inst().AppList,
+			StorageProvisioner: Inst().Provisioner,
+			ConfigMap:          Inst().ConfigMap,
+			Checks:             checks,
+			Timeout:            scaleFactor * defaultTimeout,
+			RetryInterval:      defaultRetryInterval,
+		})
+		log.InfoD("%s", report)
+
+		reportPath := fmt.Sprintf("%s/conformance_%s.json", reportDir, report.VolumeDriver)
+		if err := report.WriteJSON(reportPath); err != nil {
+			log.Warnf("Failed to write conformance report to %s. Err: %v", reportPath, err)
+		}
+		log.InfoD("Volume driver %s supports %v", report.VolumeDriver, report.Supported())
+		dash.VerifyFatal(report.Passed(), true, fmt.Sprintf("volume driver %s conforms to its declared capabilities", report.VolumeDriver))
+	})
+
+	AfterEach(func() {
+		defer EndTorpedoTest()
+	})
+})
+
+func TestMain(m *testing.M) {
+	// call flag.Parse() here if TestMain uses flags
+	ParseFlags()
+	os.Exit(m.Run())
+}
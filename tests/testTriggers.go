@@ -28,6 +28,7 @@ import (
 	"github.com/kubernetes-csi/external-snapshotter/client/v4/apis/volumesnapshot/v1beta1"
 	"github.com/onsi/ginkgo"
 
+	snapv1 "github.com/kubernetes-incubator/external-storage/snapshot/pkg/apis/crd/v1"
 	opsapi "github.com/libopenstorage/openstorage/api"
 	"github.com/pborman/uuid"
 	api "github.com/portworx/px-backup-api/pkg/apis/v1"
@@ -38,6 +39,7 @@ import (
 	storkv1 "github.com/libopenstorage/stork/pkg/apis/stork/v1alpha1"
 	storage "github.com/portworx/sched-ops/k8s/storage"
 	storkops "github.com/portworx/sched-ops/k8s/stork"
+	driver_api "github.com/portworx/torpedo/drivers/api"
 	"github.com/portworx/torpedo/drivers/backup"
 	"github.com/portworx/torpedo/drivers/monitor/prometheus"
 	"github.com/portworx/torpedo/drivers/node"
@@ -45,6 +47,11 @@ import (
 	"github.com/portworx/torpedo/drivers/scheduler/k8s"
 	"github.com/portworx/torpedo/drivers/scheduler/spec"
 	"github.com/portworx/torpedo/drivers/volume"
+	"github.com/portworx/torpedo/drivers/workload"
+	"github.com/portworx/torpedo/pkg/crashutils"
+	"github.com/portworx/torpedo/pkg/ioprobe"
+	"github.com/portworx/torpedo/pkg/kernelmonitor"
+	"github.com/portworx/torpedo/pkg/secretsutils"
 	appsapi "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	storageapi "k8s.io/api/storage/v1"
@@ -137,6 +144,9 @@ var newNamespaceCounter = 0
 // jiraEvents to store raised jira events data
 var jiraEvents = make(map[string][]string)
 
+// jiraEventsLock guards jiraEvents, as issues are raised from the trigger, event collection and email goroutines
+var jiraEventsLock sync.Mutex
+
 // isAutoFsTrimEnabled to store if auto fs trim enalbed
 var isAutoFsTrimEnabled = false
 
@@ -188,10 +198,72 @@ type Event struct {
 // EventRecord recodes which event took
 // place at what time with what outcome
 type EventRecord struct {
-	Event   Event
-	Start   string
-	End     string
-	Outcome []error
+	Event    Event
+	Start    string
+	End      string
+	Outcome  []error
+	IOStalls IOStalls
+	Crashes  Crashes
+	// KernelEvents are the kernel problems the trigger of the event is expected to cause
+	KernelEvents KernelEvents
+}
+
+// IOStalls is the maximum time app IO was stalled on each volume during an event
+type IOStalls map[string]time.Duration
+
+// Max returns the longest IO stall of all volumes
+func (s IOStalls) Max() time.Duration {
+	var maxStall time.Duration
+	for _, stall := range s {
+		if stall > maxStall {
+			maxStall = stall
+		}
+	}
+	return maxStall
+}
+
+func (s IOStalls) String() string {
+	if len(s) == 0 {
+		return "-"
+	}
+	var vols []string
+	for vol := range s {
+		vols = append(vols, vol)
+	}
+	sort.Strings(vols)
+	var stalls []string
+	for _, vol := range vols {
+		stalls = append(stalls, fmt.Sprintf("%s: %v", vol, s[vol]))
+	}
+	return fmt.Sprintf("max %v<br>%s", s.Max(), strings.Join(stalls, "<br>"))
+}
+
+// Crashes are the new crashes found during an event
+type Crashes []*crashutils.Crash
+
+func (c Crashes) String() string {
+	if len(c) == 0 {
+		return "-"
+	}
+	var crashes []string
+	for _, crash := range c {
+		crashes = append(crashes, fmt.Sprintf("%s: %v (<a href=\"%s\">stack</a>)", crash.Signature(), crash, crash.Link))
+	}
+	return strings.Join(crashes, "<br>")
+}
+
+// KernelEvents are the kernel problems found during an event which its trigger is expected to cause
+type KernelEvents []kernelmonitor.Finding
+
+func (k KernelEvents) String() string {
+	if len(k) == 0 {
+		return "-"
+	}
+	var events []string
+	for _, f := range k {
+		events = append(events, f.Error())
+	}
+	return strings.Join(events, "<br>")
 }
 
 // eventRing is circular buffer to store
@@ -395,8 +467,98 @@ const (
 	AutopilotRebalance = "autopilotRebalance"
 	// VolumeCreatePxRestart performs  volume create and px restart parallel
 	VolumeCreatePxRestart = "volumeCreatePxRestart"
+	// RollingUpdateApps performs a rolling config update of all apps
+	RollingUpdateApps = "rollingUpdateApps"
+	// PartitionedRollout performs a partitioned rollout of all statefulsets
+	PartitionedRollout = "partitionedRollout"
+	// RollbackApps performs a rolling update of all apps and rolls it back
+	RollbackApps = "rollbackApps"
+	// DrainNodes drains and uncordons storage nodes one by one
+	DrainNodes = "drainNodes"
+	// PoolCreate creates a storage pool on a storage node
+	PoolCreate = "poolCreate"
+	// PoolDelete deletes a storage pool created by PoolCreate
+	PoolDelete = "poolDelete"
+	// EncryptionKeyRotation rotates the volume encryption keys and restarts the volume driver
+	EncryptionKeyRotation = "encryptionKeyRotation"
+	// SplitBrainPartition partitions the storage nodes into a minority and a majority and heals the partition
+	SplitBrainPartition = "splitBrainPartition"
+	// PoolDriveFault injects a disk fault on a storage pool drive and recovers it
+	PoolDriveFault = "poolDriveFault"
+	// KubeletHang pauses kubelet on a storage node
+	KubeletHang = "kubeletHang"
+	// NodeMemoryPressure uses most of the available memory of a storage node
+	NodeMemoryPressure = "nodeMemoryPressure"
+	// ClockSkew moves the clock of a storage node and restores it
+	ClockSkew = "clockSkew"
+	// VMHardReset hard resets the VM of a storage node
+	VMHardReset = "vmHardReset"
+	// VMDeviceHotplug hot-unplugs a drive or NIC from the VM of a storage node and plugs it back
+	VMDeviceHotplug = "vmDeviceHotplug"
 )
 
+// triggerCapabilities are the driver capabilities needed by triggers. Triggers not listed here
+// only use operations supported by all drivers
+var triggerCapabilities = map[string]RequiredCapabilities{
+	HAIncrease:            {Volume: []driver_api.Capability{volume.CapabilityReplication}},
+	HADecrease:            {Volume: []driver_api.Capability{volume.CapabilityReplication}},
+	HAIncreaseAndReboot:   {Volume: []driver_api.Capability{volume.CapabilityReplication}, Node: []driver_api.Capability{node.CapabilityReboot}},
+	RestartVolDriver:      {Volume: []driver_api.Capability{volume.CapabilityDriverRestart}},
+	RestartManyVolDriver:  {Volume: []driver_api.Capability{volume.CapabilityDriverRestart}},
+	RestartKvdbVolDriver:  {Volume: []driver_api.Capability{volume.CapabilityDriverRestart, volume.CapabilityKvdb}},
+	CrashVolDriver:        {Volume: []driver_api.Capability{volume.CapabilityDriverRestart}},
+	VolumeCreatePxRestart: {Volume: []driver_api.Capability{volume.CapabilityDriverRestart}},
+	RebootNode:            {Node: []driver_api.Capability{node.CapabilityReboot}},
+	RebootManyNodes:       {Node: []driver_api.Capability{node.CapabilityReboot}},
+	CrashNode:             {Node: []driver_api.Capability{node.CapabilityCrash}},
+	CloudSnapShot:         {Volume: []driver_api.Capability{volume.CapabilityCloudsnap}},
+	LocalSnapShot:         {Volume: []driver_api.Capability{volume.CapabilitySnapshot}},
+	DeleteLocalSnapShot:   {Volume: []driver_api.Capability{volume.CapabilitySnapshot}},
+	CsiSnapShot:           {Scheduler: []driver_api.Capability{scheduler.CapabilityCSISnapshot}},
+	CsiSnapRestore:        {Scheduler: []driver_api.Capability{scheduler.CapabilityCSISnapshot}},
+	CoreChecker:           {Node: []driver_api.Capability{node.CapabilityRunCommand}},
+	PoolResizeDisk:        {Volume: []driver_api.Capability{volume.CapabilityPoolExpand}},
+	PoolAddDisk:           {Volume: []driver_api.Capability{volume.CapabilityPoolExpand}},
+	AddDrive:              {Volume: []driver_api.Capability{volume.CapabilityAddDrive}},
+	AddDiskAndReboot:      {Volume: []driver_api.Capability{volume.CapabilityPoolExpand}, Node: []driver_api.Capability{node.CapabilityReboot}},
+	ResizeDiskAndReboot:   {Volume: []driver_api.Capability{volume.CapabilityPoolExpand}, Node: []driver_api.Capability{node.CapabilityReboot}},
+	AutopilotRebalance:    {Scheduler: []driver_api.Capability{scheduler.CapabilityAutopilot}},
+	UpgradeVolumeDriver:   {Volume: []driver_api.Capability{volume.CapabilityUpgrade}},
+	UpdateVolume:          {Volume: []driver_api.Capability{volume.CapabilityIOPriority}},
+	NodeDecommission:      {Volume: []driver_api.Capability{volume.CapabilityDecommission}},
+	NodeRejoin:            {Volume: []driver_api.Capability{volume.CapabilityDecommission}},
+	KVDBFailover:          {Volume: []driver_api.Capability{volume.CapabilityKvdb}},
+	ValidateDeviceMapper:  {Node: []driver_api.Capability{node.CapabilityRunCommand}},
+	AppTasksDown:          {Scheduler: []driver_api.Capability{scheduler.CapabilityScale}},
+	RollingUpdateApps:     {Scheduler: []driver_api.Capability{scheduler.CapabilityRollout}},
+	PartitionedRollout:    {Scheduler: []driver_api.Capability{scheduler.CapabilityRollout}},
+	RollbackApps:          {Scheduler: []driver_api.Capability{scheduler.CapabilityRollout}},
+	DrainNodes:            {Scheduler: []driver_api.Capability{scheduler.CapabilityDrain}},
+	PoolCreate:            {Volume: []driver_api.Capability{volume.CapabilityPoolLifecycle}},
+	PoolDelete:            {Volume: []driver_api.Capability{volume.CapabilityPoolLifecycle}},
+	EncryptionKeyRotation: {Volume: []driver_api.Capability{volume.CapabilityEncryption, volume.CapabilityDriverRestart}},
+	SplitBrainPartition:   {Node: []driver_api.Capability{node.CapabilityNetworkFault}},
+	PoolDriveFault:        {Node: []driver_api.Capability{node.CapabilityDiskFault}},
+	KubeletHang:           {Node: []driver_api.Capability{node.CapabilityProcessChaos}},
+	NodeMemoryPressure:    {Node: []driver_api.Capability{node.CapabilityProcessChaos}},
+	ClockSkew:             {Node: []driver_api.Capability{node.CapabilityClockSkew}},
+	VMHardReset:           {Node: []driver_api.Capability{node.CapabilityVMReset}},
+	VMDeviceHotplug:       {Node: []driver_api.Capability{node.CapabilityVMDeviceHotplug}},
+}
+
+// UnsupportedTriggerReason returns why the given trigger cannot run with the configured drivers, or an
+// empty string if it can
+func UnsupportedTriggerReason(triggerType string) string {
+	required, ok := triggerCapabilities[triggerType]
+	if !ok {
+		return ""
+	}
+	return required.UnsupportedReason()
+}
+
+// rolloutIDEnv is the environment variable set on app containers to roll out a config update
+const rolloutIDEnv = "TORPEDO_ROLLOUT_ID"
+
 // TriggerCoreChecker checks if any cores got generated
 func TriggerCoreChecker(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
 	defer ginkgo.GinkgoRecover()
@@ -439,11 +601,16 @@ func TriggerCoreChecker(contexts *[]*scheduler.Context, recordChan *chan *EventR
 				if len(file) != 0 {
 					log.Warnf("[%s] found on node [%s]", file, n.Name)
 					coresMap[n.Name] = "1"
-					createLongevityJiraIssue(event, fmt.Errorf("[%s] found on node [%s]", file, n.Name))
 				} else {
 					coresMap[n.Name] = ""
 
 				}
+
+				for _, crash := range CollectCrashes(n, file) {
+					log.Warnf("crash [%s] found on node [%s]: %v", crash.Signature(), n.Name, crash)
+					event.Crashes = append(event.Crashes, crash)
+					createLongevityJiraIssue(event, fmt.Errorf("crash [%s] found on node [%s]: %v", crash.Signature(), n.Name, crash))
+				}
 			}
 		})
 	})
@@ -840,6 +1007,19 @@ func TriggerHAIncrease(contexts *[]*scheduler.Context, recordChan *chan *EventRe
 						}
 					})
 			}
+			if hasVolumePlacementStrategy(ctx) {
+				stepLog = fmt.Sprintf("validate volume placement strategy after increasing HA for app: %s",
+					ctx.App.Key)
+				Step(stepLog, func() {
+					log.InfoD(stepLog)
+					errorChan := make(chan error, errorChannelSize)
+					ValidateVolumePlacementStrategy(ctx, &errorChan)
+					close(errorChan)
+					for err := range errorChan {
+						UpdateOutcome(event, err)
+					}
+				})
+			}
 			stepLog = fmt.Sprintf("validating context after increasing HA for app: %s",
 				ctx.App.Key)
 			Step(stepLog, func() {
@@ -964,6 +1144,19 @@ func TriggerHADecrease(contexts *[]*scheduler.Context, recordChan *chan *EventRe
 
 					})
 			}
+			if hasVolumePlacementStrategy(ctx) {
+				stepLog = fmt.Sprintf("validate volume placement strategy after reducing HA for app: %s",
+					ctx.App.Key)
+				Step(stepLog, func() {
+					log.InfoD(stepLog)
+					errorChan := make(chan error, errorChannelSize)
+					ValidateVolumePlacementStrategy(ctx, &errorChan)
+					close(errorChan)
+					for err := range errorChan {
+						UpdateOutcome(event, err)
+					}
+				})
+			}
 			stepLog = fmt.Sprintf("validating context after reducing HA for app: %s",
 				ctx.App.Key)
 			Step(stepLog, func() {
@@ -1051,7 +1244,18 @@ func TriggerCrashVolDriver(contexts *[]*scheduler.Context, recordChan *chan *Eve
 		*recordChan <- event
 	}()
 	setMetrics(*event)
-	stepLog := "crash volume driver in all nodes"
+	stepLog := "start IO probes on app volumes"
+	var ioProbes []*ioprobe.Probe
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		var errs []error
+		ioProbes, errs = StartIOProbes(*contexts)
+		for _, err := range errs {
+			UpdateOutcome(event, err)
+		}
+	})
+
+	stepLog = "crash volume driver in all nodes"
 	Step(stepLog, func() {
 		log.InfoD(stepLog)
 		for _, appNode := range node.GetStorageDriverNodes() {
@@ -1072,6 +1276,12 @@ func TriggerCrashVolDriver(contexts *[]*scheduler.Context, recordChan *chan *Eve
 		}
 		updateMetrics(*event)
 	})
+
+	stepLog = "stop IO probes and record IO stalls of app volumes"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		StopIOProbes(event, ioProbes)
+	})
 }
 
 // TriggerRestartVolDriver restarts volume driver and validates app
@@ -1344,7 +1554,29 @@ func TriggerRebootNodes(contexts *[]*scheduler.Context, recordChan *chan *EventR
 	}()
 
 	setMetrics(*event)
-	stepLog := "get all nodes and reboot one by one"
+	stepLog := "start measuring app workloads"
+	var workloads map[*scheduler.Context][]workload.Driver
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		var errs []error
+		workloads, errs = StartWorkloads(*contexts)
+		for _, err := range errs {
+			UpdateOutcome(event, err)
+		}
+	})
+
+	stepLog = "start IO probes on app volumes"
+	var ioProbes []*ioprobe.Probe
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		var errs []error
+		ioProbes, errs = StartIOProbes(*contexts)
+		for _, err := range errs {
+			UpdateOutcome(event, err)
+		}
+	})
+
+	stepLog = "get all nodes and reboot one by one"
 	Step(stepLog, func() {
 		log.InfoD(stepLog)
 		nodesToReboot := node.GetWorkerNodes()
@@ -1421,6 +1653,20 @@ func TriggerRebootNodes(contexts *[]*scheduler.Context, recordChan *chan *EventR
 			updateMetrics(*event)
 		})
 	})
+
+	stepLog = "verify app workloads kept doing IO while nodes rebooted"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, err := range VerifyWorkloads(workloads) {
+			UpdateOutcome(event, err)
+		}
+	})
+
+	stepLog = "stop IO probes and record IO stalls of app volumes"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		StopIOProbes(event, ioProbes)
+	})
 }
 
 // TriggerRebootManyNodes reboots one or more nodes on which apps are running
@@ -2358,11 +2604,122 @@ func getCreateCloudCredentialRequest(uid string) (*api.CloudCredentialCreateRequ
 	return req, nil
 }
 
+var (
+	// kernelMonitor tails the kernel messages of the nodes during the tests
+	kernelMonitor *kernelmonitor.Monitor
+	// kernelFindings are the kernel problems found during each trigger not yet added to its event record
+	kernelFindings = make(map[string][]kernelmonitor.Finding)
+	// runningTrigger is the trigger running now and lastTrigger the one which ran last
+	runningTrigger, lastTrigger string
+	kernelFindingsLock          sync.Mutex
+)
+
+// expectedKernelEvents are the kinds of kernel problems each trigger causes by design. They are recorded in the
+// event record of the trigger but not counted as failures.
+var expectedKernelEvents = map[string][]kernelmonitor.Category{
+	PoolDriveFault:       {kernelmonitor.CategoryIOError},
+	NodeMemoryPressure:   {kernelmonitor.CategoryOOMKill},
+	RebootNode:           {kernelmonitor.CategoryNFSNotResponding},
+	RebootManyNodes:      {kernelmonitor.CategoryNFSNotResponding},
+	CrashNode:            {kernelmonitor.CategoryNFSNotResponding},
+	CrashVolDriver:       {kernelmonitor.CategoryNFSNotResponding},
+	RestartVolDriver:     {kernelmonitor.CategoryNFSNotResponding},
+	RestartManyVolDriver: {kernelmonitor.CategoryNFSNotResponding},
+	RestartKvdbVolDriver: {kernelmonitor.CategoryNFSNotResponding},
+	HAIncreaseAndReboot:  {kernelmonitor.CategoryNFSNotResponding},
+	AddDiskAndReboot:     {kernelmonitor.CategoryNFSNotResponding},
+	ResizeDiskAndReboot:  {kernelmonitor.CategoryNFSNotResponding},
+	DrainNodes:           {kernelmonitor.CategoryNFSNotResponding},
+	SplitBrainPartition:  {kernelmonitor.CategoryNFSNotResponding},
+	VMHardReset:          {kernelmonitor.CategoryNFSNotResponding},
+	VMDeviceHotplug:      {kernelmonitor.CategoryIOError, kernelmonitor.CategoryNFSNotResponding},
+}
+
+// isExpectedKernelEvent returns whether the trigger is expected to cause the kernel problem
+func isExpectedKernelEvent(trigger string, f kernelmonitor.Finding) bool {
+	for _, category := range expectedKernelEvents[trigger] {
+		if f.Category == category {
+			return true
+		}
+	}
+	return false
+}
+
+// StartKernelMonitor starts tailing the kernel messages of the nodes for hung tasks, IO errors, NFS stalls and OOM
+// kills. Problems found are tied to the trigger running at the time, or to the last one if none is running, and
+// added as errors to its event record.
+func StartKernelMonitor(nodes []node.Node) {
+	monitor := kernelmonitor.NewMonitor(Inst().N, kernelmonitor.DefaultInterval, func(f kernelmonitor.Finding) {
+		kernelFindingsLock.Lock()
+		defer kernelFindingsLock.Unlock()
+		f.Trigger = runningTrigger
+		if len(f.Trigger) == 0 {
+			f.Trigger = lastTrigger
+		}
+		if isExpectedKernelEvent(f.Trigger, f) {
+			log.Infof("%v. Expected during trigger: [%s]", f, f.Trigger)
+		} else {
+			log.Errorf("%v. Trigger: [%s]", f, f.Trigger)
+		}
+		if len(f.Trigger) > 0 {
+			kernelFindings[f.Trigger] = append(kernelFindings[f.Trigger], f)
+		}
+	})
+	kernelFindingsLock.Lock()
+	kernelMonitor = monitor
+	kernelFindingsLock.Unlock()
+	monitor.Start(nodes)
+}
+
+// StopKernelMonitor stops tailing the kernel messages of the nodes. It is safe to call from several triggers at once.
+func StopKernelMonitor() {
+	kernelFindingsLock.Lock()
+	monitor := kernelMonitor
+	kernelMonitor = nil
+	kernelFindingsLock.Unlock()
+	if monitor != nil {
+		monitor.Stop()
+	}
+}
+
+// SetRunningTrigger sets the trigger kernel problems found from now on are tied to. An empty trigger marks the
+// running one as done.
+func SetRunningTrigger(trigger string) {
+	kernelFindingsLock.Lock()
+	defer kernelFindingsLock.Unlock()
+	if len(runningTrigger) > 0 {
+		lastTrigger = runningTrigger
+	}
+	runningTrigger = trigger
+}
+
+// addKernelFindings adds the kernel problems found during the trigger of the event to its outcome, or to its expected
+// kernel events if the trigger causes them by design
+func addKernelFindings(event *EventRecord) {
+	kernelFindingsLock.Lock()
+	trigger := strings.Split(event.Event.Type, "<br>")[0]
+	findings := kernelFindings[trigger]
+	delete(kernelFindings, trigger)
+	kernelFindingsLock.Unlock()
+
+	for _, f := range findings {
+		if isExpectedKernelEvent(trigger, f) {
+			event.KernelEvents = append(event.KernelEvents, f)
+			continue
+		}
+		Inst().M.IncrementCounterMetric(TestFailedCount, event.Event.Type)
+		event.Outcome = append(event.Outcome, fmt.Errorf("%v<br>", f))
+		// one issue per kind of problem on each node, however many times it is found
+		createLongevityJiraIssue(event, fmt.Errorf("kernel %s on node %s", f.Category, f.Node))
+	}
+}
+
 // CollectEventRecords collects eventRecords from channel
 // and stores in buffer for future email notifications
 func CollectEventRecords(recordChan *chan *EventRecord) {
 	eventRing = ring.New(100)
 	for eventRecord := range *recordChan {
+		addKernelFindings(eventRecord)
 		eventRing.Value = eventRecord
 		eventRing = eventRing.Next()
 	}
@@ -2430,6 +2787,8 @@ func TriggerEmailReporter() {
 	for i := 0; i < eventRing.Len(); i++ {
 		record := eventRing.Value
 		if record != nil {
+			// kernel problems may be found after the record of their trigger was collected
+			addKernelFindings(record.(*EventRecord))
 			emailData.EmailRecords.Records = append(emailData.EmailRecords.Records, *record.(*EventRecord))
 			eventRing.Value = nil
 		}
@@ -5314,6 +5673,7 @@ func getCloudSnapInterval(triggerType string) int {
 func createLongevityJiraIssue(event *EventRecord, err error) {
 
 	actualEvent := strings.Split(event.Event.Type, "<br>")[0]
+	jiraEventsLock.Lock()
 	eventsGenerated, ok := jiraEvents[actualEvent]
 	issueExists := false
 	t := time.Now().Format(time.RFC1123)
@@ -5333,12 +5693,15 @@ func createLongevityJiraIssue(event *EventRecord, err error) {
 	}
 
 	if !issueExists {
-		log.Info("Creating Jira Issue")
-
 		//adding issue to existing jiraEvents
 		issues := jiraEvents[actualEvent]
 		issues = append(issues, fmt.Sprintf("%v->%v", t, err.Error()))
 		jiraEvents[actualEvent] = issues
+	}
+	jiraEventsLock.Unlock()
+
+	if !issueExists {
+		log.Info("Creating Jira Issue")
 
 		summary := fmt.Sprintf("[%v]: Error %v occured in Torpedo Longevity", actualEvent, err)
 		summary = strings.Replace(summary, "\r\n", "", -1)
@@ -5355,6 +5718,13 @@ func createLongevityJiraIssue(event *EventRecord, err error) {
 		lines = append(lines, fmt.Sprintf("Error Occured time: %v", t))
 		lines = append(lines, fmt.Sprintf("Event: %v", event.Event.Type))
 		lines = append(lines, fmt.Sprintf("Error: %v", err.Error()))
+		for _, crash := range event.Crashes {
+			if strings.Contains(err.Error(), crash.Signature()) {
+				lines = append(lines, fmt.Sprintf("Crashed binary: %v %v", crash.Binary, crash.Version))
+				lines = append(lines, fmt.Sprintf("Core or stack: %v", crash.Link))
+				lines = append(lines, fmt.Sprintf("{noformat}%v{noformat}", crash.Stack))
+			}
+		}
 
 		description := ""
 
@@ -5386,7 +5756,18 @@ func TriggerKVDBFailover(contexts *[]*scheduler.Context, recordChan *chan *Event
 	}()
 
 	setMetrics(*event)
-	stepLog := "perform kvdb failover in a cyclic manner"
+	stepLog := "start IO probes on app volumes"
+	var ioProbes []*ioprobe.Probe
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		var errs []error
+		ioProbes, errs = StartIOProbes(*contexts)
+		for _, err := range errs {
+			UpdateOutcome(event, err)
+		}
+	})
+
+	stepLog = "perform kvdb failover in a cyclic manner"
 	context(stepLog, func() {
 		log.InfoD(stepLog)
 		stepLog = "Get KVDB nodes and perform failover"
@@ -5510,6 +5891,12 @@ func TriggerKVDBFailover(contexts *[]*scheduler.Context, recordChan *chan *Event
 
 		})
 	})
+
+	stepLog = "stop IO probes and record IO stalls of app volumes"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		StopIOProbes(event, ioProbes)
+	})
 	updateMetrics(*event)
 }
 
@@ -6083,6 +6470,1607 @@ func TriggerStorkAppBkpVolResize(contexts *[]*scheduler.Context, recordChan *cha
 	})
 }
 
+// TriggerRollingUpdateApps does a rolling config update of the Deployments and StatefulSets of all apps and
+// verifies that the volumes re-attach on the new pods without data loss
+func TriggerRollingUpdateApps(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(RollingUpdateApps)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: RollingUpdateApps,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+	setMetrics(*event)
+	for _, ctx := range *contexts {
+		stepLog := fmt.Sprintf("roll out config update of app: [%s]", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			rolloutAndValidate(event, ctx, false, func() error {
+				return Inst().S.RolloutApplication(ctx, scheduler.RolloutOptions{
+					Env: map[string]string{rolloutIDEnv: GenerateUUID()},
+				})
+			})
+		})
+	}
+	updateMetrics(*event)
+}
+
+// TriggerPartitionedRollout rolls out a config update to the StatefulSets of all apps in two phases, first to
+// the pods above the partition ordinal and then to the remaining pods
+func TriggerPartitionedRollout(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(PartitionedRollout)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: PartitionedRollout,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+	setMetrics(*event)
+	for _, ctx := range *contexts {
+		partition, found, err := getRolloutPartition(ctx)
+		if err != nil {
+			UpdateOutcome(event, err)
+			continue
+		}
+		if !found {
+			continue
+		}
+		rolloutID := GenerateUUID()
+		stepLog := fmt.Sprintf("roll out config update of app: [%s] to pods from ordinal %d", ctx.App.Key, partition)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			if partition == 0 {
+				log.InfoD("Skipping the partitioned phase of app: [%s] as its StatefulSets have a single replica", ctx.App.Key)
+				return
+			}
+			rolloutAndValidate(event, ctx, true, func() error {
+				return Inst().S.RolloutApplication(ctx, scheduler.RolloutOptions{
+					Env:       map[string]string{rolloutIDEnv: rolloutID},
+					Partition: &partition,
+				})
+			})
+		})
+		stepLog = fmt.Sprintf("roll out config update of app: [%s] to the remaining pods", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			rolloutAndValidate(event, ctx, false, func() error {
+				return Inst().S.RolloutApplication(ctx, scheduler.RolloutOptions{
+					Env: map[string]string{rolloutIDEnv: rolloutID},
+				})
+			})
+		})
+	}
+	updateMetrics(*event)
+}
+
+// TriggerRollbackApps rolls out a config update to all apps and then rolls it back
+func TriggerRollbackApps(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(RollbackApps)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: RollbackApps,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+	setMetrics(*event)
+	for _, ctx := range *contexts {
+		stepLog := fmt.Sprintf("roll out config update of app: [%s]", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			rolloutAndValidate(event, ctx, false, func() error {
+				return Inst().S.RolloutApplication(ctx, scheduler.RolloutOptions{
+					Env: map[string]string{rolloutIDEnv: GenerateUUID()},
+				})
+			})
+		})
+		stepLog = fmt.Sprintf("roll back app: [%s] to its previous revision", ctx.App.Key)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			rolloutAndValidate(event, ctx, false, func() error {
+				return Inst().S.RollbackApplication(ctx, 0)
+			})
+		})
+	}
+	updateMetrics(*event)
+}
+
+// getRolloutPartition returns the partition ordinal splitting the pods of the StatefulSets of the app in half,
+// based on the StatefulSet with the fewest replicas, and whether the app has any StatefulSet
+func getRolloutPartition(ctx *scheduler.Context) (int32, bool, error) {
+	scales, err := Inst().S.GetScaleFactorMap(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	found := false
+	var minReplicas int32
+	for name, replicas := range scales {
+		if !strings.HasSuffix(name, k8s.StatefulSetSuffix) {
+			continue
+		}
+		if !found || replicas < minReplicas {
+			minReplicas = replicas
+		}
+		found = true
+	}
+	return minReplicas / 2, found, nil
+}
+
+// rolloutAndValidate runs the given rollout of the app, validates the app afterwards and verifies that
+// data written to its volumes before the rollout is visible to the new pods. With updatedOnly, e.g. for a
+// partitioned rollout which leaves some pods on the old revision, only the updated pods are checked.
+func rolloutAndValidate(event *EventRecord, ctx *scheduler.Context, updatedOnly bool, rollout func() error) {
+	markers, err := WriteVolumeMarkers(ctx)
+	if err != nil {
+		UpdateOutcome(event, err)
+		return
+	}
+	defer func() {
+		for _, err := range RemoveVolumeMarkers(markers) {
+			log.Warnf("Failed to remove volume marker. Err: %v", err)
+		}
+	}()
+	if err := rollout(); err != nil {
+		PrintDescribeContext(ctx)
+		UpdateOutcome(event, err)
+		return
+	}
+	errorChan := make(chan error, errorChannelSize)
+	ValidateContext(ctx, &errorChan)
+	for err := range errorChan {
+		UpdateOutcome(event, err)
+	}
+	verify := VerifyVolumeMarkers
+	if updatedOnly {
+		verify = VerifyVolumeMarkersOnUpdatedPods
+	}
+	for _, err := range verify(markers) {
+		UpdateOutcome(event, err)
+	}
+}
+
+// TriggerDrainNodes drains the storage nodes one at a time through the eviction API, honoring
+// PodDisruptionBudgets, and uncordons them again
+func TriggerDrainNodes(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(DrainNodes)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: DrainNodes,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+	setMetrics(*event)
+	for _, n := range node.GetStorageDriverNodes() {
+		stepLog := fmt.Sprintf("drain node: %s", n.Name)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			report, err := Inst().S.DrainNode(n, scheduler.DrainOptions{WaitForReattach: true})
+			UpdateOutcome(event, err)
+			if report != nil {
+				var maxReattach time.Duration
+				for _, reattach := range report.VolumeReattachTimes {
+					if reattach > maxReattach {
+						maxReattach = reattach
+					}
+				}
+				var blocked int
+				for _, count := range report.PDBBlockedEvictions {
+					blocked += count
+				}
+				taskStep := fmt.Sprintf("drain node: %s. evicted %d pods in %v, %d evictions blocked by PDBs, max volume re-attach %v, "+
+					"%d pods without a controller left on the node", n.MgmtIp, len(report.EvictedPods), report.Duration.Round(time.Second),
+					blocked, maxReattach.Round(time.Second), len(report.UnmanagedPods))
+				log.InfoD(taskStep)
+				event.Event.Type += "<br>" + taskStep
+			}
+		})
+		stepLog = fmt.Sprintf("uncordon node: %s", n.Name)
+		Step(stepLog, func() {
+			log.InfoD(stepLog)
+			err := Inst().S.EnableSchedulingOnNode(n)
+			UpdateOutcome(event, err)
+		})
+		Step("validate apps", func() {
+			for _, ctx := range *contexts {
+				stepLog = fmt.Sprintf("DrainNode: validating app [%s]", ctx.App.Key)
+				Step(stepLog, func() {
+					errorChan := make(chan error, errorChannelSize)
+					ValidateContext(ctx, &errorChan)
+					for err := range errorChan {
+						UpdateOutcome(event, err)
+					}
+				})
+			}
+		})
+	}
+	updateMetrics(*event)
+}
+
+const (
+	// poolLifecycleLabel marks the storage pools created by the poolCreate trigger, poolDelete only deletes those
+	poolLifecycleLabel = "torpedo/pool-lifecycle"
+	// maxPoolsPerNode is the number of storage pools a Portworx node supports
+	maxPoolsPerNode = 8
+)
+
+// TriggerPoolCreate creates a storage pool on a random storage node and labels it
+func TriggerPoolCreate(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(PoolCreate)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: PoolCreate,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	stepLog := "create a storage pool on a storage node"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		storageNodes := node.GetStorageNodes()
+		if len(storageNodes) == 0 {
+			UpdateOutcome(event, fmt.Errorf("no storage nodes to create a pool on"))
+			return
+		}
+		storageNode, err := getNodeWithPoolCapacity(storageNodes)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		if storageNode == nil {
+			log.InfoD("All storage nodes have %d pools, skipping pool create", maxPoolsPerNode)
+			return
+		}
+
+		driveSpec, err := getPoolDriveSpec(*storageNode)
+		if err != nil {
+			log.Warnf("Skipping pool create on %s: %v", storageNode.Name, err)
+			return
+		}
+		poolUUID, err := Inst().V.CreatePool(storageNode, driveSpec)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		err = Inst().V.UpdatePoolLabels(storageNode, poolUUID, map[string]string{poolLifecycleLabel: "true"})
+		if err != nil {
+			UpdateOutcome(event, err)
+			// poolDelete only deletes labeled pools, so the unlabeled pool is deleted here
+			log.Warnf("Deleting pool %s on %s which failed to get labeled", poolUUID, storageNode.Name)
+			UpdateOutcome(event, Inst().V.DeletePool(storageNode, poolUUID))
+		}
+	})
+
+	stepLog = "validate all apps after pool create"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+// getNodeWithPoolCapacity returns a random storage node with room for another pool, or nil if all are full
+func getNodeWithPoolCapacity(storageNodes []node.Node) (*node.Node, error) {
+	for _, i := range rand.Perm(len(storageNodes)) {
+		n := storageNodes[i]
+		pxNode, err := Inst().V.GetPxNode(&n)
+		if err != nil {
+			return nil, err
+		}
+		if len(pxNode.GetPools()) < maxPoolsPerNode {
+			return &n, nil
+		}
+	}
+	return nil, nil
+}
+
+// getPoolDriveSpec returns the cloud drive spec or a free block drive to create a pool on the node from
+func getPoolDriveSpec(n node.Node) (string, error) {
+	isCloudDrive, err := IsCloudDriveInitialised(n)
+	if err != nil {
+		return "", err
+	}
+	if isCloudDrive {
+		specs, err := GetCloudDriveDeviceSpecs()
+		if err != nil {
+			return "", err
+		}
+		if len(specs) == 0 {
+			return "", fmt.Errorf("no cloud drive specs found")
+		}
+		return specs[0], nil
+	}
+
+	blockDrives, err := Inst().N.GetBlockDrives(n, node.SystemctlOpts{
+		ConnectionOpts: node.ConnectionOpts{
+			Timeout:         defaultTimeout,
+			TimeBeforeRetry: defaultRetryInterval,
+		},
+		Action: "start",
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, drv := range blockDrives {
+		if !strings.Contains(drv.Path, "pxd") && drv.MountPoint == "" && drv.FSType == "" && drv.Type == "disk" {
+			// pools on device-mapper drives can get disk faults injected by the poolDriveFault trigger
+			if Inst().N.Capabilities().Has(node.CapabilityDiskFault) {
+				return Inst().N.PrepareDiskForFaults(n, drv.Path)
+			}
+			return drv.Path, nil
+		}
+	}
+	return "", fmt.Errorf("no free block drives on node %s", n.Name)
+}
+
+// TriggerPoolDelete deletes a storage pool created by the poolCreate trigger and validates its volumes were
+// moved off it or the deletion was blocked
+func TriggerPoolDelete(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(PoolDelete)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: PoolDelete,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	stepLog := "delete a storage pool created by the pool create trigger"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		pools, err := Inst().V.ListStoragePools(meta_v1.LabelSelector{MatchLabels: map[string]string{poolLifecycleLabel: "true"}})
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		if len(pools) == 0 {
+			log.InfoD("No pools created by %s to delete", PoolCreate)
+			return
+		}
+		var poolUUIDs []string
+		for poolUUID := range pools {
+			poolUUIDs = append(poolUUIDs, poolUUID)
+		}
+		poolUUID := poolUUIDs[rand.Intn(len(poolUUIDs))]
+		storageNode, err := GetNodeWithGivenPoolID(poolUUID)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+
+		deleted, err := Inst().V.ValidateDeletePool(storageNode, poolUUID)
+		UpdateOutcome(event, err)
+		if err == nil && !deleted {
+			log.InfoD("Deletion of pool %s on %s was blocked by its volumes", poolUUID, storageNode.Name)
+		}
+	})
+
+	stepLog = "validate all apps after pool delete"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+const (
+	// encryptionVolumeKey and encryptionClusterKey are the keys rotated by the encryptionKeyRotation trigger
+	encryptionVolumeKey  = "torpedo-volume-key"
+	encryptionClusterKey = "torpedo-cluster-key"
+	// encryptionDataSizeMB is the size of the random data the encryptionKeyRotation trigger writes to its volumes
+	encryptionDataSizeMB = 1
+)
+
+// encryptionKeyring keeps the key versions created by the encryptionKeyRotation trigger across its runs
+var encryptionKeyring *secretsutils.Keyring
+
+// encryptedTestVolume is a raw volume created by the encryptionKeyRotation trigger along with the checksum of the
+// random data written to it
+type encryptedTestVolume struct {
+	ID string
+	// KeyName is the key the volume is encrypted with, empty for the cluster key
+	KeyName string
+	// NodeName is the node the volume was attached to when the data was written
+	NodeName string
+	Checksum string
+}
+
+// TriggerEncryptionKeyRotation validates encrypted app volumes use their configured key and rotates the per
+// volume and cluster wide keys. Portworx cannot re-encrypt a volume with a new key, so rotating a key means new
+// volumes use the new version while existing volumes keep using theirs. The trigger writes data to a volume
+// encrypted with the current per volume key, rotates the keys, writes data to volumes encrypted with the new
+// versions, restarts the volume driver and validates all of them still attach with their data intact.
+func TriggerEncryptionKeyRotation(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(EncryptionKeyRotation)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: EncryptionKeyRotation,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	keyring, err := getEncryptionKeyring()
+	if err != nil {
+		UpdateOutcome(event, err)
+		updateMetrics(*event)
+		return
+	}
+	store := keyring.Store()
+
+	usesClusterKey := false
+	var markers []*ioprobe.Marker
+	stepLog := "validate encrypted app volumes use their configured key"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			appVolumes, err := Inst().S.GetVolumes(ctx)
+			if err != nil {
+				UpdateOutcome(event, err)
+				continue
+			}
+			for _, vol := range appVolumes {
+				encryption, err := Inst().V.GetVolumeEncryption(vol)
+				if err != nil {
+					UpdateOutcome(event, err)
+					continue
+				}
+				if !encryption.Encrypted {
+					continue
+				}
+				if len(encryption.KeyName) == 0 {
+					usesClusterKey = true
+				}
+				UpdateOutcome(event, validateVolumeEncryptionKey(store, vol, encryption))
+
+				// app volumes keep their key, their markers check their data survives the driver restart
+				marker, err := ioprobe.WriteMarkerForPVC(vol.ID, vol.Name, vol.Namespace)
+				if err != nil {
+					UpdateOutcome(event, err)
+					continue
+				}
+				if marker != nil {
+					markers = append(markers, marker)
+				}
+			}
+		}
+	})
+
+	var testVolumes []*encryptedTestVolume
+	defer func() {
+		for _, marker := range markers {
+			if err := marker.Remove(); err != nil {
+				log.Warnf("Failed to remove marker of volume %s: %v", marker.Volume, err)
+			}
+		}
+		for _, vol := range testVolumes {
+			if err := Inst().V.DeleteVolume(vol.ID); err != nil {
+				log.Warnf("Failed to delete encrypted test volume %s: %v", vol.ID, err)
+			}
+		}
+	}()
+
+	stepLog = "write data to a volume encrypted with the current per volume key"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		key, ok := keyring.Current(encryptionVolumeKey)
+		if !ok {
+			if key, err = keyring.Rotate(encryptionVolumeKey); err != nil {
+				UpdateOutcome(event, err)
+				return
+			}
+		}
+		vol, err := createEncryptedTestVolume(key.Name)
+		if vol != nil {
+			testVolumes = append(testVolumes, vol)
+		}
+		UpdateOutcome(event, err)
+	})
+
+	stepLog = "rotate the per volume and cluster wide keys and write data to volumes encrypted with the new keys"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		key, err := keyring.Rotate(encryptionVolumeKey)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		vol, err := createEncryptedTestVolume(key.Name)
+		if vol != nil {
+			testVolumes = append(testVolumes, vol)
+		}
+		UpdateOutcome(event, err)
+
+		// volumes encrypted with the cluster key cannot be re-encrypted, changing it would leave them unreadable
+		if usesClusterKey {
+			log.InfoD("Skipping cluster key rotation since app volumes are encrypted with the cluster key")
+			return
+		}
+		clusterKey, err := keyring.Rotate(encryptionClusterKey)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		storageNodes := node.GetStorageNodes()
+		if len(storageNodes) == 0 {
+			UpdateOutcome(event, fmt.Errorf("no storage nodes to set the cluster key on"))
+			return
+		}
+		if err := Inst().V.SetClusterEncryptionKey(storageNodes[0], clusterKey.Name); err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		vol, err = createEncryptedTestVolume("")
+		if vol != nil {
+			testVolumes = append(testVolumes, vol)
+		}
+		UpdateOutcome(event, err)
+	})
+
+	stepLog = "restart the volume driver after key rotation"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, n := range getEncryptionRestartNodes(markers, testVolumes) {
+			if err := Inst().V.RestartDriver(n, nil); err != nil {
+				UpdateOutcome(event, err)
+				continue
+			}
+			UpdateOutcome(event, Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout))
+		}
+	})
+
+	stepLog = "validate apps and data written before the driver restart"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+		for _, marker := range markers {
+			UpdateOutcome(event, marker.Verify())
+		}
+		for _, vol := range testVolumes {
+			UpdateOutcome(event, vol.verify())
+		}
+	})
+
+	stepLog = "delete encrypted test volumes and old keys"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		deleted := true
+		for _, vol := range testVolumes {
+			if err := Inst().V.DeleteVolume(vol.ID); err != nil {
+				UpdateOutcome(event, err)
+				deleted = false
+			}
+		}
+		testVolumes = nil
+		// old key versions must be kept as long as a volume encrypted with them is left
+		if deleted {
+			UpdateOutcome(event, keyring.DeleteOldVersions(encryptionVolumeKey))
+		}
+	})
+	updateMetrics(*event)
+}
+
+// getEncryptionKeyring returns the keyring of the encryptionKeyRotation trigger, creating it on the first run. The
+// keys are stored in the secrets the volume driver uses, so vault secrets need the address of its vault.
+func getEncryptionKeyring() (*secretsutils.Keyring, error) {
+	if encryptionKeyring != nil {
+		return encryptionKeyring, nil
+	}
+	namespace, err := Inst().V.GetVolumeDriverNamespace()
+	if err != nil {
+		return nil, err
+	}
+	secretType := Inst().SecretType
+	if len(secretType) == 0 {
+		secretType = scheduler.SecretK8S
+	}
+	if secretType == scheduler.SecretVault && len(Inst().VaultAddress) == 0 {
+		return nil, fmt.Errorf("vault secrets need the address of the vault used by the volume driver")
+	}
+	store, err := secretsutils.NewStore(secretType, namespace, Inst().VaultAddress, Inst().VaultToken)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKeyring = secretsutils.NewKeyring(store)
+	return encryptionKeyring, nil
+}
+
+// validateVolumeEncryptionKey checks the volume is encrypted with the key its PVC asks for, or the cluster key if
+// none, and that the key is in the secrets store
+func validateVolumeEncryptionKey(store *secretsutils.Store, vol *volume.Volume, encryption *volume.Encryption) error {
+	pvc, err := core.Instance().GetPersistentVolumeClaim(vol.Name, vol.Namespace)
+	if err != nil {
+		return err
+	}
+	sc, err := core.Instance().GetStorageClassForPVC(pvc)
+	if err != nil {
+		log.Warnf("Failed to get storage class of PVC %s/%s: %v", pvc.Namespace, pvc.Name, err)
+	}
+	expected, ok := secretsutils.KeyForPVC(pvc, sc)
+	if !ok {
+		if len(encryption.KeyName) > 0 {
+			return fmt.Errorf("volume %s is encrypted with key %s, expected the cluster key", vol.ID, encryption.KeyName)
+		}
+		return nil
+	}
+	if encryption.KeyName != expected.Name {
+		return fmt.Errorf("volume %s is encrypted with key %s, expected %s", vol.ID, encryption.KeyName, expected.Name)
+	}
+	key := secretsutils.Key{Name: encryption.KeyName, SecretName: encryption.SecretName, SecretNamespace: encryption.SecretNamespace}
+	if _, err := store.GetKey(key); err != nil {
+		return fmt.Errorf("key %s of volume %s is not in the secrets store. Err: %v", key.Name, vol.ID, err)
+	}
+	log.Infof("Volume %s is encrypted with key %s", vol.ID, key.Name)
+	return nil
+}
+
+// createEncryptedTestVolume creates a raw volume encrypted with the key, or the cluster key if the key name is
+// empty, checks the driver reports the key and writes random data to it. The volume is returned once created,
+// even if writing to it fails, so it can be deleted.
+func createEncryptedTestVolume(keyName string) (*encryptedTestVolume, error) {
+	volName := fmt.Sprintf("torpedo-encrypted-%s", GenerateUUID()[:8])
+	volID, err := Inst().V.CreateVolumeUsingRequest(&opsapi.SdkVolumeCreateRequest{
+		Name: volName,
+		Spec: &opsapi.VolumeSpec{
+			Size:       units.GiB,
+			HaLevel:    1,
+			Format:     opsapi.FSType_FS_TYPE_NONE,
+			Encrypted:  true,
+			Passphrase: keyName,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	vol := &encryptedTestVolume{ID: volID, KeyName: keyName}
+	encryption, err := Inst().V.GetVolumeEncryption(&volume.Volume{ID: volID, Name: volName})
+	if err != nil {
+		return vol, err
+	}
+	if !encryption.Encrypted || encryption.KeyName != keyName {
+		return vol, fmt.Errorf("volume %s is not encrypted with key [%s], encryption: %+v", volID, keyName, encryption)
+	}
+	cmd := fmt.Sprintf("dd if=/dev/urandom of=%%[1]s bs=1M count=%[1]d conv=fsync status=none && "+
+		"dd if=%%[1]s bs=1M count=%[1]d iflag=direct status=none | md5sum", encryptionDataSizeMB)
+	vol.NodeName, vol.Checksum, err = runOnEncryptedTestVolume(volID, cmd)
+	if err != nil {
+		return vol, err
+	}
+	log.Infof("Wrote data with checksum %s to volume %s encrypted with key [%s]", vol.Checksum, volID, keyName)
+	return vol, nil
+}
+
+// verify attaches the volume and checks the data written to it is unchanged, which needs the volume's key
+func (v *encryptedTestVolume) verify() error {
+	if len(v.Checksum) == 0 {
+		return nil
+	}
+	cmd := fmt.Sprintf("dd if=%%[1]s bs=1M count=%d iflag=direct status=none | md5sum", encryptionDataSizeMB)
+	_, checksum, err := runOnEncryptedTestVolume(v.ID, cmd)
+	if err != nil {
+		return err
+	}
+	if checksum != v.Checksum {
+		return fmt.Errorf("data of volume %s encrypted with key [%s] changed, checksum %s, expected %s",
+			v.ID, v.KeyName, checksum, v.Checksum)
+	}
+	log.Infof("Verified data of volume %s encrypted with key [%s]", v.ID, v.KeyName)
+	return nil
+}
+
+// runOnEncryptedTestVolume attaches the volume, runs the command formatted with its device path on the node it is
+// attached to, detaches it again and returns the node along with the md5sum the command printed
+func runOnEncryptedTestVolume(volID, cmd string) (string, string, error) {
+	devicePath, err := Inst().V.AttachVolume(volID)
+	if err != nil {
+		return "", "", err
+	}
+	defer func() {
+		if err := Inst().V.DetachVolume(volID); err != nil {
+			log.Warnf("Failed to detach volume %s: %v", volID, err)
+		}
+	}()
+	n, err := Inst().V.GetNodeForVolume(&volume.Volume{ID: volID}, defaultTimeout, defaultRetryInterval)
+	if err != nil {
+		return "", "", err
+	}
+	if n == nil {
+		return "", "", fmt.Errorf("volume %s is not attached to any node", volID)
+	}
+	output, err := Inst().N.RunCommand(*n, fmt.Sprintf(cmd, devicePath), node.ConnectionOpts{
+		Timeout:         defaultCmdTimeout,
+		TimeBeforeRetry: defaultCmdRetryInterval,
+		Sudo:            true,
+	})
+	if err != nil {
+		return n.Name, "", fmt.Errorf("failed to access volume %s at %s on node %s. Err: %v", volID, devicePath, n.Name, err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return n.Name, "", fmt.Errorf("unexpected md5sum output of volume %s: %s", volID, output)
+	}
+	return n.Name, fields[0], nil
+}
+
+// getEncryptionRestartNodes returns the nodes of the pods the markers were written from and the nodes the test
+// volumes were written on, or a random storage node
+func getEncryptionRestartNodes(markers []*ioprobe.Marker, testVolumes []*encryptedTestVolume) []node.Node {
+	var nodeNames []string
+	for _, marker := range markers {
+		nodeNames = append(nodeNames, marker.NodeName)
+	}
+	for _, vol := range testVolumes {
+		nodeNames = append(nodeNames, vol.NodeName)
+	}
+	var nodes []node.Node
+	seen := make(map[string]bool)
+	for _, name := range nodeNames {
+		if len(name) == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		n, err := node.GetNodeByName(name)
+		if err != nil {
+			log.Warnf("Failed to get node %s: %v", name, err)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	if len(nodes) == 0 {
+		if storageNodes := node.GetStorageNodes(); len(storageNodes) > 0 {
+			nodes = append(nodes, storageNodes[rand.Intn(len(storageNodes))])
+		}
+	}
+	return nodes
+}
+
+const (
+	// splitBrainDuration is how long the splitBrainPartition trigger keeps the cluster partitioned
+	splitBrainDuration = 5 * time.Minute
+	// splitBrainHealTimeout is how long after splitBrainDuration the partitioned nodes heal the partition
+	// themselves, in case they cannot be reached to remove the rules, e.g. once ssh is partitioned too
+	splitBrainHealTimeout = 10 * time.Minute
+)
+
+// splitBrainPorts are the port sets the splitBrainPartition trigger partitions the cluster on. An empty set drops
+// all traffic between the sides, including ssh between them.
+var splitBrainPorts = map[string][]int{
+	"all":       nil,
+	"kvdb":      {9018, 9019},
+	"mgmt+data": {9001, 9002, 9003},
+}
+
+// TriggerSplitBrainPartition partitions the storage nodes into a minority and a majority, validates the driver stays
+// up on the majority, heals the partition and validates all apps
+func TriggerSplitBrainPartition(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(SplitBrainPartition)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: SplitBrainPartition,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	storageNodes := node.GetStorageNodes()
+	if len(storageNodes) < 3 {
+		log.InfoD("Skipping %s since it needs at least 3 storage nodes, found %d", SplitBrainPartition, len(storageNodes))
+		updateMetrics(*event)
+		return
+	}
+	rand.Shuffle(len(storageNodes), func(i, j int) { storageNodes[i], storageNodes[j] = storageNodes[j], storageNodes[i] })
+	minority := storageNodes[:(len(storageNodes)-1)/2]
+	majority := storageNodes[(len(storageNodes)-1)/2:]
+
+	var rules []*node.NetworkRule
+	defer func() {
+		for _, rule := range rules {
+			if err := Inst().N.RemoveNetworkFault(rule); err != nil {
+				UpdateOutcome(event, err)
+			}
+		}
+	}()
+
+	var portSets []string
+	for name := range splitBrainPorts {
+		portSets = append(portSets, name)
+	}
+	sort.Strings(portSets)
+	portSet := portSets[rand.Intn(len(portSets))]
+	stepLog := fmt.Sprintf("partition %d storage nodes from the other %d on %s ports", len(minority), len(majority), portSet)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, n := range minority {
+			log.InfoD("Partitioning node %s from the majority", n.Name)
+		}
+		var err error
+		rules, err = Inst().N.PartitionNodes(minority, majority, splitBrainPorts[portSet], splitBrainDuration+splitBrainHealTimeout)
+		UpdateOutcome(event, err)
+	})
+	if len(rules) == 0 {
+		updateMetrics(*event)
+		return
+	}
+
+	stepLog = "validate the volume driver stays up on the majority during the partition"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		deadline := time.Now().Add(splitBrainDuration)
+		for _, n := range majority {
+			UpdateOutcome(event, Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout))
+		}
+		if wait := time.Until(deadline); wait > 0 {
+			log.InfoD("Keeping the cluster partitioned for %v", wait)
+			time.Sleep(wait)
+		}
+	})
+
+	stepLog = "heal the partition"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		// rules failing to be removed are retried when the trigger returns
+		var remaining []*node.NetworkRule
+		for _, rule := range rules {
+			if err := Inst().N.RemoveNetworkFault(rule); err != nil {
+				UpdateOutcome(event, err)
+				remaining = append(remaining, rule)
+			}
+		}
+		rules = remaining
+		for _, n := range storageNodes {
+			UpdateOutcome(event, Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout))
+		}
+	})
+
+	stepLog = "validate all apps after healing the partition"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+const (
+	// poolDriveFaultDuration is how long the poolDriveFault trigger keeps a fault injected
+	poolDriveFaultDuration = 3 * time.Minute
+)
+
+// poolDriveFaults are the faults the poolDriveFault trigger picks from
+var poolDriveFaults = []node.DiskFault{
+	{Type: node.DiskFaultDelay, Delay: 500 * time.Millisecond},
+	{Type: node.DiskFaultFlakey, UpInterval: 30 * time.Second, DownInterval: 5 * time.Second},
+	{Type: node.DiskFaultReadOnly},
+	{Type: node.DiskFaultError},
+}
+
+// TriggerPoolDriveFault injects a disk fault on a drive backing a storage pool, recovers the drive and validates
+// the node, its pools and all apps are healthy again
+func TriggerPoolDriveFault(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(PoolDriveFault)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: PoolDriveFault,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	stepLog := "recover disk faults left by previous runs"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, n := range node.GetStorageNodes() {
+			states, err := Inst().N.GetDiskFaults(n)
+			if err != nil {
+				UpdateOutcome(event, err)
+				continue
+			}
+			for _, state := range states {
+				log.InfoD("Recovering drive %s of node %s left faulty", state.Device, n.Name)
+				UpdateOutcome(event, Inst().N.RecoverDiskFault(n, state.Device))
+			}
+		}
+	})
+
+	var faultyNode node.Node
+	var drive string
+	stepLog = "inject a fault on a pool drive"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		type poolDrive struct {
+			node  node.Node
+			drive string
+		}
+		var candidates []poolDrive
+		for _, n := range node.GetStorageNodes() {
+			for _, d := range getPoolDrives(n) {
+				candidates = append(candidates, poolDrive{n, d})
+			}
+		}
+		rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+		// drives which are not device-mapper devices with linear targets only support error faults
+		faults := []node.DiskFault{poolDriveFaults[rand.Intn(len(poolDriveFaults))]}
+		if faults[0].Type != node.DiskFaultError {
+			faults = append(faults, node.DiskFault{Type: node.DiskFaultError})
+		}
+		for _, fault := range faults {
+			for _, c := range candidates {
+				log.InfoD("Injecting %s fault on drive %s of node %s", fault.Type, c.drive, c.node.Name)
+				err := Inst().N.InjectDiskFault(c.node, c.drive, fault)
+				if _, ok := err.(*node.ErrDiskNotFaultable); ok {
+					log.InfoD("Skipping drive %s of node %s: %v", c.drive, c.node.Name, err)
+					continue
+				}
+				if err != nil {
+					UpdateOutcome(event, err)
+					return
+				}
+				faultyNode, drive = c.node, c.drive
+				break
+			}
+			if len(drive) > 0 {
+				break
+			}
+		}
+		if len(drive) == 0 {
+			log.InfoD("No pool drives to inject faults on")
+			return
+		}
+		log.InfoD("Keeping the fault for %v", poolDriveFaultDuration)
+		time.Sleep(poolDriveFaultDuration)
+	})
+	if len(drive) == 0 {
+		updateMetrics(*event)
+		return
+	}
+
+	stepLog = "recover the pool drive and validate the node"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		if err := Inst().N.RecoverDiskFault(faultyNode, drive); err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		// pools which went offline due to the fault come back when the driver restarts
+		if err := Inst().V.WaitDriverUpOnNode(faultyNode, Inst().DriverStartTimeout); err != nil {
+			log.Warnf("Driver is not up on %s after recovering drive %s, restarting it: %v", faultyNode.Name, drive, err)
+			if err := Inst().V.RestartDriver(faultyNode, nil); err != nil {
+				UpdateOutcome(event, err)
+				return
+			}
+			if err := Inst().V.WaitDriverUpOnNode(faultyNode, Inst().DriverStartTimeout); err != nil {
+				UpdateOutcome(event, err)
+				return
+			}
+		}
+		status, err := Inst().V.GetNodeStatus(faultyNode)
+		if err != nil {
+			UpdateOutcome(event, err)
+			return
+		}
+		if *status != opsapi.Status_STATUS_OK {
+			UpdateOutcome(event, fmt.Errorf("node %s has status %v after recovering drive %s", faultyNode.Name, status, drive))
+		}
+	})
+
+	stepLog = "validate all apps after the pool drive fault"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+// getPoolDrives returns the drives of the node backing its storage pools
+func getPoolDrives(n node.Node) []string {
+	if n.StorageNode == nil {
+		return nil
+	}
+	var drives []string
+	for path, disk := range n.Disks {
+		if disk.GetMetadata() || disk.GetPoolMetadataDev() || disk.GetCache() {
+			continue
+		}
+		drives = append(drives, path)
+	}
+	sort.Strings(drives)
+	return drives
+}
+
+// TriggerKubeletHang pauses kubelet on a storage node and validates the volume driver stays up while kubelet hangs
+func TriggerKubeletHang(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	triggerProcessChaos(contexts, recordChan, KubeletHang, node.ProcessChaos{
+		Type:     node.ProcessChaosPause,
+		Target:   "kubelet",
+		Duration: 3 * time.Minute,
+	})
+}
+
+// TriggerNodeMemoryPressure uses most of the available memory of a storage node and validates the volume driver
+// stays up under memory pressure
+func TriggerNodeMemoryPressure(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	triggerProcessChaos(contexts, recordChan, NodeMemoryPressure, node.ProcessChaos{
+		Type:          node.ProcessChaosMemory,
+		Workers:       2,
+		MemoryPercent: 90,
+		Duration:      5 * time.Minute,
+	})
+}
+
+// triggerProcessChaos injects the process chaos on a random storage node, validates the volume driver stays up on
+// it until the chaos is over and then validates the node and all apps
+func triggerProcessChaos(contexts *[]*scheduler.Context, recordChan *chan *EventRecord, triggerType string, chaos node.ProcessChaos) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(triggerType)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: triggerType,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	storageNodes := node.GetStorageNodes()
+	if len(storageNodes) == 0 {
+		UpdateOutcome(event, fmt.Errorf("no storage nodes to inject %s chaos on", chaos.Type))
+		updateMetrics(*event)
+		return
+	}
+	chaosNode := storageNodes[rand.Intn(len(storageNodes))]
+
+	var handle *node.ProcessChaosHandle
+	stepLog := fmt.Sprintf("inject %s chaos on node %s for %v", chaos.Type, chaosNode.Name, chaos.Duration)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		var err error
+		handle, err = Inst().N.InjectProcessChaos(chaosNode, chaos)
+		UpdateOutcome(event, err)
+	})
+	if handle == nil {
+		updateMetrics(*event)
+		return
+	}
+
+	stepLog = fmt.Sprintf("validate the volume driver stays up on node %s during the chaos", chaosNode.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for time.Now().Before(handle.Until) {
+			if err := Inst().V.WaitDriverUpOnNode(chaosNode, defaultTimeout); err != nil {
+				UpdateOutcome(event, err)
+				break
+			}
+			time.Sleep(defaultRetryInterval)
+		}
+		if wait := time.Until(handle.Until); wait > 0 {
+			time.Sleep(wait)
+		}
+		// the chaos reverts itself on the node, reverting it again only makes sure it is over
+		UpdateOutcome(event, Inst().N.RevertProcessChaos(handle))
+	})
+
+	stepLog = fmt.Sprintf("validate node %s and all apps after the chaos", chaosNode.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		t := func() (interface{}, bool, error) {
+			if err := Inst().S.IsNodeReady(chaosNode); err != nil {
+				return nil, true, err
+			}
+			return nil, false, nil
+		}
+		if _, err := task.DoRetryWithTimeout(t, defaultTimeout, defaultRetryInterval); err != nil {
+			UpdateOutcome(event, err)
+		}
+		UpdateOutcome(event, Inst().V.WaitDriverUpOnNode(chaosNode, Inst().DriverStartTimeout))
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+const (
+	// clockSkewDuration is how long the clockSkew trigger keeps the clock of a node skewed
+	clockSkewDuration = 10 * time.Minute
+)
+
+// vmDeviceDetachDuration is how long the vmDeviceHotplug trigger keeps a device unplugged
+const vmDeviceDetachDuration = 2 * time.Minute
+
+// clockSkewOffsets are the offsets the clockSkew trigger picks from
+var clockSkewOffsets = []time.Duration{10 * time.Minute, -10 * time.Minute, time.Hour}
+
+// TriggerClockSkew moves the clock of a storage node and validates KVDB members, the license, snapshot schedules
+// and backup schedules while the clock is skewed and after it is restored
+func TriggerClockSkew(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(ClockSkew)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: ClockSkew,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	storageNodes := node.GetStorageNodes()
+	if len(storageNodes) == 0 {
+		UpdateOutcome(event, fmt.Errorf("no storage nodes to skew the clock of"))
+		updateMetrics(*event)
+		return
+	}
+	skewedNode := storageNodes[rand.Intn(len(storageNodes))]
+	offset := clockSkewOffsets[rand.Intn(len(clockSkewOffsets))]
+
+	var namespaces []string
+	seen := make(map[string]bool)
+	for _, ctx := range *contexts {
+		appVolumes, err := Inst().S.GetVolumes(ctx)
+		if err != nil {
+			UpdateOutcome(event, err)
+			continue
+		}
+		for _, vol := range appVolumes {
+			if !seen[vol.Namespace] {
+				seen[vol.Namespace] = true
+				namespaces = append(namespaces, vol.Namespace)
+			}
+		}
+	}
+	license, err := Inst().V.GetLicenseSummary()
+	if err != nil {
+		log.Warnf("Skipping license validation, failed to get license summary: %v", err)
+	}
+	skewStart := time.Now()
+
+	var handle *node.ClockSkewHandle
+	stepLog := fmt.Sprintf("skew the clock of node %s by %v", skewedNode.Name, offset)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		handle, err = Inst().N.SkewClock(skewedNode, offset, clockSkewDuration)
+		UpdateOutcome(event, err)
+	})
+	if handle == nil {
+		updateMetrics(*event)
+		return
+	}
+	defer func() {
+		// restoring is a no-op if the clock was restored already
+		if err := Inst().N.RestoreClock(handle); err != nil {
+			log.Errorf("Failed to restore clock of node %s: %v", skewedNode.Name, err)
+		}
+	}()
+
+	stepLog = "validate KVDB members and license while the clock is skewed"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		if wait := time.Until(handle.Until) - 2*time.Minute; wait > 0 {
+			log.InfoD("Waiting %v with the clock skewed", wait)
+			time.Sleep(wait)
+		}
+		UpdateOutcome(event, validateKvdbMembersHealthy(skewedNode))
+		if license.SKU != "" {
+			UpdateOutcome(event, validateLicenseUnchanged(license))
+		}
+	})
+
+	stepLog = fmt.Sprintf("restore the clock of node %s", skewedNode.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		UpdateOutcome(event, Inst().N.RestoreClock(handle))
+		UpdateOutcome(event, Inst().V.WaitDriverUpOnNode(skewedNode, Inst().DriverStartTimeout))
+	})
+
+	stepLog = "validate KVDB members, license and schedules after restoring the clock"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		UpdateOutcome(event, validateKvdbMembersHealthy(skewedNode))
+		if license.SKU != "" {
+			UpdateOutcome(event, validateLicenseUnchanged(license))
+		}
+		for _, namespace := range namespaces {
+			for _, err := range validateSchedulesSince(namespace, skewStart) {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+
+	stepLog = "validate all apps after the clock skew"
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+	updateMetrics(*event)
+}
+
+// validateKvdbMembersHealthy checks all KVDB members are healthy and one of them is the leader
+func validateKvdbMembersHealthy(n node.Node) error {
+	t := func() (interface{}, bool, error) {
+		members, err := Inst().V.GetKvdbMembers(n)
+		if err != nil {
+			return nil, true, err
+		}
+		leaders := 0
+		for id, member := range members {
+			if !member.IsHealthy {
+				return nil, true, fmt.Errorf("KVDB member %s is not healthy", id)
+			}
+			if member.Leader {
+				leaders++
+			}
+		}
+		if leaders != 1 {
+			return nil, true, fmt.Errorf("KVDB has %d leaders, expected 1", leaders)
+		}
+		return nil, false, nil
+	}
+	_, err := task.DoRetryWithTimeout(t, 2*time.Minute, defaultRetryInterval)
+	return err
+}
+
+// validateLicenseUnchanged checks the license has the same SKU as before and did not expire
+func validateLicenseUnchanged(before volume.LicenseSummary) error {
+	summary, err := Inst().V.GetLicenseSummary()
+	if err != nil {
+		return err
+	}
+	if summary.SKU != before.SKU {
+		return fmt.Errorf("license SKU changed from %s to %s", before.SKU, summary.SKU)
+	}
+	if strings.Contains(strings.ToLower(summary.LicenesConditionMsg), "expired") {
+		return fmt.Errorf("license expired: %s", summary.LicenesConditionMsg)
+	}
+	return nil
+}
+
+// validateSchedulesSince checks no snapshot or backup scheduled in the namespace since the given time failed
+func validateSchedulesSince(namespace string, since time.Time) []error {
+	var errs []error
+	snapshotSchedules, err := storkops.Instance().ListSnapshotSchedules(namespace)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		for _, schedule := range snapshotSchedules.Items {
+			created := 0
+			for _, items := range schedule.Status.Items {
+				for _, item := range items {
+					if item.CreationTimestamp.Time.Before(since) {
+						continue
+					}
+					created++
+					if item.Status == snapv1.VolumeSnapshotConditionError {
+						errs = append(errs, fmt.Errorf("snapshot %s of schedule %s/%s failed", item.Name, namespace, schedule.Name))
+					}
+				}
+			}
+			if created == 0 {
+				log.Warnf("Snapshot schedule %s/%s took no snapshot since %v", namespace, schedule.Name, since)
+			}
+		}
+	}
+
+	backupSchedules, err := storkops.Instance().ListApplicationBackupSchedules(namespace, meta_v1.ListOptions{})
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		for _, schedule := range backupSchedules.Items {
+			created := 0
+			for _, items := range schedule.Status.Items {
+				for _, item := range items {
+					if item.CreationTimestamp.Time.Before(since) {
+						continue
+					}
+					created++
+					if item.Status == storkv1.ApplicationBackupStatusFailed {
+						errs = append(errs, fmt.Errorf("backup %s of schedule %s/%s failed", item.Name, namespace, schedule.Name))
+					}
+				}
+			}
+			if created == 0 {
+				log.Warnf("Backup schedule %s/%s took no backup since %v", namespace, schedule.Name, since)
+			}
+		}
+	}
+	return errs
+}
+
+// TriggerVMHardReset presses the reset button of the VM of a storage node and validates the node, the volume
+// driver and the apps after it comes back
+func TriggerVMHardReset(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(VMHardReset)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: VMHardReset,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	storageNodes := node.GetStorageNodes()
+	if len(storageNodes) == 0 {
+		UpdateOutcome(event, fmt.Errorf("no storage nodes to reset the VM of"))
+		updateMetrics(*event)
+		return
+	}
+	n := storageNodes[rand.Intn(len(storageNodes))]
+
+	stepLog := fmt.Sprintf("hard reset the VM of node %s", n.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		UpdateOutcome(event, Inst().N.ResetVM(n))
+	})
+	waitForNodeAndDriver(event, n)
+	validateAllContexts(event, contexts, "validate all apps after the VM hard reset")
+	updateMetrics(*event)
+}
+
+// TriggerVMDeviceHotplug hot-unplugs a drive of the volume driver or a NIC from the VM of a storage node, plugs it
+// back after a while and validates the volume driver and the apps
+func TriggerVMDeviceHotplug(contexts *[]*scheduler.Context, recordChan *chan *EventRecord) {
+	defer ginkgo.GinkgoRecover()
+	defer endLongevityTest()
+	startLongevityTest(VMDeviceHotplug)
+	event := &EventRecord{
+		Event: Event{
+			ID:   GenerateUUID(),
+			Type: VMDeviceHotplug,
+		},
+		Start:   time.Now().Format(time.RFC1123),
+		Outcome: []error{},
+	}
+
+	defer func() {
+		event.End = time.Now().Format(time.RFC1123)
+		*recordChan <- event
+	}()
+
+	setMetrics(*event)
+	storageNodes := node.GetStorageNodes()
+	if len(storageNodes) == 0 {
+		UpdateOutcome(event, fmt.Errorf("no storage nodes to hotplug devices of"))
+		updateMetrics(*event)
+		return
+	}
+	n := storageNodes[rand.Intn(len(storageNodes))]
+
+	candidates, err := getHotplugCandidates(n)
+	if err != nil {
+		UpdateOutcome(event, err)
+		updateMetrics(*event)
+		return
+	}
+
+	var detached *node.VMDevice
+	stepLog := fmt.Sprintf("hot-unplug a drive or NIC from the VM of node %s", n.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, i := range rand.Perm(len(candidates)) {
+			device := candidates[i]
+			err := Inst().N.DetachVMDevice(n, device)
+			if _, inUse := err.(*node.ErrVMDeviceInUse); inUse {
+				log.Infof("Skipping %s: %v", device, err)
+				continue
+			}
+			UpdateOutcome(event, err)
+			if err == nil {
+				detached = &device
+			}
+			return
+		}
+		log.InfoD("No drive or NIC of node %s can be unplugged", n.Name)
+	})
+	if detached == nil {
+		updateMetrics(*event)
+		return
+	}
+
+	stepLog = fmt.Sprintf("plug %s back into the VM of node %s after %v", detached, n.Name, vmDeviceDetachDuration)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		time.Sleep(vmDeviceDetachDuration)
+		UpdateOutcome(event, Inst().N.AttachVMDevice(n, *detached))
+	})
+	waitForNodeAndDriver(event, n)
+	validateAllContexts(event, contexts, "validate all apps after the VM device hotplug")
+	updateMetrics(*event)
+}
+
+// getHotplugCandidates returns the drives of the volume driver and the NICs of the VM of the node
+func getHotplugCandidates(n node.Node) ([]node.VMDevice, error) {
+	devices, err := Inst().N.GetVMDevices(n)
+	if err != nil {
+		return nil, err
+	}
+	pxNode, err := Inst().V.GetPxNode(&n)
+	if err != nil {
+		return nil, err
+	}
+	drives := make(map[string]bool)
+	for _, disk := range pxNode.GetDisks() {
+		drives[disk.GetPath()] = true
+	}
+	var candidates []node.VMDevice
+	for _, device := range devices {
+		if device.Type == node.VMDeviceNIC || drives["/dev/"+device.ID] {
+			candidates = append(candidates, device)
+		}
+	}
+	return candidates, nil
+}
+
+// waitForNodeAndDriver waits for the node to be reachable and ready and for the volume driver to be up on it
+func waitForNodeAndDriver(event *EventRecord, n node.Node) {
+	stepLog := fmt.Sprintf("wait for node %s and the volume driver on it to be back up", n.Name)
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		err := Inst().N.TestConnection(n, node.ConnectionOpts{
+			Timeout:         15 * time.Minute,
+			TimeBeforeRetry: 10 * time.Second,
+		})
+		UpdateOutcome(event, err)
+		UpdateOutcome(event, Inst().S.IsNodeReady(n))
+		UpdateOutcome(event, Inst().V.WaitDriverUpOnNode(n, Inst().DriverStartTimeout))
+	})
+}
+
+// validateAllContexts validates all the contexts in a step
+func validateAllContexts(event *EventRecord, contexts *[]*scheduler.Context, stepLog string) {
+	Step(stepLog, func() {
+		log.InfoD(stepLog)
+		for _, ctx := range *contexts {
+			errorChan := make(chan error, errorChannelSize)
+			ValidateContext(ctx, &errorChan)
+			for err := range errorChan {
+				UpdateOutcome(event, err)
+			}
+		}
+	})
+}
+
 func prepareEmailBody(eventRecords emailData) (string, error) {
 	var err error
 	t := template.New("t").Funcs(templateFuncs)
@@ -6298,6 +8286,9 @@ tbody tr:last-child {
    <td align="center"><h4>Start Time </h4></td>
    <td align="center"><h4>End Time </h4></td>
    <td class="wrapper" width="600" align="center"><h4>Errors </h4></td>
+   <td class="wrapper" width="300" align="center"><h4>IO Stalls </h4></td>
+   <td class="wrapper" width="400" align="center"><h4>Crashes </h4></td>
+   <td class="wrapper" width="400" align="center"><h4>Expected Kernel Events </h4></td>
  </tr>
 {{range .EmailRecords.Records}}<tr>
 {{range rangeStruct .}} <td>{{.}}</td>